@@ -0,0 +1,34 @@
+package accounts
+
+import (
+	"log"
+	"time"
+
+	"github.com/comforme/comforme/databaseActions"
+)
+
+const pollInterval = time.Hour
+
+// Deletes accounts whose grace period has ended, and forgets where old audit
+// events came from, until the process exits.
+func Run() {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		deleted, err := databaseActions.DeleteDueAccounts()
+		if err != nil {
+			log.Printf("Error deleting accounts: %s\n", err.Error())
+		} else if deleted > 0 {
+			log.Printf("Deleted %d accounts.\n", deleted)
+		}
+
+		if cleared, err := databaseActions.ClearOldAuditRequestInfo(); err != nil {
+			log.Printf("Error clearing old audit event addresses: %s\n", err.Error())
+		} else if cleared > 0 {
+			log.Printf("Cleared the addresses of %d audit events.\n", cleared)
+		}
+
+		<-ticker.C
+	}
+}
@@ -0,0 +1,375 @@
+package activitypub
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/comforme/comforme/common"
+	"github.com/comforme/comforme/databaseActions"
+)
+
+const securityContext = "https://w3id.org/security/v1"
+
+func Register(router *httprouter.Router) {
+	router.GET("/.well-known/webfinger", WebFingerHandler)
+
+	router.GET("/ap/category/:category", ActorHandler)
+	router.GET("/ap/category/:category/followers", FollowersHandler)
+	router.GET("/ap/category/:category/outbox", OutboxHandler)
+	router.POST("/ap/category/:category/inbox", InboxHandler)
+	router.GET("/ap/community/:id", ActorHandler)
+	router.GET("/ap/community/:id/followers", FollowersHandler)
+	router.GET("/ap/community/:id/outbox", OutboxHandler)
+	router.POST("/ap/community/:id/inbox", InboxHandler)
+
+	router.GET("/ap/pages/:id", ObjectHandler)
+	router.GET("/ap/posts/:id", ObjectHandler)
+	router.POST("/ap/inbox", InboxHandler)
+}
+
+func writeActivityJSON(res http.ResponseWriter, value interface{}) {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		log.Println("Error encoding ActivityPub document:", err)
+		http.Error(res, common.DatabaseError.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	res.Header().Set("Content-Type", activityJSON)
+	res.Write(encoded)
+}
+
+// Whether the client wants an ActivityStreams document rather than the page
+// a person would see.
+func wantsActivity(req *http.Request) bool {
+	accept := req.Header.Get("Accept")
+	return strings.Contains(accept, "application/activity+json") || strings.Contains(accept, "application/ld+json")
+}
+
+// Looks up the actor named by the route's parameters.
+func routeActor(ps httprouter.Params) (common.LocalActor, error) {
+	if id := ps.ByName("id"); id != "" {
+		communityID, err := strconv.Atoi(id)
+		if err != nil || communityID <= 0 {
+			return common.LocalActor{}, common.ActorNotFound
+		}
+		return databaseActions.GetLocalActor("", communityID)
+	}
+	return databaseActions.GetLocalActor(ps.ByName("category"), 0)
+}
+
+// Looks up a local actor by its ID.
+func actorByURL(uri string) (common.LocalActor, error) {
+	path := strings.TrimPrefix(uri, common.FederationURL("/ap/"))
+	if path == uri {
+		return common.LocalActor{}, common.ActorNotFound
+	}
+
+	parts := strings.Split(path, "/")
+	if len(parts) != 2 {
+		return common.LocalActor{}, common.ActorNotFound
+	}
+	categorySlug, communityID, ok := common.ParseActorName(parts[0] + "-" + parts[1])
+	if !ok {
+		return common.LocalActor{}, common.ActorNotFound
+	}
+	return databaseActions.GetLocalActor(categorySlug, communityID)
+}
+
+func writeActorError(res http.ResponseWriter, req *http.Request, err error) {
+	if err == common.ActorNotFound || err == common.FederationDisabled {
+		http.NotFound(res, req)
+		return
+	}
+	http.Error(res, err.Error(), http.StatusInternalServerError)
+}
+
+func WebFingerHandler(res http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	if !common.FederationEnabled() {
+		http.NotFound(res, req)
+		return
+	}
+
+	resource := req.URL.Query().Get("resource")
+	var actor common.LocalActor
+	var err error
+	if strings.HasPrefix(resource, "acct:") {
+		account := strings.TrimPrefix(resource, "acct:")
+		at := strings.LastIndex(account, "@")
+		if at < 0 || !strings.EqualFold(account[at+1:], common.FederationDomain) {
+			http.NotFound(res, req)
+			return
+		}
+		categorySlug, communityID, ok := common.ParseActorName(account[:at])
+		if !ok {
+			http.NotFound(res, req)
+			return
+		}
+		actor, err = databaseActions.GetLocalActor(categorySlug, communityID)
+	} else {
+		actor, err = actorByURL(resource)
+	}
+	if err != nil {
+		writeActorError(res, req, err)
+		return
+	}
+
+	encoded, err := json.Marshal(map[string]interface{}{
+		"subject": fmt.Sprintf("acct:%s@%s", actor.Username(), common.FederationDomain),
+		"aliases": []string{actor.URL()},
+		"links": []map[string]string{
+			{"rel": "self", "type": activityJSON, "href": actor.URL()},
+		},
+	})
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	res.Header().Set("Content-Type", "application/jrd+json")
+	res.Write(encoded)
+}
+
+func ActorHandler(res http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	actor, err := routeActor(ps)
+	if err != nil {
+		writeActorError(res, req, err)
+		return
+	}
+
+	document := map[string]interface{}{
+		"@context":          []string{common.ActivityStreamsContext, securityContext},
+		"id":                actor.URL(),
+		"type":              "Group",
+		"preferredUsername": actor.Username(),
+		"name":              actor.Name,
+		"summary":           html.EscapeString(actor.Summary),
+		"inbox":             actor.URL() + "/inbox",
+		"outbox":            actor.URL() + "/outbox",
+		"followers":         actor.URL() + "/followers",
+		"endpoints": map[string]string{
+			"sharedInbox": common.FederationURL("/ap/inbox"),
+		},
+		"publicKey": map[string]string{
+			"id":           actor.URL() + "#main-key",
+			"owner":        actor.URL(),
+			"publicKeyPem": actor.PublicKey,
+		},
+	}
+	if actor.CommunityID != 0 {
+		if !wantsActivity(req) {
+			http.Redirect(res, req, fmt.Sprintf("/community/%d", actor.CommunityID), http.StatusSeeOther)
+			return
+		}
+		document["url"] = common.FederationURL(fmt.Sprintf("/community/%d", actor.CommunityID))
+	}
+
+	writeActivityJSON(res, document)
+}
+
+// Only the number of followers is shown, not who they are.
+func FollowersHandler(res http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	actor, err := routeActor(ps)
+	if err != nil {
+		writeActorError(res, req, err)
+		return
+	}
+
+	writeActivityJSON(res, map[string]interface{}{
+		"@context":   common.ActivityStreamsContext,
+		"id":         actor.URL() + "/followers",
+		"type":       "OrderedCollection",
+		"totalItems": actor.Followers,
+	})
+}
+
+// Activities are only delivered as they happen, so the outbox is empty.
+func OutboxHandler(res http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	actor, err := routeActor(ps)
+	if err != nil {
+		writeActorError(res, req, err)
+		return
+	}
+
+	writeActivityJSON(res, map[string]interface{}{
+		"@context":     common.ActivityStreamsContext,
+		"id":           actor.URL() + "/outbox",
+		"type":         "OrderedCollection",
+		"totalItems":   0,
+		"orderedItems": []string{},
+	})
+}
+
+func ObjectHandler(res http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	id, err := strconv.Atoi(ps.ByName("id"))
+	if err != nil {
+		http.NotFound(res, req)
+		return
+	}
+
+	var note common.Note
+	if strings.HasPrefix(req.URL.Path, "/ap/pages/") {
+		note, err = databaseActions.GetPageNote(id)
+		if err == common.PageNotFound {
+			// The page may have been merged into another one
+			if pageID, mergedErr := databaseActions.GetMergedPageID(id); mergedErr == nil {
+				http.Redirect(res, req, common.FederationURL(common.PageObjectPath(pageID)), http.StatusMovedPermanently)
+				return
+			}
+		}
+	} else {
+		note, err = databaseActions.GetPostNote(id)
+	}
+	if err == common.PageNotFound || err == common.PostNotFound || err == common.FederationDisabled {
+		http.NotFound(res, req)
+		return
+	} else if err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if !wantsActivity(req) {
+		http.Redirect(res, req, note.URL, http.StatusSeeOther)
+		return
+	}
+
+	note.Context = common.ActivityStreamsContext
+	writeActivityJSON(res, note)
+}
+
+// An incoming activity. Object is decoded according to Type.
+type activity struct {
+	ID     string          `json:"id"`
+	Type   string          `json:"type"`
+	Actor  string          `json:"actor"`
+	Object json.RawMessage `json:"object"`
+}
+
+type incomingNote struct {
+	ID           string `json:"id"`
+	Type         string `json:"type"`
+	AttributedTo string `json:"attributedTo"`
+	InReplyTo    string `json:"inReplyTo"`
+	Content      string `json:"content"`
+}
+
+// Returns the ID of an object that may be given by ID or in full.
+func objectID(object json.RawMessage) string {
+	var id string
+	if json.Unmarshal(object, &id) == nil {
+		return id
+	}
+	var full struct {
+		ID string `json:"id"`
+	}
+	json.Unmarshal(object, &full)
+	return full.ID
+}
+
+// Handles activities sent to the shared inbox or an actor's inbox. Only
+// follows, replies and deletions of replies are acted on; anything else is
+// accepted and dropped.
+func InboxHandler(res http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	if !common.FederationEnabled() {
+		http.NotFound(res, req)
+		return
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(req.Body, maxDocumentSize))
+	if err != nil {
+		http.Error(res, "Could not read body.", http.StatusBadRequest)
+		return
+	}
+
+	signer, err := verifyRequest(req, body)
+	if err != nil {
+		log.Printf("Rejected unsigned or badly signed activity: %s\n", err.Error())
+		http.Error(res, "Invalid signature.", http.StatusUnauthorized)
+		return
+	}
+
+	var incoming activity
+	if err := json.Unmarshal(body, &incoming); err != nil {
+		http.Error(res, "Invalid activity.", http.StatusBadRequest)
+		return
+	}
+	if incoming.Actor != signer.ID {
+		http.Error(res, "Activity is not from the signer.", http.StatusForbidden)
+		return
+	}
+
+	err = handleActivity(signer, incoming, body)
+	if err != nil {
+		log.Printf("Error handling %s activity %s: %s\n", incoming.Type, incoming.ID, err.Error())
+		if err == common.DatabaseError {
+			http.Error(res, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	res.WriteHeader(http.StatusAccepted)
+}
+
+func handleActivity(signer common.RemoteActor, incoming activity, body []byte) error {
+	switch incoming.Type {
+	case "Follow":
+		actor, err := actorByURL(objectID(incoming.Object))
+		if err != nil {
+			return err
+		}
+		return databaseActions.AddFollower(actor, signer, json.RawMessage(body))
+
+	case "Undo":
+		var undone activity
+		if err := json.Unmarshal(incoming.Object, &undone); err != nil || undone.Type != "Follow" {
+			return nil
+		}
+		if undone.Actor != signer.ID {
+			return nil
+		}
+		actor, err := actorByURL(objectID(undone.Object))
+		if err != nil {
+			return err
+		}
+		return databaseActions.RemoveFollower(actor, signer.ID)
+
+	case "Create":
+		var note incomingNote
+		if err := json.Unmarshal(incoming.Object, &note); err != nil || note.Type != "Note" {
+			return nil
+		}
+		if note.AttributedTo != signer.ID || note.ID == "" {
+			return nil
+		}
+		return databaseActions.ReceiveReply(signer, note.ID, note.InReplyTo, htmlToText(note.Content))
+
+	case "Delete":
+		return databaseActions.DeleteRemoteObject(signer.ID, objectID(incoming.Object))
+	}
+	return nil
+}
+
+var (
+	lineBreaks = regexp.MustCompile(`(?i)<br\s*/?>`)
+	paragraphs = regexp.MustCompile(`(?i)</p>\s*`)
+	tags       = regexp.MustCompile(`<[^>]*>`)
+	blankLines = regexp.MustCompile(`\n{3,}`)
+)
+
+// Turns the HTML content of a remote note into the plain text posts hold.
+func htmlToText(content string) string {
+	text := lineBreaks.ReplaceAllString(content, "\n")
+	text = paragraphs.ReplaceAllString(text, "\n\n")
+	text = tags.ReplaceAllString(text, "")
+	text = html.UnescapeString(text)
+	text = blankLines.ReplaceAllString(text, "\n\n")
+	return strings.TrimSpace(text)
+}
@@ -0,0 +1,105 @@
+package activitypub
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/comforme/comforme/common"
+	"github.com/comforme/comforme/databaseActions"
+)
+
+const (
+	pollInterval  = 30 * time.Second
+	batchSize     = 20
+	maxErrorBytes = 512
+)
+
+// Delivers queued activities until the process exits, the same way
+// webhooks.Run sends webhook deliveries. Does nothing if federation is off.
+func Run() {
+	if !common.FederationEnabled() {
+		return
+	}
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		deliverDue()
+
+		select {
+		case <-ticker.C:
+		case <-databaseActions.ActivitiesQueued:
+		}
+	}
+}
+
+func deliverDue() {
+	for {
+		deliveries, err := databaseActions.ClaimActivityDeliveries(batchSize)
+		if err != nil {
+			log.Printf("Error claiming activity deliveries: %s\n", err.Error())
+			return
+		}
+
+		for _, delivery := range deliveries {
+			err := deliver(delivery)
+			if err != nil {
+				log.Printf("Activity delivery (%d) to %s failed: %s\n", delivery.Id, delivery.Inbox, err.Error())
+			}
+			if err := databaseActions.RecordActivityDelivery(delivery, err); err != nil {
+				log.Printf("Error recording activity delivery (%d): %s\n", delivery.Id, err.Error())
+			}
+		}
+
+		if len(deliveries) < batchSize {
+			return
+		}
+	}
+}
+
+func deliver(delivery common.FederationDelivery) error {
+	key, err := parsePrivateKey(delivery.PrivateKey)
+	if err != nil {
+		return err
+	}
+	return post(delivery.Inbox, []byte(delivery.Activity), delivery.KeyID, key)
+}
+
+// Posts a signed activity to an inbox. Anything but a 2xx response is an
+// error.
+func post(inbox string, body []byte, keyID string, key *rsa.PrivateKey) error {
+	err := checkURL(inbox)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", inbox, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", activityJSON)
+
+	err = sign(req, body, keyID, key)
+	if err != nil {
+		return err
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		excerpt, _ := ioutil.ReadAll(io.LimitReader(res.Body, maxErrorBytes))
+		return fmt.Errorf("%s: %s", res.Status, bytes.TrimSpace(excerpt))
+	}
+	return nil
+}
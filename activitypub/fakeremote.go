@@ -0,0 +1,240 @@
+package activitypub
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/comforme/comforme/common"
+)
+
+// A stand-in for another fediverse server, for trying federation out on a
+// development machine. It has a single user who follows one local actor,
+// logs every activity it receives and sends replies, unfollows and
+// deletions when asked to.
+type fakeRemote struct {
+	base   string
+	key    *rsa.PrivateKey
+	target common.RemoteActor
+
+	mutex sync.Mutex
+	notes int
+}
+
+func (fake *fakeRemote) actorID() string {
+	return fake.base + "/users/tester"
+}
+
+func (fake *fakeRemote) send(value interface{}) error {
+	body, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return post(fake.target.Inbox, body, fake.actorID()+"#main-key", fake.key)
+}
+
+func (fake *fakeRemote) follow() common.Activity {
+	return common.Activity{
+		Context: common.ActivityStreamsContext,
+		ID:      fake.base + "/follows/1",
+		Type:    "Follow",
+		Actor:   fake.actorID(),
+		Object:  fake.target.ID,
+	}
+}
+
+// Finds an actor from its user@host address.
+func webFinger(account string) (string, error) {
+	at := strings.LastIndex(account, "@")
+	if at < 0 {
+		return "", errors.New("address must look like name@host")
+	}
+
+	var jrd struct {
+		Links []struct {
+			Rel  string `json:"rel"`
+			Type string `json:"type"`
+			Href string `json:"href"`
+		} `json:"links"`
+	}
+	err := fetch(fmt.Sprintf("http://%s/.well-known/webfinger?resource=%s", account[at+1:], url.QueryEscape("acct:"+account)), &jrd)
+	if err != nil {
+		return "", err
+	}
+
+	for _, link := range jrd.Links {
+		if link.Rel == "self" && link.Type == activityJSON {
+			return link.Href, nil
+		}
+	}
+	return "", errors.New("no ActivityPub actor for " + account)
+}
+
+func (fake *fakeRemote) actorHandler(res http.ResponseWriter, req *http.Request) {
+	publicKey, err := x509.MarshalPKIXPublicKey(&fake.key.PublicKey)
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeActivityJSON(res, map[string]interface{}{
+		"@context":          []string{common.ActivityStreamsContext, securityContext},
+		"id":                fake.actorID(),
+		"type":              "Person",
+		"preferredUsername": "tester",
+		"inbox":             fake.actorID() + "/inbox",
+		"endpoints":         map[string]string{"sharedInbox": fake.base + "/inbox"},
+		"publicKey": map[string]string{
+			"id":           fake.actorID() + "#main-key",
+			"owner":        fake.actorID(),
+			"publicKeyPem": string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKey})),
+		},
+	})
+}
+
+func (fake *fakeRemote) inboxHandler(res http.ResponseWriter, req *http.Request) {
+	body, err := ioutil.ReadAll(io.LimitReader(req.Body, maxDocumentSize))
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	signer, err := verifyRequest(req, body)
+	if err != nil {
+		log.Printf("Rejected activity with a bad signature: %s\n", err.Error())
+		http.Error(res, "Invalid signature.", http.StatusUnauthorized)
+		return
+	}
+
+	var incoming activity
+	json.Unmarshal(body, &incoming)
+	log.Printf("Received %s %s from %s:\n%s\n", incoming.Type, incoming.ID, signer.ID, body)
+	res.WriteHeader(http.StatusAccepted)
+}
+
+// POST /send/reply with "to" (the ID of a page or post) and "text".
+// POST /send/delete with "id" (a reply sent earlier).
+// POST /send/follow and /send/unfollow.
+func (fake *fakeRemote) sendHandler(res http.ResponseWriter, req *http.Request) {
+	if req.Method != "POST" {
+		http.Error(res, "POST only.", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var value interface{}
+	switch strings.TrimPrefix(req.URL.Path, "/send/") {
+	case "reply":
+		fake.mutex.Lock()
+		fake.notes++
+		noteID := fmt.Sprintf("%s/notes/%d-%d", fake.base, time.Now().Unix(), fake.notes)
+		fake.mutex.Unlock()
+
+		value = common.Activity{
+			Context: common.ActivityStreamsContext,
+			ID:      noteID + "/activity",
+			Type:    "Create",
+			Actor:   fake.actorID(),
+			Object: common.Note{
+				ID:           noteID,
+				Type:         "Note",
+				AttributedTo: fake.actorID(),
+				InReplyTo:    req.PostFormValue("to"),
+				Content:      "<p>" + html.EscapeString(req.PostFormValue("text")) + "</p>",
+				URL:          noteID,
+				Published:    time.Now().UTC(),
+				To:           []string{common.ActivityStreamsPublic},
+			},
+			To: []string{common.ActivityStreamsPublic},
+		}
+		fmt.Fprintln(res, noteID)
+	case "delete":
+		value = common.Activity{
+			Context: common.ActivityStreamsContext,
+			ID:      req.PostFormValue("id") + "/delete",
+			Type:    "Delete",
+			Actor:   fake.actorID(),
+			Object:  req.PostFormValue("id"),
+		}
+	case "follow":
+		value = fake.follow()
+	case "unfollow":
+		value = common.Activity{
+			Context: common.ActivityStreamsContext,
+			ID:      fake.base + "/follows/1/undo",
+			Type:    "Undo",
+			Actor:   fake.actorID(),
+			Object:  fake.follow(),
+		}
+	default:
+		http.NotFound(res, req)
+		return
+	}
+
+	err := fake.send(value)
+	if err != nil {
+		log.Printf("Sending failed: %s\n", err.Error())
+		http.Error(res, err.Error(), http.StatusBadGateway)
+		return
+	}
+	log.Printf("Sent to %s\n", fake.target.Inbox)
+}
+
+// Runs a fake remote server on the given local port whose user follows the
+// actor at account, such as category-food@localhost:5000.
+func RunFakeRemote(port int, account string) error {
+	if !localDevelopment() {
+		return errors.New("FEDERATION_DOMAIN must be a localhost address to use a fake remote server")
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return err
+	}
+	fake := &fakeRemote{base: fmt.Sprintf("http://localhost:%d", port), key: key}
+
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users/tester", fake.actorHandler)
+	mux.HandleFunc("/users/tester/inbox", fake.inboxHandler)
+	mux.HandleFunc("/inbox", fake.inboxHandler)
+	mux.HandleFunc("/send/", fake.sendHandler)
+	go func() {
+		log.Fatal(http.Serve(listener, mux))
+	}()
+
+	actorID, err := webFinger(account)
+	if err != nil {
+		return err
+	}
+	fake.target, err = fetchActor(actorID)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Fake remote server running at %s as %s\n", fake.base, fake.actorID())
+	err = fake.send(fake.follow())
+	if err != nil {
+		return err
+	}
+	log.Printf("Followed %s\n", fake.target.ID)
+
+	select {}
+}
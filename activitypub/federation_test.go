@@ -0,0 +1,249 @@
+package activitypub
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/comforme/comforme/common"
+	"github.com/comforme/comforme/database"
+	"github.com/comforme/comforme/databaseActions"
+	"github.com/comforme/comforme/dbtest"
+)
+
+// A category actor here and a remote server to federate it with.
+type federationFixture struct {
+	conn     *sql.DB
+	actor    common.LocalActor
+	category int
+	author   int
+	remote   *testRemote
+}
+
+func newFederationFixture(t *testing.T) (f federationFixture) {
+	var dsn string
+	f.conn, dsn = dbtest.New(t)
+	testDB, err := database.NewDB(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	databaseActions.UseDB(testDB)
+	federateLocally(t)
+
+	f.category = dbtest.Category(t, f.conn, "Food")
+	f.author = dbtest.User(t, f.conn, "author")
+	f.actor, err = databaseActions.GetLocalActor("food", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f.remote = newTestRemote(t)
+	f.remote.verify = f.verifyFromActor
+	return
+}
+
+// Checks that a delivery was signed by the category actor.
+func (f federationFixture) verifyFromActor(req *http.Request, body []byte) error {
+	sig, err := parseSignature(req)
+	if err != nil {
+		return err
+	}
+	if sig.KeyID != f.actor.URL()+"#main-key" {
+		return errors.New("signed with " + sig.KeyID)
+	}
+	if err := checkSignedRequest(req, sig, body); err != nil {
+		return err
+	}
+	key, err := parsePublicKey(f.actor.PublicKey)
+	if err != nil {
+		return err
+	}
+	return verifySignature(req, sig, key)
+}
+
+func (f federationFixture) follow() common.Activity {
+	return common.Activity{
+		Context: common.ActivityStreamsContext,
+		ID:      f.remote.server.URL + "/follows/1",
+		Type:    "Follow",
+		Actor:   f.remote.actorID(),
+		Object:  f.actor.URL(),
+	}
+}
+
+// Follows the category actor and takes the Accept.
+func (f federationFixture) followActor(t *testing.T) {
+	if code := f.remote.send(f.actor.Path()+"/inbox", f.follow()); code != http.StatusAccepted {
+		t.Fatalf("follow: got %d", code)
+	}
+	deliverDue()
+	f.remote.takeReceived()
+}
+
+func (f federationFixture) pendingDeliveries(t *testing.T) (count int) {
+	if err := f.conn.QueryRow("SELECT count(*) FROM ap_deliveries WHERE status = 'pending'").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	return
+}
+
+func TestFollowIsAccepted(t *testing.T) {
+	f := newFederationFixture(t)
+
+	if code := f.remote.send(f.actor.Path()+"/inbox", f.follow()); code != http.StatusAccepted {
+		t.Fatalf("got %d", code)
+	}
+
+	var inbox string
+	if err := f.conn.QueryRow(
+		"SELECT inbox FROM ap_followers WHERE actor_id = $1 AND follower = $2",
+		f.actor.Id,
+		f.remote.actorID(),
+	).Scan(&inbox); err != nil {
+		t.Fatal(err)
+	}
+	if inbox != f.remote.server.URL+"/inbox" {
+		t.Errorf("follower delivered to %s, want the shared inbox", inbox)
+	}
+
+	deliverDue()
+	received := f.remote.takeReceived()
+	if len(received) != 1 {
+		t.Fatalf("got %d deliveries, want an Accept", len(received))
+	}
+	accept := received[0]
+	if accept.inbox != "/users/tester/inbox" || accept.activity.Type != "Accept" || accept.activity.Actor != f.actor.URL() {
+		t.Errorf("got %s from %s at %s", accept.activity.Type, accept.activity.Actor, accept.inbox)
+	}
+	if objectID(accept.activity.Object) != f.follow().ID {
+		t.Errorf("accepted %s", accept.activity.Object)
+	}
+	if f.pendingDeliveries(t) != 0 {
+		t.Error("delivered Accept is still pending")
+	}
+
+	undo := common.Activity{
+		ID:     f.follow().ID + "/undo",
+		Type:   "Undo",
+		Actor:  f.remote.actorID(),
+		Object: f.follow(),
+	}
+	if code := f.remote.send("/ap/inbox", undo); code != http.StatusAccepted {
+		t.Fatalf("undo: got %d", code)
+	}
+	var followers int
+	f.conn.QueryRow("SELECT count(*) FROM ap_followers WHERE actor_id = $1", f.actor.Id).Scan(&followers)
+	if followers != 0 {
+		t.Errorf("got %d followers after Undo", followers)
+	}
+}
+
+func TestNewPagesAreDeliveredToFollowers(t *testing.T) {
+	f := newFederationFixture(t)
+	f.followActor(t)
+
+	_, _, err := databaseActions.CreatePage(f.author, "Corner Bakery", "Bread every morning.", "", "", nil, f.category)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deliverDue()
+
+	received := f.remote.takeReceived()
+	if len(received) != 1 {
+		t.Fatalf("got %d deliveries, want one Create", len(received))
+	}
+	create := received[0]
+	if create.inbox != "/inbox" || create.activity.Type != "Create" || create.activity.Actor != f.actor.URL() {
+		t.Fatalf("got %s from %s at %s", create.activity.Type, create.activity.Actor, create.inbox)
+	}
+
+	var note common.Note
+	if err := json.Unmarshal(create.activity.Object, &note); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(note.ID, common.FederationURL("/ap/pages/")) || note.AttributedTo != f.actor.URL() {
+		t.Errorf("got note %s attributed to %s", note.ID, note.AttributedTo)
+	}
+	if !strings.Contains(note.Content, "Corner Bakery") || strings.Contains(note.Content, "author") {
+		t.Errorf("got content %q", note.Content)
+	}
+}
+
+func TestRepliesAreHeldForModeration(t *testing.T) {
+	f := newFederationFixture(t)
+	pageID := dbtest.Page(t, f.conn, f.author, f.category, "Bakery")
+	moderator := common.UserInfo{UserID: dbtest.User(t, f.conn, "moderator"), Role: common.RoleModerator}
+
+	reply := func(id, attributedTo, inReplyTo string) common.Activity {
+		return common.Activity{
+			ID:    id + "/activity",
+			Type:  "Create",
+			Actor: f.remote.actorID(),
+			Object: common.Note{
+				ID:           id,
+				Type:         "Note",
+				AttributedTo: attributedTo,
+				InReplyTo:    inReplyTo,
+				Content:      "<p>Great <b>bread</b>!</p><p>Try the rye.</p>",
+			},
+		}
+	}
+	pageObject := common.FederationURL(common.PageObjectPath(pageID))
+	replies := []common.Activity{
+		reply(f.remote.server.URL+"/notes/1", f.remote.actorID(), pageObject),
+		// Not a reply to anything here
+		reply(f.remote.server.URL+"/notes/2", f.remote.actorID(), "https://elsewhere.example.com/notes/1"),
+		// Attributed to someone other than the sender
+		reply(f.remote.server.URL+"/notes/3", f.remote.server.URL+"/users/someone-else", pageObject),
+	}
+	for _, activity := range replies {
+		if code := f.remote.send("/ap/inbox", activity); code != http.StatusAccepted {
+			t.Fatalf("got %d", code)
+		}
+	}
+
+	var posts int
+	f.conn.QueryRow("SELECT count(*) FROM posts WHERE page_id = $1", pageID).Scan(&posts)
+	if posts != 0 {
+		t.Errorf("reply posted before moderation")
+	}
+
+	pending, err := databaseActions.GetPendingReplies(moderator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 {
+		t.Fatalf("got %d pending replies, want 1", len(pending))
+	}
+	held := pending[0]
+	if held.PageID != pageID || held.Actor != f.remote.actorID() || held.Content != "Great bread!\n\nTry the rye." {
+		t.Errorf("got pending reply %+v", held)
+	}
+	if _, err := databaseActions.GetPendingReplies(common.UserInfo{UserID: f.author, Role: common.RoleUser}); err != common.PermissionDenied {
+		t.Errorf("users can see pending replies: %v", err)
+	}
+
+	if err := databaseActions.ApproveReply(moderator, held.Id); err != nil {
+		t.Fatal(err)
+	}
+	var remoteAuthor string
+	if err := f.conn.QueryRow("SELECT remote_author FROM posts WHERE page_id = $1", pageID).Scan(&remoteAuthor); err != nil {
+		t.Fatal(err)
+	}
+	if remoteAuthor != held.Author {
+		t.Errorf("posted as %q, want %q", remoteAuthor, held.Author)
+	}
+
+	// Its author deleting the reply removes the post
+	remove := common.Activity{ID: held.ObjectID + "/delete", Type: "Delete", Actor: f.remote.actorID(), Object: held.ObjectID}
+	if code := f.remote.send("/ap/inbox", remove); code != http.StatusAccepted {
+		t.Fatalf("delete: got %d", code)
+	}
+	f.conn.QueryRow("SELECT count(*) FROM posts WHERE page_id = $1", pageID).Scan(&posts)
+	if posts != 0 {
+		t.Errorf("deleted reply is still posted")
+	}
+}
@@ -0,0 +1,261 @@
+package activitypub
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/comforme/comforme/common"
+)
+
+const (
+	fetchTimeout    = 10 * time.Second
+	maxDocumentSize = 1 << 20
+	actorCacheTime  = time.Hour
+	activityJSON    = "application/activity+json"
+	acceptActivity  = `application/activity+json, application/ld+json; profile="https://www.w3.org/ns/activitystreams"`
+)
+
+var (
+	errForbiddenURL     = errors.New("URL is not allowed")
+	errForbiddenAddress = errors.New("address is not allowed")
+	errNotAnActor       = errors.New("document is not an actor")
+	errKeyMismatch      = errors.New("key does not belong to actor")
+)
+
+// Whether this is a development server federating with other servers on the
+// same machine, such as the fake remote instance. Only then are plain http
+// and loopback addresses allowed.
+func localDevelopment() bool {
+	host := common.FederationDomain
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return host == "localhost" || host == "127.0.0.1" || host == "::1"
+}
+
+// Keeps remote servers from making us fetch from or deliver to our own
+// network.
+func checkAddress(network, address string, conn syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return errForbiddenAddress
+	}
+	if ip.IsLoopback() && localDevelopment() {
+		return nil
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsUnspecified() || ip.IsMulticast() {
+		return errForbiddenAddress
+	}
+	return nil
+}
+
+var client = &http.Client{
+	Timeout: fetchTimeout,
+	Transport: &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout: fetchTimeout,
+			Control: checkAddress,
+		}).DialContext,
+		TLSHandshakeTimeout: fetchTimeout,
+	},
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 3 {
+			return errors.New("too many redirects")
+		}
+		return checkURL(req.URL.String())
+	},
+}
+
+func checkURL(uri string) error {
+	parsed, err := url.Parse(uri)
+	if err != nil || parsed.Host == "" {
+		return errForbiddenURL
+	}
+	if parsed.Scheme == "https" || (parsed.Scheme == "http" && localDevelopment()) {
+		return nil
+	}
+	return errForbiddenURL
+}
+
+// Fetches an ActivityStreams document.
+func fetch(uri string, document interface{}) error {
+	err := checkURL(uri)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("GET", uri, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", acceptActivity)
+
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetching %s: %s", uri, res.Status)
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(res.Body, maxDocumentSize))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, document)
+}
+
+type publicKey struct {
+	ID           string `json:"id"`
+	Owner        string `json:"owner"`
+	PublicKeyPem string `json:"publicKeyPem"`
+}
+
+type actorDocument struct {
+	ID                string      `json:"id"`
+	Type              string      `json:"type"`
+	Inbox             string      `json:"inbox"`
+	PreferredUsername string      `json:"preferredUsername"`
+	URL               interface{} `json:"url"`
+	Endpoints         struct {
+		SharedInbox string `json:"sharedInbox"`
+	} `json:"endpoints"`
+	PublicKey publicKey `json:"publicKey"`
+
+	// Set when the document is a key on its own rather than an actor
+	Owner        string `json:"owner"`
+	PublicKeyPem string `json:"publicKeyPem"`
+}
+
+type cachedActor struct {
+	actor   common.RemoteActor
+	fetched time.Time
+}
+
+var actorCache = struct {
+	sync.Mutex
+	byKey map[string]cachedActor
+}{byKey: map[string]cachedActor{}}
+
+func stripFragment(uri string) string {
+	if i := strings.Index(uri, "#"); i >= 0 {
+		return uri[:i]
+	}
+	return uri
+}
+
+// Fetches an actor by its ID.
+func fetchActor(id string) (actor common.RemoteActor, err error) {
+	var document actorDocument
+	err = fetch(id, &document)
+	if err != nil {
+		return
+	}
+
+	if document.ID == "" || document.Inbox == "" || stripFragment(document.ID) != stripFragment(id) {
+		return actor, errNotAnActor
+	}
+	for _, uri := range []string{document.Inbox, document.Endpoints.SharedInbox} {
+		if uri != "" && checkURL(uri) != nil {
+			return actor, errForbiddenURL
+		}
+	}
+
+	actor = common.RemoteActor{
+		ID:                document.ID,
+		Inbox:             document.Inbox,
+		SharedInbox:       document.Endpoints.SharedInbox,
+		PreferredUsername: document.PreferredUsername,
+		PublicKeyID:       document.PublicKey.ID,
+		PublicKeyPem:      document.PublicKey.PublicKeyPem,
+	}
+	if profile, ok := document.URL.(string); ok && checkURL(profile) == nil {
+		actor.URL = profile
+	}
+	if document.PublicKey.Owner != "" && document.PublicKey.Owner != document.ID {
+		return actor, errKeyMismatch
+	}
+	return
+}
+
+// Finds the actor that owns a key, using the cache unless refresh is set.
+func actorForKey(keyID string, refresh bool) (actor common.RemoteActor, err error) {
+	actorCache.Lock()
+	cached, ok := actorCache.byKey[keyID]
+	actorCache.Unlock()
+	if ok && !refresh && time.Since(cached.fetched) < actorCacheTime {
+		return cached.actor, nil
+	}
+
+	// The key ID is usually the actor's ID with a fragment, but may be a
+	// document of its own naming its owner.
+	var document actorDocument
+	err = fetch(stripFragment(keyID), &document)
+	if err != nil {
+		return
+	}
+	actorID := document.ID
+	if document.Owner != "" && document.Inbox == "" {
+		actorID = document.Owner
+	}
+
+	actor, err = fetchActor(actorID)
+	if err != nil {
+		return
+	}
+	if actor.PublicKeyID != keyID || actor.PublicKeyPem == "" {
+		return actor, errKeyMismatch
+	}
+
+	actorCache.Lock()
+	actorCache.byKey[keyID] = cachedActor{actor, time.Now()}
+	actorCache.Unlock()
+	return actor, nil
+}
+
+// Verifies a signed request and returns the actor that signed it. A key that
+// fails to verify is fetched again once, in case it was rotated.
+func verifyRequest(req *http.Request, body []byte) (actor common.RemoteActor, err error) {
+	sig, err := parseSignature(req)
+	if err != nil {
+		return
+	}
+	err = checkSignedRequest(req, sig, body)
+	if err != nil {
+		return
+	}
+
+	for _, refresh := range []bool{false, true} {
+		actor, err = actorForKey(sig.KeyID, refresh)
+		if err != nil {
+			return
+		}
+
+		key, keyErr := parsePublicKey(actor.PublicKeyPem)
+		if keyErr != nil {
+			return actor, keyErr
+		}
+		err = verifySignature(req, sig, key)
+		if err == nil {
+			return
+		}
+	}
+	return
+}
@@ -0,0 +1,170 @@
+package activitypub
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/comforme/comforme/common"
+)
+
+// The domain tests federate under. Being local, it lets the test remote be
+// fetched over plain http from a loopback address.
+const testDomain = "localhost"
+
+func federateLocally(t *testing.T) {
+	previous := common.FederationDomain
+	common.FederationDomain = testDomain
+	t.Cleanup(func() { common.FederationDomain = previous })
+}
+
+// Another server for tests to federate with, like the fake remote but
+// recording what it receives. Its user signs with key; keyOwner, if set, is
+// who its key document claims owns the key.
+type testRemote struct {
+	t        *testing.T
+	server   *httptest.Server
+	key      *rsa.PrivateKey
+	keyOwner string
+
+	// Checks the signature on activities delivered to the inbox; nil to
+	// accept any.
+	verify func(req *http.Request, body []byte) error
+
+	mutex    sync.Mutex
+	received []received
+}
+
+// An activity delivered to the test remote, and the inbox it came to.
+type received struct {
+	inbox    string
+	activity activity
+}
+
+func newTestRemote(t *testing.T) *testRemote {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	remote := &testRemote{t: t, key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users/tester", remote.actorHandler)
+	mux.HandleFunc("/users/tester/inbox", remote.inboxHandler)
+	mux.HandleFunc("/inbox", remote.inboxHandler)
+	remote.server = httptest.NewServer(mux)
+	t.Cleanup(remote.server.Close)
+	return remote
+}
+
+func (remote *testRemote) actorID() string {
+	return remote.server.URL + "/users/tester"
+}
+
+func (remote *testRemote) keyID() string {
+	return remote.actorID() + "#main-key"
+}
+
+func (remote *testRemote) actorHandler(res http.ResponseWriter, req *http.Request) {
+	publicKey, err := x509.MarshalPKIXPublicKey(&remote.key.PublicKey)
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	owner := remote.keyOwner
+	if owner == "" {
+		owner = remote.actorID()
+	}
+	writeActivityJSON(res, map[string]interface{}{
+		"@context":          []string{common.ActivityStreamsContext, securityContext},
+		"id":                remote.actorID(),
+		"type":              "Person",
+		"preferredUsername": "tester",
+		"inbox":             remote.actorID() + "/inbox",
+		"endpoints":         map[string]string{"sharedInbox": remote.server.URL + "/inbox"},
+		"publicKey": map[string]string{
+			"id":           remote.keyID(),
+			"owner":        owner,
+			"publicKeyPem": string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKey})),
+		},
+	})
+}
+
+func (remote *testRemote) inboxHandler(res http.ResponseWriter, req *http.Request) {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if remote.verify != nil {
+		if err := remote.verify(req, body); err != nil {
+			remote.t.Errorf("delivery to %s: %s", req.URL.Path, err)
+			http.Error(res, "Invalid signature.", http.StatusUnauthorized)
+			return
+		}
+	}
+
+	var incoming activity
+	if err := json.Unmarshal(body, &incoming); err != nil {
+		remote.t.Errorf("delivery to %s: %s", req.URL.Path, err)
+		http.Error(res, "Invalid activity.", http.StatusBadRequest)
+		return
+	}
+
+	remote.mutex.Lock()
+	remote.received = append(remote.received, received{req.URL.Path, incoming})
+	remote.mutex.Unlock()
+	res.WriteHeader(http.StatusAccepted)
+}
+
+// Takes the activities received so far.
+func (remote *testRemote) takeReceived() []received {
+	remote.mutex.Lock()
+	defer remote.mutex.Unlock()
+	taken := remote.received
+	remote.received = nil
+	return taken
+}
+
+// Makes a request to path on this server signed with key, as the remote
+// would send it.
+func (remote *testRemote) signedRequest(path string, value interface{}, key *rsa.PrivateKey) *http.Request {
+	body, err := json.Marshal(value)
+	if err != nil {
+		remote.t.Fatal(err)
+	}
+
+	req, err := http.NewRequest("POST", "http://"+testDomain+path, bytes.NewReader(body))
+	if err != nil {
+		remote.t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", activityJSON)
+	if err := sign(req, body, remote.keyID(), key); err != nil {
+		remote.t.Fatal(err)
+	}
+	return req
+}
+
+// Sends an activity to an inbox here, returning the response's status code.
+func (remote *testRemote) send(path string, value interface{}) int {
+	return serve(remote.signedRequest(path, value, remote.key))
+}
+
+func serve(req *http.Request) int {
+	router := httprouter.New()
+	Register(router)
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	return res.Code
+}
@@ -0,0 +1,202 @@
+package activitypub
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// HTTP Signatures as used across the fediverse (draft-cavage-http-signatures,
+// rsa-sha256).
+
+const maxClockSkew = time.Hour
+
+// The headers signed on requests we send and required on those we receive.
+var signedHeaders = []string{"(request-target)", "host", "date", "digest"}
+
+var signatureParam = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+var (
+	errNoSignature        = errors.New("request is not signed")
+	errBadSignatureHeader = errors.New("malformed Signature header")
+	errUnsignedHeader     = errors.New("required header is not signed")
+	errStaleDate          = errors.New("Date header is too far from now")
+	errBadDigest          = errors.New("Digest does not match body")
+	errBadSignature       = errors.New("signature does not verify")
+	errBadKey             = errors.New("unusable key")
+)
+
+type signature struct {
+	KeyID     string
+	Algorithm string
+	Headers   []string
+	Signature []byte
+}
+
+func digest(body []byte) string {
+	sum := sha256.Sum256(body)
+	return "SHA-256=" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func signingString(req *http.Request, headers []string) string {
+	lines := make([]string, len(headers))
+	for i, header := range headers {
+		var value string
+		switch header {
+		case "(request-target)":
+			value = strings.ToLower(req.Method) + " " + req.URL.RequestURI()
+		case "host":
+			value = req.Host
+			if value == "" {
+				value = req.URL.Host
+			}
+		default:
+			value = strings.Join(req.Header[http.CanonicalHeaderKey(header)], ", ")
+		}
+		lines[i] = header + ": " + value
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Signs a request with the given key, setting its Date and Digest headers.
+func sign(req *http.Request, body []byte, keyID string, key *rsa.PrivateKey) error {
+	req.Header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+	req.Header.Set("Digest", digest(body))
+	if req.Host == "" {
+		req.Host = req.URL.Host
+	}
+
+	hashed := sha256.Sum256([]byte(signingString(req, signedHeaders)))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hashed[:])
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Signature", fmt.Sprintf(
+		`keyId="%s",algorithm="rsa-sha256",headers="%s",signature="%s"`,
+		keyID,
+		strings.Join(signedHeaders, " "),
+		base64.StdEncoding.EncodeToString(sig),
+	))
+	return nil
+}
+
+func parseSignature(req *http.Request) (sig signature, err error) {
+	header := req.Header.Get("Signature")
+	if header == "" {
+		return sig, errNoSignature
+	}
+
+	params := map[string]string{}
+	for _, match := range signatureParam.FindAllStringSubmatch(header, -1) {
+		params[match[1]] = match[2]
+	}
+
+	sig.KeyID = params["keyId"]
+	sig.Algorithm = params["algorithm"]
+	sig.Headers = strings.Fields(strings.ToLower(params["headers"]))
+	if len(sig.Headers) == 0 {
+		sig.Headers = []string{"date"}
+	}
+	sig.Signature, err = base64.StdEncoding.DecodeString(params["signature"])
+	if err != nil || sig.KeyID == "" || len(sig.Signature) == 0 {
+		return sig, errBadSignatureHeader
+	}
+
+	switch sig.Algorithm {
+	case "", "rsa-sha256", "hs2019":
+	default:
+		return sig, errBadSignatureHeader
+	}
+	return sig, nil
+}
+
+// Checks everything about a signed request except the signature itself:
+// that the headers that matter are signed, that it is recent and that the
+// body is the one that was signed.
+func checkSignedRequest(req *http.Request, sig signature, body []byte) error {
+	signed := map[string]bool{}
+	for _, header := range sig.Headers {
+		signed[header] = true
+	}
+	required := []string{"(request-target)", "host", "date"}
+	if req.Method == "POST" {
+		required = append(required, "digest")
+	}
+	for _, header := range required {
+		if !signed[header] {
+			return errUnsignedHeader
+		}
+	}
+
+	date, err := http.ParseTime(req.Header.Get("Date"))
+	if err != nil {
+		return errStaleDate
+	}
+	if skew := time.Since(date); skew > maxClockSkew || skew < -maxClockSkew {
+		return errStaleDate
+	}
+
+	if req.Method == "POST" {
+		expected := digest(body)
+		found := false
+		for _, value := range strings.Split(req.Header.Get("Digest"), ",") {
+			value = strings.TrimSpace(value)
+			if strings.HasPrefix(strings.ToUpper(value), "SHA-256=") &&
+				subtle.ConstantTimeCompare([]byte(value[len("SHA-256="):]), []byte(expected[len("SHA-256="):])) == 1 {
+				found = true
+			}
+		}
+		if !found {
+			return errBadDigest
+		}
+	}
+	return nil
+}
+
+func verifySignature(req *http.Request, sig signature, key *rsa.PublicKey) error {
+	hashed := sha256.Sum256([]byte(signingString(req, sig.Headers)))
+	if rsa.VerifyPKCS1v15(key, crypto.SHA256, hashed[:], sig.Signature) != nil {
+		return errBadSignature
+	}
+	return nil
+}
+
+func parsePublicKey(keyPem string) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode([]byte(keyPem))
+	if block == nil {
+		return nil, errBadKey
+	}
+
+	if block.Type == "RSA PUBLIC KEY" {
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, errBadKey
+	}
+	return rsaKey, nil
+}
+
+func parsePrivateKey(keyPem string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(keyPem))
+	if block == nil {
+		return nil, errBadKey
+	}
+	return x509.ParsePKCS1PrivateKey(block.Bytes)
+}
@@ -0,0 +1,133 @@
+package activitypub
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/comforme/comforme/common"
+)
+
+func TestSignedRequestVerifies(t *testing.T) {
+	federateLocally(t)
+	remote := newTestRemote(t)
+
+	req := remote.signedRequest("/ap/inbox", map[string]string{"type": "Like"}, remote.key)
+	body, _ := ioutil.ReadAll(req.Body)
+	actor, err := verifyRequest(req, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actor.ID != remote.actorID() || actor.SharedInbox != remote.server.URL+"/inbox" {
+		t.Errorf("got actor %+v", actor)
+	}
+	if actor.Handle() != "tester@"+strings.TrimPrefix(remote.server.URL, "http://") {
+		t.Errorf("got handle %s", actor.Handle())
+	}
+}
+
+func TestSignatureRejectsAlteredRequests(t *testing.T) {
+	federateLocally(t)
+	remote := newTestRemote(t)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	activity := map[string]string{"type": "Like"}
+	tests := []struct {
+		name   string
+		key    *rsa.PrivateKey
+		alter  func(req *http.Request, body []byte) []byte
+		reason error
+	}{
+		{"signed with another key", otherKey, nil, errBadSignature},
+		{"changed body", remote.key, func(req *http.Request, body []byte) []byte {
+			return []byte(`{"type":"Delete"}`)
+		}, errBadDigest},
+		{"changed digest", remote.key, func(req *http.Request, body []byte) []byte {
+			req.Header.Set("Digest", digest([]byte(`{"type":"Delete"}`)))
+			return []byte(`{"type":"Delete"}`)
+		}, errBadSignature},
+		{"changed path", remote.key, func(req *http.Request, body []byte) []byte {
+			req.URL.Path = "/ap/category/food/inbox"
+			return body
+		}, errBadSignature},
+		{"old date", remote.key, func(req *http.Request, body []byte) []byte {
+			req.Header.Set("Date", time.Now().Add(-2*maxClockSkew).UTC().Format(http.TimeFormat))
+			return body
+		}, errStaleDate},
+		{"digest not signed", remote.key, func(req *http.Request, body []byte) []byte {
+			req.Header.Set("Signature", strings.Replace(req.Header.Get("Signature"), " digest", "", 1))
+			return body
+		}, errUnsignedHeader},
+		{"unsigned", remote.key, func(req *http.Request, body []byte) []byte {
+			req.Header.Del("Signature")
+			return body
+		}, errNoSignature},
+	}
+	for _, test := range tests {
+		req := remote.signedRequest("/ap/inbox", activity, test.key)
+		body, _ := ioutil.ReadAll(req.Body)
+		if test.alter != nil {
+			body = test.alter(req, body)
+		}
+		if _, err := verifyRequest(req, body); err != test.reason {
+			t.Errorf("%s: got %v, want %v", test.name, err, test.reason)
+		}
+	}
+}
+
+func TestSignatureRejectsKeysOfOtherActors(t *testing.T) {
+	federateLocally(t)
+	remote := newTestRemote(t)
+	remote.keyOwner = remote.server.URL + "/users/someone-else"
+
+	req := remote.signedRequest("/ap/inbox", map[string]string{"type": "Like"}, remote.key)
+	body, _ := ioutil.ReadAll(req.Body)
+	if _, err := verifyRequest(req, body); err != errKeyMismatch {
+		t.Errorf("got %v, want %v", err, errKeyMismatch)
+	}
+}
+
+func TestInboxRejectsBadSignatures(t *testing.T) {
+	federateLocally(t)
+	remote := newTestRemote(t)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	like := common.Activity{ID: remote.server.URL + "/likes/1", Type: "Like", Actor: remote.actorID()}
+
+	if code := serve(remote.signedRequest("/ap/inbox", like, otherKey)); code != http.StatusUnauthorized {
+		t.Errorf("badly signed activity: got %d", code)
+	}
+
+	unsigned := remote.signedRequest("/ap/inbox", like, remote.key)
+	unsigned.Header.Del("Signature")
+	if code := serve(unsigned); code != http.StatusUnauthorized {
+		t.Errorf("unsigned activity: got %d", code)
+	}
+
+	// Activities must come from whoever signed them
+	like.Actor = remote.server.URL + "/users/someone-else"
+	if code := remote.send("/ap/inbox", like); code != http.StatusForbidden {
+		t.Errorf("activity from another actor: got %d", code)
+	}
+}
+
+func TestInboxNeedsFederation(t *testing.T) {
+	remote := newTestRemote(t)
+	previous := common.FederationDomain
+	common.FederationDomain = ""
+	defer func() { common.FederationDomain = previous }()
+
+	like := common.Activity{ID: remote.server.URL + "/likes/1", Type: "Like", Actor: remote.actorID()}
+	if code := remote.send("/ap/inbox", like); code != http.StatusNotFound {
+		t.Errorf("got %d", code)
+	}
+}
@@ -0,0 +1,103 @@
+package admin
+
+import (
+	"html/template"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/comforme/comforme/common"
+	"github.com/comforme/comforme/databaseActions"
+	"github.com/comforme/comforme/i18n"
+	"github.com/comforme/comforme/templates"
+)
+
+var dashboardTemplate *template.Template
+var categoriesTemplate *template.Template
+var communitiesTemplate *template.Template
+var usersTemplate *template.Template
+var auditTemplate *template.Template
+var reportsTemplate *template.Template
+var webhooksTemplate *template.Template
+var webhookTemplate *template.Template
+var federationTemplate *template.Template
+var importTemplate *template.Template
+var duplicatesTemplate *template.Template
+
+func init() {
+	dashboardTemplate = newAdminTemplate(dashboardTemplateText)
+	categoriesTemplate = newAdminTemplate(categoriesTemplateText)
+	communitiesTemplate = newAdminTemplate(communitiesTemplateText)
+	usersTemplate = newAdminTemplate(usersTemplateText)
+	auditTemplate = newAdminTemplate(auditTemplateText)
+	reportsTemplate = newAdminTemplate(reportsTemplateText)
+	webhooksTemplate = newAdminTemplate(webhooksTemplateText)
+	webhookTemplate = newAdminTemplate(webhookTemplateText)
+	federationTemplate = newAdminTemplate(federationTemplateText)
+	importTemplate = newAdminTemplate(importTemplateText)
+	duplicatesTemplate = newAdminTemplate(duplicatesTemplateText)
+}
+
+func newAdminTemplate(content string) *template.Template {
+	tmpl := template.Must(template.New("siteLayout").Funcs(i18n.Funcs).Parse(templates.SiteLayout))
+	template.Must(tmpl.New("nav").Parse(templates.NavBar))
+	template.Must(tmpl.New("adminNav").Parse(templates.AdminNav))
+	template.Must(tmpl.New("content").Parse(content))
+	return tmpl
+}
+
+func newData(req *http.Request, userInfo common.UserInfo, titleKey string) map[string]interface{} {
+	data := map[string]interface{}{}
+	data["siteName"] = common.SiteName
+	data["locale"] = userInfo.Locale
+	data["formAction"] = req.URL.Path
+	data["pageTitle"] = i18n.Translate(userInfo.Locale, titleKey)
+	return data
+}
+
+func formInt(req *http.Request, key string) (int, error) {
+	value, err := strconv.ParseInt(req.PostFormValue(key), 10, 0)
+	if err != nil {
+		log.Printf("Error parsing %s: %s\n", key, err.Error())
+	}
+	return int(value), err
+}
+
+func DashboardHandler(res http.ResponseWriter, req *http.Request, ps httprouter.Params, userInfo common.UserInfo) {
+	data := newData(req, userInfo, "admin.statistics.admin")
+
+	stats, err := databaseActions.GetSiteStatistics()
+	if err != nil {
+		data["errorMsg"] = i18n.ErrorMessage(userInfo.Locale, err)
+	} else {
+		data["stats"] = stats
+	}
+
+	common.ExecTemplate(dashboardTemplate, res, data)
+}
+
+const dashboardTemplateText = `
+	<div class="content">
+		<div class="row">
+			<div class="columns">
+				<h1><i class="fi-graph-bar"></i> {{t $.locale "admin.statistics.title"}}</h1>
+{{template "adminNav" .}}{{if .errorMsg}}
+				<div class="alert-box alert">{{.errorMsg}}</div>{{end}}{{with .stats}}
+				<table>
+					<tbody>
+						<tr><th>{{t $.locale "admin.statistics.users"}}</th><td>{{.Users}}</td></tr>
+						<tr><th>{{t $.locale "admin.statistics.suspended_users"}}</th><td>{{.SuspendedUsers}}</td></tr>
+						<tr><th>{{t $.locale "admin.statistics.sessions"}}</th><td>{{.Sessions}}</td></tr>
+						<tr><th>{{t $.locale "admin.statistics.pages"}}</th><td>{{.Pages}} ({{t $.locale "admin.statistics.this_week" .PagesThisWeek}})</td></tr>
+						<tr><th>{{t $.locale "admin.statistics.posts"}}</th><td>{{.Posts}} ({{t $.locale "admin.statistics.this_week" .PostsThisWeek}})</td></tr>
+						<tr><th>{{t $.locale "admin.statistics.categories"}}</th><td>{{.Categories}}</td></tr>
+						<tr><th>{{t $.locale "admin.statistics.communities"}}</th><td>{{.Communities}}</td></tr>
+						<tr><th>{{t $.locale "admin.statistics.memberships"}}</th><td>{{.Memberships}}</td></tr>
+					</tbody>
+				</table>{{end}}
+			</div>
+		</div>
+	</div>
+`
@@ -0,0 +1,88 @@
+package admin
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/comforme/comforme/common"
+	"github.com/comforme/comforme/databaseActions"
+	"github.com/comforme/comforme/i18n"
+)
+
+func AuditHandler(res http.ResponseWriter, req *http.Request, ps httprouter.Params, userInfo common.UserInfo) {
+	data := newData(req, userInfo, "admin.audit.title")
+
+	query := req.URL.Query()
+	filter := common.AuditFilter{
+		Action:    query.Get("action"),
+		IpAddress: query.Get("ip"),
+	}
+	filter.BeforeID, _ = strconv.ParseInt(query.Get("before"), 10, 64)
+	username := query.Get("user")
+
+	data["action"] = filter.Action
+	data["ip"] = filter.IpAddress
+	data["user"] = username
+
+	events, err := databaseActions.GetAuditEvents(filter, username)
+	if err != nil {
+		data["errorMsg"] = i18n.ErrorMessage(userInfo.Locale, err)
+	}
+	data["events"] = events
+
+	// Link to the next page of older events
+	if len(events) > 0 {
+		next := req.URL.Query()
+		next.Set("before", strconv.FormatInt(events[len(events)-1].Id, 10))
+		data["olderURL"] = req.URL.Path + "?" + next.Encode()
+	}
+
+	common.ExecTemplate(auditTemplate, res, data)
+}
+
+const auditTemplateText = `
+	<div class="content">
+		<div class="row">
+			<div class="columns">
+				<h1><i class="fi-clipboard-notes"></i> {{t $.locale "admin.audit.title"}}</h1>
+{{template "adminNav" .}}{{if .errorMsg}}
+				<div class="alert-box alert">{{.errorMsg}}</div>{{end}}
+				<form method="get" action="{{.formAction}}">
+					<div class="row">
+						<div class="medium-3 columns">
+							<input type="text" name="action" placeholder="{{t $.locale "admin.audit.action_placeholder"}}"{{if .action}} value="{{.action}}"{{end}}>
+						</div>
+						<div class="medium-3 columns">
+							<input type="text" name="user" placeholder="{{t $.locale "admin.audit.user_placeholder"}}"{{if .user}} value="{{.user}}"{{end}}>
+						</div>
+						<div class="medium-3 columns">
+							<input type="text" name="ip" placeholder="{{t $.locale "admin.audit.ip_placeholder"}}"{{if .ip}} value="{{.ip}}"{{end}}>
+						</div>
+						<div class="medium-3 columns">
+							<button type="submit" class="button small">{{t $.locale "admin.audit.filter"}}</button>
+						</div>
+					</div>
+				</form>
+				<table>
+					<thead>
+						<tr><th>{{t $.locale "admin.date"}}</th><th>{{t $.locale "admin.audit.action"}}</th><th>{{t $.locale "admin.audit.actor"}}</th><th>{{t $.locale "admin.user"}}</th><th>{{t $.locale "admin.audit.ip"}}</th><th>{{t $.locale "admin.audit.details"}}</th></tr>
+					</thead>
+					<tbody>{{range .events}}
+						<tr>
+							<td>{{.Date.Format "2006-01-02 15:04:05"}}</td>
+							<td>{{.Action}}</td>
+							<td>{{if .ActorName}}{{.ActorName}}{{else if .ActorID}}#{{.ActorID}}{{end}}</td>
+							<td>{{if .SubjectName}}{{.SubjectName}}{{else if .SubjectUserID}}#{{.SubjectUserID}}{{end}}</td>
+							<td title="{{.UserAgent}}">{{.IpAddress}}</td>
+							<td>{{.Details}}</td>
+						</tr>{{else}}
+						<tr><td colspan="6">{{t $.locale "admin.audit.none"}}</td></tr>{{end}}
+					</tbody>
+				</table>{{if .olderURL}}
+				<a href="{{.olderURL}}" class="button small secondary">{{t $.locale "admin.audit.older"}}</a>{{end}}
+			</div>
+		</div>
+	</div>
+`
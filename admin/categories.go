@@ -0,0 +1,106 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/comforme/comforme/common"
+	"github.com/comforme/comforme/databaseActions"
+	"github.com/comforme/comforme/i18n"
+)
+
+func CategoriesHandler(res http.ResponseWriter, req *http.Request, ps httprouter.Params, userInfo common.UserInfo) {
+	data := newData(req, userInfo, "admin.categories.title")
+
+	if req.Method == "POST" {
+		var err error
+		switch req.PostFormValue("action") {
+		case "create":
+			err = databaseActions.CreateCategory(userInfo, req.PostFormValue("name"))
+			if err == nil {
+				data["successMsg"] = i18n.Translate(userInfo.Locale, "admin.categories.created")
+			}
+		case "rename":
+			var categoryID int
+			if categoryID, err = formInt(req, "categoryid"); err == nil {
+				err = databaseActions.RenameCategory(userInfo, categoryID, req.PostFormValue("name"))
+			}
+			if err == nil {
+				data["successMsg"] = i18n.Translate(userInfo.Locale, "admin.categories.renamed")
+			}
+		case "delete":
+			var categoryID int
+			if categoryID, err = formInt(req, "categoryid"); err == nil {
+				err = databaseActions.DeleteCategory(userInfo, categoryID)
+			}
+			if err == nil {
+				data["successMsg"] = i18n.Translate(userInfo.Locale, "admin.categories.deleted")
+			}
+		}
+		if err != nil {
+			data["errorMsg"] = i18n.ErrorMessage(userInfo.Locale, err)
+		}
+	}
+
+	categories, err := databaseActions.GetCategories()
+	if err != nil {
+		data["errorMsg"] = i18n.ErrorMessage(userInfo.Locale, err)
+	}
+	data["categories"] = categories
+
+	common.ExecTemplate(categoriesTemplate, res, data)
+}
+
+const categoriesTemplateText = `
+	<div class="content">
+		<div class="row">
+			<div class="columns">
+				<h1><i class="fi-folder"></i> {{t $.locale "admin.categories.title"}}</h1>
+{{template "adminNav" .}}{{if .successMsg}}
+				<div class="alert-box success">{{.successMsg}}</div>{{end}}{{if .errorMsg}}
+				<div class="alert-box alert">{{.errorMsg}}</div>{{end}}
+				<form method="post" action="{{.formAction}}">
+					<div class="row collapse">
+						<div class="small-10 columns">
+							<input type="text" name="name" placeholder="{{t $.locale "admin.categories.new"}}">
+						</div>
+						<div class="small-2 columns">
+							<button type="submit" class="button postfix" name="action" value="create">{{t $.locale "admin.add"}}</button>
+						</div>
+					</div>
+				</form>
+				<table>
+					<thead>
+						<tr><th>{{t $.locale "admin.name"}}</th><th>{{t $.locale "admin.categories.slug"}}</th><th>{{t $.locale "admin.pages"}}</th><th></th></tr>
+					</thead>
+					<tbody>{{range .categories}}
+						<tr>
+							<td>
+								<form method="post" action="{{$.formAction}}">
+									<input type="hidden" name="categoryid" value="{{.Id}}">
+									<div class="row collapse">
+										<div class="small-9 columns">
+											<input type="text" name="name" value="{{.Name}}">
+										</div>
+										<div class="small-3 columns">
+											<button type="submit" class="button postfix" name="action" value="rename">{{t $.locale "admin.rename"}}</button>
+										</div>
+									</div>
+								</form>
+							</td>
+							<td>{{.Slug}}</td>
+							<td>{{.PageCount}}</td>
+							<td>
+								<form method="post" action="{{$.formAction}}">
+									<input type="hidden" name="categoryid" value="{{.Id}}">
+									<button type="submit" class="button tiny alert" name="action" value="delete"{{if .PageCount}} disabled{{end}}>{{t $.locale "admin.delete"}}</button>
+								</form>
+							</td>
+						</tr>{{end}}
+					</tbody>
+				</table>
+			</div>
+		</div>
+	</div>
+`
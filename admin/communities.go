@@ -0,0 +1,199 @@
+package admin
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/comforme/comforme/common"
+	"github.com/comforme/comforme/databaseActions"
+	"github.com/comforme/comforme/i18n"
+)
+
+func CommunitiesHandler(res http.ResponseWriter, req *http.Request, ps httprouter.Params, userInfo common.UserInfo) {
+	data := newData(req, userInfo, "admin.communities.title")
+
+	if req.Method == "POST" {
+		var err error
+		switch req.PostFormValue("action") {
+		case "create":
+			err = databaseActions.CreateCommunity(userInfo, req.PostFormValue("name"))
+			if err == nil {
+				data["successMsg"] = i18n.Translate(userInfo.Locale, "admin.communities.created")
+			}
+		case "rename":
+			var communityID int
+			if communityID, err = formInt(req, "communityid"); err == nil {
+				err = databaseActions.RenameCommunity(userInfo, communityID, req.PostFormValue("name"))
+			}
+			if err == nil {
+				data["successMsg"] = i18n.Translate(userInfo.Locale, "admin.communities.renamed")
+			}
+		case "delete":
+			var communityID int
+			if communityID, err = formInt(req, "communityid"); err == nil {
+				err = databaseActions.DeleteCommunity(userInfo, communityID)
+			}
+			if err == nil {
+				data["successMsg"] = i18n.Translate(userInfo.Locale, "admin.communities.deleted")
+			}
+		case "setParent":
+			var communityID, parentID int
+			if communityID, err = formInt(req, "communityid"); err == nil {
+				if parentID, err = formInt(req, "parentid"); err == nil {
+					err = databaseActions.SetCommunityParent(userInfo, communityID, parentID)
+				}
+			}
+			if err == nil {
+				data["successMsg"] = i18n.Translate(userInfo.Locale, "admin.communities.moved")
+			}
+		case "setAliases":
+			var communityID int
+			if communityID, err = formInt(req, "communityid"); err == nil {
+				err = databaseActions.SetCommunityAliases(userInfo, communityID, req.PostFormValue("aliases"))
+			}
+			if err == nil {
+				data["successMsg"] = i18n.Translate(userInfo.Locale, "admin.communities.aliases_saved")
+			}
+		case "merge":
+			var fromID, intoID, moved int
+			if fromID, err = formInt(req, "fromid"); err == nil {
+				if intoID, err = formInt(req, "intoid"); err == nil {
+					moved, err = databaseActions.MergeCommunities(userInfo, fromID, intoID)
+				}
+			}
+			if err == nil {
+				data["successMsg"] = i18n.Plural(userInfo.Locale, "admin.communities.merged", moved)
+			}
+		}
+		if err != nil {
+			data["errorMsg"] = i18n.ErrorMessage(userInfo.Locale, err)
+		}
+	}
+
+	communities, err := databaseActions.GetCommunities()
+	if err != nil {
+		data["errorMsg"] = i18n.ErrorMessage(userInfo.Locale, err)
+	}
+	data["communities"] = communities
+
+	aliases, err := databaseActions.GetCommunityAliases()
+	if err != nil {
+		data["errorMsg"] = i18n.ErrorMessage(userInfo.Locale, err)
+	}
+	aliasLists := map[int]string{}
+	for communityID, communityAliases := range aliases {
+		aliasLists[communityID] = strings.Join(communityAliases, ", ")
+	}
+	data["aliases"] = aliasLists
+
+	common.ExecTemplate(communitiesTemplate, res, data)
+}
+
+const communitiesTemplateText = `
+	<div class="content">
+		<div class="row">
+			<div class="columns">
+				<h1><i class="fi-torsos-all"></i> {{t $.locale "admin.communities.title"}}</h1>
+{{template "adminNav" .}}{{if .successMsg}}
+				<div class="alert-box success">{{.successMsg}}</div>{{end}}{{if .errorMsg}}
+				<div class="alert-box alert">{{.errorMsg}}</div>{{end}}
+				<form method="post" action="{{.formAction}}">
+					<div class="row collapse">
+						<div class="small-10 columns">
+							<input type="text" name="name" placeholder="{{t $.locale "admin.communities.new"}}">
+						</div>
+						<div class="small-2 columns">
+							<button type="submit" class="button postfix" name="action" value="create">{{t $.locale "admin.add"}}</button>
+						</div>
+					</div>
+				</form>
+				<form method="post" action="{{.formAction}}">
+					<fieldset>
+						<legend>{{t $.locale "admin.communities.merge_legend"}}</legend>
+						<div class="row">
+							<div class="medium-5 columns">
+								<label>
+									{{t $.locale "admin.merge"}}
+									<select name="fromid">{{range .communities}}
+										<option value="{{.Id}}">{{.Name}} ({{.MemberCount}})</option>{{end}}
+									</select>
+								</label>
+							</div>
+							<div class="medium-5 columns">
+								<label>
+									{{t $.locale "admin.communities.into"}}
+									<select name="intoid">{{range .communities}}
+										<option value="{{.Id}}">{{.Name}} ({{.MemberCount}})</option>{{end}}
+									</select>
+								</label>
+							</div>
+							<div class="medium-2 columns">
+								<button type="submit" class="button small" name="action" value="merge">{{t $.locale "admin.merge"}}</button>
+							</div>
+						</div>
+					</fieldset>
+				</form>
+				<table>
+					<thead>
+						<tr><th>{{t $.locale "admin.name"}}</th><th>{{t $.locale "admin.communities.aliases"}}</th><th>{{t $.locale "admin.communities.part_of"}}</th><th>{{t $.locale "admin.communities.members"}}</th><th></th></tr>
+					</thead>
+					<tbody>{{range .communities}}
+						<tr>
+							<td>
+								<form method="post" action="{{$.formAction}}">
+									<input type="hidden" name="communityid" value="{{.Id}}">
+									<div class="row collapse">
+										<div class="small-9 columns">
+											<input type="text" name="name" value="{{.Name}}">
+										</div>
+										<div class="small-3 columns">
+											<button type="submit" class="button postfix" name="action" value="rename">{{t $.locale "admin.rename"}}</button>
+										</div>
+									</div>
+								</form>
+							</td>
+							<td>
+								<form method="post" action="{{$.formAction}}">
+									<input type="hidden" name="communityid" value="{{.Id}}">
+									<div class="row collapse">
+										<div class="small-9 columns">
+											<input type="text" name="aliases" placeholder="{{t $.locale "admin.communities.comma_separated"}}" value="{{index $.aliases .Id}}">
+										</div>
+										<div class="small-3 columns">
+											<button type="submit" class="button postfix" name="action" value="setAliases">{{t $.locale "admin.save"}}</button>
+										</div>
+									</div>
+								</form>
+							</td>
+							<td>
+								<form method="post" action="{{$.formAction}}">
+									<input type="hidden" name="communityid" value="{{.Id}}">
+									<div class="row collapse">
+										<div class="small-9 columns">{{$community := .}}
+											<select name="parentid">
+												<option value="0">{{t $.locale "admin.none"}}</option>{{range $.communities}}{{if ne .Id $community.Id}}
+												<option value="{{.Id}}"{{if eq .Id $community.ParentID}} selected=""{{end}}>{{.Name}}</option>{{end}}{{end}}
+											</select>
+										</div>
+										<div class="small-3 columns">
+											<button type="submit" class="button postfix" name="action" value="setParent">{{t $.locale "admin.communities.move"}}</button>
+										</div>
+									</div>
+								</form>
+							</td>
+							<td>{{.MemberCount}}</td>
+							<td>
+								<form method="post" action="{{$.formAction}}">
+									<input type="hidden" name="communityid" value="{{.Id}}">
+									<button type="submit" class="button tiny alert" name="action" value="delete">{{t $.locale "admin.delete"}}</button>
+								</form>
+							</td>
+						</tr>{{end}}
+					</tbody>
+				</table>
+			</div>
+		</div>
+	</div>
+`
@@ -0,0 +1,116 @@
+package admin
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/comforme/comforme/common"
+	"github.com/comforme/comforme/databaseActions"
+	"github.com/comforme/comforme/i18n"
+)
+
+// Lists the pages that may be duplicates of a page so that moderators can
+// merge them. Merging this page away redirects to the page it went into.
+func DuplicatesHandler(res http.ResponseWriter, req *http.Request, ps httprouter.Params, userInfo common.UserInfo) {
+	data := newData(req, userInfo, "admin.duplicates.title")
+
+	pageID, err := strconv.Atoi(ps.ByName("id"))
+	if err != nil {
+		http.NotFound(res, req)
+		return
+	}
+
+	if req.Method == "POST" {
+		var err error
+		switch req.PostFormValue("action") {
+		case "merge-into":
+			var into common.Page
+			if linked := req.PostFormValue("into"); linked != "" {
+				into, err = databaseActions.GetPageByLink(linked)
+			} else if into.Id, err = formInt(req, "intoid"); err != nil {
+				err = common.PageNotFound
+			}
+			if err == nil {
+				err = databaseActions.MergePages(userInfo, pageID, into.Id)
+			}
+			if err == nil {
+				http.Redirect(res, req, "/admin/duplicates/"+strconv.Itoa(into.Id), http.StatusSeeOther)
+				return
+			}
+		case "merge-from":
+			var fromID int
+			if fromID, err = formInt(req, "fromid"); err == nil {
+				err = databaseActions.MergePages(userInfo, fromID, pageID)
+			}
+			if err == nil {
+				data["successMsg"] = i18n.Translate(userInfo.Locale, "admin.duplicates.merged")
+			}
+		}
+		if err != nil {
+			data["errorMsg"] = i18n.ErrorMessage(userInfo.Locale, err)
+		}
+	}
+
+	page, similar, err := databaseActions.FindDuplicatePages(pageID)
+	if err == common.PageNotFound {
+		http.NotFound(res, req)
+		return
+	}
+	if err != nil {
+		data["errorMsg"] = i18n.ErrorMessage(userInfo.Locale, err)
+	}
+	data["page"] = page
+	data["similar"] = similar
+
+	common.ExecTemplate(duplicatesTemplate, res, data)
+}
+
+const duplicatesTemplateText = `
+	<div class="content">
+		<div class="row">
+			<div class="columns">
+				<h1><i class="fi-page-multiple"></i> {{t $.locale "admin.duplicates.title"}}</h1>
+{{template "adminNav" .}}{{if .successMsg}}
+				<div class="alert-box success">{{.successMsg}}</div>{{end}}{{if .errorMsg}}
+				<div class="alert-box alert">{{.errorMsg}}</div>{{end}}{{with .page}}
+				<p>{{t $.locale "admin.duplicates.intro"}} <a href="/page/{{.CategorySlug}}/{{.PageSlug}}">{{.Title}}</a> {{t $.locale "admin.duplicates.in_category" .Category}}{{if .Address}}, {{.Address}}{{end}}{{if .Website}}, {{.Website}}{{end}}.</p>
+				<p>{{t $.locale "admin.duplicates.help"}}</p>{{end}}
+				<table>
+					<thead>
+						<tr><th>{{t $.locale "admin.duplicates.page"}}</th><th>{{t $.locale "admin.category"}}</th><th>{{t $.locale "admin.duplicates.address"}}</th><th>{{t $.locale "admin.duplicates.website"}}</th><th>{{t $.locale "admin.duplicates.why"}}</th><th></th></tr>
+					</thead>
+					<tbody>{{range .similar}}
+						<tr>
+							<td><a href="/page/{{.CategorySlug}}/{{.PageSlug}}">{{.Title}}</a></td>
+							<td>{{.Category}}</td>
+							<td>{{.Address}}</td>
+							<td>{{.Website}}</td>
+							<td>{{range $i, $reason := .Reasons}}{{if $i}}, {{end}}{{msg $.locale $reason}}{{end}}</td>
+							<td>
+								<form method="post" action="{{$.formAction}}">
+									<input type="hidden" name="intoid" value="{{.Id}}">
+									<input type="hidden" name="fromid" value="{{.Id}}">
+									<button type="submit" class="button tiny" name="action" value="merge-from">{{t $.locale "admin.duplicates.merge_from"}}</button>
+									<button type="submit" class="button tiny secondary" name="action" value="merge-into">{{t $.locale "admin.duplicates.merge_into"}}</button>
+								</form>
+							</td>
+						</tr>{{else}}
+						<tr><td colspan="6">{{t $.locale "admin.duplicates.none"}}</td></tr>{{end}}
+					</tbody>
+				</table>
+				<form method="post" action="{{.formAction}}">
+					<div class="row collapse">
+						<div class="small-9 columns">
+							<input type="text" name="into" placeholder="{{t $.locale "admin.duplicates.link"}}">
+						</div>
+						<div class="small-3 columns">
+							<button type="submit" class="button postfix" name="action" value="merge-into">{{t $.locale "admin.merge"}}</button>
+						</div>
+					</div>
+				</form>
+			</div>
+		</div>
+	</div>
+`
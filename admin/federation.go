@@ -0,0 +1,98 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/comforme/comforme/common"
+	"github.com/comforme/comforme/databaseActions"
+	"github.com/comforme/comforme/i18n"
+)
+
+func FederationHandler(res http.ResponseWriter, req *http.Request, ps httprouter.Params, userInfo common.UserInfo) {
+	data := newData(req, userInfo, "admin.federation.title")
+	data["enabled"] = common.FederationEnabled()
+	data["domain"] = common.FederationDomain
+
+	if req.Method == "POST" {
+		replyID, err := formInt(req, "replyid")
+		if err == nil {
+			if req.PostFormValue("action") == "approve" {
+				err = databaseActions.ApproveReply(userInfo, replyID)
+				data["successMsg"] = i18n.Translate(userInfo.Locale, "admin.federation.approved")
+			} else {
+				err = databaseActions.RejectReply(userInfo, replyID)
+				data["successMsg"] = i18n.Translate(userInfo.Locale, "admin.federation.rejected")
+			}
+		}
+		if err != nil {
+			delete(data, "successMsg")
+			data["errorMsg"] = i18n.ErrorMessage(userInfo.Locale, err)
+		}
+	}
+
+	replies, err := databaseActions.GetPendingReplies(userInfo)
+	if err != nil {
+		data["errorMsg"] = i18n.ErrorMessage(userInfo.Locale, err)
+	}
+	data["replies"] = replies
+
+	actors, err := databaseActions.GetFollowedActors(userInfo)
+	if err != nil {
+		data["errorMsg"] = i18n.ErrorMessage(userInfo.Locale, err)
+	}
+	data["actors"] = actors
+
+	common.ExecTemplate(federationTemplate, res, data)
+}
+
+const federationTemplateText = `
+	<div class="content">
+		<div class="row">
+			<div class="columns">
+				<h1><i class="fi-web"></i> {{t $.locale "admin.federation.title"}}</h1>
+{{template "adminNav" .}}{{if .successMsg}}
+				<div class="alert-box success">{{.successMsg}}</div>{{end}}{{if .errorMsg}}
+				<div class="alert-box alert">{{.errorMsg}}</div>{{end}}{{if not .enabled}}
+				<div class="alert-box secondary">{{t $.locale "admin.federation.off"}}</div>{{end}}
+				<h3>{{t $.locale "admin.federation.replies"}}</h3>
+				<table>
+					<thead>
+						<tr><th>{{t $.locale "admin.date"}}</th><th>{{t $.locale "admin.federation.from"}}</th><th>{{t $.locale "admin.federation.on"}}</th><th>{{t $.locale "admin.federation.reply"}}</th><th></th></tr>
+					</thead>
+					<tbody>{{range .replies}}
+						<tr>
+							<td>{{.DateCreated.Format "2006-01-02 15:04"}}</td>
+							<td><a href="{{.Actor}}" rel="nofollow">{{.Author}}</a></td>
+							<td><a href="/page/{{.CategorySlug}}/{{.PageSlug}}">{{.PageTitle}}</a></td>
+							<td>{{.Content}}</td>
+							<td>
+								<form method="post" action="{{$.formAction}}">
+									<input type="hidden" name="replyid" value="{{.Id}}">
+									<button type="submit" class="button tiny" name="action" value="approve">{{t $.locale "admin.approve"}}</button>
+									<button type="submit" class="button tiny alert" name="action" value="reject">{{t $.locale "admin.reject"}}</button>
+								</form>
+							</td>
+						</tr>{{else}}
+						<tr><td colspan="5">{{t $.locale "admin.federation.no_replies"}}</td></tr>{{end}}
+					</tbody>
+				</table>
+				<h3>{{t $.locale "admin.federation.actors"}}</h3>
+				<table>
+					<thead>
+						<tr><th>{{t $.locale "admin.federation.actor"}}</th><th>{{t $.locale "admin.federation.address"}}</th><th>{{t $.locale "admin.federation.followers"}}</th></tr>
+					</thead>
+					<tbody>{{range .actors}}
+						<tr>
+							<td>{{.Name}}</td>
+							<td>{{.Username}}@{{$.domain}}</td>
+							<td>{{.Followers}}</td>
+						</tr>{{else}}
+						<tr><td colspan="3">{{t $.locale "admin.federation.no_actors"}}</td></tr>{{end}}
+					</tbody>
+				</table>
+			</div>
+		</div>
+	</div>
+`
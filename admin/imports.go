@@ -0,0 +1,162 @@
+package admin
+
+import (
+	"io/ioutil"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/comforme/comforme/common"
+	"github.com/comforme/comforme/databaseActions"
+	"github.com/comforme/comforme/i18n"
+	"github.com/comforme/comforme/imports"
+)
+
+const maxImportBytes = 4 << 20
+
+// Imports pages from an uploaded file. Previewing runs a dry run and keeps
+// the file in the form, so that importing it afterwards does not need it to
+// be chosen again.
+func ImportHandler(res http.ResponseWriter, req *http.Request, ps httprouter.Params, userInfo common.UserInfo) {
+	data := newData(req, userInfo, "admin.import.title")
+	data["fields"] = common.ImportFields
+	data["format"] = ""
+	data["categoryID"] = 0
+
+	mapping := imports.Mapping{}
+	if req.Method == "POST" {
+		req.Body = http.MaxBytesReader(res, req.Body, maxImportBytes+1<<20)
+		err := importPages(req, userInfo, mapping, data)
+		if err != nil {
+			data["errorMsg"] = i18n.ErrorMessage(userInfo.Locale, err)
+		}
+	}
+	data["mapping"] = mapping
+
+	categories, err := databaseActions.GetCategories()
+	if err != nil {
+		data["errorMsg"] = i18n.ErrorMessage(userInfo.Locale, err)
+	}
+	data["categories"] = categories
+
+	common.ExecTemplate(importTemplate, res, data)
+}
+
+func importPages(req *http.Request, userInfo common.UserInfo, mapping imports.Mapping, data map[string]interface{}) error {
+	if err := req.ParseMultipartForm(maxImportBytes); err != nil {
+		return err
+	}
+
+	for _, field := range common.ImportFields {
+		mapping[field] = req.PostFormValue("map-" + field)
+	}
+	format := req.PostFormValue("format")
+	data["format"] = format
+	categoryID, err := formInt(req, "categoryid")
+	if err != nil {
+		return err
+	}
+	data["categoryID"] = categoryID
+
+	filename := req.PostFormValue("filename")
+	content := []byte(req.PostFormValue("content"))
+	if file, header, err := req.FormFile("file"); err == nil {
+		defer file.Close()
+		filename = header.Filename
+		if content, err = ioutil.ReadAll(file); err != nil {
+			return err
+		}
+	}
+	if len(content) == 0 {
+		return common.ImportEmpty
+	}
+	data["filename"] = filename
+	data["content"] = string(content)
+
+	if format == "" {
+		format = imports.DetectFormat(filename, content)
+	}
+	rows, err := imports.Parse(content, format, mapping)
+	if err != nil {
+		return err
+	}
+
+	dryRun := req.PostFormValue("action") != "import"
+	report, err := databaseActions.ImportPages(userInfo, rows, categoryID, dryRun)
+	if err != nil {
+		return err
+	}
+	data["report"] = report
+
+	if !dryRun {
+		data["successMsg"] = i18n.Translate(userInfo.Locale, "admin.import.finished")
+		delete(data, "content")
+	}
+	return nil
+}
+
+const importTemplateText = `
+	<div class="content">
+		<div class="row">
+			<div class="columns">
+				<h1><i class="fi-upload"></i> {{t $.locale "admin.import.title"}}</h1>
+{{template "adminNav" .}}{{if .successMsg}}
+				<div class="alert-box success">{{.successMsg}}</div>{{end}}{{if .errorMsg}}
+				<div class="alert-box alert">{{.errorMsg}}</div>{{end}}
+				<p>{{t $.locale "admin.import.help"}}</p>
+				<form method="post" action="{{.formAction}}" enctype="multipart/form-data">
+					<fieldset>
+						<legend>{{t $.locale "admin.import.file"}}</legend>{{if .content}}
+						<input type="hidden" name="filename" value="{{.filename}}">
+						<textarea name="content" hidden>{{.content}}</textarea>
+						<p>{{if .filename}}{{t $.locale "admin.import.using" .filename}}{{else}}{{t $.locale "admin.import.using" (t $.locale "admin.import.previewed_file")}}{{end}}</p>{{end}}
+						<input type="file" name="file" accept=".csv,.json,text/csv,application/json">
+						<label>
+							{{t $.locale "admin.import.format"}}
+							<select name="format">
+								<option value="">{{t $.locale "admin.import.detect"}}</option>
+								<option value="csv"{{if eq .format "csv"}} selected{{end}}>CSV</option>
+								<option value="json"{{if eq .format "json"}} selected{{end}}>JSON</option>
+							</select>
+						</label>
+					</fieldset>
+					<fieldset>
+						<legend>{{t $.locale "admin.import.columns"}}</legend>
+						<p>{{t $.locale "admin.import.columns_help"}}</p>
+						<div class="row">{{range .fields}}
+							<div class="large-2 columns left">
+								<label>
+									{{.}}
+									<input type="text" name="map-{{.}}" placeholder="{{.}}" value="{{index $.mapping .}}">
+								</label>
+							</div>{{end}}
+						</div>
+						<label>
+							{{t $.locale "admin.import.default_category"}}
+							<select name="categoryid">
+								<option value="0">{{t $.locale "admin.none"}}</option>{{range .categories}}
+								<option value="{{.Id}}"{{if eq .Id $.categoryID}} selected{{end}}>{{.Name}}</option>{{end}}
+							</select>
+						</label>
+					</fieldset>
+					<button type="submit" class="button small secondary" name="action" value="preview">{{t $.locale "admin.import.preview"}}</button>{{if .report}}{{if .report.DryRun}}{{if .report.Valid}}
+					<button type="submit" class="button small" name="action" value="import">{{plural $.locale "admin.import.submit" .report.Valid}}</button>{{end}}{{end}}{{end}}
+				</form>{{with .report}}
+				<h3>{{if .DryRun}}{{t $.locale "admin.import.preview_summary" .Valid}}{{else}}{{t $.locale "admin.import.created_summary" .Created}}{{end}}{{t $.locale "admin.import.summary" .Duplicates .Invalid}}</h3>
+				<table>
+					<thead>
+						<tr><th>{{t $.locale "admin.import.row"}}</th><th>{{t $.locale "admin.import.page_title"}}</th><th>{{t $.locale "admin.import.result"}}</th><th></th></tr>
+					</thead>
+					<tbody>{{range .Results}}
+						<tr>
+							<td>{{.Row}}</td>
+							<td>{{if .Path}}<a href="{{.Path}}">{{.Title}}</a>{{else}}{{.Title}}{{end}}</td>
+							<td>{{t $.locale (printf "import.%s" .Status)}}</td>
+							<td>{{with .Error}}{{error $.locale .}}{{end}}</td>
+						</tr>{{end}}
+					</tbody>
+				</table>{{end}}
+			</div>
+		</div>
+	</div>
+`
@@ -0,0 +1,68 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/comforme/comforme/common"
+	"github.com/comforme/comforme/databaseActions"
+	"github.com/comforme/comforme/i18n"
+)
+
+func ReportsHandler(res http.ResponseWriter, req *http.Request, ps httprouter.Params, userInfo common.UserInfo) {
+	data := newData(req, userInfo, "admin.reports.title")
+
+	if req.Method == "POST" {
+		reportID, err := formInt(req, "reportid")
+		if err == nil {
+			err = databaseActions.ResolveReport(userInfo, reportID)
+		}
+		if err != nil {
+			data["errorMsg"] = i18n.ErrorMessage(userInfo.Locale, err)
+		} else {
+			data["successMsg"] = i18n.Translate(userInfo.Locale, "admin.reports.resolved")
+		}
+	}
+
+	reports, err := databaseActions.GetOpenReports(userInfo)
+	if err != nil {
+		data["errorMsg"] = i18n.ErrorMessage(userInfo.Locale, err)
+	}
+	data["reports"] = reports
+
+	common.ExecTemplate(reportsTemplate, res, data)
+}
+
+const reportsTemplateText = `
+	<div class="content">
+		<div class="row">
+			<div class="columns">
+				<h1><i class="fi-flag"></i> {{t $.locale "admin.reports.title"}}</h1>
+{{template "adminNav" .}}{{if .successMsg}}
+				<div class="alert-box success">{{.successMsg}}</div>{{end}}{{if .errorMsg}}
+				<div class="alert-box alert">{{.errorMsg}}</div>{{end}}
+				<table>
+					<thead>
+						<tr><th>{{t $.locale "admin.date"}}</th><th>{{t $.locale "admin.user"}}</th><th>{{t $.locale "admin.reports.reported_by"}}</th><th>{{t $.locale "admin.reports.reason"}}</th><th></th></tr>
+					</thead>
+					<tbody>{{range .reports}}
+						<tr>
+							<td>{{.DateCreated.Format "2006-01-02 15:04"}}</td>
+							<td><a href="/user/{{.ReportedName}}">{{.ReportedName}}</a> (<a href="/admin/users?q={{.ReportedName}}">{{t $.locale "admin.reports.manage"}}</a>)</td>
+							<td>{{.ReporterName}}</td>
+							<td>{{.Reason}}</td>
+							<td>
+								<form method="post" action="{{$.formAction}}">
+									<input type="hidden" name="reportid" value="{{.Id}}">
+									<button type="submit" class="button tiny" name="action" value="resolve">{{t $.locale "admin.reports.resolve"}}</button>
+								</form>
+							</td>
+						</tr>{{else}}
+						<tr><td colspan="5">{{t $.locale "admin.reports.none"}}</td></tr>{{end}}
+					</tbody>
+				</table>
+			</div>
+		</div>
+	</div>
+`
@@ -0,0 +1,112 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/comforme/comforme/common"
+	"github.com/comforme/comforme/databaseActions"
+	"github.com/comforme/comforme/i18n"
+)
+
+func UsersHandler(res http.ResponseWriter, req *http.Request, ps httprouter.Params, userInfo common.UserInfo) {
+	data := newData(req, userInfo, "admin.users.title")
+
+	query := req.URL.Query().Get("q")
+	data["query"] = query
+	data["formAction"] = req.URL.RequestURI()
+
+	if req.Method == "POST" {
+		userID, err := formInt(req, "userid")
+		if err == nil {
+			switch req.PostFormValue("action") {
+			case "suspend":
+				if err = databaseActions.SetSuspended(userInfo, userID, true); err == nil {
+					data["successMsg"] = i18n.Translate(userInfo.Locale, "admin.users.suspended")
+				}
+			case "unsuspend":
+				if err = databaseActions.SetSuspended(userInfo, userID, false); err == nil {
+					data["successMsg"] = i18n.Translate(userInfo.Locale, "admin.users.unsuspended")
+				}
+			case "resetPassword":
+				if err = databaseActions.ForcePasswordReset(userInfo, userID); err == nil {
+					data["successMsg"] = i18n.Translate(userInfo.Locale, "admin.users.reset_forced")
+				}
+			case "delete":
+				if err = databaseActions.ScheduleUserDeletion(userInfo, userID, common.GetBaseURL(req)); err == nil {
+					data["successMsg"] = i18n.Translate(userInfo.Locale, "admin.users.deletion_scheduled")
+				}
+			case "cancelDelete":
+				if err = databaseActions.CancelUserDeletion(userInfo, userID); err == nil {
+					data["successMsg"] = i18n.Translate(userInfo.Locale, "admin.users.deletion_cancelled")
+				}
+			case "deleteNow":
+				if err = databaseActions.DeleteUserNow(userInfo, userID); err == nil {
+					data["successMsg"] = i18n.Translate(userInfo.Locale, "admin.users.deleted")
+				}
+			}
+		}
+		if err != nil {
+			data["errorMsg"] = i18n.ErrorMessage(userInfo.Locale, err)
+		}
+	}
+
+	if len(query) > 0 {
+		users, err := databaseActions.SearchUsers(query)
+		if err != nil {
+			data["errorMsg"] = i18n.ErrorMessage(userInfo.Locale, err)
+		}
+		data["users"] = users
+	}
+
+	common.ExecTemplate(usersTemplate, res, data)
+}
+
+const usersTemplateText = `
+	<div class="content">
+		<div class="row">
+			<div class="columns">
+				<h1><i class="fi-torsos"></i> {{t $.locale "admin.users.title"}}</h1>
+{{template "adminNav" .}}{{if .successMsg}}
+				<div class="alert-box success">{{.successMsg}}</div>{{end}}{{if .errorMsg}}
+				<div class="alert-box alert">{{.errorMsg}}</div>{{end}}
+				<form method="get" action="/admin/users">
+					<div class="row collapse">
+						<div class="small-10 columns">
+							<input type="text" name="q" placeholder="{{t $.locale "admin.users.search"}}"{{if .query}} value="{{.query}}"{{end}}>
+						</div>
+						<div class="small-2 columns">
+							<button type="submit" class="button postfix">{{t $.locale "admin.users.search_button"}}</button>
+						</div>
+					</div>
+				</form>{{if .query}}
+				<table>
+					<thead>
+						<tr><th>{{t $.locale "admin.users.username"}}</th><th>{{t $.locale "admin.users.email"}}</th><th>{{t $.locale "admin.users.role"}}</th><th>{{t $.locale "admin.users.status"}}</th><th></th></tr>
+					</thead>
+					<tbody>{{range .users}}
+						<tr>
+							<td>{{.Username}}</td>
+							<td>{{.Email}}</td>
+							<td>{{.Role}}</td>
+							<td>{{if .Suspended}}{{t $.locale "admin.users.status_suspended"}}{{else}}{{t $.locale "admin.users.status_active"}}{{end}}{{if .ResetRequired}}{{t $.locale "admin.users.status_reset_required"}}{{end}}{{if .DeletionScheduled}}{{t $.locale "admin.users.status_deleted_on" (.DeletionScheduled.Format "2006-01-02")}}{{end}}</td>
+							<td>
+								<form method="post" action="{{$.formAction}}">
+									<input type="hidden" name="userid" value="{{.Id}}">{{if .Suspended}}
+									<button type="submit" class="button tiny" name="action" value="unsuspend">{{t $.locale "admin.users.unsuspend"}}</button>{{else}}
+									<button type="submit" class="button tiny alert" name="action" value="suspend">{{t $.locale "admin.users.suspend"}}</button>{{end}}
+									<button type="submit" class="button tiny secondary" name="action" value="resetPassword">{{t $.locale "admin.users.force_reset"}}</button>{{if .DeletionScheduled}}
+									<button type="submit" class="button tiny secondary" name="action" value="cancelDelete">{{t $.locale "admin.users.cancel_deletion"}}</button>
+									<button type="submit" class="button tiny alert" name="action" value="deleteNow">{{t $.locale "admin.users.delete_now"}}</button>{{else}}
+									<button type="submit" class="button tiny alert" name="action" value="delete">{{t $.locale "admin.delete"}}</button>{{end}}
+								</form>
+							</td>
+						</tr>{{else}}
+						<tr><td colspan="5">{{t $.locale "admin.users.none"}}</td></tr>{{end}}
+					</tbody>
+				</table>{{end}}
+			</div>
+		</div>
+	</div>
+`
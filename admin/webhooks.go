@@ -0,0 +1,207 @@
+package admin
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/comforme/comforme/common"
+	"github.com/comforme/comforme/databaseActions"
+	"github.com/comforme/comforme/i18n"
+)
+
+func WebhooksHandler(res http.ResponseWriter, req *http.Request, ps httprouter.Params, userInfo common.UserInfo) {
+	data := newData(req, userInfo, "admin.webhooks.title")
+
+	if req.Method == "POST" {
+		var err error
+		switch req.PostFormValue("action") {
+		case "create":
+			var categoryID int
+			var secret string
+			if categoryID, err = formInt(req, "categoryid"); err == nil {
+				req.ParseForm()
+				secret, err = databaseActions.CreateWebhook(userInfo, req.PostFormValue("url"), req.PostForm["event"], categoryID)
+			}
+			if err == nil {
+				data["successMsg"] = i18n.Translate(userInfo.Locale, "admin.webhooks.added")
+				data["newSecret"] = secret
+			}
+		case "enable", "disable":
+			var webhookID int
+			if webhookID, err = formInt(req, "webhookid"); err == nil {
+				err = databaseActions.SetWebhookActive(userInfo, webhookID, req.PostFormValue("action") == "enable")
+			}
+			if err == nil {
+				data["successMsg"] = i18n.Translate(userInfo.Locale, "admin.webhooks.updated")
+			}
+		case "delete":
+			var webhookID int
+			if webhookID, err = formInt(req, "webhookid"); err == nil {
+				err = databaseActions.DeleteWebhook(userInfo, webhookID)
+			}
+			if err == nil {
+				data["successMsg"] = i18n.Translate(userInfo.Locale, "admin.webhooks.deleted")
+			}
+		}
+		if err != nil {
+			data["errorMsg"] = i18n.ErrorMessage(userInfo.Locale, err)
+		}
+	}
+
+	webhooks, err := databaseActions.GetWebhooks(userInfo)
+	if err != nil {
+		data["errorMsg"] = i18n.ErrorMessage(userInfo.Locale, err)
+	}
+	data["webhooks"] = webhooks
+
+	categories, err := databaseActions.GetCategories()
+	if err != nil {
+		data["errorMsg"] = i18n.ErrorMessage(userInfo.Locale, err)
+	}
+	data["categories"] = categories
+	data["events"] = common.WebhookEvents
+
+	common.ExecTemplate(webhooksTemplate, res, data)
+}
+
+func WebhookHandler(res http.ResponseWriter, req *http.Request, ps httprouter.Params, userInfo common.UserInfo) {
+	data := newData(req, userInfo, "admin.webhook.title")
+
+	webhookID, err := strconv.Atoi(ps.ByName("id"))
+	if err != nil {
+		http.NotFound(res, req)
+		return
+	}
+
+	if req.Method == "POST" {
+		deliveryID, err := formInt(req, "deliveryid")
+		if err == nil {
+			err = databaseActions.ReplayDelivery(userInfo, webhookID, deliveryID)
+		}
+		if err != nil {
+			data["errorMsg"] = i18n.ErrorMessage(userInfo.Locale, err)
+		} else {
+			data["successMsg"] = i18n.Translate(userInfo.Locale, "admin.webhook.replayed")
+		}
+	}
+
+	webhook, err := databaseActions.GetWebhook(userInfo, webhookID)
+	if err == common.WebhookNotFound {
+		http.NotFound(res, req)
+		return
+	}
+	if err != nil {
+		data["errorMsg"] = i18n.ErrorMessage(userInfo.Locale, err)
+	}
+	data["webhook"] = webhook
+
+	deliveries, err := databaseActions.GetDeliveries(userInfo, webhookID)
+	if err != nil {
+		data["errorMsg"] = i18n.ErrorMessage(userInfo.Locale, err)
+	}
+	data["deliveries"] = deliveries
+
+	common.ExecTemplate(webhookTemplate, res, data)
+}
+
+const webhooksTemplateText = `
+	<div class="content">
+		<div class="row">
+			<div class="columns">
+				<h1><i class="fi-link"></i> {{t $.locale "admin.webhooks.title"}}</h1>
+{{template "adminNav" .}}{{if .successMsg}}
+				<div class="alert-box success">{{.successMsg}}</div>{{end}}{{if .errorMsg}}
+				<div class="alert-box alert">{{.errorMsg}}</div>{{end}}{{if .newSecret}}
+				<div class="panel">
+					<p>{{t $.locale "admin.webhooks.secret"}}</p>
+					<pre>{{.newSecret}}</pre>
+				</div>{{end}}
+				<form method="post" action="{{.formAction}}">
+					<fieldset>
+						<legend>{{t $.locale "admin.webhooks.legend"}}</legend>
+						<label>
+							{{t $.locale "admin.webhooks.url"}}
+							<input type="url" name="url" placeholder="https://example.com/hooks/comforme">
+						</label>
+						<label>{{t $.locale "admin.webhooks.events"}}</label>{{range .events}}
+						<input type="checkbox" name="event" value="{{.}}" id="event-{{.}}"><label for="event-{{.}}">{{.}}</label>{{end}}
+						<label>
+							{{t $.locale "admin.webhooks.category"}}
+							<select name="categoryid">
+								<option value="0">{{t $.locale "admin.every_category"}}</option>{{range .categories}}
+								<option value="{{.Id}}">{{.Name}}</option>{{end}}
+							</select>
+						</label>
+						<button type="submit" class="button small" name="action" value="create">{{t $.locale "admin.add"}}</button>
+					</fieldset>
+				</form>
+				<table>
+					<thead>
+						<tr><th>{{t $.locale "admin.webhooks.url"}}</th><th>{{t $.locale "admin.webhooks.events"}}</th><th>{{t $.locale "admin.category"}}</th><th>{{t $.locale "admin.webhooks.added_on"}}</th><th></th></tr>
+					</thead>
+					<tbody>{{range .webhooks}}
+						<tr>
+							<td><a href="/admin/webhooks/{{.Id}}">{{.URL}}</a>{{if not .Active}} {{t $.locale "admin.webhooks.disabled"}}{{end}}</td>
+							<td>{{range .Events}}{{.}} {{end}}</td>
+							<td>{{if .CategoryName}}{{.CategoryName}}{{else}}{{t $.locale "admin.every_category"}}{{end}}</td>
+							<td>{{.DateCreated.Format "2006-01-02"}}</td>
+							<td>
+								<form method="post" action="{{$.formAction}}">
+									<input type="hidden" name="webhookid" value="{{.Id}}">{{if .Active}}
+									<button type="submit" class="button tiny secondary" name="action" value="disable">{{t $.locale "admin.webhooks.disable"}}</button>{{else}}
+									<button type="submit" class="button tiny" name="action" value="enable">{{t $.locale "admin.webhooks.enable"}}</button>{{end}}
+									<button type="submit" class="button tiny alert" name="action" value="delete">{{t $.locale "admin.delete"}}</button>
+								</form>
+							</td>
+						</tr>{{else}}
+						<tr><td colspan="5">{{t $.locale "admin.webhooks.none"}}</td></tr>{{end}}
+					</tbody>
+				</table>
+			</div>
+		</div>
+	</div>
+`
+
+const webhookTemplateText = `
+	<div class="content">
+		<div class="row">
+			<div class="columns">
+				<h1><i class="fi-link"></i> {{t $.locale "admin.webhook.heading"}}</h1>
+{{template "adminNav" .}}{{if .successMsg}}
+				<div class="alert-box success">{{.successMsg}}</div>{{end}}{{if .errorMsg}}
+				<div class="alert-box alert">{{.errorMsg}}</div>{{end}}{{with .webhook}}
+				<p><a href="/admin/webhooks">{{t $.locale "admin.webhooks.title"}}</a> &raquo; {{.URL}}{{if not .Active}} {{t $.locale "admin.webhooks.disabled"}}{{end}}</p>{{end}}
+				<table>
+					<thead>
+						<tr><th>{{t $.locale "admin.date"}}</th><th>{{t $.locale "admin.webhook.event"}}</th><th>{{t $.locale "admin.webhook.status"}}</th><th>{{t $.locale "admin.webhook.attempts"}}</th><th>{{t $.locale "admin.webhook.last_attempt"}}</th><th>{{t $.locale "admin.webhook.response"}}</th><th></th></tr>
+					</thead>
+					<tbody>{{range .deliveries}}
+						<tr>
+							<td>{{.DateCreated.Format "2006-01-02 15:04:05"}}</td>
+							<td>
+								{{.Event}}
+								<details>
+									<summary>{{t $.locale "admin.webhook.payload"}}</summary>
+									<pre>{{.Payload}}</pre>
+								</details>
+							</td>
+							<td>{{t $.locale (printf "delivery.%s" .Status)}}{{if eq .Status "pending"}} {{t $.locale "admin.webhook.next" (.NextAttempt.Format "15:04:05")}}{{end}}</td>
+							<td>{{.Attempts}}</td>
+							<td>{{with .LastAttempt}}{{.Format "2006-01-02 15:04:05"}}{{end}}</td>
+							<td>{{if .ResponseStatus}}{{.ResponseStatus}} {{end}}{{.LastError}}</td>
+							<td>
+								<form method="post" action="{{$.formAction}}">
+									<input type="hidden" name="deliveryid" value="{{.Id}}">
+									<button type="submit" class="button tiny" name="action" value="replay">{{t $.locale "admin.webhook.replay"}}</button>
+								</form>
+							</td>
+						</tr>{{else}}
+						<tr><td colspan="7">{{t $.locale "admin.webhook.none"}}</td></tr>{{end}}
+					</tbody>
+				</table>
+			</div>
+		</div>
+	</div>
+`
@@ -5,18 +5,22 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
 	"strconv"
+	"time"
 
 	"github.com/julienschmidt/httprouter"
 
 	"github.com/comforme/comforme/common"
 	"github.com/comforme/comforme/databaseActions"
+	"github.com/comforme/comforme/i18n"
 )
 
 const (
-	JSONLoginError  = "{ \"error\": \"Not logged in.\" }"
-	JSONActionError = "{ \"error\": \"Invalid action.\" }"
-	JSONError       = "{ \"error\": \"Unknown error.\" }"
+	JSONLoginError      = "{ \"error\": \"Not logged in.\" }"
+	JSONActionError     = "{ \"error\": \"Invalid action.\" }"
+	JSONError           = "{ \"error\": \"Unknown error.\" }"
+	JSONPermissionError = "{ \"error\": \"You do not have permission to do that.\" }"
 )
 
 type AjaxResultNum struct {
@@ -32,6 +36,46 @@ type AjaxError struct {
 	Message string `json:"error"`
 }
 
+type AjaxCommunity struct {
+	Id         int    `json:"id"`
+	Name       string `json:"name"`
+	ParentName string `json:"parentName,omitempty"`
+	IsMember   bool   `json:"isMember"`
+}
+
+type AjaxCommunities struct {
+	Communities []AjaxCommunity `json:"communities"`
+}
+
+type AjaxFeedItem struct {
+	Author     string `json:"author"` // Empty for anonymous posts
+	ProfileURL string `json:"profileUrl,omitempty"`
+	PageTitle  string `json:"pageTitle"`
+	PageURL    string `json:"pageUrl"`
+	Body       string `json:"body"`
+	IsPage     bool   `json:"isPage"`
+	Date       string `json:"date"`
+}
+
+type AjaxFeed struct {
+	Items      []AjaxFeedItem `json:"items"`
+	NextOffset int            `json:"nextOffset"`
+	More       bool           `json:"more"`
+}
+
+func newAjaxCommunities(communities []common.Community) AjaxCommunities {
+	result := AjaxCommunities{[]AjaxCommunity{}}
+	for _, community := range communities {
+		result.Communities = append(result.Communities, AjaxCommunity{
+			community.Id,
+			community.Name,
+			community.ParentName,
+			community.IsMember,
+		})
+	}
+	return result
+}
+
 func HandleAction(res http.ResponseWriter, req *http.Request, ps httprouter.Params, userInfo common.UserInfo) {
 	res.Header().Set("Content-Type", "application/json; charset=utf-8")
 
@@ -44,17 +88,31 @@ func HandleAction(res http.ResponseWriter, req *http.Request, ps httprouter.Para
 			log.Println("Error parsing communityid:", err)
 			result = AjaxError{"Invalid communityid."}
 		} else {
-			err = databaseActions.SetCommunityMembership(userInfo.UserID, int(community_id), action == "addCommunity")
+			err = databaseActions.SetCommunityMembership(userInfo, int(community_id), action == "addCommunity")
 			if err != nil {
-				result = AjaxError{err.Error()}
+				result = AjaxError{i18n.ErrorMessage(userInfo.Locale, err)}
 			} else {
 				result = AjaxResult{fmt.Sprintf("Successfully set membership in community %d to %t.", community_id, action == "addCommunity")}
 			}
 		}
+	} else if action == "setMembershipVisibility" {
+		community_id, err := strconv.ParseInt(req.PostFormValue("communityid"), 10, 0)
+		if err != nil {
+			log.Println("Error parsing communityid:", err)
+			result = AjaxError{"Invalid communityid."}
+		} else {
+			visibility := req.PostFormValue("visibility")
+			err = databaseActions.SetMembershipVisibility(userInfo, int(community_id), visibility)
+			if err != nil {
+				result = AjaxError{i18n.ErrorMessage(userInfo.Locale, err)}
+			} else {
+				result = AjaxResult{fmt.Sprintf("Successfully set membership visibility in community %d to %s.", community_id, visibility)}
+			}
+		}
 	} else if action == "logoutOtherSessions" {
-		loggedOut, err := databaseActions.LogoutOtherSessions(userInfo.SessionID, userInfo.UserID)
+		loggedOut, err := databaseActions.LogoutOtherSessions(userInfo)
 		if err != nil {
-			result = AjaxError{err.Error()}
+			result = AjaxError{i18n.ErrorMessage(userInfo.Locale, err)}
 		} else {
 			result = AjaxResultNum{
 				fmt.Sprintf(
@@ -65,6 +123,102 @@ func HandleAction(res http.ResponseWriter, req *http.Request, ps httprouter.Para
 				loggedOut,
 			}
 		}
+	} else if action == "searchCommunities" {
+		communities, err := databaseActions.SearchCommunities(userInfo.UserID, req.PostFormValue("query"))
+		if err != nil {
+			result = AjaxError{i18n.ErrorMessage(userInfo.Locale, err)}
+		} else {
+			result = newAjaxCommunities(communities)
+		}
+	} else if action == "suggestCommunities" {
+		communities, err := databaseActions.SuggestCommunities(userInfo.UserID)
+		if err != nil {
+			result = AjaxError{i18n.ErrorMessage(userInfo.Locale, err)}
+		} else {
+			result = newAjaxCommunities(communities)
+		}
+	} else if action == "removePost" {
+		post_id, err := strconv.ParseInt(req.PostFormValue("postid"), 10, 0)
+		if err != nil {
+			log.Println("Error parsing postid:", err)
+			result = AjaxError{"Invalid postid."}
+		} else {
+			err = databaseActions.RemovePost(userInfo, int(post_id))
+			if err != nil {
+				result = AjaxError{i18n.ErrorMessage(userInfo.Locale, err)}
+			} else {
+				result = AjaxResult{fmt.Sprintf("Successfully removed post %d.", post_id)}
+			}
+		}
+	} else if action == "unreadNotifications" {
+		unread, err := databaseActions.CountUnreadNotifications(userInfo.UserID)
+		if err != nil {
+			result = AjaxError{i18n.ErrorMessage(userInfo.Locale, err)}
+		} else {
+			result = AjaxResultNum{"Unread notifications.", unread}
+		}
+	} else if action == "markNotificationRead" {
+		notification_id, err := strconv.ParseInt(req.PostFormValue("notificationid"), 10, 0)
+		if err != nil {
+			log.Println("Error parsing notificationid:", err)
+			result = AjaxError{"Invalid notificationid."}
+		} else {
+			err = databaseActions.MarkNotificationRead(userInfo.UserID, int(notification_id))
+			if err != nil {
+				result = AjaxError{i18n.ErrorMessage(userInfo.Locale, err)}
+			} else {
+				result = AjaxResult{fmt.Sprintf("Marked notification %d as read.", notification_id)}
+			}
+		}
+	} else if action == "markAllNotificationsRead" {
+		marked, err := databaseActions.MarkAllNotificationsRead(userInfo.UserID)
+		if err != nil {
+			result = AjaxError{i18n.ErrorMessage(userInfo.Locale, err)}
+		} else {
+			result = AjaxResultNum{"Marked notifications as read.", marked}
+		}
+	} else if action == "followPage" || action == "unfollowPage" {
+		page_id, err := strconv.ParseInt(req.PostFormValue("pageid"), 10, 0)
+		if err != nil {
+			log.Println("Error parsing pageid:", err)
+			result = AjaxError{"Invalid pageid."}
+		} else {
+			err = databaseActions.SetPageSubscription(userInfo.UserID, int(page_id), action == "followPage")
+			if err != nil {
+				result = AjaxError{i18n.ErrorMessage(userInfo.Locale, err)}
+			} else {
+				result = AjaxResult{fmt.Sprintf("Successfully set subscription to page %d to %t.", page_id, action == "followPage")}
+			}
+		}
+	} else if action == "feed" {
+		asOf, err := time.Parse(time.RFC3339Nano, req.PostFormValue("asOf"))
+		if err != nil {
+			log.Println("Error parsing asOf:", err)
+			result = AjaxError{"Invalid asOf."}
+		} else {
+			offset, _ := strconv.Atoi(req.PostFormValue("offset"))
+			items, more, err := databaseActions.GetFeed(userInfo.UserID, asOf, offset)
+			if err != nil {
+				result = AjaxError{i18n.ErrorMessage(userInfo.Locale, err)}
+			} else {
+				feed := AjaxFeed{[]AjaxFeedItem{}, offset + len(items), more}
+				for _, item := range items {
+					ajaxItem := AjaxFeedItem{
+						Author:    item.Author,
+						PageTitle: item.PageTitle,
+						PageURL:   fmt.Sprintf("/page/%s/%s", item.CategorySlug, item.PageSlug),
+						Body:      item.Body,
+						IsPage:    item.IsPage,
+						Date:      item.Date.Format("2006-01-02 15:04"),
+					}
+					if item.Author != "" && !item.Pseudonymous {
+						ajaxItem.ProfileURL = "/user/" + url.PathEscape(item.Author)
+					}
+					feed.Items = append(feed.Items, ajaxItem)
+				}
+				result = feed
+			}
+		}
 	} else {
 		fmt.Fprintln(res, JSONActionError)
 		return
@@ -0,0 +1,300 @@
+// Package api serves the versioned JSON API under /api/v1. Every endpoint is
+// described once in the routes table, which is used both to register it with
+// the router and to generate the OpenAPI document.
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/comforme/comforme/ajax"
+	"github.com/comforme/comforme/common"
+	"github.com/comforme/comforme/databaseActions"
+	"github.com/comforme/comforme/i18n"
+	"github.com/comforme/comforme/requireLogin"
+)
+
+const (
+	Prefix = "/api/v1"
+
+	defaultLimit = 20
+	maxLimit     = 100
+)
+
+type handlerFunc func(req *http.Request, ps httprouter.Params, userInfo common.UserInfo) (interface{}, error)
+
+type param struct {
+	name        string
+	kind        string // "string", "integer" or "boolean"
+	description string
+	required    bool
+}
+
+type route struct {
+	method   string
+	path     string // Relative to Prefix, in httprouter syntax
+	summary  string
+	query    []param
+	body     interface{} // Zero value of the request body type, nil if none
+	response interface{} // Zero value of the response type, nil if none
+	status   int         // Status on success, http.StatusOK if zero
+	public   bool        // Does not need a logged in user
+	handle   handlerFunc
+}
+
+// The error object every endpoint returns on failure. It extends the
+// {"error": message} shape used by /ajax/:action. Messages are in English
+// until the error is written in the reader's language.
+type Error struct {
+	ajax.AjaxError
+	Code   string            `json:"code"`
+	Fields map[string]string `json:"fields,omitempty"`
+
+	status int
+	cause  error
+	fields fieldErrors
+}
+
+func (err *Error) Error() string {
+	return err.Message
+}
+
+func newError(status int, code string, cause error) *Error {
+	return &Error{AjaxError: ajax.AjaxError{Message: cause.Error()}, Code: code, status: status, cause: cause}
+}
+
+// A copy of err with its messages in the given locale.
+func (err *Error) in(locale string) *Error {
+	localized := *err
+	localized.Message = i18n.ErrorMessage(locale, err.cause)
+	if err.fields != nil {
+		localized.Fields = err.fields.in(locale)
+	}
+	return &localized
+}
+
+// Validation errors keyed by the name of the offending field.
+type fieldErrors map[string]error
+
+func (fields fieldErrors) err() error {
+	if len(fields) == 0 {
+		return nil
+	}
+	apiErr := newError(http.StatusUnprocessableEntity, "validation_failed", i18n.NewError("api.validation_failed"))
+	apiErr.Fields = fields.in(i18n.DefaultLocale)
+	apiErr.fields = fields
+	return apiErr
+}
+
+func (fields fieldErrors) in(locale string) map[string]string {
+	messages := map[string]string{}
+	for field, err := range fields {
+		messages[field] = i18n.ErrorMessage(locale, err)
+	}
+	return messages
+}
+
+var (
+	notLoggedIn            = newError(http.StatusUnauthorized, "not_logged_in", i18n.NewError("api.not_logged_in"))
+	passwordChangeRequired = newError(http.StatusForbidden, "password_change_required", i18n.NewError("api.password_change_required"))
+	notFound               = newError(http.StatusNotFound, "not_found", i18n.NewError("api.not_found"))
+	unsupportedMediaType   = newError(http.StatusUnsupportedMediaType, "unsupported_media_type", i18n.NewError("api.unsupported_media_type"))
+	invalidJSON            = newError(http.StatusBadRequest, "invalid_json", i18n.NewError("api.invalid_json"))
+)
+
+// Maps the errors returned by databaseActions onto API errors. Anything not
+// listed is passed on with its message as a bad request, except database
+// errors, which are internal.
+func toAPIError(err error) *Error {
+	switch err := err.(type) {
+	case *Error:
+		return err
+	}
+
+	switch err {
+	case common.PageNotFound, common.PostNotFound, common.UserNotFound, common.CategoryNotFound, common.CommunityNotFound,
+		common.PseudonymNotFound, common.NotificationNotFound:
+		return newError(http.StatusNotFound, "not_found", err)
+	case common.PermissionDenied, common.BlockedByPageAuthor, common.BlockedByMentionedUser:
+		return newError(http.StatusForbidden, "forbidden", err)
+	case common.NotACommunityMember:
+		return newError(http.StatusConflict, "conflict", err)
+	case common.DatabaseError:
+		return newError(http.StatusInternalServerError, "internal_error", err)
+	}
+	return newError(http.StatusBadRequest, "bad_request", err)
+}
+
+func writeJSON(res http.ResponseWriter, status int, value interface{}) {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		log.Println("Error marshaling API response:", err)
+		status = http.StatusInternalServerError
+		encoded, _ = json.Marshal(toAPIError(common.DatabaseError))
+	}
+
+	res.Header().Set("Content-Type", "application/json; charset=utf-8")
+	res.WriteHeader(status)
+	fmt.Fprintln(res, string(encoded))
+}
+
+func writeError(res http.ResponseWriter, err error, locale string) {
+	apiErr := toAPIError(err).in(locale)
+	if apiErr.Code == "invalid_token" || apiErr.Code == "insufficient_scope" {
+		res.Header().Set("WWW-Authenticate", fmt.Sprintf("Bearer error=%q", apiErr.Code))
+	}
+	writeJSON(res, apiErr.status, apiErr)
+}
+
+// Looks up the logged in user from an API token, or else the session cookie.
+func authenticate(req *http.Request) (userInfo common.UserInfo, err error) {
+	userInfo, ok, err := requireLogin.BearerLogin(req)
+	if ok {
+		if err != nil {
+			status, code := requireLogin.BearerErrorStatus(err)
+			if code == "" {
+				return userInfo, err
+			}
+			return userInfo, newError(status, code, err)
+		}
+		return userInfo, nil
+	}
+
+	cookie, err := req.Cookie("sessionid")
+	if err != nil {
+		return userInfo, notLoggedIn
+	}
+
+	userInfo, err = databaseActions.GetUserInfo(cookie.Value)
+	if err != nil {
+		return userInfo, notLoggedIn
+	}
+
+	isRequired, err := databaseActions.PasswordChangeRequired(cookie.Value)
+	if err != nil {
+		return userInfo, err
+	}
+	if isRequired {
+		return userInfo, passwordChangeRequired
+	}
+
+	userInfo.RequestInfo = common.GetRequestInfo(req)
+	userInfo.Locale = i18n.Negotiate(req.Header.Get("Accept-Language"), userInfo.Locale)
+	return userInfo, nil
+}
+
+func (r route) serve(res http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	res.Header().Set("cache-control", "private, max-age=0, no-cache")
+
+	var userInfo common.UserInfo
+	locale := i18n.RequestLocale(req)
+	if !r.public {
+		var err error
+		userInfo, err = authenticate(req)
+		if err != nil {
+			writeError(res, err, locale)
+			return
+		}
+		locale = userInfo.Locale
+	}
+
+	result, err := r.handle(req, ps, userInfo)
+	if err != nil {
+		writeError(res, err, locale)
+		return
+	}
+
+	status := r.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	if result == nil {
+		res.WriteHeader(status)
+		return
+	}
+	writeJSON(res, status, result)
+}
+
+// Adds every API route to the router.
+func Register(router *httprouter.Router) {
+	for _, r := range routes {
+		router.Handle(r.method, Prefix+r.path, r.serve)
+	}
+}
+
+// Decodes a JSON request body into value. Only JSON is accepted, which also
+// stops other sites from posting forms to the API with the user's cookie.
+func decodeBody(req *http.Request, value interface{}) error {
+	mediaType, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil || mediaType != "application/json" {
+		return unsupportedMediaType
+	}
+
+	err = json.NewDecoder(req.Body).Decode(value)
+	if err != nil {
+		log.Println("Error decoding API request body:", err)
+		return invalidJSON
+	}
+	return nil
+}
+
+// Reads the limit and offset query parameters.
+func pagination(req *http.Request) (limit, offset int, err error) {
+	fields := fieldErrors{}
+	limit, offset = defaultLimit, 0
+
+	if value := req.URL.Query().Get("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxLimit {
+			fields["limit"] = i18n.NewError("api.limit", maxLimit)
+		}
+	}
+	if value := req.URL.Query().Get("offset"); value != "" {
+		offset, err = strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			fields["offset"] = i18n.NewError("api.offset")
+		}
+	}
+	return limit, offset, fields.err()
+}
+
+// Returns the part of a list of n items selected by limit and offset.
+func window(n, limit, offset int) (start, end int, more bool) {
+	start = offset
+	if start > n {
+		start = n
+	}
+	end = start + limit
+	if end > n {
+		end = n
+	}
+	return start, end, end < n
+}
+
+func pathInt(ps httprouter.Params, name string) (int, error) {
+	value, err := strconv.Atoi(ps.ByName(name))
+	if err != nil {
+		return 0, notFound
+	}
+	return value, nil
+}
+
+// Turns an httprouter path into an OpenAPI one, e.g. /pages/:slug into
+// /pages/{slug}, and lists its parameters.
+func pathParams(path string) (openAPIPath string, names []string) {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			names = append(names, segment[1:])
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/"), names
+}
@@ -0,0 +1,377 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/comforme/comforme/common"
+	"github.com/comforme/comforme/database"
+	"github.com/comforme/comforme/databaseActions"
+	"github.com/comforme/comforme/dbtest"
+)
+
+func newRouter() *httprouter.Router {
+	router := httprouter.New()
+	Register(router)
+	return router
+}
+
+// Sends a request through the router and decodes the JSON response into
+// value, if it is not nil.
+func serve(t *testing.T, method, path, token, body string, value interface{}) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, Prefix+path, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	res := httptest.NewRecorder()
+	newRouter().ServeHTTP(res, req)
+
+	if value != nil {
+		if err := json.Unmarshal(res.Body.Bytes(), value); err != nil {
+			t.Fatalf("%s %s: %s in %q", method, path, err, res.Body.String())
+		}
+	}
+	return res
+}
+
+// The error envelope every endpoint uses.
+type errorBody struct {
+	Error  string            `json:"error"`
+	Code   string            `json:"code"`
+	Fields map[string]string `json:"fields"`
+}
+
+func checkError(t *testing.T, res *httptest.ResponseRecorder, body errorBody, status int, code string) {
+	if res.Code != status {
+		t.Errorf("got status %d, want %d", res.Code, status)
+	}
+	if body.Code != code {
+		t.Errorf("got code %q, want %q", body.Code, code)
+	}
+	if body.Error == "" {
+		t.Error("error message is empty")
+	}
+	if contentType := res.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "application/json") {
+		t.Errorf("got content type %q", contentType)
+	}
+}
+
+func TestNotLoggedIn(t *testing.T) {
+	var body errorBody
+	res := serve(t, "GET", "/me", "", "", &body)
+	checkError(t, res, body, http.StatusUnauthorized, "not_logged_in")
+}
+
+func TestUnknownRoute(t *testing.T) {
+	res := serve(t, "GET", "/nothing-here", "", "", nil)
+	if res.Code != http.StatusNotFound {
+		t.Errorf("got status %d, want %d", res.Code, http.StatusNotFound)
+	}
+}
+
+func TestOpenAPIDocumentMatchesRoutes(t *testing.T) {
+	var document struct {
+		OpenAPI string `json:"openapi"`
+		Paths   map[string]map[string]struct {
+			Summary    string `json:"summary"`
+			Parameters []struct {
+				Name string `json:"name"`
+				In   string `json:"in"`
+			} `json:"parameters"`
+			RequestBody interface{}              `json:"requestBody"`
+			Responses   map[string]interface{}   `json:"responses"`
+			Security    []map[string]interface{} `json:"security"`
+		} `json:"paths"`
+		Components struct {
+			Schemas map[string]interface{} `json:"schemas"`
+		} `json:"components"`
+	}
+	res := serve(t, "GET", "/openapi.json", "", "", &document)
+	if res.Code != http.StatusOK {
+		t.Fatalf("got status %d", res.Code)
+	}
+	if document.OpenAPI != "3.0.0" {
+		t.Errorf("got openapi %q", document.OpenAPI)
+	}
+	if _, ok := document.Components.Schemas["Error"]; !ok {
+		t.Error("the Error schema is missing")
+	}
+
+	operations := 0
+	for _, item := range document.Paths {
+		operations += len(item)
+	}
+	if operations != len(routes) {
+		t.Errorf("got %d operations, want one for each of the %d routes", operations, len(routes))
+	}
+
+	for _, r := range routes {
+		path, names := pathParams(r.path)
+		operation, ok := document.Paths[path][strings.ToLower(r.method)]
+		if !ok {
+			t.Errorf("%s %s is missing", r.method, path)
+			continue
+		}
+		if operation.Summary != r.summary {
+			t.Errorf("%s %s: got summary %q, want %q", r.method, path, operation.Summary, r.summary)
+		}
+
+		parameters := map[string]string{}
+		for _, p := range operation.Parameters {
+			parameters[p.Name] = p.In
+		}
+		for _, name := range names {
+			if parameters[name] != "path" {
+				t.Errorf("%s %s: path parameter %s is missing", r.method, path, name)
+			}
+		}
+		for _, p := range r.query {
+			if parameters[p.name] != "query" {
+				t.Errorf("%s %s: query parameter %s is missing", r.method, path, p.name)
+			}
+		}
+		if len(parameters) != len(names)+len(r.query) {
+			t.Errorf("%s %s: got %d parameters", r.method, path, len(parameters))
+		}
+
+		if (operation.RequestBody != nil) != (r.body != nil) {
+			t.Errorf("%s %s: request body does not match the route", r.method, path)
+		}
+		if _, ok := operation.Responses["default"]; !ok {
+			t.Errorf("%s %s: error response is missing", r.method, path)
+		}
+		if r.public != (len(operation.Security) == 0) {
+			t.Errorf("%s %s: got security %v", r.method, path, operation.Security)
+		}
+	}
+}
+
+func TestPagination(t *testing.T) {
+	tests := []struct {
+		query         string
+		limit, offset int
+		fields        []string
+	}{
+		{"", defaultLimit, 0, nil},
+		{"limit=5&offset=10", 5, 10, nil},
+		{"limit=100", 100, 0, nil},
+		{"limit=0", 0, 0, []string{"limit"}},
+		{"limit=101", 0, 0, []string{"limit"}},
+		{"limit=ten&offset=-1", 0, 0, []string{"limit", "offset"}},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest("GET", Prefix+"/pages?"+test.query, nil)
+		limit, offset, err := pagination(req)
+		if test.fields == nil {
+			if err != nil || limit != test.limit || offset != test.offset {
+				t.Errorf("%q: got %d, %d, %v", test.query, limit, offset, err)
+			}
+			continue
+		}
+
+		apiErr, ok := err.(*Error)
+		if !ok {
+			t.Errorf("%q: got %v, want field errors", test.query, err)
+			continue
+		}
+		if apiErr.status != http.StatusUnprocessableEntity || len(apiErr.Fields) != len(test.fields) {
+			t.Errorf("%q: got %d %v", test.query, apiErr.status, apiErr.Fields)
+		}
+		for _, field := range test.fields {
+			if apiErr.Fields[field] == "" {
+				t.Errorf("%q: no error for %s", test.query, field)
+			}
+		}
+	}
+}
+
+func TestWindow(t *testing.T) {
+	tests := []struct {
+		n, limit, offset int
+		start, end       int
+		more             bool
+	}{
+		{5, 2, 0, 0, 2, true},
+		{5, 2, 2, 2, 4, true},
+		{5, 2, 4, 4, 5, false},
+		{5, 2, 6, 5, 5, false},
+		{0, 20, 0, 0, 0, false},
+	}
+	for _, test := range tests {
+		start, end, more := window(test.n, test.limit, test.offset)
+		if start != test.start || end != test.end || more != test.more {
+			t.Errorf("window(%d, %d, %d) = %d, %d, %v", test.n, test.limit, test.offset, start, end, more)
+		}
+	}
+}
+
+func TestToAPIError(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{common.PageNotFound, http.StatusNotFound, "not_found"},
+		{common.PermissionDenied, http.StatusForbidden, "forbidden"},
+		{common.NotACommunityMember, http.StatusConflict, "conflict"},
+		{common.DatabaseError, http.StatusInternalServerError, "internal_error"},
+		{common.InvalidTitle, http.StatusBadRequest, "bad_request"},
+		{notLoggedIn, http.StatusUnauthorized, "not_logged_in"},
+	}
+	for _, test := range tests {
+		apiErr := toAPIError(test.err)
+		if apiErr.status != test.status || apiErr.Code != test.code || apiErr.Message == "" {
+			t.Errorf("%v: got %d %q %q", test.err, apiErr.status, apiErr.Code, apiErr.Message)
+		}
+	}
+}
+
+// Points databaseActions at a fresh database with a user and returns
+// tokens for them with each combination of scopes.
+func setUpTokens(t *testing.T) (conn *sql.DB, tokens map[string]string) {
+	conn, dsn := dbtest.New(t)
+	testDB, err := database.NewDB(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	databaseActions.UseDB(testDB)
+
+	userInfo := common.UserInfo{UserID: dbtest.User(t, conn, "apitester"), Username: "apitester", Role: common.RoleUser}
+	tokens = map[string]string{}
+	for _, scopes := range []string{"read", "write", "read write"} {
+		tokens[scopes], err = databaseActions.CreateAPIToken(userInfo, scopes, strings.Fields(scopes))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return conn, tokens
+}
+
+func TestTokenAuthentication(t *testing.T) {
+	_, tokens := setUpTokens(t)
+
+	var me User
+	res := serve(t, "GET", "/me", tokens["read"], "", &me)
+	if res.Code != http.StatusOK || me.Username != "apitester" {
+		t.Errorf("got %d %+v", res.Code, me)
+	}
+
+	var body errorBody
+	res = serve(t, "GET", "/me", "not-a-token", "", &body)
+	checkError(t, res, body, http.StatusUnauthorized, "invalid_token")
+	if !strings.Contains(res.Header().Get("WWW-Authenticate"), `error="invalid_token"`) {
+		t.Errorf("got WWW-Authenticate %q", res.Header().Get("WWW-Authenticate"))
+	}
+}
+
+func TestTokenScopes(t *testing.T) {
+	_, tokens := setUpTokens(t)
+
+	tests := []struct {
+		method, path, scopes string
+		allowed              bool
+	}{
+		{"GET", "/me", "read", true},
+		{"GET", "/me", "write", false},
+		{"DELETE", "/memberships/1", "read", false},
+		{"POST", "/pages", "read", false},
+	}
+	for _, test := range tests {
+		body := ""
+		if test.method == "POST" {
+			body = "{}"
+		}
+
+		var errBody errorBody
+		res := serve(t, test.method, test.path, tokens[test.scopes], body, &errBody)
+		if test.allowed {
+			if res.Code != http.StatusOK {
+				t.Errorf("%s %s with %s: got status %d", test.method, test.path, test.scopes, res.Code)
+			}
+			continue
+		}
+		checkError(t, res, errBody, http.StatusForbidden, "insufficient_scope")
+	}
+}
+
+func TestFieldErrors(t *testing.T) {
+	_, tokens := setUpTokens(t)
+
+	var body errorBody
+	res := serve(t, "POST", "/pages", tokens["read write"], `{"title": "x", "description": "", "categoryId": 0}`, &body)
+	checkError(t, res, body, http.StatusUnprocessableEntity, "validation_failed")
+	for _, field := range []string{"title", "description", "categoryId"} {
+		if body.Fields[field] == "" {
+			t.Errorf("no error for %s in %v", field, body.Fields)
+		}
+	}
+
+	body = errorBody{}
+	res = serve(t, "POST", "/pages", tokens["read write"], `{"title": `, &body)
+	checkError(t, res, body, http.StatusBadRequest, "invalid_json")
+
+	req := httptest.NewRequest("POST", Prefix+"/pages", strings.NewReader("title=Bakery"))
+	req.Header.Set("Authorization", "Bearer "+tokens["read write"])
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	res = httptest.NewRecorder()
+	newRouter().ServeHTTP(res, req)
+	body = errorBody{}
+	if err := json.Unmarshal(res.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	checkError(t, res, body, http.StatusUnsupportedMediaType, "unsupported_media_type")
+}
+
+func TestListPagesPagination(t *testing.T) {
+	conn, tokens := setUpTokens(t)
+	author := dbtest.User(t, conn, "author")
+	category := dbtest.Category(t, conn, "Food")
+	for _, title := range []string{"First", "Second", "Third"} {
+		dbtest.Page(t, conn, author, category, title)
+	}
+
+	tests := []struct {
+		query  string
+		titles []string
+		more   bool
+	}{
+		{"?limit=2", []string{"Third", "Second"}, true},
+		{"?limit=2&offset=2", []string{"First"}, false},
+		{"?limit=2&offset=4", []string{}, false},
+		{"?category=food", []string{"Third", "Second", "First"}, false},
+		{"?category=travel", []string{}, false},
+	}
+	for _, test := range tests {
+		var list PageList
+		res := serve(t, "GET", "/pages"+test.query, tokens["read"], "", &list)
+		if res.Code != http.StatusOK {
+			t.Errorf("%s: got status %d", test.query, res.Code)
+			continue
+		}
+
+		titles := []string{}
+		for _, page := range list.Items {
+			titles = append(titles, page.Title)
+		}
+		if strings.Join(titles, ",") != strings.Join(test.titles, ",") || list.More != test.more {
+			t.Errorf("%s: got %v, more %v; want %v, more %v", test.query, titles, list.More, test.titles, test.more)
+		}
+	}
+
+	var body errorBody
+	res := serve(t, "GET", "/pages?limit=101", tokens["read"], "", &body)
+	checkError(t, res, body, http.StatusUnprocessableEntity, "validation_failed")
+	if body.Fields["limit"] == "" {
+		t.Errorf("no error for limit in %v", body.Fields)
+	}
+}
@@ -0,0 +1,213 @@
+package api
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/comforme/comforme/common"
+)
+
+// Generates an OpenAPI 3.0 document describing every route.
+func openAPIDocument() map[string]interface{} {
+	schemas := map[string]interface{}{}
+	schemaFor(reflect.TypeOf(Error{}), schemas)
+	paths := map[string]interface{}{}
+
+	for _, r := range routes {
+		path, names := pathParams(r.path)
+
+		parameters := []interface{}{}
+		for _, name := range names {
+			parameters = append(parameters, map[string]interface{}{
+				"name":     name,
+				"in":       "path",
+				"required": true,
+				"schema":   map[string]interface{}{"type": "string"},
+			})
+		}
+		for _, p := range r.query {
+			parameters = append(parameters, map[string]interface{}{
+				"name":        p.name,
+				"in":          "query",
+				"required":    p.required,
+				"description": p.description,
+				"schema":      map[string]interface{}{"type": p.kind},
+			})
+		}
+
+		status := r.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		success := map[string]interface{}{"description": http.StatusText(status)}
+		if r.response != nil {
+			success["content"] = jsonContent(r.response, schemas)
+		}
+		responses := map[string]interface{}{
+			strconv.Itoa(status): success,
+			"default": map[string]interface{}{
+				"description": "Error",
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{
+						"schema": ref("Error"),
+					},
+				},
+			},
+		}
+
+		operation := map[string]interface{}{
+			"summary":    r.summary,
+			"parameters": parameters,
+			"responses":  responses,
+		}
+		if r.body != nil {
+			operation["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content":  jsonContent(r.body, schemas),
+			}
+		}
+		if r.public {
+			operation["security"] = []interface{}{}
+		} else {
+			operation["security"] = []interface{}{
+				map[string]interface{}{"session": []interface{}{}},
+				map[string]interface{}{"token": []interface{}{}},
+				map[string]interface{}{"oauth2": []interface{}{common.ScopeForMethod(r.method)}},
+			}
+		}
+
+		item, ok := paths[path].(map[string]interface{})
+		if !ok {
+			item = map[string]interface{}{}
+			paths[path] = item
+		}
+		item[strings.ToLower(r.method)] = operation
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.0",
+		"info": map[string]interface{}{
+			"title":   common.SiteName + " API",
+			"version": "1",
+		},
+		"servers": []interface{}{
+			map[string]interface{}{"url": Prefix},
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": schemas,
+			"securitySchemes": map[string]interface{}{
+				"session": map[string]interface{}{
+					"type": "apiKey",
+					"in":   "cookie",
+					"name": "sessionid",
+				},
+				"token": map[string]interface{}{
+					"type":   "http",
+					"scheme": "bearer",
+				},
+				"oauth2": map[string]interface{}{
+					"type": "oauth2",
+					"flows": map[string]interface{}{
+						"authorizationCode": map[string]interface{}{
+							"authorizationUrl": "/oauth/authorize",
+							"tokenUrl":         "/oauth/token",
+							"scopes": map[string]interface{}{
+								common.ScopeRead:  "Read pages, posts and communities.",
+								common.ScopeWrite: "Create pages and posts and manage memberships.",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func ref(name string) map[string]interface{} {
+	return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+}
+
+func jsonContent(value interface{}, schemas map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"application/json": map[string]interface{}{
+			"schema": schemaFor(reflect.TypeOf(value), schemas),
+		},
+	}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// Builds the schema for a Go type from its JSON struct tags. Structs are
+// added to schemas and referred to by name.
+func schemaFor(t reflect.Type, schemas map[string]interface{}) map[string]interface{} {
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Ptr:
+		return schemaFor(t.Elem(), schemas)
+	case reflect.Slice:
+		return map[string]interface{}{"type": "array", "items": schemaFor(t.Elem(), schemas)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaFor(t.Elem(), schemas)}
+	case reflect.Struct:
+		if t == timeType {
+			return map[string]interface{}{"type": "string", "format": "date-time"}
+		}
+		if _, ok := schemas[t.Name()]; ok {
+			return ref(t.Name())
+		}
+
+		properties := map[string]interface{}{}
+		required := []string{}
+		schema := map[string]interface{}{
+			"type":       "object",
+			"properties": properties,
+		}
+		schemas[t.Name()] = schema
+		addFields(t, properties, &required, schemas)
+		if len(required) > 0 {
+			sort.Strings(required)
+			schema["required"] = required
+		}
+		return ref(t.Name())
+	}
+	return map[string]interface{}{}
+}
+
+// Adds the JSON fields of a struct, including those of embedded structs.
+func addFields(t reflect.Type, properties map[string]interface{}, required *[]string, schemas map[string]interface{}) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous {
+			addFields(field.Type, properties, required, schemas)
+			continue
+		}
+
+		tag := field.Tag.Get("json")
+		if tag == "" || tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		properties[name] = schemaFor(field.Type, schemas)
+		if !strings.Contains(tag, "omitempty") {
+			*required = append(*required, name)
+		}
+	}
+}
+
+func getOpenAPI(req *http.Request, ps httprouter.Params, userInfo common.UserInfo) (interface{}, error) {
+	return openAPIDocument(), nil
+}
@@ -0,0 +1,161 @@
+package api
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/comforme/comforme/common"
+)
+
+// JSON representations of the API's resources.
+
+type User struct {
+	Id       int    `json:"id"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Role     string `json:"role"`
+}
+
+type Category struct {
+	Id   int    `json:"id"`
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+}
+
+type Page struct {
+	Id           int       `json:"id"`
+	Title        string    `json:"title"`
+	Slug         string    `json:"slug"`
+	Category     string    `json:"category"`
+	CategorySlug string    `json:"categorySlug"`
+	Description  string    `json:"description"`
+	Address      string    `json:"address"`
+	Website      string    `json:"website"`
+	Location     *Location `json:"location,omitempty"`
+	DateCreated  time.Time `json:"dateCreated"`
+	URL          string    `json:"url"`
+}
+
+type Location struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+type Post struct {
+	Id                int    `json:"id"`
+	Author            string `json:"author"` // Empty for anonymous posts
+	Anonymous         bool   `json:"anonymous"`
+	Pseudonymous      bool   `json:"pseudonymous"`
+	RemoteActor       string `json:"remoteActor,omitempty"` // Set for replies from other servers
+	Body              string `json:"body"`
+	Date              string `json:"date"`
+	CommonCommunities int    `json:"commonCommunities"`
+}
+
+type Community struct {
+	Id         int    `json:"id"`
+	Name       string `json:"name"`
+	ParentID   int    `json:"parentId,omitempty"`
+	IsMember   bool   `json:"isMember"`
+	Visibility string `json:"visibility,omitempty"`
+}
+
+type CommunityDetails struct {
+	Community
+	Description string `json:"description"`
+	MemberCount int    `json:"memberCount"`
+	ParentName  string `json:"parentName,omitempty"`
+}
+
+type Membership struct {
+	CommunityID   int    `json:"communityId"`
+	CommunityName string `json:"communityName"`
+	Visibility    string `json:"visibility"`
+}
+
+// Included in every list response.
+type Pagination struct {
+	Limit  int  `json:"limit"`
+	Offset int  `json:"offset"`
+	More   bool `json:"more"`
+}
+
+type CategoryList struct {
+	Items []Category `json:"items"`
+	Pagination
+}
+
+type PageList struct {
+	Items []Page `json:"items"`
+	Pagination
+}
+
+type PostList struct {
+	Items []Post `json:"items"`
+	Pagination
+}
+
+type CommunityList struct {
+	Items []Community `json:"items"`
+	Pagination
+}
+
+type MembershipList struct {
+	Items []Membership `json:"items"`
+	Pagination
+}
+
+// Request bodies
+
+type NewPage struct {
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Address     string    `json:"address"`
+	Website     string    `json:"website"`
+	Location    *Location `json:"location,omitempty"`
+	CategoryID  int       `json:"categoryId"`
+}
+
+type NewPost struct {
+	Body        string `json:"body"`
+	PseudonymID int    `json:"pseudonymId"`
+	Anonymous   bool   `json:"anonymous"`
+}
+
+type MembershipUpdate struct {
+	Visibility string `json:"visibility"`
+}
+
+func newPage(page common.Page) Page {
+	return Page{
+		Id:           page.Id,
+		Title:        page.Title,
+		Slug:         page.PageSlug,
+		Category:     page.Category,
+		CategorySlug: page.CategorySlug,
+		Description:  page.Description,
+		Address:      page.Address,
+		Website:      page.Website,
+		Location:     (*Location)(page.Location),
+		DateCreated:  page.DateCreated,
+		URL:          fmt.Sprintf("/page/%s/%s", page.CategorySlug, page.PageSlug),
+	}
+}
+
+func newPageList(pages []common.Page, pagination Pagination) PageList {
+	list := PageList{[]Page{}, pagination}
+	for _, page := range pages {
+		list.Items = append(list.Items, newPage(page))
+	}
+	return list
+}
+
+func newCommunity(community common.Community) Community {
+	return Community{
+		Id:         community.Id,
+		Name:       community.Name,
+		ParentID:   community.ParentID,
+		IsMember:   community.IsMember,
+		Visibility: community.Visibility,
+	}
+}
@@ -0,0 +1,479 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/comforme/comforme/common"
+	"github.com/comforme/comforme/databaseActions"
+	"github.com/comforme/comforme/i18n"
+)
+
+var paginationParams = []param{
+	{"limit", "integer", "Maximum number of items to return, from 1 to 100. Defaults to 20.", false},
+	{"offset", "integer", "Number of items to skip.", false},
+}
+
+var routes []route
+
+// Set up in init so that the OpenAPI route can refer to the table.
+func init() {
+	routes = []route{
+		{
+			method:   "GET",
+			path:     "/me",
+			summary:  "The logged in user.",
+			response: User{},
+			handle:   getMe,
+		},
+		{
+			method:   "GET",
+			path:     "/categories",
+			summary:  "List categories.",
+			query:    paginationParams,
+			response: CategoryList{},
+			handle:   listCategories,
+		},
+		{
+			method:  "GET",
+			path:    "/pages",
+			summary: "List pages, newest first.",
+			query: append([]param{
+				{"category", "string", "Only list pages in the category with this slug.", false},
+			}, paginationParams...),
+			response: PageList{},
+			handle:   listPages,
+		},
+		{
+			method:   "POST",
+			path:     "/pages",
+			summary:  "Create a page.",
+			body:     NewPage{},
+			response: Page{},
+			status:   http.StatusCreated,
+			handle:   createPage,
+		},
+		{
+			method:   "GET",
+			path:     "/pages/:category/:slug",
+			summary:  "Get a page.",
+			response: Page{},
+			handle:   getPage,
+		},
+		{
+			method:   "GET",
+			path:     "/pages/:category/:slug/posts",
+			summary:  "List the posts on a page, most relevant to the user first.",
+			query:    paginationParams,
+			response: PostList{},
+			handle:   listPosts,
+		},
+		{
+			method:  "POST",
+			path:    "/pages/:category/:slug/posts",
+			summary: "Post on a page.",
+			body:    NewPost{},
+			status:  http.StatusCreated,
+			handle:  createPost,
+		},
+		{
+			method:   "GET",
+			path:     "/communities",
+			summary:  "List communities.",
+			query:    paginationParams,
+			response: CommunityList{},
+			handle:   listCommunities,
+		},
+		{
+			method:   "GET",
+			path:     "/communities/:id",
+			summary:  "Get a community.",
+			response: CommunityDetails{},
+			handle:   getCommunity,
+		},
+		{
+			method:   "GET",
+			path:     "/memberships",
+			summary:  "List the communities the user belongs to.",
+			query:    paginationParams,
+			response: MembershipList{},
+			handle:   listMemberships,
+		},
+		{
+			method:   "PUT",
+			path:     "/memberships/:id",
+			summary:  "Join a community or change who can see the membership.",
+			body:     MembershipUpdate{},
+			response: Membership{},
+			handle:   putMembership,
+		},
+		{
+			method:  "DELETE",
+			path:    "/memberships/:id",
+			summary: "Leave a community.",
+			status:  http.StatusNoContent,
+			handle:  deleteMembership,
+		},
+		{
+			method:  "GET",
+			path:    "/search/pages",
+			summary: "Search pages by title.",
+			query: append([]param{
+				{"q", "string", "Search query.", true},
+			}, paginationParams...),
+			response: PageList{},
+			handle:   searchPages,
+		},
+		{
+			method:  "GET",
+			path:    "/search/communities",
+			summary: "Search communities by name and alias.",
+			query: append([]param{
+				{"q", "string", "Search query.", true},
+			}, paginationParams...),
+			response: CommunityList{},
+			handle:   searchCommunities,
+		},
+		{
+			method:  "GET",
+			path:    "/openapi.json",
+			summary: "This API's OpenAPI document.",
+			public:  true,
+			handle:  getOpenAPI,
+		},
+	}
+}
+
+func getMe(req *http.Request, ps httprouter.Params, userInfo common.UserInfo) (interface{}, error) {
+	return User{userInfo.UserID, userInfo.Username, userInfo.Email, userInfo.Role.String()}, nil
+}
+
+func listCategories(req *http.Request, ps httprouter.Params, userInfo common.UserInfo) (interface{}, error) {
+	limit, offset, err := pagination(req)
+	if err != nil {
+		return nil, err
+	}
+
+	categories, err := databaseActions.GetCategories()
+	if err != nil {
+		return nil, err
+	}
+
+	start, end, more := window(len(categories), limit, offset)
+	list := CategoryList{[]Category{}, Pagination{limit, offset, more}}
+	for _, category := range categories[start:end] {
+		list.Items = append(list.Items, Category{category.Id, category.Name, category.Slug})
+	}
+	return list, nil
+}
+
+func listPages(req *http.Request, ps httprouter.Params, userInfo common.UserInfo) (interface{}, error) {
+	limit, offset, err := pagination(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// One extra to find out if there are more
+	pages, err := databaseActions.ListPages(req.URL.Query().Get("category"), limit+1, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	more := len(pages) > limit
+	if more {
+		pages = pages[:limit]
+	}
+	return newPageList(pages, Pagination{limit, offset, more}), nil
+}
+
+func createPage(req *http.Request, ps httprouter.Params, userInfo common.UserInfo) (interface{}, error) {
+	var body NewPage
+	if err := decodeBody(req, &body); err != nil {
+		return nil, err
+	}
+
+	fields := fieldErrors{}
+	if len(body.Title) <= 1 {
+		fields["title"] = i18n.NewError("api.title_too_short")
+	}
+	if len(body.Description) < common.MinDescriptionLength {
+		fields["description"] = common.DescriptionTooShort
+	}
+	if body.CategoryID <= 0 {
+		fields["categoryId"] = i18n.NewError("api.invalid_category")
+	}
+	location := (*common.Location)(body.Location)
+	if location != nil && !location.Valid() {
+		fields["location"] = common.InvalidLocation
+	}
+	if err := fields.err(); err != nil {
+		return nil, err
+	}
+
+	categorySlug, pageSlug, err := databaseActions.CreatePage(
+		userInfo.UserID,
+		body.Title,
+		body.Description,
+		body.Address,
+		body.Website,
+		location,
+		body.CategoryID,
+	)
+	if err == common.InvalidTitle {
+		return nil, fieldErrors{"title": err}.err()
+	} else if err != nil {
+		return nil, err
+	}
+
+	page, err := databaseActions.GetPage(categorySlug, pageSlug)
+	if err != nil {
+		return nil, err
+	}
+	return newPage(page), nil
+}
+
+func getPage(req *http.Request, ps httprouter.Params, userInfo common.UserInfo) (interface{}, error) {
+	page, err := databaseActions.GetPage(ps.ByName("category"), ps.ByName("slug"))
+	if err != nil {
+		return nil, notFound
+	}
+	return newPage(page), nil
+}
+
+func listPosts(req *http.Request, ps httprouter.Params, userInfo common.UserInfo) (interface{}, error) {
+	limit, offset, err := pagination(req)
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := databaseActions.GetPage(ps.ByName("category"), ps.ByName("slug"))
+	if err != nil {
+		return nil, notFound
+	}
+
+	posts, err := databaseActions.GetPosts(userInfo.UserID, page)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end, more := window(len(posts), limit, offset)
+	list := PostList{[]Post{}, Pagination{limit, offset, more}}
+	for _, post := range posts[start:end] {
+		list.Items = append(list.Items, Post{
+			Id:                post.Id,
+			Author:            post.Author,
+			Anonymous:         post.Anonymous,
+			Pseudonymous:      post.Pseudonymous,
+			RemoteActor:       post.RemoteActor,
+			Body:              post.Body,
+			Date:              post.Date,
+			CommonCommunities: post.CommonCategories,
+		})
+	}
+	return list, nil
+}
+
+func createPost(req *http.Request, ps httprouter.Params, userInfo common.UserInfo) (interface{}, error) {
+	var body NewPost
+	if err := decodeBody(req, &body); err != nil {
+		return nil, err
+	}
+
+	fields := fieldErrors{}
+	if len(body.Body) < common.MinDescriptionLength {
+		fields["body"] = i18n.NewError("api.post_too_short", common.MinDescriptionLength)
+	}
+	if body.Anonymous && body.PseudonymID != 0 {
+		fields["anonymous"] = i18n.NewError("api.anonymous_pseudonym")
+	}
+	if err := fields.err(); err != nil {
+		return nil, err
+	}
+
+	page, err := databaseActions.GetPage(ps.ByName("category"), ps.ByName("slug"))
+	if err != nil {
+		return nil, notFound
+	}
+
+	err = databaseActions.CreatePost(userInfo.UserID, body.Body, page, body.PseudonymID, body.Anonymous)
+	if err == common.PseudonymNotFound {
+		return nil, fieldErrors{"pseudonymId": err}.err()
+	}
+	return nil, err
+}
+
+func listCommunities(req *http.Request, ps httprouter.Params, userInfo common.UserInfo) (interface{}, error) {
+	limit, offset, err := pagination(req)
+	if err != nil {
+		return nil, err
+	}
+
+	communities, err := databaseActions.ListCommunities(userInfo.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return newCommunityList(communities, limit, offset), nil
+}
+
+func newCommunityList(communities []common.Community, limit, offset int) CommunityList {
+	start, end, more := window(len(communities), limit, offset)
+	list := CommunityList{[]Community{}, Pagination{limit, offset, more}}
+	for _, community := range communities[start:end] {
+		list.Items = append(list.Items, newCommunity(community))
+	}
+	return list
+}
+
+func getCommunity(req *http.Request, ps httprouter.Params, userInfo common.UserInfo) (interface{}, error) {
+	communityID, err := pathInt(ps, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	community, err := databaseActions.GetCommunity(userInfo.UserID, communityID)
+	if err != nil || community.Status != common.CommunityApproved {
+		return nil, notFound
+	}
+
+	community.IsMember, err = databaseActions.IsCommunityMember(userInfo.UserID, communityID)
+	if err != nil {
+		return nil, err
+	}
+
+	return CommunityDetails{
+		Community:   newCommunity(community),
+		Description: community.Description,
+		MemberCount: community.MemberCount,
+		ParentName:  community.ParentName,
+	}, nil
+}
+
+func getMemberships(userID int) ([]Membership, error) {
+	communities, err := databaseActions.ListCommunities(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	memberships := []Membership{}
+	for _, community := range communities {
+		if community.IsMember {
+			memberships = append(memberships, Membership{community.Id, community.Name, community.Visibility})
+		}
+	}
+	return memberships, nil
+}
+
+func listMemberships(req *http.Request, ps httprouter.Params, userInfo common.UserInfo) (interface{}, error) {
+	limit, offset, err := pagination(req)
+	if err != nil {
+		return nil, err
+	}
+
+	memberships, err := getMemberships(userInfo.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end, more := window(len(memberships), limit, offset)
+	return MembershipList{memberships[start:end], Pagination{limit, offset, more}}, nil
+}
+
+func putMembership(req *http.Request, ps httprouter.Params, userInfo common.UserInfo) (interface{}, error) {
+	communityID, err := pathInt(ps, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	var body MembershipUpdate
+	if err := decodeBody(req, &body); err != nil {
+		return nil, err
+	}
+	if body.Visibility == "" {
+		body.Visibility = common.VisibilityPublic
+	}
+	if !common.ValidMembershipVisibility(body.Visibility) {
+		return nil, fieldErrors{"visibility": i18n.NewError("api.invalid_visibility")}.err()
+	}
+
+	isMember, err := databaseActions.IsCommunityMember(userInfo.UserID, communityID)
+	if err != nil {
+		return nil, err
+	}
+	if !isMember {
+		err = databaseActions.SetCommunityMembership(userInfo, communityID, true)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = databaseActions.SetMembershipVisibility(userInfo, communityID, body.Visibility)
+	if err != nil {
+		return nil, err
+	}
+
+	memberships, err := getMemberships(userInfo.UserID)
+	if err != nil {
+		return nil, err
+	}
+	for _, membership := range memberships {
+		if membership.CommunityID == communityID {
+			return membership, nil
+		}
+	}
+	return nil, notFound
+}
+
+func deleteMembership(req *http.Request, ps httprouter.Params, userInfo common.UserInfo) (interface{}, error) {
+	communityID, err := pathInt(ps, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, databaseActions.SetCommunityMembership(userInfo, communityID, false)
+}
+
+func searchQuery(req *http.Request) (string, error) {
+	query := strings.TrimSpace(req.URL.Query().Get("q"))
+	if query == "" {
+		return "", fieldErrors{"q": i18n.NewError("api.query_required")}.err()
+	}
+	return query, nil
+}
+
+func searchPages(req *http.Request, ps httprouter.Params, userInfo common.UserInfo) (interface{}, error) {
+	limit, offset, err := pagination(req)
+	if err != nil {
+		return nil, err
+	}
+	query, err := searchQuery(req)
+	if err != nil {
+		return nil, err
+	}
+
+	pages, err := databaseActions.SearchPages(userInfo.UserID, query)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end, more := window(len(pages), limit, offset)
+	return newPageList(pages[start:end], Pagination{limit, offset, more}), nil
+}
+
+func searchCommunities(req *http.Request, ps httprouter.Params, userInfo common.UserInfo) (interface{}, error) {
+	limit, offset, err := pagination(req)
+	if err != nil {
+		return nil, err
+	}
+	query, err := searchQuery(req)
+	if err != nil {
+		return nil, err
+	}
+
+	communities, err := databaseActions.SearchCommunities(userInfo.UserID, query)
+	if err != nil {
+		return nil, err
+	}
+	return newCommunityList(communities, limit, offset), nil
+}
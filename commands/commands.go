@@ -0,0 +1,216 @@
+package commands
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/comforme/comforme/activitypub"
+	"github.com/comforme/comforme/databaseActions"
+	"github.com/comforme/comforme/imports"
+)
+
+type command struct {
+	usage string
+	run   func(args []string) error
+}
+
+var commands = map[string]command{
+	"grant-role": {
+		"grant-role <email> <user|trusted|moderator|admin>",
+		grantRole,
+	},
+	"grant-community-moderator": {
+		"grant-community-moderator <email> <community id>",
+		grantCommunityModerator,
+	},
+	"send-digests": {
+		"send-digests <base url>",
+		sendDigests,
+	},
+	"add-oauth-client": {
+		"add-oauth-client <name> <redirect uri>",
+		addOAuthClient,
+	},
+	"fake-remote": {
+		"fake-remote <port> <actor address>",
+		fakeRemote,
+	},
+	"import-pages": {
+		"import-pages [-dry-run] [-category <category>] [-map <field=column,...>] <email> <csv or json file>",
+		importPages,
+	},
+}
+
+var usageError = errors.New("Invalid arguments.")
+
+// Runs the command named by the first argument. Returns false if there was no
+// command to run and the server should be started instead.
+func Run(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown command: %s\n", args[0])
+		printUsage()
+		os.Exit(2)
+	}
+
+	err := cmd.run(args[1:])
+	if err == usageError {
+		fmt.Fprintf(os.Stderr, "Usage: %s %s\n", os.Args[0], cmd.usage)
+		os.Exit(2)
+	} else if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+	return true
+}
+
+func printUsage() {
+	fmt.Fprintln(os.Stderr, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "\t%s %s\n", os.Args[0], cmd.usage)
+	}
+}
+
+func grantRole(args []string) error {
+	if len(args) != 2 {
+		return usageError
+	}
+
+	err := databaseActions.GrantRole(args[0], args[1])
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Granted role %s to %s.\n", args[1], args[0])
+	return nil
+}
+
+func grantCommunityModerator(args []string) error {
+	if len(args) != 2 {
+		return usageError
+	}
+
+	communityID, err := strconv.Atoi(args[1])
+	if err != nil {
+		return usageError
+	}
+
+	err = databaseActions.GrantCommunityModerator(args[0], communityID)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s is now a moderator of community %d.\n", args[0], communityID)
+	return nil
+}
+
+// Meant to be run at least daily by a scheduler. Each user is sent at most one
+// digest per their chosen period however often it runs.
+func sendDigests(args []string) error {
+	if len(args) != 1 {
+		return usageError
+	}
+
+	sent, err := databaseActions.SendDigests(strings.TrimRight(args[0], "/"))
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Sent %d digests.\n", sent)
+	return nil
+}
+
+func addOAuthClient(args []string) error {
+	if len(args) != 2 {
+		return usageError
+	}
+
+	clientID, err := databaseActions.RegisterOAuthClient(args[0], args[1])
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Registered %s with client ID %s.\n", args[0], clientID)
+	return nil
+}
+
+// Runs a stand-in fediverse server that follows a local actor, for trying
+// out federation during development.
+func fakeRemote(args []string) error {
+	if len(args) != 2 {
+		return usageError
+	}
+
+	port, err := strconv.Atoi(args[0])
+	if err != nil {
+		return usageError
+	}
+
+	return activitypub.RunFakeRemote(port, args[1])
+}
+
+// Imports pages from a partner's directory, created by the user with the
+// given email. Run it with -dry-run first to see what would be created.
+func importPages(args []string) error {
+	flags := flag.NewFlagSet("import-pages", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "only report what would be imported")
+	category := flags.String("category", "", "category of rows without one")
+	mappingSpec := flags.String("map", "", "columns to read page fields from")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 2 {
+		return usageError
+	}
+
+	mapping, err := imports.ParseMapping(*mappingSpec)
+	if err != nil {
+		return err
+	}
+
+	content, err := ioutil.ReadFile(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	rows, err := imports.Parse(content, imports.DetectFormat(flags.Arg(1), content), mapping)
+	if err != nil {
+		return err
+	}
+
+	defaultCategory := 0
+	if *category != "" {
+		defaultCategory, err = databaseActions.FindCategory(*category)
+		if err != nil {
+			return err
+		}
+	}
+
+	report, err := databaseActions.ImportPagesAs(flags.Arg(0), rows, defaultCategory, *dryRun)
+	if err != nil {
+		return err
+	}
+
+	for _, result := range report.Results {
+		fmt.Printf("Row %d (%s): %s", result.Row, result.Title, result.Status)
+		if result.Error != nil {
+			fmt.Printf(", %s", result.Error)
+		}
+		if result.Path != "" {
+			fmt.Printf(", %s", result.Path)
+		}
+		fmt.Println()
+	}
+	if report.DryRun {
+		fmt.Printf("Dry run: %d would be created, %d duplicates, %d invalid.\n", report.Valid, report.Duplicates, report.Invalid)
+	} else {
+		fmt.Printf("%d created, %d duplicates, %d invalid.\n", report.Created, report.Duplicates, report.Invalid)
+	}
+	return nil
+}
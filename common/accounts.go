@@ -0,0 +1,11 @@
+package common
+
+import (
+	"github.com/comforme/comforme/i18n"
+)
+
+// Errors
+var (
+	DeletionAlreadyScheduled = i18n.NewError("error.deletion_already_scheduled")
+	DeletionNotScheduled     = i18n.NewError("error.deletion_not_scheduled")
+)
@@ -0,0 +1,208 @@
+package common
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/comforme/comforme/i18n"
+)
+
+// The domain categories and communities are federated under, such as
+// comfor.me. Federation is off unless it is set.
+var FederationDomain = os.Getenv("FEDERATION_DOMAIN")
+
+const (
+	ActivityStreamsContext = "https://www.w3.org/ns/activitystreams"
+	ActivityStreamsPublic  = "https://www.w3.org/ns/activitystreams#Public"
+	MaxRemoteReplyLength   = 5000
+)
+
+// Reply statuses
+const (
+	ReplyPending  = "pending"
+	ReplyApproved = "approved"
+	ReplyRejected = "rejected"
+)
+
+func FederationEnabled() bool {
+	return FederationDomain != ""
+}
+
+// Returns the absolute URL of path on the federated domain.
+func FederationURL(path string) string {
+	return fmt.Sprintf("%s://%s%s", protocol, FederationDomain, path)
+}
+
+func PageObjectPath(pageID int) string {
+	return fmt.Sprintf("/ap/pages/%d", pageID)
+}
+
+func PostObjectPath(postID int) string {
+	return fmt.Sprintf("/ap/posts/%d", postID)
+}
+
+// Parses the ID of a local page or post object, returning the kind of object
+// ("pages" or "posts") and its ID.
+func ParseObjectID(uri string) (kind string, id int, ok bool) {
+	prefix := FederationURL("/ap/")
+	if !FederationEnabled() || !strings.HasPrefix(uri, prefix) {
+		return "", 0, false
+	}
+
+	parts := strings.Split(strings.TrimPrefix(uri, prefix), "/")
+	if len(parts) != 2 || (parts[0] != "pages" && parts[0] != "posts") {
+		return "", 0, false
+	}
+	id, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return "", 0, false
+	}
+	return parts[0], id, true
+}
+
+// A page or post as an ActivityStreams object. Notes are attributed to the
+// category actor and never name the user who wrote them.
+type Note struct {
+	Context      string    `json:"@context,omitempty"`
+	ID           string    `json:"id"`
+	Type         string    `json:"type"`
+	AttributedTo string    `json:"attributedTo"`
+	InReplyTo    string    `json:"inReplyTo,omitempty"`
+	Content      string    `json:"content"`
+	URL          string    `json:"url"`
+	Published    time.Time `json:"published"`
+	To           []string  `json:"to"`
+	Cc           []string  `json:"cc,omitempty"`
+}
+
+type Activity struct {
+	Context   string      `json:"@context,omitempty"`
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	Actor     string      `json:"actor"`
+	Object    interface{} `json:"object"`
+	To        []string    `json:"to,omitempty"`
+	Cc        []string    `json:"cc,omitempty"`
+	Published *time.Time  `json:"published,omitempty"`
+}
+
+// A category or community as an ActivityPub actor. Exactly one of
+// CategorySlug and CommunityID is set.
+type LocalActor struct {
+	Id           int
+	CategorySlug string
+	CommunityID  int
+	Name         string
+	Summary      string
+	PublicKey    string
+	PrivateKey   string
+	Followers    int
+}
+
+// The name the actor is found under with WebFinger.
+func (actor LocalActor) Username() string {
+	if actor.CommunityID != 0 {
+		return fmt.Sprintf("community-%d", actor.CommunityID)
+	}
+	return "category-" + actor.CategorySlug
+}
+
+func (actor LocalActor) Path() string {
+	if actor.CommunityID != 0 {
+		return fmt.Sprintf("/ap/community/%d", actor.CommunityID)
+	}
+	return "/ap/category/" + actor.CategorySlug
+}
+
+// The actor's ActivityPub ID.
+func (actor LocalActor) URL() string {
+	return FederationURL(actor.Path())
+}
+
+// Parses a WebFinger username or actor path into a category slug or a
+// community ID.
+func ParseActorName(name string) (categorySlug string, communityID int, ok bool) {
+	if strings.HasPrefix(name, "category-") {
+		categorySlug = strings.TrimPrefix(name, "category-")
+		return categorySlug, 0, categorySlug != ""
+	}
+	if strings.HasPrefix(name, "community-") {
+		communityID, err := strconv.Atoi(strings.TrimPrefix(name, "community-"))
+		return "", communityID, err == nil && communityID > 0
+	}
+	return "", 0, false
+}
+
+// An actor on another server, as fetched from its ID.
+type RemoteActor struct {
+	ID                string
+	Inbox             string
+	SharedInbox       string
+	PreferredUsername string
+	URL               string
+	PublicKeyID       string
+	PublicKeyPem      string
+}
+
+// The inbox to deliver to, preferring the shared inbox.
+func (actor RemoteActor) DeliveryInbox() string {
+	if actor.SharedInbox != "" {
+		return actor.SharedInbox
+	}
+	return actor.Inbox
+}
+
+// The actor's name in the user@host form used across the fediverse.
+func (actor RemoteActor) Handle() string {
+	host := actor.ID
+	if i := strings.Index(host, "://"); i >= 0 {
+		host = host[i+3:]
+	}
+	if i := strings.Index(host, "/"); i >= 0 {
+		host = host[:i]
+	}
+
+	username := actor.PreferredUsername
+	if username == "" {
+		username = "unknown"
+	}
+	return username + "@" + host
+}
+
+// A reply to a page or post from a remote actor.
+type RemoteReply struct {
+	Id           int
+	PageID       int
+	PageTitle    string
+	CategorySlug string
+	PageSlug     string
+	ObjectID     string
+	Actor        string
+	Author       string
+	Content      string
+	DateCreated  time.Time
+}
+
+// A signed activity to deliver to a remote inbox.
+type FederationDelivery struct {
+	Id         int
+	ActorID    int
+	Inbox      string
+	Activity   string
+	Attempts   int
+	KeyID      string
+	PrivateKey string
+}
+
+// Errors
+var (
+	FederationDisabled = i18n.NewError("error.federation_disabled")
+	ActorNotFound      = i18n.NewError("error.actor_not_found")
+	ReplyNotFound      = i18n.NewError("error.reply_not_found")
+	NotAReply          = i18n.NewError("error.not_a_reply")
+	ReplyEmpty         = i18n.NewError("error.reply_empty")
+	ReplyTooLong       = i18n.NewError("error.reply_too_long", MaxRemoteReplyLength)
+)
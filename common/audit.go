@@ -0,0 +1,130 @@
+package common
+
+import (
+	"net/http"
+	"time"
+)
+
+type AuditAction string
+
+// Security events
+const (
+	AuditLogin                  AuditAction = "login"
+	AuditLoginFailed            AuditAction = "login.failed"
+	AuditRegistered             AuditAction = "registered"
+	AuditPasswordChanged        AuditAction = "password.changed"
+	AuditPasswordChangeFailed   AuditAction = "password.change_failed"
+	AuditPasswordResetRequested AuditAction = "password.reset_requested"
+	AuditPasswordReset          AuditAction = "password.reset"
+	AuditUsernameChanged        AuditAction = "username.changed"
+	AuditSessionsRevoked        AuditAction = "sessions.revoked"
+	AuditMembershipAdded        AuditAction = "membership.added"
+	AuditMembershipRemoved      AuditAction = "membership.removed"
+	AuditMembershipVisibility   AuditAction = "membership.visibility_changed"
+	AuditTokenCreated           AuditAction = "token.created"
+	AuditTokenRevoked           AuditAction = "token.revoked"
+	AuditOAuthAuthorized        AuditAction = "oauth.authorized"
+	AuditIdentityLinked         AuditAction = "identity.linked"
+	AuditIdentityUnlinked       AuditAction = "identity.unlinked"
+	AuditPasskeyAdded           AuditAction = "passkey.added"
+	AuditPasskeyRemoved         AuditAction = "passkey.removed"
+	AuditExportRequested        AuditAction = "export.requested"
+	AuditExportDownloaded       AuditAction = "export.downloaded"
+	AuditDeletionRequested      AuditAction = "account.deletion_requested"
+	AuditDeletionCancelled      AuditAction = "account.deletion_cancelled"
+)
+
+// Moderation events
+const (
+	AuditRoleGranted         AuditAction = "role.granted"
+	AuditCommunityModerator  AuditAction = "community.moderator_added"
+	AuditPostRemoved         AuditAction = "post.removed"
+	AuditPageEdited          AuditAction = "page.edited"
+	AuditUserSuspended       AuditAction = "user.suspended"
+	AuditUserUnsuspended     AuditAction = "user.unsuspended"
+	AuditPasswordResetForced AuditAction = "password.reset_forced"
+	AuditUserReported        AuditAction = "user.reported"
+	AuditReportResolved      AuditAction = "report.resolved"
+	AuditCategoryCreated     AuditAction = "category.created"
+	AuditCategoryRenamed     AuditAction = "category.renamed"
+	AuditCategoryDeleted     AuditAction = "category.deleted"
+	AuditCommunityCreated    AuditAction = "community.created"
+	AuditCommunityRenamed    AuditAction = "community.renamed"
+	AuditCommunityAliases    AuditAction = "community.aliases_changed"
+	AuditCommunityDeleted    AuditAction = "community.deleted"
+	AuditCommunitiesMerged   AuditAction = "community.merged"
+	AuditCommunityProposed   AuditAction = "community.proposed"
+	AuditCommunityApproved   AuditAction = "community.approved"
+	AuditCommunityRejected   AuditAction = "community.rejected"
+	AuditCommunityMoved      AuditAction = "community.moved"
+	AuditWebhookCreated      AuditAction = "webhook.created"
+	AuditWebhookChanged      AuditAction = "webhook.changed"
+	AuditWebhookDeleted      AuditAction = "webhook.deleted"
+	AuditWebhookReplayed     AuditAction = "webhook.replayed"
+	AuditReplyApproved       AuditAction = "reply.approved"
+	AuditReplyRejected       AuditAction = "reply.rejected"
+	AuditAccountDeleted      AuditAction = "account.deleted"
+	AuditPagesImported       AuditAction = "pages.imported"
+	AuditPagesMerged         AuditAction = "page.merged"
+)
+
+// Actions shown to users in their own security history.
+var SecurityAuditActions = []AuditAction{
+	AuditLogin,
+	AuditLoginFailed,
+	AuditRegistered,
+	AuditPasswordChanged,
+	AuditPasswordChangeFailed,
+	AuditPasswordResetRequested,
+	AuditPasswordReset,
+	AuditUsernameChanged,
+	AuditSessionsRevoked,
+	AuditUserSuspended,
+	AuditUserUnsuspended,
+	AuditPasswordResetForced,
+	AuditRoleGranted,
+	AuditTokenCreated,
+	AuditTokenRevoked,
+	AuditOAuthAuthorized,
+	AuditIdentityLinked,
+	AuditIdentityUnlinked,
+	AuditPasskeyAdded,
+	AuditPasskeyRemoved,
+	AuditExportRequested,
+	AuditExportDownloaded,
+	AuditDeletionRequested,
+	AuditDeletionCancelled,
+}
+
+// Where a request came from, recorded alongside audit events.
+type RequestInfo struct {
+	IpAddress string
+	UserAgent string
+}
+
+type AuditEvent struct {
+	Id            int64
+	Date          time.Time
+	Action        AuditAction
+	ActorID       int
+	ActorName     string
+	SubjectUserID int
+	SubjectName   string
+	IpAddress     string
+	UserAgent     string
+	Details       string
+}
+
+type AuditFilter struct {
+	Action    string
+	UserID    int
+	IpAddress string
+	BeforeID  int64
+}
+
+func GetRequestInfo(req *http.Request) RequestInfo {
+	return RequestInfo{
+		IpAddress: GetIpAddress(req),
+		UserAgent: req.UserAgent(),
+	}
+}
@@ -1,8 +1,8 @@
 package common
 
 import (
+	"bytes"
 	"encoding/hex"
-	"errors"
 	"fmt"
 	"html/template"
 	"log"
@@ -18,6 +18,8 @@ import (
 
 	"github.com/sendgrid/sendgrid-go"
 	"golang.org/x/crypto/scrypt"
+
+	"github.com/comforme/comforme/i18n"
 )
 
 var (
@@ -31,6 +33,49 @@ var (
 	linkAgeLimit = time.Hour * 24 * 14
 )
 
+// Community statuses
+const (
+	CommunityApproved = "approved"
+	CommunityPending  = "pending"
+	CommunityRejected = "rejected"
+)
+
+// Ways a user can silence another user
+const (
+	BlockKindBlock = "block"
+	BlockKindMute  = "mute"
+)
+
+// How often a user gets an email digest
+const (
+	DigestNever  = "never"
+	DigestDaily  = "daily"
+	DigestWeekly = "weekly"
+)
+
+func ValidDigestFrequency(frequency string) bool {
+	switch frequency {
+	case DigestNever, DigestDaily, DigestWeekly:
+		return true
+	}
+	return false
+}
+
+// Who can see a community membership
+const (
+	VisibilityPublic  = "public"
+	VisibilityMembers = "members"
+	VisibilityPrivate = "private"
+)
+
+func ValidMembershipVisibility(visibility string) bool {
+	switch visibility {
+	case VisibilityPublic, VisibilityMembers, VisibilityPrivate:
+		return true
+	}
+	return false
+}
+
 const (
 	alphaNumeric            = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
 	sessionIdLength         = 25
@@ -39,6 +84,8 @@ const (
 	slugRemoveChars         = "'\""
 	slugLength              = 25
 	MinDescriptionLength    = 3
+	MaxBioLength            = 1000
+	digestExcerptLength     = 140
 )
 
 type UserInfo struct {
@@ -46,6 +93,17 @@ type UserInfo struct {
 	Email     string
 	Username  string
 	UserID    int
+	Role      Role
+	RequestInfo
+
+	// The user's language setting until requireLogin negotiates the
+	// locale to show, taking Accept-Language into account.
+	Locale string
+
+	// Set when the user authenticated with an API token instead of a
+	// session cookie.
+	ViaToken bool
+	Scopes   []string
 }
 
 // Database row types
@@ -58,9 +116,135 @@ type PagePostCount struct {
 }
 
 type Community struct {
+	Id           int
+	Name         string
+	IsMember     bool
+	Visibility   string
+	MemberCount  int
+	Description  string
+	ParentID     int
+	ParentName   string
+	Status       string
+	ProposerName string
+	DateCreated  time.Time
+
+	// Only filled in for community searches
+	Aliases []string
+
+	// Filled in when communities are arranged as a tree
+	Children      []Community
+	ChildIsMember bool
+}
+
+// What anyone can see about a user.
+type Profile struct {
 	Id       int
-	Name     string
-	IsMember bool
+	Username string
+	Bio      string
+}
+
+// A report about a user waiting for a moderator.
+type UserReport struct {
+	Id             int
+	ReporterName   string
+	ReportedUserID int
+	ReportedName   string
+	Reason         string
+	DateCreated    time.Time
+}
+
+// A page or post in a user's home feed.
+type FeedItem struct {
+	Contribution
+	Pseudonymous bool
+	Affinity     float64
+}
+
+// A new post on a page the user follows.
+type Notification struct {
+	Id           int
+	PostID       int
+	Author       string // Empty for anonymous posts
+	PageTitle    string
+	PageSlug     string
+	CategorySlug string
+	Date         time.Time
+	Read         bool
+}
+
+// A user who is due an email digest. Since is the start of the period the
+// digest covers.
+type DigestRecipient struct {
+	UserID   int
+	Email    string
+	Username string
+	Since    time.Time
+}
+
+// What happened since a user's last digest.
+type Digest struct {
+	Pages          []Contribution // New pages by members of the user's communities
+	CommunityPosts []Contribution // New posts by members of the user's communities
+	FollowedPosts  []Contribution // New posts on pages the user follows
+}
+
+func (digest Digest) IsEmpty() bool {
+	return len(digest.Pages) == 0 && len(digest.CommunityPosts) == 0 && len(digest.FollowedPosts) == 0
+}
+
+// A user that another user has blocked or muted.
+type BlockedUser struct {
+	Id          int
+	Username    string
+	Kind        string
+	DateCreated time.Time
+}
+
+// An alternate name a user can post under.
+type Pseudonym struct {
+	Id   int
+	Name string
+}
+
+// A page or post made by a member of a community.
+type Contribution struct {
+	Author       string
+	PageTitle    string
+	PageSlug     string
+	CategorySlug string
+	Body         string
+	IsPage       bool
+	Date         time.Time
+}
+
+type Category struct {
+	Id        int
+	Name      string
+	Slug      string
+	PageCount int
+}
+
+type User struct {
+	Id                int
+	Username          string
+	Email             string
+	Role              Role
+	Suspended         bool
+	ResetRequired     bool
+	DeletionScheduled *time.Time
+}
+
+type SiteStatistics struct {
+	Users          int
+	SuspendedUsers int
+	Sessions       int
+	Pages          int
+	PagesThisWeek  int
+	Posts          int
+	PostsThisWeek  int
+	Categories     int
+	Communities    int
+	Memberships    int
 }
 
 type Page struct {
@@ -72,30 +256,67 @@ type Page struct {
 	Description  string
 	Address      string
 	Website      string
+	Location     *Location
 	DateCreated  time.Time
+	AuthorID     int
 }
 
 type Post struct {
-	Author           string
+	Id               int
+	Author           string // Empty for anonymous posts
+	Anonymous        bool
+	Pseudonymous     bool
+	RemoteActor      string // Set for replies from other servers
+	Muted            bool   // Only set for posts shown under a username
 	Body             string
 	CommonCategories int
+	Affinity         float64
 	Date             string
 }
 
 // Errors
 var (
-	EmailFailed               = errors.New("Sending email failed.")
-	EmailInUse                = errors.New("You have already registered with this email address.")
-	UsernameInUse             = errors.New("This username is in use. Please select a different one.")
-	InvalidUsernameOrPassword = errors.New("Invalid username or password.")
-	DatabaseError             = errors.New("Unknown database error.")
-	InvalidSessionID          = errors.New("Invalid sessionid.")
-	InvalidEmail              = errors.New("The provided email address is not valid.")
-	InvalidIpAddress          = errors.New("There is something wrong with your IP address.")
-	InvalidTitle              = errors.New("Invalid page title.")
-	PageAlreadyExists         = errors.New("A page with this category and title already exists.")
-	PageNotFound              = errors.New("Page not found.")
-	InvalidLink               = errors.New("Invalid link. It may have expired or possibly you already used it.")
+	EmailFailed               = i18n.NewError("error.email_failed")
+	EmailInUse                = i18n.NewError("error.email_in_use")
+	UsernameInUse             = i18n.NewError("error.username_in_use")
+	InvalidUsernameOrPassword = i18n.NewError("error.invalid_username_or_password")
+	DatabaseError             = i18n.NewError("error.database_error")
+	InvalidSessionID          = i18n.NewError("error.invalid_session_id")
+	InvalidEmail              = i18n.NewError("error.invalid_email")
+	InvalidIpAddress          = i18n.NewError("error.invalid_ip_address")
+	InvalidTitle              = i18n.NewError("error.invalid_title")
+	PageAlreadyExists         = i18n.NewError("error.page_already_exists")
+	PageNotFound              = i18n.NewError("error.page_not_found")
+	PostNotFound              = i18n.NewError("error.post_not_found")
+	InvalidLink               = i18n.NewError("error.invalid_link")
+	AccountSuspended          = i18n.NewError("error.account_suspended")
+	UserNotFound              = i18n.NewError("error.user_not_found")
+	CategoryNotFound          = i18n.NewError("error.category_not_found")
+	CategoryAlreadyExists     = i18n.NewError("error.category_already_exists")
+	CategoryNotEmpty          = i18n.NewError("error.category_not_empty")
+	CommunityNotFound         = i18n.NewError("error.community_not_found")
+	CommunityAlreadyExists    = i18n.NewError("error.community_already_exists")
+	InvalidName               = i18n.NewError("error.invalid_name")
+	CannotMergeIntoSelf       = i18n.NewError("error.cannot_merge_into_self")
+	CannotMergeIntoChild      = i18n.NewError("error.cannot_merge_into_child")
+	SimilarCommunitiesExist   = i18n.NewError("error.similar_communities_exist")
+	CommunityNotPending       = i18n.NewError("error.community_not_pending")
+	InvalidParentCommunity    = i18n.NewError("error.invalid_parent_community")
+	DescriptionTooShort       = i18n.NewError("error.description_too_short", MinDescriptionLength)
+	InvalidVisibility         = i18n.NewError("error.invalid_visibility")
+	NotACommunityMember       = i18n.NewError("error.not_a_community_member")
+	PseudonymNotFound         = i18n.NewError("error.pseudonym_not_found")
+	CannotBlockSelf           = i18n.NewError("error.cannot_block_self")
+	InvalidBlockKind          = i18n.NewError("error.invalid_block_kind")
+	BlockedByPageAuthor       = i18n.NewError("error.blocked_by_page_author")
+	BlockedByMentionedUser    = i18n.NewError("error.blocked_by_mentioned_user")
+	BioTooLong                = i18n.NewError("error.bio_too_long", MaxBioLength)
+	ReportReasonTooShort      = i18n.NewError("error.report_reason_too_short", MinDescriptionLength)
+	CannotReportSelf          = i18n.NewError("error.cannot_report_self")
+	ReportNotFound            = i18n.NewError("error.report_not_found")
+	NotificationNotFound      = i18n.NewError("error.notification_not_found")
+	InvalidDigestFrequency    = i18n.NewError("error.invalid_digest_frequency")
+	InvalidLocale             = i18n.NewError("error.invalid_locale")
 )
 
 // Regex
@@ -106,6 +327,7 @@ var (
 	slugEndCap     *regexp.Regexp
 	slugRemove     *regexp.Regexp
 	slugMiddle     *regexp.Regexp
+	mentionRegex   *regexp.Regexp
 )
 
 func init() {
@@ -116,6 +338,7 @@ func init() {
 	slugEndCap = regexp.MustCompile("^[^" + slugChars + "]+")
 	slugMiddle = regexp.MustCompile("[^" + slugChars + "]+")
 	slugRemove = regexp.MustCompile("[" + slugRemoveChars + "]")
+	mentionRegex = regexp.MustCompile("(?:^|\\s)@([^\\s,]+)")
 }
 
 func RandSeq(n int) string {
@@ -186,6 +409,87 @@ The %s team
 	return sendEmail(email, SiteName+" Password Reset", emailText)
 }
 
+func SendExportEmail(email, link string, expires time.Time) error {
+	emailText := fmt.Sprintf(`The copy of your data you asked for on %s is ready.
+
+Log in and then copy and paste the following link into your web browser to download it:
+%s
+
+If you did not ask for your data, please change your password.
+
+This link will be valid until %s.
+
+Hope to see you soon,
+The %s team
+`, SiteName, link, expires.Format("January 2, 2006"), SiteName)
+	return sendEmail(email, "Your "+SiteName+" data", emailText)
+}
+
+func SendDeletionEmail(email, baseURL string, scheduled time.Time) error {
+	emailText := fmt.Sprintf(`Your %s account is scheduled to be deleted on %s.
+
+Your email address, sessions, tokens and community memberships will then be
+deleted. Pages and posts you wrote will stay, shown as written by a deleted
+user.
+
+If you change your mind, log in before then and cancel on your settings page:
+%s/settings
+
+If you did not ask for this, please log in, cancel and change your password.
+
+The %s team
+`, SiteName, scheduled.Format("January 2, 2006"), baseURL, SiteName)
+	return sendEmail(email, "Your "+SiteName+" account will be deleted", emailText)
+}
+
+func SendDigestEmail(recipient DigestRecipient, digest Digest, unsubscribeLink, baseURL string) error {
+	var text bytes.Buffer
+	fmt.Fprintf(&text, "Hi %s,\n\nHere is what happened on %s since %s.\n", recipient.Username, SiteName, recipient.Since.Format("January 2"))
+
+	writeSection := func(heading string, contributions []Contribution) {
+		if len(contributions) == 0 {
+			return
+		}
+		fmt.Fprintf(&text, "\n%s\n\n", heading)
+		for _, contribution := range contributions {
+			fmt.Fprintf(&text, "* %s\n  %s/page/%s/%s\n", contribution.PageTitle, baseURL, contribution.CategorySlug, contribution.PageSlug)
+			if !contribution.IsPage {
+				fmt.Fprintf(&text, "  %s\n", excerpt(contribution.Body, digestExcerptLength))
+			}
+		}
+	}
+	writeSection("New resources from your communities:", digest.Pages)
+	writeSection("New posts from your communities:", digest.CommunityPosts)
+	writeSection("New posts on pages you follow:", digest.FollowedPosts)
+
+	fmt.Fprintf(&text, `
+You can change how often you get these emails in your settings:
+%s/settings
+
+To stop getting them, copy and paste the following link into your web browser:
+%s%s
+
+Hope to see you soon,
+The %s team
+`, baseURL, baseURL, unsubscribeLink, SiteName)
+	return sendEmail(recipient.Email, "Your "+SiteName+" digest", text.String())
+}
+
+// Shortens text to at most length characters, cutting at a space where
+// possible.
+func excerpt(text string, length int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	runes := []rune(text)
+	if len(runes) <= length {
+		return text
+	}
+	text = string(runes[:length])
+	if space := strings.LastIndex(text, " "); space > 0 {
+		text = text[:space]
+	}
+	return text + "..."
+}
+
 func sendEmail(recipient, subject, text string) error {
 	log.Printf("Sending email to: %s\n", recipient)
 	log.Printf("Subject: %s\nText:\n%s\n", subject, text)
@@ -210,6 +514,17 @@ func ValidEmail(email string) bool {
 	return emailRegex.Match([]byte(email))
 }
 
+// Returns the usernames mentioned as @username in text.
+func Mentions(text string) (usernames []string) {
+	for _, match := range mentionRegex.FindAllStringSubmatch(text, -1) {
+		username := strings.TrimRight(match[1], ".!?:;)")
+		if username != "" {
+			usernames = append(usernames, username)
+		}
+	}
+	return
+}
+
 func SetSessionCookie(res http.ResponseWriter, sessionid string) {
 	http.SetCookie(res, &http.Cookie{Name: "sessionid", Value: sessionid, Expires: time.Now().AddDate(10, 0, 0)})
 }
@@ -0,0 +1,120 @@
+package common
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/comforme/comforme/i18n"
+)
+
+// A page compared against when looking for duplicates.
+type PageCandidate struct {
+	Page
+	CategoryID int
+}
+
+// A page that may be a duplicate, with why it was matched.
+type SimilarPage struct {
+	Page
+	Reasons []i18n.Message
+}
+
+// Words left out when comparing titles word by word.
+var titleStopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "at": true, "for": true, "in": true, "of": true, "the": true,
+}
+
+func titleWords(title string) map[string]bool {
+	words := map[string]bool{}
+	for _, word := range strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if !titleStopWords[word] {
+			words[word] = true
+		}
+	}
+	return words
+}
+
+// The words of a title that SimilarTitles compares, sorted.
+func TitleWords(title string) []string {
+	words := []string{}
+	for word := range titleWords(title) {
+		words = append(words, word)
+	}
+	sort.Strings(words)
+	return words
+}
+
+// Whether two page titles probably name the same place. Besides names that
+// are alike, a title whose words all appear in the other counts, so that
+// "Planned Parenthood - Downtown" matches "Planned Parenthood Downtown
+// Clinic".
+func SimilarTitles(a, b string) bool {
+	if SimilarNames(a, b) {
+		return true
+	}
+
+	wordsA, wordsB := titleWords(a), titleWords(b)
+	if len(wordsB) < len(wordsA) {
+		wordsA, wordsB = wordsB, wordsA
+	}
+	if len(wordsA) < 2 {
+		return false
+	}
+	for word := range wordsA {
+		if !wordsB[word] {
+			return false
+		}
+	}
+	return true
+}
+
+var addressAbbreviations = map[string]string{
+	"apartment": "apt",
+	"avenue":    "ave",
+	"boulevard": "blvd",
+	"drive":     "dr",
+	"east":      "e",
+	"highway":   "hwy",
+	"lane":      "ln",
+	"north":     "n",
+	"place":     "pl",
+	"road":      "rd",
+	"south":     "s",
+	"street":    "st",
+	"suite":     "ste",
+	"west":      "w",
+}
+
+// Reduces an address to lowercase words with common street words
+// abbreviated, so that "12 North Main Street, Suite 4" and "12 N. Main St
+// Ste 4" compare equal.
+func NormalizeAddress(address string) string {
+	words := strings.FieldsFunc(strings.ToLower(address), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, word := range words {
+		if abbreviation, ok := addressAbbreviations[word]; ok {
+			words[i] = abbreviation
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// Reduces a website to its host and path, without the scheme, "www." or a
+// trailing slash.
+func NormalizeWebsite(website string) string {
+	website = strings.ToLower(strings.TrimSpace(website))
+	for _, prefix := range []string{"https://", "http://", "www."} {
+		website = strings.TrimPrefix(website, prefix)
+	}
+	return strings.TrimRight(website, "/")
+}
+
+// Errors
+var (
+	CannotMergePageIntoSelf = i18n.NewError("error.cannot_merge_page_into_self")
+	SimilarPagesExist       = i18n.NewError("error.similar_pages_exist")
+)
@@ -0,0 +1,86 @@
+package common
+
+import (
+	"time"
+
+	"github.com/comforme/comforme/i18n"
+)
+
+// Data export statuses
+const (
+	ExportPending  = "pending"
+	ExportBuilding = "building"
+	ExportReady    = "ready"
+	ExportFailed   = "failed"
+	ExportExpired  = "expired"
+)
+
+// A request for an archive of a user's personal data.
+type DataExport struct {
+	Id            int
+	UserID        int
+	Status        string
+	BaseURL       string
+	Size          int
+	DateRequested time.Time
+	DateCompleted *time.Time
+	Expires       *time.Time
+}
+
+// Everything about a user that goes into their data export.
+type ExportData struct {
+	Profile     ExportProfile
+	Pseudonyms  []string
+	Memberships []ExportMembership
+	Pages       []ExportPage
+	Posts       []ExportPost
+	Sessions    []ExportSession
+	AuditEvents []AuditEvent
+}
+
+type ExportProfile struct {
+	Username        string    `json:"username"`
+	Email           string    `json:"email"`
+	Role            string    `json:"role"`
+	Bio             string    `json:"bio"`
+	DigestFrequency string    `json:"digestFrequency"`
+	JoinDate        time.Time `json:"joinDate"`
+}
+
+type ExportMembership struct {
+	CommunityID int    `json:"communityId"`
+	Community   string `json:"community"`
+	Visibility  string `json:"visibility"`
+}
+
+type ExportPage struct {
+	Id          int       `json:"id"`
+	Title       string    `json:"title"`
+	Category    string    `json:"category"`
+	Description string    `json:"description"`
+	Address     string    `json:"address"`
+	Website     string    `json:"website"`
+	URL         string    `json:"url"`
+	DateCreated time.Time `json:"dateCreated"`
+}
+
+type ExportPost struct {
+	Id          int       `json:"id"`
+	PageTitle   string    `json:"pageTitle"`
+	PageURL     string    `json:"pageUrl"`
+	Body        string    `json:"body"`
+	PostedAs    string    `json:"postedAs"` // Username, pseudonym or "anonymous"
+	DateCreated time.Time `json:"dateCreated"`
+}
+
+// Session IDs are credentials, so only when a session started is exported.
+type ExportSession struct {
+	DateCreated time.Time `json:"dateCreated"`
+}
+
+// Errors
+var (
+	ExportRateLimited = i18n.NewError("error.export_rate_limited")
+	ExportNotFound    = i18n.NewError("error.export_not_found")
+	ExportLinkInvalid = i18n.NewError("error.export_link_invalid")
+)
@@ -0,0 +1,45 @@
+package common
+
+import (
+	"time"
+
+	"github.com/comforme/comforme/i18n"
+)
+
+// A login with an external OpenID Connect provider linked to a user.
+type Identity struct {
+	Id          int
+	Provider    string
+	Email       string
+	DateCreated time.Time
+	LastUsed    *time.Time
+}
+
+// What an OpenID Connect provider says about the user who logged in, once
+// the ID token has been verified.
+type ExternalIdentity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+}
+
+// An OpenID Connect login waiting for the provider to send the user back.
+type OIDCLogin struct {
+	Provider     string
+	Nonce        string
+	CodeVerifier string
+	LinkUserID   int // 0 unless linking from settings
+	ReturnTo     string
+	Expires      time.Time
+}
+
+// Errors
+var (
+	UnknownProvider       = i18n.NewError("error.unknown_provider")
+	IdentityInUse         = i18n.NewError("error.identity_in_use")
+	ProviderAlreadyLinked = i18n.NewError("error.provider_already_linked")
+	IdentityNotFound      = i18n.NewError("error.identity_not_found")
+	EmailNotVerified      = i18n.NewError("error.email_not_verified")
+	ExternalLoginFailed   = i18n.NewError("error.external_login_failed")
+)
@@ -0,0 +1,60 @@
+package common
+
+import (
+	"github.com/comforme/comforme/i18n"
+)
+
+const MaxImportRows = 5000
+
+// Page fields that imported columns can be mapped onto.
+var ImportFields = []string{"title", "description", "address", "website", "location", "category"}
+
+// What happened to one imported row.
+const (
+	ImportCreated   = "created"
+	ImportValid     = "valid"
+	ImportDuplicate = "duplicate"
+	ImportInvalid   = "invalid"
+)
+
+// A page read from an imported file, before it is checked. Row counts from 1
+// at the first record after any header.
+type ImportRow struct {
+	Row         int
+	Title       string
+	Description string
+	Address     string
+	Website     string
+	Location    string // As read by ParseLocation
+	Category    string
+}
+
+type ImportResult struct {
+	Row    int
+	Title  string
+	Status string
+	Error  error
+	Path   string // Of the created page or the page it duplicates
+}
+
+// The outcome of an import. In a dry run nothing is created and rows that
+// would have been are ImportValid.
+type ImportReport struct {
+	DryRun     bool
+	Results    []ImportResult
+	Created    int
+	Valid      int
+	Duplicates int
+	Invalid    int
+}
+
+// Errors
+var (
+	ImportFormatUnknown   = i18n.NewError("error.import_format_unknown")
+	ImportEmpty           = i18n.NewError("error.import_empty")
+	ImportTooLarge        = i18n.NewError("error.import_too_large", MaxImportRows)
+	ImportNoTitleColumn   = i18n.NewError("error.import_no_title_column")
+	ImportCategoryUnknown = i18n.NewError("error.import_category_unknown")
+	ImportCategoryMissing = i18n.NewError("error.import_category_missing")
+	ImportDuplicateInFile = i18n.NewError("error.import_duplicate_in_file")
+)
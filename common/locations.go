@@ -0,0 +1,97 @@
+package common
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/comforme/comforme/i18n"
+)
+
+// Pages with locations closer than this are likely the same place.
+const NearbyPageDistance = 100 // meters
+
+const earthRadius = 6371000 // meters
+
+// A point on the earth, in degrees. Pages without a location have a nil
+// *Location.
+type Location struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// Reads a location written as "latitude, longitude", which is how map sites
+// let people copy one. An empty value is no location.
+func ParseLocation(value string) (*Location, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(value, ",")
+	if len(parts) != 2 {
+		return nil, InvalidLocation
+	}
+	latitude, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	if err != nil {
+		return nil, InvalidLocation
+	}
+	longitude, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if err != nil {
+		return nil, InvalidLocation
+	}
+
+	location := Location{latitude, longitude}
+	if !location.Valid() {
+		return nil, InvalidLocation
+	}
+	return &location, nil
+}
+
+// Whether a location is a point on the earth.
+func (location Location) Valid() bool {
+	return location.Latitude >= -90 && location.Latitude <= 90 &&
+		location.Longitude >= -180 && location.Longitude <= 180
+}
+
+// Formats a location the way ParseLocation reads it.
+func (location Location) String() string {
+	return strconv.FormatFloat(location.Latitude, 'f', -1, 64) + ", " + strconv.FormatFloat(location.Longitude, 'f', -1, 64)
+}
+
+func toRadians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
+
+// Distance in meters between two locations.
+func (location Location) Distance(other Location) float64 {
+	dLatitude := toRadians(other.Latitude - location.Latitude)
+	dLongitude := toRadians(other.Longitude - location.Longitude)
+	h := math.Sin(dLatitude/2)*math.Sin(dLatitude/2) +
+		math.Cos(toRadians(location.Latitude))*math.Cos(toRadians(other.Latitude))*math.Sin(dLongitude/2)*math.Sin(dLongitude/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(h))
+}
+
+// The radius in degrees of a circle around location that contains every
+// point within meters of it, for finding nearby locations with an index
+// before measuring the distance to them. A degree of longitude gets shorter
+// away from the equator, so the circle is wider than it needs to be north and
+// south.
+func (location Location) RadiusDegrees(meters float64) float64 {
+	angle := meters / earthRadius
+	latitude := toRadians(location.Latitude)
+	// Close enough to a pole, every longitude is nearby
+	if math.Sin(angle) >= math.Cos(latitude) {
+		return 360
+	}
+
+	// The furthest east or west a point within meters can be, with a little
+	// to spare for rounding
+	longitude := math.Asin(math.Sin(angle) / math.Cos(latitude))
+	return longitude * 180 / math.Pi * 1.01
+}
+
+// Errors
+var (
+	InvalidLocation = i18n.NewError("error.invalid_location")
+)
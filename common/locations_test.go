@@ -0,0 +1,80 @@
+package common
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseLocation(t *testing.T) {
+	tests := []struct {
+		value    string
+		location *Location
+		err      error
+	}{
+		{"", nil, nil},
+		{"  ", nil, nil},
+		{"40.7128, -74.0060", &Location{40.7128, -74.006}, nil},
+		{"-33.8688,151.2093", &Location{-33.8688, 151.2093}, nil},
+		{"90, 180", &Location{90, 180}, nil},
+		{"40.7128", nil, InvalidLocation},
+		{"40.7128, -74.0060, 10", nil, InvalidLocation},
+		{"north, west", nil, InvalidLocation},
+		{"91, 0", nil, InvalidLocation},
+		{"0, -181", nil, InvalidLocation},
+		{"NaN, 0", nil, InvalidLocation},
+		{"0, Inf", nil, InvalidLocation},
+	}
+
+	for _, test := range tests {
+		location, err := ParseLocation(test.value)
+		if err != test.err || (location == nil) != (test.location == nil) || location != nil && *location != *test.location {
+			t.Errorf("%q: got %v, %v, want %v, %v", test.value, location, err, test.location, test.err)
+		}
+		if location != nil {
+			if again, err := ParseLocation(location.String()); err != nil || *again != *location {
+				t.Errorf("%q: %q does not read back", test.value, location.String())
+			}
+		}
+	}
+}
+
+func TestDistance(t *testing.T) {
+	newYork := Location{40.7128, -74.0060}
+	tests := []struct {
+		name     string
+		other    Location
+		distance float64 // meters
+	}{
+		{"same place", newYork, 0},
+		{"50 meters north", Location{40.71325, -74.0060}, 50},
+		{"London", Location{51.5074, -0.1278}, 5570000},
+	}
+
+	for _, test := range tests {
+		distance := newYork.Distance(test.other)
+		if math.Abs(distance-test.distance) > test.distance/100+1 {
+			t.Errorf("%s: got %.0f meters, want %.0f", test.name, distance, test.distance)
+		}
+	}
+}
+
+// Every point NearbyPageDistance away is inside the circle, whichever
+// direction it is in.
+func TestRadiusDegrees(t *testing.T) {
+	for _, latitude := range []float64{0, 45, -60, 89.99} {
+		location := Location{latitude, 10}
+		radius := location.RadiusDegrees(NearbyPageDistance)
+		for bearing := 0.0; bearing < 360; bearing += 15 {
+			angle := float64(NearbyPageDistance) / earthRadius
+			b := toRadians(bearing)
+			lat1 := toRadians(latitude)
+			lat2 := math.Asin(math.Sin(lat1)*math.Cos(angle) + math.Cos(lat1)*math.Sin(angle)*math.Cos(b))
+			dLongitude := math.Atan2(math.Sin(b)*math.Sin(angle)*math.Cos(lat1), math.Cos(angle)-math.Sin(lat1)*math.Sin(lat2))
+			other := Location{lat2 * 180 / math.Pi, 10 + dLongitude*180/math.Pi}
+
+			if math.Hypot(other.Latitude-location.Latitude, other.Longitude-location.Longitude) > radius {
+				t.Errorf("latitude %g, bearing %g: %v is outside %g degrees", latitude, bearing, other, radius)
+			}
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package common
+
+import (
+	"time"
+
+	"github.com/comforme/comforme/i18n"
+)
+
+const MaxPasskeyNameLen = 128
+
+// A passkey as listed in the user's settings.
+type Passkey struct {
+	Id          int
+	Name        string
+	DateCreated time.Time
+	LastUsed    *time.Time
+}
+
+// What is needed to check a login with a passkey.
+type PasskeyCredential struct {
+	Id        int
+	UserID    int
+	PublicKey []byte // COSE_Key
+	SignCount uint32
+}
+
+// Errors
+var (
+	PasskeyNotFound     = i18n.NewError("error.passkey_not_found")
+	PasskeyNameRequired = i18n.NewError("error.passkey_name_required")
+	PasskeyNameTooLong  = i18n.NewError("error.passkey_name_too_long")
+	PasskeyAlreadyAdded = i18n.NewError("error.passkey_already_added")
+	PasskeyVerifyFailed = i18n.NewError("error.passkey_verify_failed")
+	PasskeyCloned       = i18n.NewError("error.passkey_cloned")
+)
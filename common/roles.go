@@ -0,0 +1,85 @@
+package common
+
+import (
+	"github.com/comforme/comforme/i18n"
+)
+
+type Role int
+
+const (
+	RoleUser Role = iota
+	RoleTrusted
+	RoleModerator
+	RoleAdmin
+)
+
+type Permission int
+
+const (
+	PermissionEditAnyPage Permission = iota
+	PermissionManageCategories
+	PermissionManageCommunities
+	PermissionModerate
+	PermissionManageUsers
+	PermissionViewStatistics
+	PermissionViewAuditLog
+	PermissionManageWebhooks
+	PermissionImportPages
+)
+
+var roleNames = map[Role]string{
+	RoleUser:      "user",
+	RoleTrusted:   "trusted",
+	RoleModerator: "moderator",
+	RoleAdmin:     "admin",
+}
+
+// Each role has every permission of the roles below it.
+var rolePermissions = map[Role][]Permission{
+	RoleUser:      {},
+	RoleTrusted:   {PermissionEditAnyPage},
+	RoleModerator: {PermissionEditAnyPage, PermissionModerate},
+	RoleAdmin: {
+		PermissionEditAnyPage,
+		PermissionManageCategories,
+		PermissionManageCommunities,
+		PermissionModerate,
+		PermissionManageUsers,
+		PermissionViewStatistics,
+		PermissionViewAuditLog,
+		PermissionManageWebhooks,
+		PermissionImportPages,
+	},
+}
+
+// Errors
+var (
+	PermissionDenied = i18n.NewError("error.permission_denied")
+	InvalidRole      = i18n.NewError("error.invalid_role")
+)
+
+func ParseRole(name string) (Role, error) {
+	for role, roleName := range roleNames {
+		if roleName == name {
+			return role, nil
+		}
+	}
+	return RoleUser, InvalidRole
+}
+
+func (role Role) String() string {
+	return roleNames[role]
+}
+
+func (role Role) Can(permission Permission) bool {
+	for _, granted := range rolePermissions[role] {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
+
+func (userInfo UserInfo) Can(permission Permission) bool {
+	return userInfo.Role.Can(permission)
+}
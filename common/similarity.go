@@ -0,0 +1,123 @@
+package common
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Minimum similarity for two names to be considered near-duplicates.
+const SimilarNameThreshold = 0.8
+
+// Reduces a name to lowercase letters and digits so that "Trans Women",
+// "trans-women" and "TransWomen" compare equal.
+func NormalizeName(name string) string {
+	var normalized []rune
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			normalized = append(normalized, r)
+		}
+	}
+	return string(normalized)
+}
+
+// Returns a score between 0 and 1 of how alike two names are, based on the
+// edit distance between their normalized forms.
+func NameSimilarity(a, b string) float64 {
+	ra := []rune(NormalizeName(a))
+	rb := []rune(NormalizeName(b))
+	if len(ra) == 0 && len(rb) == 0 {
+		return 1
+	}
+
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+func SimilarNames(a, b string) bool {
+	na := NormalizeName(a)
+	nb := NormalizeName(b)
+	if na == nb {
+		return true
+	}
+	// Plurals and short qualifiers, e.g. "Trans woman" and "Trans women"
+	if len(na) >= 4 && len(nb) >= 4 && (strings.HasPrefix(na, nb) || strings.HasPrefix(nb, na)) {
+		if abs(len(na)-len(nb)) <= 2 {
+			return true
+		}
+	}
+	return NameSimilarity(a, b) >= SimilarNameThreshold
+}
+
+func levenshtein(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min3(
+				previous[j]+1,
+				current[j-1]+1,
+				previous[j-1]+cost,
+			)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// Scores how well a search query matches a name, from 0 for no match to 1
+// for an exact match. Prefix and substring matches score highly, otherwise
+// the closest word in the name is compared by edit distance so that typos
+// still find results.
+func MatchScore(query, name string) float64 {
+	normalizedQuery := NormalizeName(query)
+	normalizedName := NormalizeName(name)
+	if len(normalizedQuery) == 0 {
+		return 0
+	}
+
+	switch {
+	case normalizedQuery == normalizedName:
+		return 1
+	case strings.HasPrefix(normalizedName, normalizedQuery):
+		return 0.9
+	case strings.Contains(normalizedName, normalizedQuery):
+		return 0.8
+	}
+
+	best := NameSimilarity(query, name)
+	for _, word := range strings.Fields(name) {
+		if score := NameSimilarity(query, word) * 0.9; score > best {
+			best = score
+		}
+	}
+	return best
+}
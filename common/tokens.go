@@ -0,0 +1,141 @@
+package common
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/comforme/comforme/i18n"
+)
+
+// What an API token may do. Logging in with a session cookie allows
+// everything.
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+)
+
+var Scopes = []string{ScopeRead, ScopeWrite}
+
+const (
+	tokenPrefix     = "cmf_"
+	tokenBytes      = 32
+	MaxTokenNameLen = 128
+)
+
+type APIToken struct {
+	Id          int
+	Name        string
+	ClientName  string // Empty for personal access tokens
+	Scopes      []string
+	DateCreated time.Time
+	LastUsed    *time.Time
+}
+
+type OAuthClient struct {
+	Id          int
+	ClientID    string
+	Name        string
+	RedirectURI string
+}
+
+// An authorization code waiting to be exchanged for a token.
+type OAuthCode struct {
+	ClientID      int
+	UserID        int
+	RedirectURI   string
+	Scopes        []string
+	CodeChallenge string
+	Expires       time.Time
+}
+
+// Errors
+var (
+	InvalidToken        = i18n.NewError("error.invalid_token")
+	InsufficientScope   = i18n.NewError("error.insufficient_scope")
+	SessionRequired     = i18n.NewError("error.session_required")
+	InvalidScope        = i18n.NewError("error.invalid_scope")
+	TokenNameTooLong    = i18n.NewError("error.token_name_too_long")
+	TokenNameRequired   = i18n.NewError("error.token_name_required")
+	TokenNotFound       = i18n.NewError("error.token_not_found")
+	OAuthClientNotFound = i18n.NewError("error.oauth_client_not_found")
+	InvalidRedirectURI  = i18n.NewError("error.invalid_redirect_uri")
+	InvalidGrant        = i18n.NewError("error.invalid_grant")
+	InvalidCodeVerifier = i18n.NewError("error.invalid_code_verifier")
+)
+
+// Generates a random, URL safe secret for use as a token or authorization
+// code. Unlike RandSeq this uses crypto/rand, since it grants access by
+// itself.
+func NewToken() (string, error) {
+	b := make([]byte, tokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return tokenPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Tokens and codes are stored hashed so that a copy of the database cannot
+// be used to act as users.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Parses a space separated scope list, as used by OAuth2. Duplicates are
+// dropped and the result is in canonical order.
+func ParseScopes(list string) ([]string, error) {
+	requested := map[string]bool{}
+	for _, scope := range strings.Fields(list) {
+		if !validScope(scope) {
+			return nil, InvalidScope
+		}
+		requested[scope] = true
+	}
+
+	scopes := []string{}
+	for _, scope := range Scopes {
+		if requested[scope] {
+			scopes = append(scopes, scope)
+		}
+	}
+	if len(scopes) == 0 {
+		return nil, InvalidScope
+	}
+	return scopes, nil
+}
+
+func validScope(scope string) bool {
+	for _, valid := range Scopes {
+		if scope == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// The scope a request needs: reading for safe methods, writing otherwise.
+func ScopeForMethod(method string) string {
+	switch method {
+	case "GET", "HEAD", "OPTIONS":
+		return ScopeRead
+	}
+	return ScopeWrite
+}
+
+// Reports whether the user may do something needing scope. Users logged in
+// with a session cookie may do anything.
+func (userInfo UserInfo) HasScope(scope string) bool {
+	if !userInfo.ViaToken {
+		return true
+	}
+	for _, granted := range userInfo.Scopes {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+package common
+
+import (
+	"time"
+
+	"github.com/comforme/comforme/i18n"
+)
+
+// Events webhooks can subscribe to
+const (
+	EventPageCreated      = "page.created"
+	EventPageUpdated      = "page.updated"
+	EventPostCreated      = "post.created"
+	EventCommunityCreated = "community.created"
+	EventReportFiled      = "report.filed"
+)
+
+var WebhookEvents = []string{
+	EventPageCreated,
+	EventPageUpdated,
+	EventPostCreated,
+	EventCommunityCreated,
+	EventReportFiled,
+}
+
+// Delivery statuses
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+type Webhook struct {
+	Id           int
+	URL          string
+	Secret       string
+	Events       []string
+	CategoryID   int // 0 for every category
+	CategoryName string
+	Active       bool
+	DateCreated  time.Time
+}
+
+// Reports whether the webhook subscribes to event.
+func (webhook Webhook) Has(event string) bool {
+	for _, subscribed := range webhook.Events {
+		if subscribed == event {
+			return true
+		}
+	}
+	return false
+}
+
+type WebhookDelivery struct {
+	Id             int
+	WebhookID      int
+	Event          string
+	Payload        string
+	Status         string
+	Attempts       int
+	NextAttempt    time.Time
+	LastAttempt    *time.Time
+	ResponseStatus int
+	LastError      string
+	DateCreated    time.Time
+}
+
+// Errors
+var (
+	InvalidWebhookURL   = i18n.NewError("error.invalid_webhook_url")
+	InvalidWebhookEvent = i18n.NewError("error.invalid_webhook_event")
+	WebhookNotFound     = i18n.NewError("error.webhook_not_found")
+	DeliveryNotFound    = i18n.NewError("error.delivery_not_found")
+)
+
+// A delivery ready to be sent, along with where to send it.
+type PendingDelivery struct {
+	WebhookDelivery
+	URL    string
+	Secret string
+}
@@ -0,0 +1,244 @@
+package communities
+
+import (
+	"html/template"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/comforme/comforme/common"
+	"github.com/comforme/comforme/databaseActions"
+	"github.com/comforme/comforme/i18n"
+	"github.com/comforme/comforme/templates"
+)
+
+var communityTemplate *template.Template
+var proposeTemplate *template.Template
+var pendingTemplate *template.Template
+
+func init() {
+	communityTemplate = template.Must(template.New("siteLayout").Funcs(i18n.Funcs).Parse(templates.SiteLayout))
+	template.Must(communityTemplate.New("nav").Parse(templates.NavBar))
+	template.Must(communityTemplate.New("content").Parse(communityTemplateText))
+
+	proposeTemplate = template.Must(template.New("siteLayout").Funcs(i18n.Funcs).Parse(templates.SiteLayout))
+	template.Must(proposeTemplate.New("nav").Parse(templates.NavBar))
+	template.Must(proposeTemplate.New("content").Parse(proposeTemplateText))
+
+	pendingTemplate = template.Must(template.New("siteLayout").Funcs(i18n.Funcs).Parse(templates.SiteLayout))
+	template.Must(pendingTemplate.New("nav").Parse(templates.NavBar))
+	template.Must(pendingTemplate.New("content").Parse(pendingTemplateText))
+}
+
+func CommunityHandler(res http.ResponseWriter, req *http.Request, ps httprouter.Params, userInfo common.UserInfo) {
+	data := map[string]interface{}{}
+	data["siteName"] = common.SiteName
+	data["locale"] = userInfo.Locale
+
+	communityID, err := strconv.Atoi(ps.ByName("id"))
+	if err != nil {
+		http.NotFound(res, req)
+		return
+	}
+
+	community, err := databaseActions.GetCommunity(userInfo.UserID, communityID)
+	if err != nil || community.Status != common.CommunityApproved {
+		http.NotFound(res, req)
+		log.Printf("Error looking up community (%s): %v\n", req.URL.Path, err)
+		return
+	}
+	data["community"] = community
+	data["pageTitle"] = community.Name
+
+	data["subcommunities"], err = databaseActions.GetSubcommunities(communityID)
+	if err != nil {
+		data["errorMsg"] = i18n.ErrorMessage(userInfo.Locale, err)
+	}
+
+	data["contributions"], err = databaseActions.GetCommunityContributions(userInfo.UserID, communityID)
+	if err != nil {
+		data["errorMsg"] = i18n.ErrorMessage(userInfo.Locale, err)
+	}
+
+	common.ExecTemplate(communityTemplate, res, data)
+}
+
+func ProposeHandler(res http.ResponseWriter, req *http.Request, ps httprouter.Params, userInfo common.UserInfo) {
+	data := map[string]interface{}{}
+	data["siteName"] = common.SiteName
+	data["locale"] = userInfo.Locale
+	data["formAction"] = req.URL.Path
+	data["pageTitle"] = i18n.Translate(userInfo.Locale, "propose.title")
+
+	name := req.PostFormValue("name")
+	description := req.PostFormValue("description")
+	parentID, _ := strconv.Atoi(req.PostFormValue("parent"))
+	data["name"] = name
+	data["description"] = description
+	data["parentID"] = parentID
+
+	parents, err := databaseActions.GetCommunities()
+	if err != nil {
+		data["errorMsg"] = i18n.ErrorMessage(userInfo.Locale, err)
+	}
+	data["parents"] = parents
+
+	if req.Method == "POST" && err == nil {
+		confirmed := req.PostFormValue("confirmed") == "true"
+		similar, err := databaseActions.ProposeCommunity(userInfo, name, description, parentID, confirmed)
+		if err != nil {
+			data["errorMsg"] = i18n.ErrorMessage(userInfo.Locale, err)
+			data["similar"] = similar
+		} else {
+			data["successMsg"] = i18n.Translate(userInfo.Locale, "propose.success")
+			data["name"] = ""
+			data["description"] = ""
+			data["parentID"] = 0
+		}
+	}
+
+	common.ExecTemplate(proposeTemplate, res, data)
+}
+
+func PendingHandler(res http.ResponseWriter, req *http.Request, ps httprouter.Params, userInfo common.UserInfo) {
+	data := map[string]interface{}{}
+	data["siteName"] = common.SiteName
+	data["locale"] = userInfo.Locale
+	data["formAction"] = req.URL.Path
+	data["pageTitle"] = i18n.Translate(userInfo.Locale, "pending.title")
+
+	if req.Method == "POST" {
+		communityID, err := strconv.Atoi(req.PostFormValue("communityid"))
+		if err == nil {
+			approve := req.PostFormValue("action") == "approve"
+			err = databaseActions.ReviewCommunity(userInfo, communityID, approve)
+			if err == nil && approve {
+				data["successMsg"] = i18n.Translate(userInfo.Locale, "pending.approved")
+			} else if err == nil {
+				data["successMsg"] = i18n.Translate(userInfo.Locale, "pending.rejected")
+			}
+		}
+		if err != nil {
+			data["errorMsg"] = i18n.ErrorMessage(userInfo.Locale, err)
+		}
+	}
+
+	pending, err := databaseActions.GetReviewableCommunities(userInfo)
+	if err != nil {
+		data["errorMsg"] = i18n.ErrorMessage(userInfo.Locale, err)
+	}
+	data["pending"] = pending
+
+	common.ExecTemplate(pendingTemplate, res, data)
+}
+
+const communityTemplateText = `
+	<div class="content">
+		<div class="row">
+			<div class="columns">
+				<h1><i class="fi-torsos-all"></i> {{.community.Name}} <small><a href="/feeds/community/{{.community.Id}}" title="{{t $.locale "community.feed"}}"><i class="fi-rss"></i></a></small></h1>{{if .community.ParentName}}
+				<h6>{{t $.locale "community.part_of"}} <a href="/community/{{.community.ParentID}}">{{.community.ParentName}}</a></h6>{{end}}
+				<p>{{.community.Description}}</p>
+				<p>{{plural $.locale "community.members" .community.MemberCount}}</p>{{if .subcommunities}}
+				<h6>{{t $.locale "community.subcommunities"}}{{range .subcommunities}} <a href="/community/{{.Id}}" class="label secondary">{{.Name}}</a>{{end}}</h6>{{end}}{{if .errorMsg}}
+				<div class="alert-box alert">{{.errorMsg}}</div>{{end}}
+			</div>
+		</div>
+		<div class="row">
+			<div class="columns">
+				<h2>{{t $.locale "community.recent_contributions"}}</h2>
+			</div>{{range .contributions}}
+			<div class="columns">
+				<p>
+					<strong><a href="/user/{{.Author}}">{{.Author}}</a></strong>
+					{{if .IsPage}}{{t $.locale "feed.added"}}{{else}}{{t $.locale "feed.posted_on"}}{{end}}
+					<a href="/page/{{.CategorySlug}}/{{.PageSlug}}">{{.PageTitle}}</a>
+					<small>{{.Date.Format "2006-01-02 15:04"}}</small>
+				</p>
+				<p>{{.Body}}</p>
+			</div>{{else}}
+			<div class="columns">
+				<p>{{t $.locale "community.no_contributions"}}</p>
+			</div>{{end}}
+		</div>
+	</div>
+`
+
+const proposeTemplateText = `
+<div class="row">
+	<div class="large-centered medium-centered large-8 medium-8 columns">
+		<div class="content">{{if .successMsg}}
+			<div class="alert-box success">{{.successMsg}}</div>{{end}}{{if .errorMsg}}
+			<div class="alert-box alert">{{.errorMsg}}</div>{{end}}
+			<form method="POST" action="{{.formAction}}">
+				<fieldset>
+					<legend>{{t $.locale "propose.legend"}}</legend>{{if .similar}}
+					<div class="panel">
+						<h5>{{t $.locale "propose.similar"}}</h5>
+						<ul>{{range .similar}}
+							<li>{{if eq .Status "approved"}}<a href="/community/{{.Id}}">{{.Name}}</a>{{else}}{{.Name}} {{t $.locale "propose.awaiting_approval"}}{{end}}</li>{{end}}
+						</ul>
+						<label>
+							<input type="checkbox" name="confirmed" value="true">
+							{{t $.locale "propose.confirm_different"}}
+						</label>
+					</div>{{end}}
+					<div>
+						<input type="text" name="name" placeholder="{{t $.locale "propose.name"}}"{{if .name}} value="{{.name}}"{{end}}>
+					</div>
+					<div>
+						<textarea name="description" placeholder="{{t $.locale "propose.description"}}" rows="6">{{.description}}</textarea>
+					</div>
+					<div>
+						<label>
+							{{t $.locale "propose.parent"}}
+							<select name="parent">
+								<option value="0">{{t $.locale "propose.no_parent"}}</option>{{range .parents}}
+								<option value="{{.Id}}"{{if eq .Id $.parentID}} selected=""{{end}}>{{.Name}}</option>{{end}}
+							</select>
+						</label>
+					</div>
+					<div style="text-align:center">
+						<button type="submit" class="button">{{t $.locale "propose.submit"}}</button>
+					</div>
+				</fieldset>
+			</form>
+		</div>
+	</div>
+</div>
+`
+
+const pendingTemplateText = `
+	<div class="content">
+		<div class="row">
+			<div class="columns">
+				<h1><i class="fi-check"></i> {{t $.locale "pending.title"}}</h1>{{if .successMsg}}
+				<div class="alert-box success">{{.successMsg}}</div>{{end}}{{if .errorMsg}}
+				<div class="alert-box alert">{{.errorMsg}}</div>{{end}}
+				<table>
+					<thead>
+						<tr><th>{{t $.locale "pending.name"}}</th><th>{{t $.locale "pending.description"}}</th><th>{{t $.locale "pending.part_of"}}</th><th>{{t $.locale "pending.proposed_by"}}</th><th></th></tr>
+					</thead>
+					<tbody>{{range .pending}}
+						<tr>
+							<td>{{.Name}}</td>
+							<td>{{.Description}}</td>
+							<td>{{.ParentName}}</td>
+							<td>{{.ProposerName}}</td>
+							<td>
+								<form method="post" action="{{$.formAction}}">
+									<input type="hidden" name="communityid" value="{{.Id}}">
+									<button type="submit" class="button tiny" name="action" value="approve">{{t $.locale "pending.approve"}}</button>
+									<button type="submit" class="button tiny alert" name="action" value="reject">{{t $.locale "pending.reject"}}</button>
+								</form>
+							</td>
+						</tr>{{else}}
+						<tr><td colspan="5">{{t $.locale "pending.none"}}</td></tr>{{end}}
+					</tbody>
+				</table>
+			</div>
+		</div>
+	</div>
+`
@@ -0,0 +1,65 @@
+package communities
+
+import (
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/comforme/comforme/common"
+	"github.com/comforme/comforme/database"
+	"github.com/comforme/comforme/databaseActions"
+	"github.com/comforme/comforme/dbtest"
+	"github.com/comforme/comforme/i18n"
+)
+
+// An outsider looking at a community sees only its public members, in the
+// member count and in the recent contributions.
+func TestCommunityHidesPrivateMembers(t *testing.T) {
+	conn, dsn := dbtest.New(t)
+	testDB, err := database.NewDB(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	databaseActions.UseDB(testDB)
+
+	community := dbtest.Community(t, conn, "Gardeners")
+	public := dbtest.User(t, conn, "public")
+	membersOnly := dbtest.User(t, conn, "membersonly")
+	private := dbtest.User(t, conn, "private")
+	outsider := dbtest.User(t, conn, "outsider")
+	dbtest.Join(t, conn, public, community, "public")
+	dbtest.Join(t, conn, membersOnly, community, "members")
+	dbtest.Join(t, conn, private, community, "private")
+
+	category := dbtest.Category(t, conn, "Food")
+	dbtest.Page(t, conn, public, category, "Public page")
+	dbtest.Page(t, conn, membersOnly, category, "Members page")
+	dbtest.Page(t, conn, private, category, "Private page")
+
+	path := "/community/" + strconv.Itoa(community)
+	req := httptest.NewRequest("GET", path, nil)
+	res := httptest.NewRecorder()
+	ps := httprouter.Params{{Key: "id", Value: strconv.Itoa(community)}}
+	CommunityHandler(res, req, ps, common.UserInfo{UserID: outsider, Username: "outsider", Role: common.RoleUser})
+
+	body := res.Body.String()
+	if !strings.Contains(body, "Public page") {
+		t.Fatalf("public member's page is missing from %q", body)
+	}
+	if !strings.Contains(body, i18n.Plural(i18n.DefaultLocale, "community.members", 1)) {
+		t.Error("member count is not 1")
+	}
+	for _, count := range []int{2, 3} {
+		if strings.Contains(body, i18n.Plural(i18n.DefaultLocale, "community.members", count)) {
+			t.Errorf("member count is %d", count)
+		}
+	}
+	for _, hidden := range []string{"Members page", "Private page", "membersonly", "/user/private"} {
+		if strings.Contains(body, hidden) {
+			t.Errorf("%q is shown to an outsider", hidden)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package database
+
+import (
+	"log"
+	"time"
+
+	"github.com/comforme/comforme/common"
+)
+
+// Schedules a user's account to be deleted after grace, replacing any earlier
+// schedule.
+func (db DB) ScheduleDeletion(userID int, grace time.Duration) (scheduled time.Time, err error) {
+	err = db.conn.QueryRow(`
+		UPDATE users
+		SET deletion_scheduled = now() + $2::integer * interval '1 second'
+		WHERE id = $1 AND NOT tombstone
+		RETURNING deletion_scheduled;`,
+		userID,
+		int(grace/time.Second),
+	).Scan(&scheduled)
+	if err != nil {
+		log.Printf("Error scheduling deletion of user (%d): %s\n", userID, err.Error())
+		err = common.UserNotFound
+	}
+	return
+}
+
+func (db DB) CancelDeletion(userID int) error {
+	result, err := db.conn.Exec(
+		"UPDATE users SET deletion_scheduled = NULL WHERE id = $1 AND deletion_scheduled IS NOT NULL;",
+		userID,
+	)
+	if err != nil {
+		common.LogError(err)
+		return common.DatabaseError
+	}
+
+	return checkSingleRow(result, common.DeletionNotScheduled)
+}
+
+func (db DB) GetDueDeletions() (userIDs []int, err error) {
+	rows, err := db.conn.Query(
+		"SELECT id FROM users WHERE deletion_scheduled <= now() AND NOT tombstone ORDER BY deletion_scheduled;",
+	)
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+
+	defer rows.Close()
+
+	userIDs = []int{}
+	for rows.Next() {
+		var userID int
+		if err := rows.Scan(&userID); err != nil {
+			log.Fatal(err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+	return
+}
+
+// Deletes a user whose deletion is due. Their pages and posts are moved to
+// the tombstone user, and posts made under a pseudonym lose it since the
+// pseudonym is deleted with them. Everything else about the user is deleted
+// by the foreign keys on users. Audit events are kept, and keep referring to
+// the user by id. Their details can include old usernames, and their IP
+// addresses and user agents are kept until ClearAuditRequestInfo clears them.
+func (db DB) DeleteAccount(userID int) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		common.LogError(err)
+		return common.DatabaseError
+	}
+	defer tx.Rollback()
+
+	var tombstoneID int
+	err = tx.QueryRow("SELECT id FROM users WHERE tombstone;").Scan(&tombstoneID)
+	if err != nil {
+		common.LogError(err)
+		return common.DatabaseError
+	}
+
+	for _, query := range []string{
+		"UPDATE pages SET user_id = $2 WHERE user_id = $1;",
+		"UPDATE posts SET user_id = $2, pseudonym_id = NULL WHERE user_id = $1;",
+	} {
+		if _, err = tx.Exec(query, userID, tombstoneID); err != nil {
+			common.LogError(err)
+			return common.DatabaseError
+		}
+	}
+
+	result, err := tx.Exec(
+		"DELETE FROM users WHERE id = $1 AND deletion_scheduled <= now() AND NOT tombstone;",
+		userID,
+	)
+	if err != nil {
+		common.LogError(err)
+		return common.DatabaseError
+	}
+	if err = checkSingleRow(result, common.DeletionNotScheduled); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		common.LogError(err)
+		return common.DatabaseError
+	}
+	return nil
+}
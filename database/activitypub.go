@@ -0,0 +1,476 @@
+package database
+
+import (
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/comforme/comforme/common"
+)
+
+// Looks up a category or community as an actor. Id and the keys are only
+// set once the actor has been created with NewLocalActor.
+func (db DB) GetLocalActor(categorySlug string, communityID int) (actor common.LocalActor, err error) {
+	var row *sql.Row
+	if communityID != 0 {
+		row = db.conn.QueryRow(`
+			SELECT
+				COALESCE(ap_actors.id, 0),
+				'',
+				communities.id,
+				communities.name,
+				communities.description,
+				COALESCE(ap_actors.public_key, ''),
+				COALESCE(ap_actors.private_key, ''),
+				(SELECT count(*) FROM ap_followers WHERE ap_followers.actor_id = ap_actors.id)
+			FROM communities
+			LEFT JOIN ap_actors ON ap_actors.community_id = communities.id
+			WHERE communities.id = $1 AND communities.status = 'approved';`,
+			communityID,
+		)
+	} else {
+		row = db.conn.QueryRow(`
+			SELECT
+				COALESCE(ap_actors.id, 0),
+				categories.slug,
+				0,
+				categories.name,
+				'',
+				COALESCE(ap_actors.public_key, ''),
+				COALESCE(ap_actors.private_key, ''),
+				(SELECT count(*) FROM ap_followers WHERE ap_followers.actor_id = ap_actors.id)
+			FROM categories
+			LEFT JOIN ap_actors ON ap_actors.category_id = categories.id
+			WHERE categories.slug = $1;`,
+			categorySlug,
+		)
+	}
+
+	err = row.Scan(
+		&actor.Id,
+		&actor.CategorySlug,
+		&actor.CommunityID,
+		&actor.Name,
+		&actor.Summary,
+		&actor.PublicKey,
+		&actor.PrivateKey,
+		&actor.Followers,
+	)
+	if err == sql.ErrNoRows {
+		err = common.ActorNotFound
+	} else if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+	}
+	return
+}
+
+// Stores the keys of a category or community actor. If another request
+// created the actor first, its keys are kept.
+func (db DB) NewLocalActor(categorySlug string, communityID int, publicKey, privateKey string) error {
+	var err error
+	if communityID != 0 {
+		_, err = db.conn.Exec(`
+			INSERT INTO ap_actors (community_id, public_key, private_key)
+			VALUES ($1, $2, $3)
+			ON CONFLICT DO NOTHING;`,
+			communityID,
+			publicKey,
+			privateKey,
+		)
+	} else {
+		_, err = db.conn.Exec(`
+			INSERT INTO ap_actors (category_id, public_key, private_key)
+			SELECT id, $2, $3 FROM categories WHERE slug = $1
+			ON CONFLICT DO NOTHING;`,
+			categorySlug,
+			publicKey,
+			privateKey,
+		)
+	}
+	if err != nil {
+		common.LogError(err)
+		return common.DatabaseError
+	}
+	return nil
+}
+
+// Lists the actors that have followers.
+func (db DB) GetFollowedActors() (actors []common.LocalActor, err error) {
+	rows, err := db.conn.Query(`
+		SELECT
+			ap_actors.id,
+			COALESCE(categories.slug, ''),
+			COALESCE(communities.id, 0),
+			COALESCE(categories.name, communities.name),
+			count(*)
+		FROM ap_actors
+		JOIN ap_followers ON ap_followers.actor_id = ap_actors.id
+		LEFT JOIN categories ON categories.id = ap_actors.category_id
+		LEFT JOIN communities ON communities.id = ap_actors.community_id
+		GROUP BY ap_actors.id, categories.slug, categories.name, communities.id, communities.name
+		ORDER BY count(*) DESC;`,
+	)
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+
+	return scanActors(rows)
+}
+
+// Finds the followed actors a new page or post should be delivered from:
+// that of its category, and those of the communities its author is publicly
+// a member of. authorID is 0 when the author must not be revealed.
+func (db DB) GetAudienceActors(categorySlug string, authorID int) (actors []common.LocalActor, err error) {
+	rows, err := db.conn.Query(`
+		SELECT
+			ap_actors.id,
+			COALESCE(categories.slug, ''),
+			COALESCE(ap_actors.community_id, 0),
+			'',
+			0
+		FROM ap_actors
+		LEFT JOIN categories ON categories.id = ap_actors.category_id
+		WHERE
+			EXISTS (SELECT 1 FROM ap_followers WHERE ap_followers.actor_id = ap_actors.id)
+			AND (
+				categories.slug = $1
+				OR ap_actors.community_id IN (
+					SELECT community_id FROM visible_memberships(0) WHERE user_id = $2
+				)
+			);`,
+		categorySlug,
+		authorID,
+	)
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+
+	return scanActors(rows)
+}
+
+func scanActors(rows *sql.Rows) (actors []common.LocalActor, err error) {
+	defer rows.Close()
+
+	actors = []common.LocalActor{}
+	for rows.Next() {
+		var row common.LocalActor
+		if err := rows.Scan(&row.Id, &row.CategorySlug, &row.CommunityID, &row.Name, &row.Followers); err != nil {
+			log.Fatal(err)
+		}
+		actors = append(actors, row)
+	}
+
+	if err = rows.Err(); err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+	}
+	return
+}
+
+// Adds or updates a follower of a local actor.
+func (db DB) AddFollower(actorID int, follower, inbox string) error {
+	_, err := db.conn.Exec(`
+		INSERT INTO ap_followers (actor_id, follower, inbox)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (actor_id, follower) DO UPDATE SET inbox = EXCLUDED.inbox;`,
+		actorID,
+		follower,
+		inbox,
+	)
+	if err != nil {
+		common.LogError(err)
+		return common.DatabaseError
+	}
+	return nil
+}
+
+func (db DB) RemoveFollower(actorID int, follower string) error {
+	_, err := db.conn.Exec(
+		"DELETE FROM ap_followers WHERE actor_id = $1 AND follower = $2;",
+		actorID,
+		follower,
+	)
+	if err != nil {
+		common.LogError(err)
+		return common.DatabaseError
+	}
+	return nil
+}
+
+// Queues an activity for delivery to every follower of an actor, once per
+// inbox.
+func (db DB) QueueActivityForFollowers(actorID int, activity string) (queued int64, err error) {
+	result, err := db.conn.Exec(`
+		INSERT INTO ap_deliveries (actor_id, inbox, activity)
+		SELECT DISTINCT $1::integer, inbox, $2 FROM ap_followers WHERE actor_id = $1;`,
+		actorID,
+		activity,
+	)
+	if err != nil {
+		common.LogError(err)
+		return 0, common.DatabaseError
+	}
+
+	queued, err = result.RowsAffected()
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+	}
+	return
+}
+
+// Queues an activity for delivery to a single inbox.
+func (db DB) QueueActivity(actorID int, inbox, activity string) error {
+	_, err := db.conn.Exec(
+		"INSERT INTO ap_deliveries (actor_id, inbox, activity) VALUES ($1, $2, $3);",
+		actorID,
+		inbox,
+		activity,
+	)
+	if err != nil {
+		common.LogError(err)
+		return common.DatabaseError
+	}
+	return nil
+}
+
+// Claims up to limit activity deliveries that are due, along with the keys
+// to sign them with. Like webhook deliveries, they are pushed back by lease.
+func (db DB) ClaimActivityDeliveries(limit int, lease time.Duration) (deliveries []common.FederationDelivery, actors []common.LocalActor, err error) {
+	rows, err := db.conn.Query(`
+		WITH due AS (
+			UPDATE ap_deliveries SET next_attempt = now() + $2::integer * interval '1 second'
+			WHERE id IN (
+				SELECT id
+				FROM ap_deliveries
+				WHERE status = 'pending' AND next_attempt <= now()
+				ORDER BY next_attempt ASC
+				LIMIT $1
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING id, actor_id, inbox, activity, attempts
+		)
+		SELECT
+			due.id,
+			due.actor_id,
+			due.inbox,
+			due.activity,
+			due.attempts,
+			COALESCE(categories.slug, ''),
+			COALESCE(ap_actors.community_id, 0),
+			ap_actors.private_key
+		FROM due
+		JOIN ap_actors ON ap_actors.id = due.actor_id
+		LEFT JOIN categories ON categories.id = ap_actors.category_id;`,
+		limit,
+		int(lease.Seconds()),
+	)
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+
+	defer rows.Close()
+
+	deliveries = []common.FederationDelivery{}
+	actors = []common.LocalActor{}
+	for rows.Next() {
+		var delivery common.FederationDelivery
+		var actor common.LocalActor
+		if err := rows.Scan(
+			&delivery.Id,
+			&delivery.ActorID,
+			&delivery.Inbox,
+			&delivery.Activity,
+			&delivery.Attempts,
+			&actor.CategorySlug,
+			&actor.CommunityID,
+			&actor.PrivateKey,
+		); err != nil {
+			log.Fatal(err)
+		}
+		actor.Id = delivery.ActorID
+		deliveries = append(deliveries, delivery)
+		actors = append(actors, actor)
+	}
+
+	if err = rows.Err(); err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+	}
+	return
+}
+
+func (db DB) RecordActivityDelivery(deliveryID int, status string, attempts int, nextAttempt time.Time, lastError string) error {
+	_, err := db.conn.Exec(`
+		UPDATE ap_deliveries
+		SET status = $2, attempts = $3, next_attempt = $4, last_error = $5
+		WHERE id = $1;`,
+		deliveryID,
+		status,
+		attempts,
+		nextAttempt,
+		lastError,
+	)
+	if err != nil {
+		common.LogError(err)
+		return common.DatabaseError
+	}
+	return nil
+}
+
+// Returns the page a local post is on, along with what it says. Replies from
+// other servers are not found, as they are not ours to publish.
+func (db DB) GetLocalPost(postID int) (pageID int, body string, dateCreated time.Time, err error) {
+	err = db.conn.QueryRow(
+		"SELECT page_id, body, date_created FROM posts WHERE id = $1 AND user_id IS NOT NULL;",
+		postID,
+	).Scan(&pageID, &body, &dateCreated)
+	if err == sql.ErrNoRows {
+		err = common.PostNotFound
+	} else if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+	}
+	return
+}
+
+// Stores a reply for moderation. Replies that were already received are
+// ignored.
+func (db DB) NewRemoteReply(pageID int, objectID, actor, author, content string) error {
+	_, err := db.conn.Exec(`
+		INSERT INTO ap_replies (page_id, object_id, actor, author, content)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (object_id) DO NOTHING;`,
+		pageID,
+		objectID,
+		actor,
+		author,
+		content,
+	)
+	if err != nil {
+		common.LogError(err)
+		return common.DatabaseError
+	}
+	return nil
+}
+
+func (db DB) GetPendingReplies() (replies []common.RemoteReply, err error) {
+	rows, err := db.conn.Query(`
+		SELECT
+			ap_replies.id,
+			pages.id,
+			pages.title,
+			categories.slug,
+			pages.slug,
+			ap_replies.object_id,
+			ap_replies.actor,
+			ap_replies.author,
+			ap_replies.content,
+			ap_replies.date_created
+		FROM ap_replies
+		JOIN pages ON pages.id = ap_replies.page_id
+		JOIN categories ON categories.id = pages.category
+		WHERE ap_replies.status = 'pending'
+		ORDER BY ap_replies.date_created ASC;`,
+	)
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+
+	defer rows.Close()
+
+	replies = []common.RemoteReply{}
+	for rows.Next() {
+		var row common.RemoteReply
+		if err := rows.Scan(
+			&row.Id,
+			&row.PageID,
+			&row.PageTitle,
+			&row.CategorySlug,
+			&row.PageSlug,
+			&row.ObjectID,
+			&row.Actor,
+			&row.Author,
+			&row.Content,
+			&row.DateCreated,
+		); err != nil {
+			log.Fatal(err)
+		}
+		replies = append(replies, row)
+	}
+
+	if err = rows.Err(); err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+	}
+	return
+}
+
+// Approves a pending reply, posting it on its page.
+func (db DB) ApproveRemoteReply(replyID int) (postID, pageID int, body string, err error) {
+	err = db.conn.QueryRow(`
+		WITH reply AS (
+			UPDATE ap_replies SET status = 'approved'
+			WHERE id = $1 AND status = 'pending'
+			RETURNING page_id, object_id, actor, author, content
+		)
+		INSERT INTO posts (page_id, body, remote_author, remote_actor, remote_object)
+		SELECT page_id, content, author, actor, object_id FROM reply
+		RETURNING id, page_id, body;`,
+		replyID,
+	).Scan(&postID, &pageID, &body)
+	if err == sql.ErrNoRows {
+		err = common.ReplyNotFound
+	} else if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+	}
+	return
+}
+
+func (db DB) RejectRemoteReply(replyID int) error {
+	result, err := db.conn.Exec(
+		"UPDATE ap_replies SET status = 'rejected' WHERE id = $1 AND status = 'pending';",
+		replyID,
+	)
+	if err != nil {
+		common.LogError(err)
+		return common.DatabaseError
+	}
+
+	return checkSingleRow(result, common.ReplyNotFound)
+}
+
+// Removes a reply its author deleted on their server, whether it is still
+// waiting for moderation or has been posted.
+func (db DB) DeleteRemoteObject(actor, objectID string) error {
+	_, err := db.conn.Exec(
+		"DELETE FROM ap_replies WHERE object_id = $1 AND actor = $2;",
+		objectID,
+		actor,
+	)
+	if err != nil {
+		common.LogError(err)
+		return common.DatabaseError
+	}
+
+	_, err = db.conn.Exec(
+		"DELETE FROM posts WHERE remote_object = $1 AND remote_actor = $2;",
+		objectID,
+		actor,
+	)
+	if err != nil {
+		common.LogError(err)
+		return common.DatabaseError
+	}
+	return nil
+}
@@ -0,0 +1,511 @@
+package database
+
+import (
+	"log"
+
+	"github.com/comforme/comforme/common"
+)
+
+func (db DB) GetCategories() (categories []common.Category, err error) {
+	rows, err := db.conn.Query(`
+		SELECT
+			categories.id,
+			categories.name,
+			categories.slug,
+			count(pages.id)
+		FROM
+			categories
+		LEFT JOIN
+			pages
+				ON
+					pages.category = categories.id
+		GROUP BY
+			categories.id
+		ORDER BY categories.name ASC;
+		`,
+	)
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+
+	defer rows.Close()
+
+	categories = []common.Category{}
+	for rows.Next() {
+		var row common.Category
+		if err := rows.Scan(
+			&row.Id,
+			&row.Name,
+			&row.Slug,
+			&row.PageCount,
+		); err != nil {
+			log.Println("Unknown iteration error:", err)
+			return nil, err
+		}
+		categories = append(categories, row)
+	}
+
+	if err = rows.Err(); err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+
+	// Success
+	return
+}
+
+func (db DB) NewCategory(name, slug string) (categoryID int, err error) {
+	err = db.conn.QueryRow(
+		"INSERT INTO categories (name, slug) VALUES ($1, $2) RETURNING id",
+		name,
+		slug,
+	).Scan(&categoryID)
+	if err != nil {
+		log.Printf("Error adding category (%s): %s\n", name, err.Error())
+		err = common.CategoryAlreadyExists
+	}
+	return
+}
+
+func (db DB) UpdateCategory(categoryID int, name, slug string) error {
+	result, err := db.conn.Exec(
+		"UPDATE categories SET name = $2, slug = $3 WHERE id = $1;",
+		categoryID,
+		name,
+		slug,
+	)
+	if err != nil {
+		log.Printf("Error updating category (%d): %s\n", categoryID, err.Error())
+		return common.CategoryAlreadyExists
+	}
+
+	return checkSingleRow(result, common.CategoryNotFound)
+}
+
+func (db DB) DeleteCategory(categoryID int) error {
+	var numPages int
+	err := db.conn.QueryRow("SELECT count(*) FROM pages WHERE category = $1", categoryID).Scan(&numPages)
+	if err != nil {
+		common.LogError(err)
+		return common.DatabaseError
+	}
+	if numPages != 0 {
+		return common.CategoryNotEmpty
+	}
+
+	result, err := db.conn.Exec("DELETE FROM categories WHERE id = $1;", categoryID)
+	if err != nil {
+		common.LogError(err)
+		return common.DatabaseError
+	}
+
+	return checkSingleRow(result, common.CategoryNotFound)
+}
+
+func (db DB) GetCommunities() (communities []common.Community, err error) {
+	rows, err := db.conn.Query(`
+		SELECT
+			communities.id,
+			communities.name,
+			COALESCE(communities.parent_id, 0),
+			count(community_memberships.user_id)
+		FROM
+			communities
+		LEFT JOIN
+			community_memberships
+				ON
+					community_memberships.community_id = communities.id
+		WHERE
+			communities.status = 'approved'
+		GROUP BY
+			communities.id
+		ORDER BY communities.name ASC;
+		`,
+	)
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+
+	defer rows.Close()
+
+	communities = []common.Community{}
+	for rows.Next() {
+		var row common.Community
+		if err := rows.Scan(
+			&row.Id,
+			&row.Name,
+			&row.ParentID,
+			&row.MemberCount,
+		); err != nil {
+			log.Println("Unknown iteration error:", err)
+			return nil, err
+		}
+		communities = append(communities, row)
+	}
+
+	if err = rows.Err(); err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+
+	// Success
+	return
+}
+
+func (db DB) NewCommunity(name string) (communityID int, err error) {
+	err = db.conn.QueryRow(
+		"INSERT INTO communities (name) VALUES ($1) RETURNING id",
+		name,
+	).Scan(&communityID)
+	if err != nil {
+		log.Printf("Error adding community (%s): %s\n", name, err.Error())
+		err = common.CommunityAlreadyExists
+	}
+	return
+}
+
+func (db DB) RenameCommunity(communityID int, name string) error {
+	result, err := db.conn.Exec(
+		"UPDATE communities SET name = $2 WHERE id = $1;",
+		communityID,
+		name,
+	)
+	if err != nil {
+		log.Printf("Error renaming community (%d): %s\n", communityID, err.Error())
+		return common.CommunityAlreadyExists
+	}
+
+	return checkSingleRow(result, common.CommunityNotFound)
+}
+
+func (db DB) DeleteCommunity(communityID int) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		common.LogError(err)
+		return common.DatabaseError
+	}
+	defer tx.Rollback()
+
+	for _, query := range []string{
+		"DELETE FROM community_memberships WHERE community_id = $1;",
+		"DELETE FROM community_moderators WHERE community_id = $1;",
+	} {
+		if _, err = tx.Exec(query, communityID); err != nil {
+			common.LogError(err)
+			return common.DatabaseError
+		}
+	}
+
+	result, err := tx.Exec("DELETE FROM communities WHERE id = $1;", communityID)
+	if err != nil {
+		common.LogError(err)
+		return common.DatabaseError
+	}
+	if err = checkSingleRow(result, common.CommunityNotFound); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		common.LogError(err)
+		return common.DatabaseError
+	}
+	return nil
+}
+
+// Moves every membership, moderator, subcommunity and alias of one community
+// into another and then deletes the now empty community, keeping its name as
+// an alias of the other. Users who were in both keep one membership. Refuses to merge a community into one of its own
+// subcommunities, which would leave the subcommunities in a cycle.
+func (db DB) MergeCommunities(fromID, intoID int) (moved int, err error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+	defer tx.Rollback()
+
+	var wouldCycle bool
+	err = tx.QueryRow(
+		"SELECT count(*) > 0 FROM community_ancestors WHERE community_id = $2 AND ancestor_id = $1",
+		fromID,
+		intoID,
+	).Scan(&wouldCycle)
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+	if wouldCycle {
+		err = common.CannotMergeIntoChild
+		return
+	}
+
+	result, err := tx.Exec(`
+		INSERT INTO
+			community_memberships (user_id, community_id, visibility)
+		SELECT
+			user_id,
+			$2,
+			visibility
+		FROM
+			community_memberships
+		WHERE
+			community_id = $1
+			AND user_id NOT IN (
+				SELECT user_id FROM community_memberships WHERE community_id = $2
+			);
+		`,
+		fromID,
+		intoID,
+	)
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+	movedNum, err := result.RowsAffected()
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+	moved = int(movedNum)
+
+	_, err = tx.Exec(`
+		INSERT INTO
+			community_moderators (user_id, community_id)
+		SELECT
+			user_id,
+			$2
+		FROM
+			community_moderators
+		WHERE
+			community_id = $1
+			AND user_id NOT IN (
+				SELECT user_id FROM community_moderators WHERE community_id = $2
+			);
+		`,
+		fromID,
+		intoID,
+	)
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+
+	_, err = tx.Exec("UPDATE communities SET parent_id = $2 WHERE parent_id = $1;", fromID, intoID)
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+
+	// The merged community's name and aliases, skipping any the other
+	// community already goes by.
+	_, err = tx.Exec(`
+		INSERT INTO
+			community_aliases (community_id, alias)
+		SELECT DISTINCT ON (lower(alias))
+			$2,
+			alias
+		FROM (
+			SELECT name AS alias FROM communities WHERE id = $1
+			UNION ALL
+			SELECT alias FROM community_aliases WHERE community_id = $1
+		) aliases
+		WHERE
+			lower(alias) NOT IN (
+				SELECT lower(name) FROM communities WHERE id = $2
+				UNION
+				SELECT lower(alias) FROM community_aliases WHERE community_id = $2
+			);
+		`,
+		fromID,
+		intoID,
+	)
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+
+	for _, query := range []string{
+		"DELETE FROM community_memberships WHERE community_id = $1;",
+		"DELETE FROM community_moderators WHERE community_id = $1;",
+	} {
+		if _, err = tx.Exec(query, fromID); err != nil {
+			common.LogError(err)
+			err = common.DatabaseError
+			return
+		}
+	}
+
+	result, err = tx.Exec("DELETE FROM communities WHERE id = $1;", fromID)
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+	if err = checkSingleRow(result, common.CommunityNotFound); err != nil {
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+	return
+}
+
+func (db DB) SearchUsers(query string) (users []common.User, err error) {
+	rows, err := db.conn.Query(`
+		SELECT
+			id,
+			username,
+			email,
+			role,
+			suspended,
+			reset_required,
+			deletion_scheduled
+		FROM
+			users
+		WHERE
+			(username ILIKE '%' || $1 || '%' OR email ILIKE '%' || $1 || '%')
+			AND NOT tombstone
+		ORDER BY username ASC
+		LIMIT 50;
+		`,
+		query,
+	)
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+
+	defer rows.Close()
+
+	users = []common.User{}
+	for rows.Next() {
+		var row common.User
+		var role string
+		if err := rows.Scan(
+			&row.Id,
+			&row.Username,
+			&row.Email,
+			&role,
+			&row.Suspended,
+			&row.ResetRequired,
+			&row.DeletionScheduled,
+		); err != nil {
+			log.Println("Unknown iteration error:", err)
+			return nil, err
+		}
+		row.Role, _ = common.ParseRole(role)
+		users = append(users, row)
+	}
+
+	if err = rows.Err(); err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+
+	// Success
+	return
+}
+
+func (db DB) GetUserIDByUsername(username string) (userID int, err error) {
+	err = db.conn.QueryRow("SELECT id FROM users WHERE username = $1", username).Scan(&userID)
+	if err != nil {
+		log.Printf("Error looking up user with username (%s): %s\n", username, err.Error())
+		err = common.UserNotFound
+	}
+	return
+}
+
+func (db DB) GetUser(userID int) (user common.User, err error) {
+	var role string
+	err = db.conn.QueryRow(
+		"SELECT id, username, email, role, suspended, reset_required, deletion_scheduled FROM users WHERE id = $1",
+		userID,
+	).Scan(&user.Id, &user.Username, &user.Email, &role, &user.Suspended, &user.ResetRequired, &user.DeletionScheduled)
+	if err != nil {
+		log.Printf("Error looking up user (%d): %s\n", userID, err.Error())
+		err = common.UserNotFound
+		return
+	}
+	user.Role, _ = common.ParseRole(role)
+	return
+}
+
+// Suspending a user also ends all of their sessions.
+func (db DB) SetSuspended(userID int, suspended bool) error {
+	result, err := db.conn.Exec(
+		"UPDATE users SET suspended = $2 WHERE id = $1;",
+		userID,
+		suspended,
+	)
+	if err != nil {
+		common.LogError(err)
+		return common.DatabaseError
+	}
+	if err = checkSingleRow(result, common.UserNotFound); err != nil {
+		return err
+	}
+
+	if suspended {
+		_, err = db.conn.Exec("DELETE FROM sessions WHERE user_id = $1;", userID)
+		if err != nil {
+			common.LogError(err)
+			return common.DatabaseError
+		}
+	}
+	return nil
+}
+
+func (db DB) ForcePasswordReset(email string) error {
+	return db.requirePasswordReset(email)
+}
+
+func (db DB) GetSiteStatistics() (stats common.SiteStatistics, err error) {
+	err = db.conn.QueryRow(`
+		SELECT
+			(SELECT count(*) FROM users WHERE NOT tombstone),
+			(SELECT count(*) FROM users WHERE suspended AND NOT tombstone),
+			(SELECT count(*) FROM sessions),
+			(SELECT count(*) FROM pages),
+			(SELECT count(*) FROM pages WHERE date_created > now() - interval '7 days'),
+			(SELECT count(*) FROM posts),
+			(SELECT count(*) FROM posts WHERE date_created > now() - interval '7 days'),
+			(SELECT count(*) FROM categories),
+			(SELECT count(*) FROM communities WHERE status = 'approved'),
+			(SELECT count(*) FROM community_memberships);
+		`,
+	).Scan(
+		&stats.Users,
+		&stats.SuspendedUsers,
+		&stats.Sessions,
+		&stats.Pages,
+		&stats.PagesThisWeek,
+		&stats.Posts,
+		&stats.PostsThisWeek,
+		&stats.Categories,
+		&stats.Communities,
+		&stats.Memberships,
+	)
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+	}
+	return
+}
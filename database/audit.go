@@ -0,0 +1,161 @@
+package database
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"github.com/comforme/comforme/common"
+)
+
+func (db DB) RecordAuditEvent(event common.AuditEvent) error {
+	_, err := db.conn.Exec(`
+		INSERT INTO
+			audit_events (
+				action,
+				actor_id,
+				subject_user_id,
+				ip_address,
+				user_agent,
+				details
+			)
+		VALUES ($1, NULLIF($2, 0), NULLIF($3, 0), $4, $5, $6)
+		`,
+		string(event.Action),
+		event.ActorID,
+		event.SubjectUserID,
+		event.IpAddress,
+		event.UserAgent,
+		event.Details,
+	)
+	if err != nil {
+		log.Printf("Error recording audit event (%s): %s\n", event.Action, err.Error())
+		return common.DatabaseError
+	}
+	return nil
+}
+
+// Lists audit events newest first. Zero values in the filter are ignored.
+func (db DB) GetAuditEvents(filter common.AuditFilter, limit int) (events []common.AuditEvent, err error) {
+	return db.queryAuditEvents(`
+		WHERE
+			($1 = '' OR audit_events.action = $1)
+			AND ($2 = 0 OR audit_events.actor_id = $2 OR audit_events.subject_user_id = $2)
+			AND ($3 = '' OR audit_events.ip_address = $3)
+			AND ($4 = 0 OR audit_events.id < $4)
+		ORDER BY audit_events.id DESC
+		LIMIT $5;
+		`,
+		filter.Action,
+		filter.UserID,
+		filter.IpAddress,
+		filter.BeforeID,
+		limit,
+	)
+}
+
+func (db DB) GetUserAuditEvents(userID int, actions []common.AuditAction, limit int) (events []common.AuditEvent, err error) {
+	actionNames := make([]string, len(actions))
+	for i, action := range actions {
+		actionNames[i] = string(action)
+	}
+
+	return db.queryAuditEvents(`
+		WHERE
+			audit_events.subject_user_id = $1
+			AND audit_events.action = ANY(string_to_array($2, ','))
+		ORDER BY audit_events.id DESC
+		LIMIT $3;
+		`,
+		userID,
+		strings.Join(actionNames, ","),
+		limit,
+	)
+}
+
+func (db DB) queryAuditEvents(where string, args ...interface{}) (events []common.AuditEvent, err error) {
+	rows, err := db.conn.Query(`
+		SELECT
+			audit_events.id,
+			audit_events.date_created,
+			audit_events.action,
+			COALESCE(audit_events.actor_id, 0),
+			COALESCE(actors.username, ''),
+			COALESCE(audit_events.subject_user_id, 0),
+			COALESCE(subjects.username, ''),
+			audit_events.ip_address,
+			audit_events.user_agent,
+			audit_events.details
+		FROM
+			audit_events
+		LEFT JOIN
+			users actors
+				ON
+					actors.id = audit_events.actor_id
+		LEFT JOIN
+			users subjects
+				ON
+					subjects.id = audit_events.subject_user_id
+		`+where,
+		args...,
+	)
+	if err != nil {
+		common.LogErrorSkipLevels(err, 1)
+		err = common.DatabaseError
+		return
+	}
+
+	defer rows.Close()
+
+	events = []common.AuditEvent{}
+	for rows.Next() {
+		var row common.AuditEvent
+		var action string
+		if err := rows.Scan(
+			&row.Id,
+			&row.Date,
+			&action,
+			&row.ActorID,
+			&row.ActorName,
+			&row.SubjectUserID,
+			&row.SubjectName,
+			&row.IpAddress,
+			&row.UserAgent,
+			&row.Details,
+		); err != nil {
+			log.Println("Unknown iteration error:", err)
+			return nil, err
+		}
+		row.Action = common.AuditAction(action)
+		events = append(events, row)
+	}
+
+	if err = rows.Err(); err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+
+	// Success
+	return
+}
+
+// Clears the IP addresses and user agents of audit events older than before.
+// This is the only change audit_events allows.
+func (db DB) ClearAuditRequestInfo(before time.Time) (cleared int64, err error) {
+	result, err := db.conn.Exec(
+		"UPDATE audit_events SET ip_address = '', user_agent = '' WHERE date_created < $1 AND (ip_address <> '' OR user_agent <> '');",
+		before,
+	)
+	if err != nil {
+		common.LogError(err)
+		return 0, common.DatabaseError
+	}
+
+	cleared, err = result.RowsAffected()
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+	}
+	return
+}
@@ -0,0 +1,59 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/comforme/comforme/common"
+	"github.com/comforme/comforme/dbtest"
+)
+
+func TestClearAuditRequestInfo(t *testing.T) {
+	conn, _ := dbtest.New(t)
+	db := DB{conn}
+
+	userID := dbtest.User(t, conn, "audited")
+	for _, age := range []string{"100 days", "1 day"} {
+		if _, err := conn.Exec(
+			"INSERT INTO audit_events (date_created, action, subject_user_id, ip_address, user_agent, details) VALUES (now() - $1::interval, 'login', $2, '192.0.2.1', 'Browser', $1)",
+			age, userID,
+		); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cleared, err := db.ClearAuditRequestInfo(time.Now().Add(-90 * 24 * time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cleared != 1 {
+		t.Errorf("cleared %d events, want 1", cleared)
+	}
+
+	events, err := db.GetUserAuditEvents(userID, []common.AuditAction{common.AuditLogin}, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	for _, event := range events {
+		kept := event.IpAddress == "192.0.2.1" && event.UserAgent == "Browser"
+		if event.Details == "1 day" && !kept || event.Details == "100 days" && (event.IpAddress != "" || event.UserAgent != "") {
+			t.Errorf("event from %s ago has address %q and user agent %q", event.Details, event.IpAddress, event.UserAgent)
+		}
+	}
+
+	// The log is otherwise still append-only
+	for _, query := range []string{
+		"UPDATE audit_events SET details = 'changed'",
+		"UPDATE audit_events SET ip_address = '', user_agent = '', subject_user_id = NULL",
+		"UPDATE audit_events SET ip_address = '198.51.100.1'",
+		"DELETE FROM audit_events",
+		"TRUNCATE audit_events",
+	} {
+		if _, err := conn.Exec(query); err == nil {
+			t.Errorf("%s was allowed", query)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package database
+
+import (
+	"log"
+	"strings"
+
+	"github.com/comforme/comforme/common"
+)
+
+// Blocks or mutes blockedID for userID, replacing any earlier choice.
+func (db DB) SetUserBlock(userID, blockedID int, kind string) (err error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(
+		"DELETE FROM user_blocks WHERE user_id = $1 AND blocked_user_id = $2;",
+		userID,
+		blockedID,
+	)
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+
+	_, err = tx.Exec(
+		"INSERT INTO user_blocks (user_id, blocked_user_id, kind) VALUES ($1, $2, $3);",
+		userID,
+		blockedID,
+		kind,
+	)
+	if err != nil {
+		log.Printf("Error setting block (%s) of user (%d) by user (%d): %s\n", kind, blockedID, userID, err.Error())
+		err = common.DatabaseError
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+	}
+	return
+}
+
+func (db DB) DeleteUserBlock(userID, blockedID int) error {
+	result, err := db.conn.Exec(
+		"DELETE FROM user_blocks WHERE user_id = $1 AND blocked_user_id = $2;",
+		userID,
+		blockedID,
+	)
+	if err != nil {
+		common.LogError(err)
+		return common.DatabaseError
+	}
+
+	return checkSingleRow(result, common.UserNotFound)
+}
+
+func (db DB) GetUserBlocks(userID int) (blocked []common.BlockedUser, err error) {
+	rows, err := db.conn.Query(`
+		SELECT
+			users.id,
+			users.username,
+			user_blocks.kind,
+			user_blocks.date_created
+		FROM
+			user_blocks,
+			users
+		WHERE
+			user_blocks.user_id = $1
+			AND users.id = user_blocks.blocked_user_id
+		ORDER BY users.username ASC;
+		`,
+		userID,
+	)
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+
+	defer rows.Close()
+
+	blocked = []common.BlockedUser{}
+	for rows.Next() {
+		var row common.BlockedUser
+		if err := rows.Scan(
+			&row.Id,
+			&row.Username,
+			&row.Kind,
+			&row.DateCreated,
+		); err != nil {
+			log.Fatal(err)
+		}
+		blocked = append(blocked, row)
+	}
+
+	if err = rows.Err(); err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+	}
+	return
+}
+
+// Reports whether userID has blocked otherID.
+func (db DB) HasBlocked(userID, otherID int) (blocked bool, err error) {
+	err = db.conn.QueryRow(
+		"SELECT count(*) > 0 FROM user_blocks WHERE user_id = $1 AND blocked_user_id = $2 AND kind = 'block';",
+		userID,
+		otherID,
+	).Scan(&blocked)
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+	}
+	return
+}
+
+// Reports whether any of the named users has blocked userID.
+func (db DB) BlockedByAny(userID int, usernames []string) (blocked bool, err error) {
+	if len(usernames) == 0 {
+		return
+	}
+
+	err = db.conn.QueryRow(`
+		SELECT count(*) > 0
+		FROM
+			user_blocks,
+			users
+		WHERE
+			user_blocks.blocked_user_id = $1
+			AND user_blocks.kind = 'block'
+			AND users.id = user_blocks.user_id
+			AND users.username = ANY (string_to_array($2, ','));
+		`,
+		userID,
+		strings.Join(usernames, ","),
+	).Scan(&blocked)
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+	}
+	return
+}
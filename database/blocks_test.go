@@ -0,0 +1,133 @@
+package database
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/comforme/comforme/dbtest"
+)
+
+// A user who has blocked someone they share a community with, and the
+// blocked user's posts under their username, anonymously and under a
+// pseudonym on a page by somebody else.
+type blockFixture struct {
+	conn             *sql.DB
+	db               DB
+	blocker, blocked int
+	page             int
+
+	named, anonymous, pseudonymous int // Posts by the blocked user
+}
+
+func newBlockFixture(t *testing.T) (f blockFixture) {
+	f.conn, _ = dbtest.New(t)
+	f.db = DB{f.conn}
+
+	community := dbtest.Community(t, f.conn, "Gardeners")
+	f.blocker = dbtest.User(t, f.conn, "blocker")
+	f.blocked = dbtest.User(t, f.conn, "blocked")
+	pageAuthor := dbtest.User(t, f.conn, "author")
+	dbtest.Join(t, f.conn, f.blocker, community, "public")
+	dbtest.Join(t, f.conn, f.blocked, community, "public")
+	dbtest.Block(t, f.conn, f.blocker, f.blocked, "block")
+
+	f.page = dbtest.Page(t, f.conn, pageAuthor, dbtest.Category(t, f.conn, "Food"), "Bakery")
+	f.named = dbtest.Post(t, f.conn, f.blocked, f.page, "Named post")
+	f.anonymous = dbtest.AnonymousPost(t, f.conn, f.blocked, f.page, "Anonymous post")
+	f.pseudonymous = dbtest.PseudonymousPost(t, f.conn, f.blocked, f.page, "gardener", "Pseudonymous post")
+	return
+}
+
+// The bodies that should be shown to the blocker.
+var unblockedBodies = map[string]bool{"Anonymous post": true, "Pseudonymous post": true}
+
+func TestBlockingKeepsAnonymousPostsOnPage(t *testing.T) {
+	f := newBlockFixture(t)
+
+	posts, err := f.db.GetPostsForPage(f.blocker, f.page)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) != len(unblockedBodies) {
+		t.Errorf("got %d posts, want %d", len(posts), len(unblockedBodies))
+	}
+	for _, post := range posts {
+		if !unblockedBodies[post.Body] {
+			t.Errorf("%q is shown", post.Body)
+		}
+	}
+}
+
+func TestBlockingKeepsAnonymousPostsInFeed(t *testing.T) {
+	f := newBlockFixture(t)
+
+	now := time.Now()
+	items, err := f.db.GetFeed(f.blocker, now.Add(time.Hour), now.Add(-24*time.Hour), 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != len(unblockedBodies) {
+		t.Errorf("got %d feed items, want %d", len(items), len(unblockedBodies))
+	}
+	for _, item := range items {
+		if !unblockedBodies[item.Body] {
+			t.Errorf("%q is in the feed", item.Body)
+		}
+	}
+}
+
+func TestBlockingKeepsAnonymousPostsInDigest(t *testing.T) {
+	f := newBlockFixture(t)
+
+	digest, err := f.db.GetDigest(f.blocker, time.Now().Add(-24*time.Hour), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(digest.CommunityPosts) != len(unblockedBodies) {
+		t.Errorf("got %d community posts, want %d", len(digest.CommunityPosts), len(unblockedBodies))
+	}
+	for _, post := range digest.CommunityPosts {
+		if !unblockedBodies[post.Body] {
+			t.Errorf("%q is in the digest", post.Body)
+		}
+	}
+}
+
+func TestBlockingKeepsAnonymousPostNotifications(t *testing.T) {
+	f := newBlockFixture(t)
+	if _, err := f.conn.Exec(
+		"INSERT INTO page_subscriptions (user_id, page_id) VALUES ($1, $2)",
+		f.blocker,
+		f.page,
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, postID := range []int{f.named, f.anonymous, f.pseudonymous} {
+		if err := f.db.NotifySubscribers(postID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	notified := map[int]bool{}
+	rows, err := f.conn.Query("SELECT post_id FROM notifications WHERE user_id = $1", f.blocker)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var postID int
+		if err := rows.Scan(&postID); err != nil {
+			t.Fatal(err)
+		}
+		notified[postID] = true
+	}
+
+	if notified[f.named] {
+		t.Error("notified of the blocked user's named post")
+	}
+	if !notified[f.anonymous] || !notified[f.pseudonymous] {
+		t.Errorf("got notifications for %v, want the anonymous and pseudonymous posts", notified)
+	}
+}
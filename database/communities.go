@@ -0,0 +1,482 @@
+package database
+
+import (
+	"database/sql"
+	"log"
+
+	"github.com/comforme/comforme/common"
+)
+
+func (db DB) ProposeCommunity(userID int, name, description string, parentID int) (communityID int, err error) {
+	err = db.conn.QueryRow(`
+		INSERT INTO
+			communities (
+				name,
+				description,
+				parent_id,
+				status,
+				created_by
+			)
+		VALUES ($1, $2, NULLIF($3, 0), 'pending', $4)
+		RETURNING id
+		`,
+		name,
+		description,
+		parentID,
+		userID,
+	).Scan(&communityID)
+	if err != nil {
+		log.Printf("Error proposing community (%s): %s\n", name, err.Error())
+		err = common.CommunityAlreadyExists
+	}
+	return
+}
+
+// Looks up a community. Its member count only includes the memberships the
+// viewer may see.
+func (db DB) GetCommunity(viewerID, communityID int) (community common.Community, err error) {
+	err = db.conn.QueryRow(`
+		SELECT
+			communities.id,
+			communities.name,
+			communities.description,
+			COALESCE(communities.parent_id, 0),
+			COALESCE(parents.name, ''),
+			communities.status,
+			COALESCE(proposers.username, ''),
+			communities.date_created,
+			(
+				SELECT count(*)
+				FROM visible_memberships($2) memberships
+				WHERE memberships.community_id = communities.id
+			)
+		FROM
+			communities
+		LEFT JOIN
+			communities parents
+				ON
+					parents.id = communities.parent_id
+		LEFT JOIN
+			users proposers
+				ON
+					proposers.id = communities.created_by
+		WHERE
+			communities.id = $1;
+		`,
+		communityID,
+		viewerID,
+	).Scan(
+		&community.Id,
+		&community.Name,
+		&community.Description,
+		&community.ParentID,
+		&community.ParentName,
+		&community.Status,
+		&community.ProposerName,
+		&community.DateCreated,
+		&community.MemberCount,
+	)
+	if err != nil {
+		log.Printf("Error looking up community (%d): %s\n", communityID, err.Error())
+		err = common.CommunityNotFound
+	}
+	return
+}
+
+// Lists approved and pending communities, used to find near-duplicates.
+func (db DB) GetCommunityNames() (communities []common.Community, err error) {
+	return db.queryCommunities(`
+		WHERE
+			communities.status <> 'rejected'
+		ORDER BY communities.name ASC;
+		`,
+	)
+}
+
+func (db DB) GetSubcommunities(communityID int) (communities []common.Community, err error) {
+	return db.queryCommunities(`
+		WHERE
+			communities.parent_id = $1
+			AND communities.status = 'approved'
+		ORDER BY communities.name ASC;
+		`,
+		communityID,
+	)
+}
+
+// Moves a community under a new parent, or to the top level if parentID is 0.
+// Refuses to create cycles.
+func (db DB) SetCommunityParent(communityID, parentID int) error {
+	if parentID != 0 {
+		var wouldCycle bool
+		err := db.conn.QueryRow(
+			"SELECT count(*) > 0 FROM community_ancestors WHERE community_id = $1 AND ancestor_id = $2",
+			parentID,
+			communityID,
+		).Scan(&wouldCycle)
+		if err != nil {
+			common.LogError(err)
+			return common.DatabaseError
+		}
+		if wouldCycle {
+			return common.InvalidParentCommunity
+		}
+	}
+
+	result, err := db.conn.Exec(
+		"UPDATE communities SET parent_id = NULLIF($2, 0) WHERE id = $1;",
+		communityID,
+		parentID,
+	)
+	if err != nil {
+		common.LogError(err)
+		return common.CommunityNotFound
+	}
+
+	return checkSingleRow(result, common.CommunityNotFound)
+}
+
+func (db DB) GetPendingCommunities() (communities []common.Community, err error) {
+	return db.queryCommunities(`
+		WHERE
+			communities.status = 'pending'
+		ORDER BY communities.date_created ASC;
+		`,
+	)
+}
+
+func (db DB) queryCommunities(where string, args ...interface{}) (communities []common.Community, err error) {
+	rows, err := db.conn.Query(`
+		SELECT
+			communities.id,
+			communities.name,
+			communities.description,
+			COALESCE(communities.parent_id, 0),
+			COALESCE(parents.name, ''),
+			communities.status,
+			COALESCE(proposers.username, ''),
+			communities.date_created
+		FROM
+			communities
+		LEFT JOIN
+			communities parents
+				ON
+					parents.id = communities.parent_id
+		LEFT JOIN
+			users proposers
+				ON
+					proposers.id = communities.created_by
+		`+where,
+		args...,
+	)
+	if err != nil {
+		common.LogErrorSkipLevels(err, 1)
+		err = common.DatabaseError
+		return
+	}
+
+	defer rows.Close()
+
+	communities = []common.Community{}
+	for rows.Next() {
+		var row common.Community
+		if err := rows.Scan(
+			&row.Id,
+			&row.Name,
+			&row.Description,
+			&row.ParentID,
+			&row.ParentName,
+			&row.Status,
+			&row.ProposerName,
+			&row.DateCreated,
+		); err != nil {
+			log.Println("Unknown iteration error:", err)
+			return nil, err
+		}
+		communities = append(communities, row)
+	}
+
+	if err = rows.Err(); err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+
+	// Success
+	return
+}
+
+// Approves or rejects a pending community. Returns the id of the user who
+// proposed it.
+func (db DB) ReviewCommunity(communityID int, status string) (proposerID int, err error) {
+	err = db.conn.QueryRow(`
+		UPDATE
+			communities
+		SET
+			status = $2
+		WHERE
+			id = $1
+			AND status = 'pending'
+		RETURNING COALESCE(created_by, 0);
+		`,
+		communityID,
+		status,
+	).Scan(&proposerID)
+	if err != nil {
+		log.Printf("Error reviewing community (%d): %s\n", communityID, err.Error())
+		err = common.CommunityNotPending
+	}
+	return
+}
+
+func (db DB) GetCommunityContributions(viewerID, communityID, limit int) (contributions []common.Contribution, err error) {
+	rows, err := db.conn.Query(`
+		SELECT
+			contributions.author,
+			contributions.title,
+			contributions.page_slug,
+			contributions.category_slug,
+			contributions.body,
+			contributions.is_page,
+			contributions.date_created
+		FROM
+			(
+				SELECT
+					users.username AS author,
+					pages.title,
+					pages.slug AS page_slug,
+					categories.slug AS category_slug,
+					posts.body,
+					false AS is_page,
+					posts.date_created
+				FROM
+					posts,
+					pages,
+					categories,
+					users,
+					visible_memberships($3) memberships
+				WHERE
+					posts.page_id = pages.id
+					AND pages.category = categories.id
+					AND posts.user_id = users.id
+					-- Listing these here would tie them to the author
+					AND NOT posts.anonymous
+					AND posts.pseudonym_id IS NULL
+					AND memberships.user_id = users.id
+					AND memberships.community_id = $1
+				UNION ALL
+				SELECT
+					users.username AS author,
+					pages.title,
+					pages.slug AS page_slug,
+					categories.slug AS category_slug,
+					pages.description AS body,
+					true AS is_page,
+					pages.date_created
+				FROM
+					pages,
+					categories,
+					users,
+					visible_memberships($3) memberships
+				WHERE
+					pages.category = categories.id
+					AND pages.user_id = users.id
+					AND memberships.user_id = users.id
+					AND memberships.community_id = $1
+			) contributions
+		ORDER BY contributions.date_created DESC
+		LIMIT $2;
+		`,
+		communityID,
+		limit,
+		viewerID,
+	)
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+
+	return scanContributions(rows)
+}
+
+func scanContributions(rows *sql.Rows) (contributions []common.Contribution, err error) {
+	defer rows.Close()
+
+	contributions = []common.Contribution{}
+	for rows.Next() {
+		var row common.Contribution
+		if err := rows.Scan(
+			&row.Author,
+			&row.PageTitle,
+			&row.PageSlug,
+			&row.CategorySlug,
+			&row.Body,
+			&row.IsPage,
+			&row.Date,
+		); err != nil {
+			log.Println("Unknown iteration error:", err)
+			return nil, err
+		}
+		contributions = append(contributions, row)
+	}
+
+	if err = rows.Err(); err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+
+	// Success
+	return
+}
+
+func (db DB) IsCommunityMember(userID, communityID int) (isMember bool, err error) {
+	err = db.conn.QueryRow(
+		"SELECT count(*) > 0 FROM community_memberships WHERE user_id = $1 AND community_id = $2",
+		userID,
+		communityID,
+	).Scan(&isMember)
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+	}
+	return
+}
+
+func (db DB) GetCommunityAliases() (aliases map[int][]string, err error) {
+	rows, err := db.conn.Query("SELECT community_id, alias FROM community_aliases ORDER BY alias ASC;")
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+
+	defer rows.Close()
+
+	aliases = map[int][]string{}
+	for rows.Next() {
+		var communityID int
+		var alias string
+		if err := rows.Scan(&communityID, &alias); err != nil {
+			log.Println("Unknown iteration error:", err)
+			return nil, err
+		}
+		aliases[communityID] = append(aliases[communityID], alias)
+	}
+
+	if err = rows.Err(); err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+
+	// Success
+	return
+}
+
+func (db DB) SetCommunityAliases(communityID int, aliases []string) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		common.LogError(err)
+		return common.DatabaseError
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("DELETE FROM community_aliases WHERE community_id = $1;", communityID)
+	if err != nil {
+		common.LogError(err)
+		return common.DatabaseError
+	}
+
+	for _, alias := range aliases {
+		_, err = tx.Exec(
+			"INSERT INTO community_aliases (community_id, alias) VALUES ($1, $2)",
+			communityID,
+			alias,
+		)
+		if err != nil {
+			log.Printf("Error adding alias (%s) to community (%d): %s\n", alias, communityID, err.Error())
+			return common.CommunityNotFound
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		common.LogError(err)
+		return common.DatabaseError
+	}
+	return nil
+}
+
+// Suggests communities that are popular among people who share communities
+// with the user, most shared members first.
+func (db DB) SuggestCommunities(userID, limit int) (communities []common.Community, err error) {
+	rows, err := db.conn.Query(`
+		SELECT
+			communities.id,
+			communities.name,
+			COALESCE(communities.parent_id, 0),
+			COALESCE(parents.name, ''),
+			count(DISTINCT neighbours.user_id) AS shared_members
+		FROM
+			community_memberships mine,
+			visible_memberships($1) neighbours,
+			visible_memberships($1) theirs,
+			communities
+		LEFT JOIN
+			communities parents
+				ON
+					parents.id = communities.parent_id
+		WHERE
+			mine.user_id = $1
+			AND neighbours.community_id = mine.community_id
+			AND neighbours.user_id <> $1
+			AND theirs.user_id = neighbours.user_id
+			AND communities.id = theirs.community_id
+			AND communities.status = 'approved'
+			AND communities.id NOT IN (
+				SELECT community_id FROM community_memberships WHERE user_id = $1
+			)
+		GROUP BY
+			communities.id,
+			parents.name
+		ORDER BY shared_members DESC, communities.name ASC
+		LIMIT $2;
+		`,
+		userID,
+		limit,
+	)
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+
+	defer rows.Close()
+
+	communities = []common.Community{}
+	for rows.Next() {
+		var row common.Community
+		// MemberCount holds the number of shared members
+		if err := rows.Scan(
+			&row.Id,
+			&row.Name,
+			&row.ParentID,
+			&row.ParentName,
+			&row.MemberCount,
+		); err != nil {
+			log.Println("Unknown iteration error:", err)
+			return nil, err
+		}
+		communities = append(communities, row)
+	}
+
+	if err = rows.Err(); err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+
+	// Success
+	return
+}
@@ -3,6 +3,7 @@ package database
 import (
 	"database/sql"
 	"log"
+	"time"
 
 	_ "github.com/lib/pq"
 	"golang.org/x/crypto/bcrypt"
@@ -47,7 +48,8 @@ func (db DB) GetUserID(email string, password string) (userid int, err error) {
 
 	// Get hashed password
 	var hashed string
-	err = db.conn.QueryRow("SELECT id, password FROM users WHERE email = $1", email).Scan(&userid, &hashed)
+	var suspended bool
+	err = db.conn.QueryRow("SELECT id, password, suspended FROM users WHERE email = $1", email).Scan(&userid, &hashed, &suspended)
 	if err != nil {
 		common.LogError(err)
 		log.Printf("Error retrieving userid and hashed password for email (%s): %s\n", email, err.Error())
@@ -63,6 +65,12 @@ func (db DB) GetUserID(email string, password string) (userid int, err error) {
 		err = common.InvalidUsernameOrPassword
 		return
 	}
+
+	if suspended {
+		log.Printf("Suspended user with email (%s) tried to log in.\n", email)
+		err = common.AccountSuspended
+		return
+	}
 	return
 }
 
@@ -107,7 +115,9 @@ func (db DB) NewSession(userid int) (sessionid string, err error) {
 	return
 }
 
-func (db DB) NewPage(userID int, title, slug, description, address, website string, category int) (pageID int, err error) {
+func (db DB) NewPage(userID int, title, slug, description, address, website string, location *common.Location, category int) (pageID int, err error) {
+	longitude, latitude := locationArgs(location)
+
 	// Insert new page
 	err = db.conn.QueryRow(`
 		INSERT INTO
@@ -118,9 +128,10 @@ func (db DB) NewPage(userID int, title, slug, description, address, website stri
 				category,
 				slug,
 				user_id,
-				website
+				website,
+				location
 			)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, point($8, $9))
 		RETURNING id
 		`,
 		title,
@@ -130,6 +141,8 @@ func (db DB) NewPage(userID int, title, slug, description, address, website stri
 		slug,
 		userID,
 		website,
+		longitude,
+		latitude,
 	).Scan(&pageID)
 	if err != nil {
 		log.Println("Failed to insert page: ", err)
@@ -140,6 +153,38 @@ func (db DB) NewPage(userID int, title, slug, description, address, website stri
 	return
 }
 
+func (db DB) UpdatePage(pageID int, title, description, address, website string, location *common.Location, category int) error {
+	longitude, latitude := locationArgs(location)
+	result, err := db.conn.Exec(`
+		UPDATE
+			pages
+		SET
+			title = $2,
+			description = $3,
+			address = $4,
+			website = $5,
+			category = $6,
+			location = point($7, $8)
+		WHERE
+			id = $1;
+		`,
+		pageID,
+		title,
+		description,
+		address,
+		website,
+		category,
+		longitude,
+		latitude,
+	)
+	if err != nil {
+		log.Printf("Error updating page (%d): %s\n", pageID, err.Error())
+		return common.DatabaseError
+	}
+
+	return checkSingleRow(result, common.PageNotFound)
+}
+
 func (db DB) GetSlugs(pageID int) (categorySlug, pageSlug string, err error) {
 	err = db.conn.QueryRow(`
 		SELECT
@@ -181,7 +226,10 @@ func (db DB) CheckEmailInUse(email string) (err error) {
 
 func (db DB) checkUsernameInUse(username string) (err error) {
 	var numRows int
-	err = db.conn.QueryRow("SELECT count(*) FROM users WHERE username = $1", username).Scan(&numRows)
+	err = db.conn.QueryRow(
+		"SELECT (SELECT count(*) FROM users WHERE username = $1) + (SELECT count(*) FROM pseudonyms WHERE name = $1)",
+		username,
+	).Scan(&numRows)
 	if err != nil {
 		log.Printf("Error checking if username (%s) already exists: %s\n", username, err.Error())
 		err = common.DatabaseError
@@ -195,13 +243,30 @@ func (db DB) checkUsernameInUse(username string) (err error) {
 	return
 }
 
-func (db DB) NewPost(userID, pageID int, post string) (err error) {
-	_, err = db.conn.Exec(
-		"INSERT INTO posts (user_id, page_id, body) VALUES ($1, $2, $3)",
-		userID,
-		pageID,
-		post,
-	)
+// Adds a post by the user. A non-zero pseudonymID must be one of the user's
+// own pseudonyms. Anonymous posts are still stored against the user.
+func (db DB) NewPost(userID, pageID int, post string, pseudonymID int, anonymous bool) (postID int, err error) {
+	if pseudonymID == 0 {
+		err = db.conn.QueryRow(
+			"INSERT INTO posts (user_id, page_id, body, anonymous) VALUES ($1, $2, $3, $4) RETURNING id",
+			userID,
+			pageID,
+			post,
+			anonymous,
+		).Scan(&postID)
+	} else {
+		err = db.conn.QueryRow(
+			"INSERT INTO posts (user_id, page_id, body, pseudonym_id) SELECT $1, $2, $3, id FROM pseudonyms WHERE id = $4 AND user_id = $1 RETURNING id",
+			userID,
+			pageID,
+			post,
+			pseudonymID,
+		).Scan(&postID)
+	}
+	if err == sql.ErrNoRows {
+		err = common.PseudonymNotFound
+		return
+	}
 	if err != nil {
 		log.Printf("Error post (%s) to page (%d) with user (%d): %s\n", post, pageID, userID, err.Error())
 		err = common.DatabaseError
@@ -211,6 +276,25 @@ func (db DB) NewPost(userID, pageID int, post string) (err error) {
 	return
 }
 
+func (db DB) DeletePost(postID int) error {
+	result, err := db.conn.Exec("DELETE FROM posts WHERE id = $1;", postID)
+	if err != nil {
+		log.Printf("Error deleting post (%d): %s\n", postID, err.Error())
+		return common.DatabaseError
+	}
+
+	return checkSingleRow(result, common.PostNotFound)
+}
+
+func (db DB) GetPostAuthorID(postID int) (authorID int, err error) {
+	err = db.conn.QueryRow("SELECT COALESCE(user_id, 0) FROM posts WHERE id = $1", postID).Scan(&authorID)
+	if err != nil {
+		log.Printf("Error looking up author of post (%d): %s\n", postID, err.Error())
+		err = common.PostNotFound
+	}
+	return
+}
+
 func (db DB) RegisterUser(username, email, password string) (err error) {
 	err = db.CheckEmailInUse(email)
 	if err != nil {
@@ -227,11 +311,7 @@ func (db DB) RegisterUser(username, email, password string) (err error) {
 	if err != nil {
 		return
 	}
-	log.Printf(
-		"Adding user: %s with hashed password: %s\n",
-		email,
-		hashed,
-	)
+	log.Printf("Adding user: %s\n", email)
 	_, err = db.conn.Exec(
 		"INSERT INTO users (email, username, password, reset_required) VALUES ($1, $2, $3, false)",
 		email,
@@ -290,13 +370,29 @@ func (db DB) GetEmail(sessionid string) (email string, err error) {
 
 func (db DB) GetUserInfo(sessionid string) (userInfo common.UserInfo, err error) {
 	userInfo.SessionID = sessionid
+	var role string
+	var suspended bool
 	err = db.conn.QueryRow(
-		"SELECT email, username, user_id FROM sessions, users WHERE sessions.id = $1 AND sessions.user_id = users.id",
+		"SELECT email, username, user_id, role, suspended, locale FROM sessions, users WHERE sessions.id = $1 AND sessions.user_id = users.id",
 		sessionid,
-	).Scan(&userInfo.Email, &userInfo.Username, &userInfo.UserID)
+	).Scan(&userInfo.Email, &userInfo.Username, &userInfo.UserID, &role, &suspended, &userInfo.Locale)
 	if err != nil {
 		log.Printf("Error looking up email and ID associated with sessionid  (%s): %s\n", sessionid, err.Error())
 		err = common.InvalidSessionID
+		return
+	}
+
+	if suspended {
+		log.Printf("Suspended user (%d) tried to use sessionid (%s).\n", userInfo.UserID, sessionid)
+		err = common.AccountSuspended
+		return
+	}
+
+	userInfo.Role, err = common.ParseRole(role)
+	if err != nil {
+		log.Printf("Unknown role (%s) for user (%d), treating as user.\n", role, userInfo.UserID)
+		userInfo.Role = common.RoleUser
+		err = nil
 	}
 	return
 }
@@ -332,11 +428,7 @@ func (db DB) ResetPassword(email string) (password string, err error) {
 		return
 	}
 
-	log.Printf(
-		"Resetting password for user: %s with hashed password: %s\n",
-		email,
-		hashed,
-	)
+	log.Printf("Resetting password for user: %s\n", email)
 	err = db.changePassword(email, hashed)
 
 	if err != nil {
@@ -385,11 +477,7 @@ func (db DB) ChangePassword(email, newPassword string) error {
 		return err
 	}
 
-	log.Printf(
-		"Changing password for user: %s with hashed password: %s\n",
-		email,
-		hashed,
-	)
+	log.Printf("Changing password for user: %s\n", email)
 	return db.changePassword(email, hashed)
 }
 
@@ -432,13 +520,16 @@ func (db DB) ListCommunities(userid int) (communities []common.Community, err er
 		SELECT
 			communities.id,
 			communities.name,
-			my_memberships.member IS NOT NULL as is_member
+			COALESCE(communities.parent_id, 0),
+			my_memberships.member IS NOT NULL as is_member,
+			COALESCE(my_memberships.visibility, '')
 		FROM
 			communities
 		LEFT JOIN
 			(
 				SELECT
 					community_memberships.community_id,
+					community_memberships.visibility,
 					true as member
 				FROM
 					community_memberships
@@ -447,6 +538,8 @@ func (db DB) ListCommunities(userid int) (communities []common.Community, err er
 			) as my_memberships
 				ON
 					my_memberships.community_id = communities.id
+		WHERE
+			communities.status = 'approved'
 		ORDER BY communities.id ASC;
 		`,
 		userid,
@@ -465,7 +558,9 @@ func (db DB) ListCommunities(userid int) (communities []common.Community, err er
 		if err := rows.Scan(
 			&row.Id,
 			&row.Name,
+			&row.ParentID,
 			&row.IsMember,
+			&row.Visibility,
 		); err != nil {
 			log.Println("Unknown iteration error:", err)
 			return nil, err
@@ -535,7 +630,7 @@ func (db DB) GetPages() (pages []common.Page, err error) {
 	return
 }
 
-func (db DB) SearchPages(query string) (pages []common.Page, err error) {
+func (db DB) SearchPages(userID int, query string) (pages []common.Page, err error) {
 		rows, err := db.conn.Query(`
 			SELECT
 				pages.id,
@@ -550,10 +645,14 @@ func (db DB) SearchPages(query string) (pages []common.Page, err error) {
 				categories
 			WHERE
 				categories.id = pages.category AND
-				to_tsvector('english', title) @@ to_tsquery($1) -- Full text search
+				to_tsvector('english', title) @@ to_tsquery($1) AND -- Full text search
+				pages.user_id NOT IN (
+					SELECT blocked_user_id FROM user_blocks WHERE user_id = $2 AND kind = 'block'
+				)
 			ORDER BY date_created DESC
 			`,
 		query,
+		userID,
 	)
 	if err != nil {
 		common.LogError(err)
@@ -631,8 +730,8 @@ func (db DB) ListCategories() (categories map[string]string, err error) {
 }
 
 func (db DB) AddCommunityMembership(user_id, community_id int) (err error) {
-	_, err = db.conn.Exec(
-		"INSERT INTO community_memberships (user_id, community_id) VALUES ($1, $2)",
+	result, err := db.conn.Exec(
+		"INSERT INTO community_memberships (user_id, community_id) SELECT $1, id FROM communities WHERE id = $2 AND status = 'approved'",
 		user_id,
 		community_id,
 	)
@@ -641,7 +740,22 @@ func (db DB) AddCommunityMembership(user_id, community_id int) (err error) {
 		err = common.DatabaseError
 		return
 	}
-	return
+	return checkSingleRow(result, common.CommunityNotFound)
+}
+
+func (db DB) SetMembershipVisibility(user_id, community_id int, visibility string) (err error) {
+	result, err := db.conn.Exec(
+		"UPDATE community_memberships SET visibility = $3 WHERE user_id = $1 AND community_id = $2;",
+		user_id,
+		community_id,
+		visibility,
+	)
+	if err != nil {
+		log.Println("Error setting membership visibility:", err)
+		err = common.DatabaseError
+		return
+	}
+	return checkSingleRow(result, common.NotACommunityMember)
 }
 
 func (db DB) DeleteCommunityMembership(user_id, community_id int) (err error) {
@@ -694,8 +808,31 @@ func (db DB) GetPostsForPage(userid, pageid int) (posts []common.Post, err error
 	rows, err := db.conn.Query(
 		`
 			SELECT
+				posts.id,
 				posts.body,
-				authors.username AS author,
+				-- Never reveal who wrote an anonymous or pseudonymous post
+				CASE
+					WHEN posts.anonymous THEN ''
+					WHEN pseudonyms.id IS NOT NULL THEN pseudonyms.name
+					WHEN posts.remote_author IS NOT NULL THEN posts.remote_author
+					ELSE authors.username
+				END AS author,
+				posts.anonymous,
+				posts.pseudonym_id IS NOT NULL,
+				COALESCE(posts.remote_actor, ''),
+				-- Muting only collapses posts already shown under the username
+				(
+					posts.pseudonym_id IS NULL
+					AND NOT posts.anonymous
+					AND EXISTS (
+						SELECT 1
+						FROM user_blocks
+						WHERE
+							user_blocks.user_id = $1
+							AND user_blocks.blocked_user_id = authors.id
+							AND user_blocks.kind = 'mute'
+					)
+				) AS muted,
 				to_char(posts.date_created, 'YYYY-MM-DD HH24:MI:SS'),
 				(
 					SELECT count(*)
@@ -710,20 +847,53 @@ func (db DB) GetPostsForPage(userid, pageid int) (posts []common.Post, err error
 						(
 							SELECT community_id
 							FROM
-								community_memberships
+								visible_memberships($1)
 							WHERE
 								user_id = authors.id
 						) author_communities
 					WHERE
 						my_communities.community_id = author_communities.community_id
-				) AS common_communities
+				) AS common_communities,
+				(
+					-- Shared communities count fully, shared ancestors count
+					-- half as much for every step up the tree.
+					SELECT COALESCE(sum(power(0.5, greatest(my_communities.depth, author_communities.depth))), 0)
+					FROM
+						visible_inherited_memberships($1) my_communities,
+						visible_inherited_memberships($1) author_communities
+					WHERE
+						my_communities.user_id = $1
+						AND author_communities.user_id = authors.id
+						AND my_communities.community_id = author_communities.community_id
+				) AS affinity
 			FROM
-				users authors,
 				posts
+			LEFT JOIN
+				pseudonyms
+					ON
+						pseudonyms.id = posts.pseudonym_id
+			-- Replies from other servers have no local author
+			LEFT JOIN
+				users authors
+					ON
+						authors.id = posts.user_id
 			WHERE
-				posts.user_id = authors.id
-				AND posts.page_id = $2
-			ORDER BY common_communities DESC;
+				posts.page_id = $2
+				-- Hiding anonymous and pseudonymous posts of blocked users
+				-- would give away who wrote them
+				AND NOT (
+					posts.pseudonym_id IS NULL
+					AND NOT posts.anonymous
+					AND EXISTS (
+						SELECT 1
+						FROM user_blocks
+						WHERE
+							user_blocks.user_id = $1
+							AND user_blocks.blocked_user_id = posts.user_id
+							AND user_blocks.kind = 'block'
+					)
+				)
+			ORDER BY affinity DESC, common_communities DESC;
 		`,
 		userid,
 		pageid,
@@ -739,10 +909,16 @@ func (db DB) GetPostsForPage(userid, pageid int) (posts []common.Post, err error
 	for rows.Next() {
 		var row common.Post
 		if err := rows.Scan(
+			&row.Id,
 			&row.Body,
 			&row.Author,
+			&row.Anonymous,
+			&row.Pseudonymous,
+			&row.RemoteActor,
+			&row.Muted,
 			&row.Date,
 			&row.CommonCategories,
+			&row.Affinity,
 		); err != nil {
 			log.Fatal(err)
 		}
@@ -760,6 +936,7 @@ func (db DB) GetPostsForPage(userid, pageid int) (posts []common.Post, err error
 }
 
 func (db DB) GetPage(categorySlug, pageSlug string) (page common.Page, err error) {
+	var longitude, latitude sql.NullFloat64
 	err = db.conn.QueryRow(`
 		SELECT
 			pages.id,
@@ -770,7 +947,10 @@ func (db DB) GetPage(categorySlug, pageSlug string) (page common.Page, err error
 			description,
 			address,
 			website,
-			date_created
+			location[0],
+			location[1],
+			date_created,
+			pages.user_id
 		FROM
 			pages,
 			categories
@@ -789,17 +969,22 @@ func (db DB) GetPage(categorySlug, pageSlug string) (page common.Page, err error
 		&page.Description,
 		&page.Address,
 		&page.Website,
+		&longitude,
+		&latitude,
 		&page.DateCreated,
+		&page.AuthorID,
 	)
 	if err != nil {
 		log.Println("Error getting page:", err)
 		err = common.PageNotFound
 		return
 	}
+	page.Location = scannedLocation(longitude, latitude)
 	return
 }
 
-func (db DB) GetTopPages() (pages []common.PagePostCount, err error) {
+// Lists the pages with the most posts since the given time.
+func (db DB) GetTopPages(userID int, since time.Time) (pages []common.PagePostCount, err error) {
 	rows, err := db.conn.Query(`
 		SELECT
 			top_pages.title,
@@ -821,6 +1006,10 @@ func (db DB) GetTopPages() (pages []common.PagePostCount, err error) {
 					posts
 				WHERE
 					pages.id = posts.page_id
+					AND posts.date_created > $2
+					AND pages.user_id NOT IN (
+						SELECT blocked_user_id FROM user_blocks WHERE user_id = $1 AND kind = 'block'
+					)
 				GROUP BY
 					pages.id
 				ORDER BY
@@ -828,7 +1017,10 @@ func (db DB) GetTopPages() (pages []common.PagePostCount, err error) {
 				LIMIT 5
 			) as top_pages
 		WHERE
-			categories.id = top_pages.category;`,
+			categories.id = top_pages.category
+		ORDER BY top_pages.count DESC;`,
+		userID,
+		since,
 	)
 	if err != nil {
 		common.LogError(err)
@@ -0,0 +1,277 @@
+package database
+
+import (
+	"log"
+	"time"
+
+	"github.com/comforme/comforme/common"
+)
+
+// Lists the users whose digest is due. Suspended users get none.
+func (db DB) GetDigestRecipients() (recipients []common.DigestRecipient, err error) {
+	rows, err := db.conn.Query(`
+		SELECT
+			id,
+			email,
+			username,
+			COALESCE(digest_last_sent, now() - interval_length)
+		FROM
+			(
+				SELECT
+					users.*,
+					CASE digest_frequency
+						WHEN 'daily' THEN interval '1 day'
+						ELSE interval '7 days'
+					END AS interval_length
+				FROM
+					users
+				WHERE
+					digest_frequency <> 'never'
+					AND NOT suspended
+			) due
+		WHERE
+			digest_last_sent IS NULL
+			-- Leave some slack so a job that runs at the same time each day
+			-- does not skip a day because the last run was a little later
+			OR digest_last_sent <= now() - interval_length + interval '1 hour'
+		ORDER BY id ASC;
+		`,
+	)
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+
+	defer rows.Close()
+
+	recipients = []common.DigestRecipient{}
+	for rows.Next() {
+		var row common.DigestRecipient
+		if err := rows.Scan(
+			&row.UserID,
+			&row.Email,
+			&row.Username,
+			&row.Since,
+		); err != nil {
+			log.Fatal(err)
+		}
+		recipients = append(recipients, row)
+	}
+
+	if err = rows.Err(); err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+	}
+	return
+}
+
+// Collects what happened since the given time for the user's digest. Content
+// by users they have blocked is left out.
+func (db DB) GetDigest(userID int, since time.Time, limit int) (digest common.Digest, err error) {
+	rows, err := db.conn.Query(`
+		SELECT
+			users.username,
+			pages.title,
+			pages.slug,
+			categories.slug,
+			pages.description,
+			true,
+			pages.date_created
+		FROM
+			pages,
+			categories,
+			users
+		WHERE
+			pages.category = categories.id
+			AND pages.user_id = users.id
+			AND pages.date_created > $2
+			AND users.id <> $1
+			AND users.id IN (
+				SELECT theirs.user_id
+				FROM
+					community_memberships mine,
+					visible_memberships($1) theirs
+				WHERE
+					mine.user_id = $1
+					AND theirs.community_id = mine.community_id
+			)
+			AND users.id NOT IN (
+				SELECT blocked_user_id FROM user_blocks WHERE user_id = $1 AND kind = 'block'
+			)
+		ORDER BY pages.date_created DESC
+		LIMIT $3;
+		`,
+		userID,
+		since,
+		limit,
+	)
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+	digest.Pages, err = scanContributions(rows)
+	if err != nil {
+		return
+	}
+
+	// Posts on followed pages are listed separately, so they are left out
+	// here.
+	rows, err = db.conn.Query(`
+		SELECT
+			'',
+			pages.title,
+			pages.slug,
+			categories.slug,
+			posts.body,
+			false,
+			posts.date_created
+		FROM
+			posts,
+			pages,
+			categories
+		WHERE
+			posts.page_id = pages.id
+			AND pages.category = categories.id
+			AND posts.date_created > $2
+			AND posts.user_id IS DISTINCT FROM $1
+			AND posts.user_id IN (
+				SELECT theirs.user_id
+				FROM
+					community_memberships mine,
+					visible_memberships($1) theirs
+				WHERE
+					mine.user_id = $1
+					AND theirs.community_id = mine.community_id
+			)
+			-- Hiding anonymous and pseudonymous posts of blocked users would
+			-- give away who wrote them
+			AND NOT (
+				posts.pseudonym_id IS NULL
+				AND NOT posts.anonymous
+				AND EXISTS (
+					SELECT 1
+					FROM user_blocks
+					WHERE
+						user_blocks.user_id = $1
+						AND user_blocks.blocked_user_id = posts.user_id
+						AND user_blocks.kind = 'block'
+				)
+			)
+			AND pages.id NOT IN (
+				SELECT page_id FROM page_subscriptions WHERE user_id = $1
+			)
+		ORDER BY posts.date_created DESC
+		LIMIT $3;
+		`,
+		userID,
+		since,
+		limit,
+	)
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+	digest.CommunityPosts, err = scanContributions(rows)
+	if err != nil {
+		return
+	}
+
+	rows, err = db.conn.Query(`
+		SELECT
+			'',
+			pages.title,
+			pages.slug,
+			categories.slug,
+			posts.body,
+			false,
+			posts.date_created
+		FROM
+			posts,
+			pages,
+			categories,
+			page_subscriptions
+		WHERE
+			posts.page_id = pages.id
+			AND pages.category = categories.id
+			AND page_subscriptions.page_id = pages.id
+			AND page_subscriptions.user_id = $1
+			AND posts.date_created > $2
+			AND posts.user_id IS DISTINCT FROM $1
+			-- Hiding anonymous and pseudonymous posts of blocked users would
+			-- give away who wrote them
+			AND NOT (
+				posts.pseudonym_id IS NULL
+				AND NOT posts.anonymous
+				AND EXISTS (
+					SELECT 1
+					FROM user_blocks
+					WHERE
+						user_blocks.user_id = $1
+						AND user_blocks.blocked_user_id = posts.user_id
+						AND user_blocks.kind = 'block'
+				)
+			)
+		ORDER BY posts.date_created DESC
+		LIMIT $3;
+		`,
+		userID,
+		since,
+		limit,
+	)
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+	digest.FollowedPosts, err = scanContributions(rows)
+	return
+}
+
+func (db DB) MarkDigestSent(userID int) error {
+	result, err := db.conn.Exec("UPDATE users SET digest_last_sent = now() WHERE id = $1;", userID)
+	if err != nil {
+		common.LogError(err)
+		return common.DatabaseError
+	}
+
+	return checkSingleRow(result, common.UserNotFound)
+}
+
+func (db DB) GetDigestFrequency(userID int) (frequency string, err error) {
+	err = db.conn.QueryRow("SELECT digest_frequency FROM users WHERE id = $1", userID).Scan(&frequency)
+	if err != nil {
+		log.Printf("Error looking up digest frequency of user (%d): %s\n", userID, err.Error())
+		err = common.UserNotFound
+	}
+	return
+}
+
+func (db DB) SetDigestFrequency(userID int, frequency string) error {
+	result, err := db.conn.Exec(
+		"UPDATE users SET digest_frequency = $2 WHERE id = $1;",
+		userID,
+		frequency,
+	)
+	if err != nil {
+		common.LogError(err)
+		return common.DatabaseError
+	}
+
+	return checkSingleRow(result, common.UserNotFound)
+}
+
+func (db DB) UnsubscribeFromDigest(userID int) error {
+	result, err := db.conn.Exec(
+		"UPDATE users SET digest_frequency = 'never' WHERE id = $1;",
+		userID,
+	)
+	if err != nil {
+		common.LogError(err)
+		return common.DatabaseError
+	}
+
+	return checkSingleRow(result, common.UserNotFound)
+}
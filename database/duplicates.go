@@ -0,0 +1,227 @@
+package database
+
+import (
+	"database/sql"
+	"log"
+	"strings"
+
+	"github.com/comforme/comforme/common"
+)
+
+const pageCandidateQuery = `
+	SELECT
+		pages.id,
+		pages.title,
+		pages.slug,
+		categories.name,
+		categories.slug,
+		pages.category,
+		pages.address,
+		pages.website,
+		pages.location[0],
+		pages.location[1]
+	FROM
+		pages
+	INNER JOIN
+		categories
+			ON
+				categories.id = pages.category
+`
+
+func scanPageCandidate(row interface {
+	Scan(...interface{}) error
+}) (page common.PageCandidate, err error) {
+	var longitude, latitude sql.NullFloat64
+	err = row.Scan(
+		&page.Id,
+		&page.Title,
+		&page.PageSlug,
+		&page.Category,
+		&page.CategorySlug,
+		&page.CategoryID,
+		&page.Address,
+		&page.Website,
+		&longitude,
+		&latitude,
+	)
+	page.Location = scannedLocation(longitude, latitude)
+	return
+}
+
+// Lists the pages that could be duplicates of a page: those in categoryID
+// (or any category if it is 0) whose title shares one of titleWords or starts
+// with titlePrefix once normalized, those with the same normalized address
+// or website, and those within about common.NearbyPageDistance of location.
+// Empty arguments match nothing. The caller decides which candidates are
+// alike enough.
+func (db DB) GetPageCandidates(pageID, categoryID int, titleWords []string, titlePrefix, address, website string, location *common.Location) (pages []common.PageCandidate, err error) {
+	longitude, latitude := locationArgs(location)
+	var radius float64
+	if location != nil {
+		radius = location.RadiusDegrees(common.NearbyPageDistance)
+	}
+
+	rows, err := db.conn.Query(pageCandidateQuery+`
+		WHERE
+			pages.id <> $1
+			AND pages.id IN (
+				SELECT id FROM pages
+				WHERE
+					($2 = 0 OR category = $2)
+					AND (
+						string_to_array(regexp_replace(lower(title), '[^[:alnum:]]+', ' ', 'g'), ' ') && string_to_array($3, ' ')
+						OR ($4 <> '' AND left(regexp_replace(lower(title), '[^[:alnum:]]+', '', 'g'), length($4)) = $4)
+					)
+				UNION
+				SELECT id FROM pages WHERE $5 <> '' AND normalize_address(address) = $5
+				UNION
+				SELECT id FROM pages WHERE $6 <> '' AND normalize_website(website) = $6
+				UNION
+				SELECT id FROM pages WHERE location <@ circle(point($7, $8), $9)
+			)
+		ORDER BY pages.id;`,
+		pageID,
+		categoryID,
+		strings.Join(titleWords, " "),
+		titlePrefix,
+		address,
+		website,
+		longitude,
+		latitude,
+		radius,
+	)
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+
+	defer rows.Close()
+
+	pages = []common.PageCandidate{}
+	for rows.Next() {
+		page, err := scanPageCandidate(rows)
+		if err != nil {
+			log.Fatal(err)
+		}
+		pages = append(pages, page)
+	}
+	return
+}
+
+func (db DB) GetPageCandidate(pageID int) (page common.PageCandidate, err error) {
+	page, err = scanPageCandidate(db.conn.QueryRow(pageCandidateQuery+"WHERE pages.id = $1;", pageID))
+	if err != nil {
+		log.Printf("Error looking up page (%d): %s\n", pageID, err.Error())
+		err = common.PageNotFound
+	}
+	return
+}
+
+// Finds where a page that was merged away now is.
+func (db DB) GetPageRedirect(categorySlug, pageSlug string) (newCategorySlug, newPageSlug string, err error) {
+	err = db.conn.QueryRow(`
+		SELECT
+			categories.slug,
+			pages.slug
+		FROM
+			page_redirects
+		INNER JOIN
+			pages
+				ON
+					pages.id = page_redirects.page_id
+		INNER JOIN
+			categories
+				ON
+					categories.id = pages.category
+		WHERE
+			page_redirects.category_id = (SELECT id FROM categories WHERE slug = $1)
+			AND page_redirects.slug = $2;`,
+		categorySlug,
+		pageSlug,
+	).Scan(&newCategorySlug, &newPageSlug)
+	if err == sql.ErrNoRows {
+		err = common.PageNotFound
+	} else if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+	}
+	return
+}
+
+// Finds the id of the page a page was merged into.
+func (db DB) GetMergedPageID(oldPageID int) (pageID int, err error) {
+	err = db.conn.QueryRow(
+		"SELECT page_id FROM page_redirects WHERE old_page_id = $1;",
+		oldPageID,
+	).Scan(&pageID)
+	if err == sql.ErrNoRows {
+		err = common.PageNotFound
+	} else if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+	}
+	return
+}
+
+// Moves the posts, subscriptions and pending remote replies of one page to
+// another, deletes it and leaves a redirect where it was. Users following
+// both pages keep one subscription.
+func (db DB) MergePages(fromID, intoID int) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		common.LogError(err)
+		return common.DatabaseError
+	}
+	defer tx.Rollback()
+
+	// A redirect left by an earlier page with the same slug
+	_, err = tx.Exec(
+		"DELETE FROM page_redirects WHERE (category_id, slug) = (SELECT category, slug FROM pages WHERE id = $1);",
+		fromID,
+	)
+	if err != nil {
+		common.LogError(err)
+		return common.DatabaseError
+	}
+
+	for _, query := range []string{
+		"UPDATE posts SET page_id = $2 WHERE page_id = $1;",
+		`INSERT INTO
+			page_subscriptions (user_id, page_id, date_created)
+		SELECT
+			user_id,
+			$2,
+			date_created
+		FROM
+			page_subscriptions
+		WHERE
+			page_id = $1
+			AND user_id NOT IN (
+				SELECT user_id FROM page_subscriptions WHERE page_id = $2
+			);`,
+		"UPDATE ap_replies SET page_id = $2 WHERE page_id = $1;",
+		"UPDATE page_redirects SET page_id = $2 WHERE page_id = $1;",
+		"INSERT INTO page_redirects (category_id, slug, old_page_id, page_id) SELECT category, slug, id, $2 FROM pages WHERE id = $1;",
+	} {
+		if _, err = tx.Exec(query, fromID, intoID); err != nil {
+			common.LogError(err)
+			return common.DatabaseError
+		}
+	}
+
+	result, err := tx.Exec("DELETE FROM pages WHERE id = $1;", fromID)
+	if err != nil {
+		common.LogError(err)
+		return common.DatabaseError
+	}
+	if err = checkSingleRow(result, common.PageNotFound); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		common.LogError(err)
+		return common.DatabaseError
+	}
+	return nil
+}
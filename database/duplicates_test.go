@@ -0,0 +1,100 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/comforme/comforme/common"
+	"github.com/comforme/comforme/dbtest"
+)
+
+func TestPageCandidates(t *testing.T) {
+	conn, _ := dbtest.New(t)
+	db := DB{conn}
+
+	author := dbtest.User(t, conn, "author")
+	medical := dbtest.Category(t, conn, "Medical")
+	food := dbtest.Category(t, conn, "Food")
+	page := func(category int, title, address, website string) int {
+		id := dbtest.Page(t, conn, author, category, title)
+		if _, err := conn.Exec("UPDATE pages SET address = $2, website = $3 WHERE id = $1", id, address, website); err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+
+	sameWord := page(medical, "Downtown Clinic", "", "")
+	samePrefix := page(medical, "PlanedParenthood", "", "")
+	// Titles are only compared within a category
+	page(food, "Planned Parenthood Cafe", "", "")
+	sameAddress := page(food, "Corner Shop", "12 N. Main St, Ste 4", "")
+	sameWebsite := page(food, "Bakery", "", "http://www.example.org/")
+	eyeDoctor := page(medical, "Eye Doctor", "1 Elm Street", "https://example.com")
+	nearby := page(food, "Food Bank", "", "")
+	located := func(id int, latitude, longitude float64) {
+		if _, err := conn.Exec("UPDATE pages SET location = point($2, $3) WHERE id = $1", id, longitude, latitude); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// About 50 meters and a kilometer north
+	located(nearby, 40.71325, -74.0060)
+	located(eyeDoctor, 40.7218, -74.0060)
+
+	title := "Planned Parenthood"
+	address := "12 North Main Street, Suite 4"
+	website := "https://example.org"
+	candidates, err := db.GetPageCandidates(
+		0,
+		medical,
+		common.TitleWords(title+" Downtown"),
+		"pla",
+		common.NormalizeAddress(address),
+		common.NormalizeWebsite(website),
+		&common.Location{Latitude: 40.7128, Longitude: -74.0060},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[int]bool{sameWord: true, samePrefix: true, sameAddress: true, sameWebsite: true, nearby: true}
+	for _, candidate := range candidates {
+		if !want[candidate.Id] {
+			t.Errorf("%q is a candidate", candidate.Title)
+		}
+		delete(want, candidate.Id)
+	}
+	for id := range want {
+		t.Errorf("page %d is not a candidate", id)
+	}
+}
+
+func TestPageLocation(t *testing.T) {
+	conn, _ := dbtest.New(t)
+	db := DB{conn}
+
+	author := dbtest.User(t, conn, "author")
+	category := dbtest.Category(t, conn, "Food")
+	location := &common.Location{Latitude: 40.7128, Longitude: -74.0060}
+	pageID, err := db.NewPage(author, "Bakery", "bakery", "Bread every morning.", "", "", location, category)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	page, err := db.GetPage("food", "bakery")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Location == nil || *page.Location != *location {
+		t.Errorf("got location %v, want %v", page.Location, location)
+	}
+
+	if err := db.UpdatePage(pageID, "Bakery", "Bread every morning.", "", "", nil, category); err != nil {
+		t.Fatal(err)
+	}
+	candidate, err := db.GetPageCandidate(pageID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if candidate.Location != nil {
+		t.Errorf("location %v was not cleared", candidate.Location)
+	}
+}
@@ -0,0 +1,444 @@
+package database
+
+import (
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/comforme/comforme/common"
+)
+
+// Queues a data export for the user unless they already asked for one in
+// the last day.
+func (db DB) NewDataExport(userID int, baseURL string) (exportID int, err error) {
+	err = db.conn.QueryRow(`
+		INSERT INTO data_exports (user_id, base_url)
+		SELECT $1, $2
+		WHERE NOT EXISTS (
+			SELECT 1 FROM data_exports WHERE user_id = $1 AND date_requested > now() - interval '1 day'
+		)
+		RETURNING id;`,
+		userID,
+		baseURL,
+	).Scan(&exportID)
+	if err == sql.ErrNoRows {
+		err = common.ExportRateLimited
+	} else if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+	}
+	return
+}
+
+func (db DB) GetDataExports(userID, limit int) (exports []common.DataExport, err error) {
+	rows, err := db.conn.Query(`
+		SELECT id, user_id, status, base_url, size, date_requested, date_completed, expires
+		FROM data_exports
+		WHERE user_id = $1
+		ORDER BY date_requested DESC
+		LIMIT $2;`,
+		userID,
+		limit,
+	)
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+
+	defer rows.Close()
+
+	exports = []common.DataExport{}
+	for rows.Next() {
+		var row common.DataExport
+		if err := rows.Scan(
+			&row.Id,
+			&row.UserID,
+			&row.Status,
+			&row.BaseURL,
+			&row.Size,
+			&row.DateRequested,
+			&row.DateCompleted,
+			&row.Expires,
+		); err != nil {
+			log.Fatal(err)
+		}
+		exports = append(exports, row)
+	}
+
+	if err = rows.Err(); err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+	}
+	return
+}
+
+// Claims the oldest export waiting to be built. Exports claimed longer than
+// lease ago are claimed again, in case whoever claimed them stopped. found
+// is false if there is nothing to build.
+func (db DB) ClaimDataExport(lease time.Duration) (export common.DataExport, found bool, err error) {
+	err = db.conn.QueryRow(`
+		UPDATE data_exports SET status = 'building', date_claimed = now()
+		WHERE id = (
+			SELECT id
+			FROM data_exports
+			WHERE
+				status = 'pending'
+				OR (status = 'building' AND date_claimed < now() - $1::integer * interval '1 second')
+			ORDER BY date_requested ASC
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, user_id, status, base_url, date_requested;`,
+		int(lease.Seconds()),
+	).Scan(&export.Id, &export.UserID, &export.Status, &export.BaseURL, &export.DateRequested)
+	if err == sql.ErrNoRows {
+		return export, false, nil
+	} else if err != nil {
+		common.LogError(err)
+		return export, false, common.DatabaseError
+	}
+	return export, true, nil
+}
+
+func (db DB) CompleteDataExport(exportID int, archive []byte, expires time.Time) error {
+	_, err := db.conn.Exec(`
+		UPDATE data_exports
+		SET status = 'ready', archive = $2, size = $3, date_completed = now(), expires = $4
+		WHERE id = $1;`,
+		exportID,
+		archive,
+		len(archive),
+		expires,
+	)
+	if err != nil {
+		common.LogError(err)
+		return common.DatabaseError
+	}
+	return nil
+}
+
+func (db DB) FailDataExport(exportID int, lastError string) error {
+	_, err := db.conn.Exec(
+		"UPDATE data_exports SET status = 'failed', last_error = $2, date_completed = now() WHERE id = $1;",
+		exportID,
+		lastError,
+	)
+	if err != nil {
+		common.LogError(err)
+		return common.DatabaseError
+	}
+	return nil
+}
+
+// Returns a ready export's archive. Only the user it belongs to can get it.
+func (db DB) GetDataExportArchive(exportID, userID int) (archive []byte, err error) {
+	err = db.conn.QueryRow(
+		"SELECT archive FROM data_exports WHERE id = $1 AND user_id = $2 AND status = 'ready' AND expires > now();",
+		exportID,
+		userID,
+	).Scan(&archive)
+	if err == sql.ErrNoRows {
+		err = common.ExportNotFound
+	} else if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+	}
+	return
+}
+
+// Drops the archives of exports whose links have expired.
+func (db DB) ExpireDataExports() (expired int64, err error) {
+	result, err := db.conn.Exec(
+		"UPDATE data_exports SET status = 'expired', archive = NULL WHERE status = 'ready' AND expires <= now();",
+	)
+	if err != nil {
+		common.LogError(err)
+		return 0, common.DatabaseError
+	}
+
+	expired, err = result.RowsAffected()
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+	}
+	return
+}
+
+func (db DB) GetExportProfile(userID int) (profile common.ExportProfile, err error) {
+	err = db.conn.QueryRow(
+		"SELECT username, email, role, bio, digest_frequency, join_date FROM users WHERE id = $1;",
+		userID,
+	).Scan(
+		&profile.Username,
+		&profile.Email,
+		&profile.Role,
+		&profile.Bio,
+		&profile.DigestFrequency,
+		&profile.JoinDate,
+	)
+	if err == sql.ErrNoRows {
+		err = common.UserNotFound
+	} else if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+	}
+	return
+}
+
+func (db DB) GetExportPseudonyms(userID int) (pseudonyms []string, err error) {
+	rows, err := db.conn.Query("SELECT name FROM pseudonyms WHERE user_id = $1 ORDER BY name;", userID)
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+
+	defer rows.Close()
+
+	pseudonyms = []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			log.Fatal(err)
+		}
+		pseudonyms = append(pseudonyms, name)
+	}
+
+	if err = rows.Err(); err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+	}
+	return
+}
+
+func (db DB) GetExportMemberships(userID int) (memberships []common.ExportMembership, err error) {
+	rows, err := db.conn.Query(`
+		SELECT communities.id, communities.name, community_memberships.visibility
+		FROM community_memberships
+		JOIN communities ON communities.id = community_memberships.community_id
+		WHERE community_memberships.user_id = $1
+		ORDER BY communities.name;`,
+		userID,
+	)
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+
+	defer rows.Close()
+
+	memberships = []common.ExportMembership{}
+	for rows.Next() {
+		var row common.ExportMembership
+		if err := rows.Scan(&row.CommunityID, &row.Community, &row.Visibility); err != nil {
+			log.Fatal(err)
+		}
+		memberships = append(memberships, row)
+	}
+
+	if err = rows.Err(); err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+	}
+	return
+}
+
+// Lists the user's pages. URL holds the page's path.
+func (db DB) GetExportPages(userID int) (pages []common.ExportPage, err error) {
+	rows, err := db.conn.Query(`
+		SELECT
+			pages.id,
+			pages.title,
+			categories.name,
+			pages.description,
+			pages.address,
+			pages.website,
+			'/page/' || categories.slug || '/' || pages.slug,
+			pages.date_created
+		FROM pages
+		JOIN categories ON categories.id = pages.category
+		WHERE pages.user_id = $1
+		ORDER BY pages.date_created;`,
+		userID,
+	)
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+
+	defer rows.Close()
+
+	pages = []common.ExportPage{}
+	for rows.Next() {
+		var row common.ExportPage
+		if err := rows.Scan(
+			&row.Id,
+			&row.Title,
+			&row.Category,
+			&row.Description,
+			&row.Address,
+			&row.Website,
+			&row.URL,
+			&row.DateCreated,
+		); err != nil {
+			log.Fatal(err)
+		}
+		pages = append(pages, row)
+	}
+
+	if err = rows.Err(); err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+	}
+	return
+}
+
+// Lists all of the user's posts, including anonymous and pseudonymous ones,
+// since they are the user's own. PageURL holds the page's path.
+func (db DB) GetExportPosts(userID int) (posts []common.ExportPost, err error) {
+	rows, err := db.conn.Query(`
+		SELECT
+			posts.id,
+			pages.title,
+			'/page/' || categories.slug || '/' || pages.slug,
+			posts.body,
+			CASE
+				WHEN posts.anonymous THEN 'anonymous'
+				WHEN pseudonyms.id IS NOT NULL THEN pseudonyms.name
+				ELSE users.username
+			END,
+			posts.date_created
+		FROM posts
+		JOIN users ON users.id = posts.user_id
+		JOIN pages ON pages.id = posts.page_id
+		JOIN categories ON categories.id = pages.category
+		LEFT JOIN pseudonyms ON pseudonyms.id = posts.pseudonym_id
+		WHERE posts.user_id = $1
+		ORDER BY posts.date_created;`,
+		userID,
+	)
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+
+	defer rows.Close()
+
+	posts = []common.ExportPost{}
+	for rows.Next() {
+		var row common.ExportPost
+		if err := rows.Scan(
+			&row.Id,
+			&row.PageTitle,
+			&row.PageURL,
+			&row.Body,
+			&row.PostedAs,
+			&row.DateCreated,
+		); err != nil {
+			log.Fatal(err)
+		}
+		posts = append(posts, row)
+	}
+
+	if err = rows.Err(); err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+	}
+	return
+}
+
+func (db DB) GetExportSessions(userID int) (sessions []common.ExportSession, err error) {
+	rows, err := db.conn.Query(
+		"SELECT create_date FROM sessions WHERE user_id = $1 ORDER BY create_date;",
+		userID,
+	)
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+
+	defer rows.Close()
+
+	sessions = []common.ExportSession{}
+	for rows.Next() {
+		var row common.ExportSession
+		if err := rows.Scan(&row.DateCreated); err != nil {
+			log.Fatal(err)
+		}
+		sessions = append(sessions, row)
+	}
+
+	if err = rows.Err(); err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+	}
+	return
+}
+
+// Lists the audit events the user took part in. Other users taking part are
+// never named: events someone else did to the user are included without
+// saying who did it or from where, and reports about the user are left out
+// so that reporters stay anonymous.
+func (db DB) GetExportAuditEvents(userID int) (events []common.AuditEvent, err error) {
+	rows, err := db.conn.Query(`
+		SELECT
+			audit_events.id,
+			audit_events.date_created,
+			audit_events.action,
+			CASE WHEN audit_events.actor_id = $1 THEN $1 ELSE 0 END,
+			CASE WHEN audit_events.actor_id = $1 THEN users.username ELSE '' END,
+			CASE WHEN audit_events.subject_user_id = $1 THEN $1 ELSE 0 END,
+			CASE WHEN audit_events.subject_user_id = $1 THEN users.username ELSE '' END,
+			CASE WHEN audit_events.actor_id = $1 THEN audit_events.ip_address ELSE '' END,
+			CASE WHEN audit_events.actor_id = $1 THEN audit_events.user_agent ELSE '' END,
+			audit_events.details
+		FROM audit_events
+		JOIN users ON users.id = $1
+		WHERE
+			audit_events.actor_id = $1
+			OR (audit_events.subject_user_id = $1 AND audit_events.action <> $2)
+		ORDER BY audit_events.id;`,
+		userID,
+		string(common.AuditUserReported),
+	)
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+
+	defer rows.Close()
+
+	events = []common.AuditEvent{}
+	for rows.Next() {
+		var row common.AuditEvent
+		var action string
+		if err := rows.Scan(
+			&row.Id,
+			&row.Date,
+			&action,
+			&row.ActorID,
+			&row.ActorName,
+			&row.SubjectUserID,
+			&row.SubjectName,
+			&row.IpAddress,
+			&row.UserAgent,
+			&row.Details,
+		); err != nil {
+			log.Fatal(err)
+		}
+		row.Action = common.AuditAction(action)
+		events = append(events, row)
+	}
+
+	if err = rows.Err(); err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+	}
+	return
+}
@@ -0,0 +1,150 @@
+package database
+
+import (
+	"log"
+	"time"
+
+	"github.com/comforme/comforme/common"
+)
+
+// Lists recent pages and posts by people who share communities with the user,
+// best first. Items are scored by the same affinity used to order posts on a
+// page, divided by their age in hours as of asOf. Using a fixed asOf keeps the
+// order stable while the user pages through the feed.
+func (db DB) GetFeed(userID int, asOf, since time.Time, limit, offset int) (items []common.FeedItem, err error) {
+	rows, err := db.conn.Query(`
+		WITH affinities AS (
+			SELECT
+				theirs.user_id,
+				sum(power(0.5, greatest(mine.depth, theirs.depth))) AS affinity
+			FROM
+				visible_inherited_memberships($1) mine,
+				visible_inherited_memberships($1) theirs
+			WHERE
+				mine.user_id = $1
+				AND theirs.community_id = mine.community_id
+				AND theirs.user_id <> $1
+			GROUP BY theirs.user_id
+		),
+		blocked AS (
+			SELECT blocked_user_id FROM user_blocks WHERE user_id = $1 AND kind = 'block'
+		)
+		SELECT
+			feed.author,
+			feed.title,
+			feed.page_slug,
+			feed.category_slug,
+			feed.body,
+			feed.is_page,
+			feed.date_created,
+			feed.pseudonymous,
+			feed.affinity
+		FROM
+			(
+				SELECT
+					CASE
+						WHEN posts.anonymous THEN ''
+						WHEN pseudonyms.id IS NOT NULL THEN pseudonyms.name
+						ELSE authors.username
+					END AS author,
+					pages.title,
+					pages.slug AS page_slug,
+					categories.slug AS category_slug,
+					posts.body,
+					false AS is_page,
+					posts.date_created,
+					pseudonyms.id IS NOT NULL AS pseudonymous,
+					affinities.affinity
+				FROM
+					affinities,
+					users authors,
+					pages,
+					categories,
+					posts
+				LEFT JOIN
+					pseudonyms
+						ON
+							pseudonyms.id = posts.pseudonym_id
+				WHERE
+					posts.user_id = affinities.user_id
+					AND authors.id = posts.user_id
+					AND pages.id = posts.page_id
+					AND categories.id = pages.category
+					AND posts.date_created > $3
+					AND posts.date_created <= $2
+					-- Hiding anonymous and pseudonymous posts of blocked
+					-- users would give away who wrote them
+					AND NOT (
+						posts.pseudonym_id IS NULL
+						AND NOT posts.anonymous
+						AND posts.user_id IN (SELECT blocked_user_id FROM blocked)
+					)
+				UNION ALL
+				SELECT
+					authors.username AS author,
+					pages.title,
+					pages.slug AS page_slug,
+					categories.slug AS category_slug,
+					pages.description AS body,
+					true AS is_page,
+					pages.date_created,
+					false AS pseudonymous,
+					affinities.affinity
+				FROM
+					affinities,
+					users authors,
+					pages,
+					categories
+				WHERE
+					pages.user_id = affinities.user_id
+					AND authors.id = pages.user_id
+					AND categories.id = pages.category
+					AND pages.date_created > $3
+					AND pages.date_created <= $2
+					AND pages.user_id NOT IN (SELECT blocked_user_id FROM blocked)
+			) feed
+		ORDER BY
+			feed.affinity / power(extract(epoch FROM $2 - feed.date_created) / 3600 + 2, 1.5) DESC,
+			feed.date_created DESC
+		LIMIT $4
+		OFFSET $5;
+		`,
+		userID,
+		asOf,
+		since,
+		limit,
+		offset,
+	)
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+
+	defer rows.Close()
+
+	items = []common.FeedItem{}
+	for rows.Next() {
+		var row common.FeedItem
+		if err := rows.Scan(
+			&row.Author,
+			&row.PageTitle,
+			&row.PageSlug,
+			&row.CategorySlug,
+			&row.Body,
+			&row.IsPage,
+			&row.Date,
+			&row.Pseudonymous,
+			&row.Affinity,
+		); err != nil {
+			log.Fatal(err)
+		}
+		items = append(items, row)
+	}
+
+	if err = rows.Err(); err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+	}
+	return
+}
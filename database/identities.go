@@ -0,0 +1,182 @@
+package database
+
+import (
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/comforme/comforme/common"
+)
+
+// Finds the user an external identity is linked to and records that it was
+// used to log in.
+func (db DB) GetIdentityUser(provider, subject string) (userID int, suspended bool, err error) {
+	err = db.conn.QueryRow(`
+		UPDATE user_identities SET last_used = now()
+		FROM users
+		WHERE user_identities.provider = $1 AND user_identities.subject = $2 AND user_identities.user_id = users.id
+		RETURNING users.id, users.suspended;`,
+		provider,
+		subject,
+	).Scan(&userID, &suspended)
+	if err == sql.ErrNoRows {
+		err = common.IdentityNotFound
+	} else if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+	}
+	return
+}
+
+func (db DB) GetUserSuspended(userID int) (suspended bool, err error) {
+	err = db.conn.QueryRow("SELECT suspended FROM users WHERE id = $1;", userID).Scan(&suspended)
+	if err == sql.ErrNoRows {
+		err = common.UserNotFound
+	} else if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+	}
+	return
+}
+
+func (db DB) NewIdentity(userID int, identity common.ExternalIdentity) (err error) {
+	var count int
+	err = db.conn.QueryRow(
+		"SELECT count(*) FROM user_identities WHERE provider = $1 AND subject = $2;",
+		identity.Provider,
+		identity.Subject,
+	).Scan(&count)
+	if err != nil {
+		common.LogError(err)
+		return common.DatabaseError
+	}
+	if count > 0 {
+		return common.IdentityInUse
+	}
+
+	err = db.conn.QueryRow(
+		"SELECT count(*) FROM user_identities WHERE user_id = $1 AND provider = $2;",
+		userID,
+		identity.Provider,
+	).Scan(&count)
+	if err != nil {
+		common.LogError(err)
+		return common.DatabaseError
+	}
+	if count > 0 {
+		return common.ProviderAlreadyLinked
+	}
+
+	_, err = db.conn.Exec(
+		"INSERT INTO user_identities (user_id, provider, subject, email, last_used) VALUES ($1, $2, $3, $4, now());",
+		userID,
+		identity.Provider,
+		identity.Subject,
+		identity.Email,
+	)
+	if err != nil {
+		log.Printf("Error linking %s identity for user (%d): %s\n", identity.Provider, userID, err.Error())
+		err = common.DatabaseError
+	}
+	return
+}
+
+func (db DB) GetIdentities(userID int) (identities []common.Identity, err error) {
+	rows, err := db.conn.Query(
+		"SELECT id, provider, email, date_created, last_used FROM user_identities WHERE user_id = $1 ORDER BY provider ASC;",
+		userID,
+	)
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+
+	defer rows.Close()
+
+	identities = []common.Identity{}
+	for rows.Next() {
+		var row common.Identity
+		var lastUsed *time.Time
+		if err := rows.Scan(&row.Id, &row.Provider, &row.Email, &row.DateCreated, &lastUsed); err != nil {
+			log.Fatal(err)
+		}
+		row.LastUsed = lastUsed
+		identities = append(identities, row)
+	}
+
+	if err = rows.Err(); err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+	}
+	return
+}
+
+// Unlinks an identity. Returns the provider it was from.
+func (db DB) DeleteIdentity(userID, identityID int) (provider string, err error) {
+	err = db.conn.QueryRow(
+		"DELETE FROM user_identities WHERE id = $1 AND user_id = $2 RETURNING provider;",
+		identityID,
+		userID,
+	).Scan(&provider)
+	if err == sql.ErrNoRows {
+		err = common.IdentityNotFound
+	} else if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+	}
+	return
+}
+
+func (db DB) NewOIDCLogin(state string, login common.OIDCLogin) (err error) {
+	var linkUserID sql.NullInt64
+	if login.LinkUserID != 0 {
+		linkUserID = sql.NullInt64{Int64: int64(login.LinkUserID), Valid: true}
+	}
+
+	_, err = db.conn.Exec(`
+		INSERT INTO oidc_logins (state_hash, provider, nonce, code_verifier, link_user_id, return_to, expires)
+		VALUES ($1, $2, $3, $4, $5, $6, $7);`,
+		common.HashToken(state),
+		login.Provider,
+		login.Nonce,
+		login.CodeVerifier,
+		linkUserID,
+		login.ReturnTo,
+		login.Expires,
+	)
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+	}
+	return
+}
+
+// Looks up a login in progress and deletes it, along with any that expired,
+// so that each state can only be used once.
+func (db DB) TakeOIDCLogin(state string) (login common.OIDCLogin, err error) {
+	_, err = db.conn.Exec("DELETE FROM oidc_logins WHERE expires < now();")
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+
+	var linkUserID sql.NullInt64
+	err = db.conn.QueryRow(`
+		DELETE FROM oidc_logins WHERE state_hash = $1
+		RETURNING provider, nonce, code_verifier, link_user_id, return_to, expires;`,
+		common.HashToken(state),
+	).Scan(&login.Provider, &login.Nonce, &login.CodeVerifier, &linkUserID, &login.ReturnTo, &login.Expires)
+	if err == sql.ErrNoRows {
+		err = common.InvalidLink
+		return
+	} else if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+
+	login.LinkUserID = int(linkUserID.Int64)
+	return
+}
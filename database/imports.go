@@ -0,0 +1,55 @@
+package database
+
+import (
+	"database/sql"
+
+	"github.com/comforme/comforme/common"
+)
+
+// Looks for a page with the same title, address and website, ignoring case
+// and surrounding spaces.
+func (db DB) FindDuplicatePage(title, address, website string) (categorySlug, pageSlug string, found bool, err error) {
+	err = db.conn.QueryRow(`
+		SELECT
+			categories.slug,
+			pages.slug
+		FROM
+			pages
+		INNER JOIN
+			categories
+				ON
+					categories.id = pages.category
+		WHERE
+			lower(trim(pages.title)) = lower(trim($1))
+			AND lower(trim(pages.address)) = lower(trim($2))
+			AND lower(trim(pages.website)) = lower(trim($3))
+		ORDER BY pages.id
+		LIMIT 1;`,
+		title,
+		address,
+		website,
+	).Scan(&categorySlug, &pageSlug)
+	if err == sql.ErrNoRows {
+		err = nil
+		return
+	} else if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+	found = true
+	return
+}
+
+func (db DB) PageSlugTaken(categoryID int, slug string) (taken bool, err error) {
+	err = db.conn.QueryRow(
+		"SELECT EXISTS (SELECT 1 FROM pages WHERE category = $1 AND slug = $2);",
+		categoryID,
+		slug,
+	).Scan(&taken)
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+	}
+	return
+}
@@ -0,0 +1,30 @@
+package database
+
+import (
+	"log"
+
+	"github.com/comforme/comforme/common"
+)
+
+func (db DB) GetLocale(userID int) (locale string, err error) {
+	err = db.conn.QueryRow("SELECT locale FROM users WHERE id = $1", userID).Scan(&locale)
+	if err != nil {
+		log.Printf("Error looking up locale of user (%d): %s\n", userID, err.Error())
+		err = common.UserNotFound
+	}
+	return
+}
+
+func (db DB) SetLocale(userID int, locale string) error {
+	result, err := db.conn.Exec(
+		"UPDATE users SET locale = $2 WHERE id = $1;",
+		userID,
+		locale,
+	)
+	if err != nil {
+		common.LogError(err)
+		return common.DatabaseError
+	}
+
+	return checkSingleRow(result, common.UserNotFound)
+}
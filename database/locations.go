@@ -0,0 +1,25 @@
+package database
+
+import (
+	"database/sql"
+
+	"github.com/comforme/comforme/common"
+)
+
+// Page locations are stored as points of longitude and latitude.
+
+// The arguments for point($1, $2), which is NULL without a location.
+func locationArgs(location *common.Location) (longitude, latitude sql.NullFloat64) {
+	if location == nil {
+		return
+	}
+	return sql.NullFloat64{Float64: location.Longitude, Valid: true}, sql.NullFloat64{Float64: location.Latitude, Valid: true}
+}
+
+// The location read from location[0] and location[1], if there is one.
+func scannedLocation(longitude, latitude sql.NullFloat64) *common.Location {
+	if !longitude.Valid || !latitude.Valid {
+		return nil
+	}
+	return &common.Location{Latitude: latitude.Float64, Longitude: longitude.Float64}
+}
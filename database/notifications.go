@@ -0,0 +1,216 @@
+package database
+
+import (
+	"log"
+
+	"github.com/comforme/comforme/common"
+)
+
+func (db DB) SubscribeToPage(userID, pageID int) error {
+	_, err := db.conn.Exec(`
+		INSERT INTO
+			page_subscriptions (user_id, page_id)
+		SELECT
+			$1,
+			$2
+		WHERE
+			NOT EXISTS (
+				SELECT 1 FROM page_subscriptions WHERE user_id = $1 AND page_id = $2
+			);
+		`,
+		userID,
+		pageID,
+	)
+	if err != nil {
+		log.Printf("Error subscribing user (%d) to page (%d): %s\n", userID, pageID, err.Error())
+		return common.DatabaseError
+	}
+	return nil
+}
+
+func (db DB) UnsubscribeFromPage(userID, pageID int) error {
+	_, err := db.conn.Exec(
+		"DELETE FROM page_subscriptions WHERE user_id = $1 AND page_id = $2;",
+		userID,
+		pageID,
+	)
+	if err != nil {
+		log.Printf("Error unsubscribing user (%d) from page (%d): %s\n", userID, pageID, err.Error())
+		return common.DatabaseError
+	}
+	return nil
+}
+
+func (db DB) IsSubscribed(userID, pageID int) (subscribed bool, err error) {
+	err = db.conn.QueryRow(
+		"SELECT count(*) > 0 FROM page_subscriptions WHERE user_id = $1 AND page_id = $2",
+		userID,
+		pageID,
+	).Scan(&subscribed)
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+	}
+	return
+}
+
+// Notifies everyone following the post's page except its author and anyone
+// who has blocked the author of a post made under their username.
+func (db DB) NotifySubscribers(postID int) error {
+	_, err := db.conn.Exec(`
+		INSERT INTO
+			notifications (user_id, post_id)
+		SELECT
+			page_subscriptions.user_id,
+			posts.id
+		FROM
+			posts,
+			page_subscriptions
+		WHERE
+			posts.id = $1
+			AND page_subscriptions.page_id = posts.page_id
+			AND page_subscriptions.user_id IS DISTINCT FROM posts.user_id
+			-- Leaving out blockers of the author of an anonymous or
+			-- pseudonymous post would give away who wrote it
+			AND NOT (
+				posts.pseudonym_id IS NULL
+				AND NOT posts.anonymous
+				AND EXISTS (
+					SELECT 1
+					FROM user_blocks
+					WHERE
+						user_blocks.user_id = page_subscriptions.user_id
+						AND user_blocks.blocked_user_id = posts.user_id
+						AND user_blocks.kind = 'block'
+				)
+			);
+		`,
+		postID,
+	)
+	if err != nil {
+		log.Printf("Error notifying subscribers of post (%d): %s\n", postID, err.Error())
+		return common.DatabaseError
+	}
+	return nil
+}
+
+func (db DB) GetNotifications(userID, limit int) (notifications []common.Notification, err error) {
+	rows, err := db.conn.Query(`
+		SELECT
+			notifications.id,
+			posts.id,
+			CASE
+				WHEN posts.anonymous THEN ''
+				WHEN pseudonyms.id IS NOT NULL THEN pseudonyms.name
+				WHEN posts.remote_author IS NOT NULL THEN posts.remote_author
+				ELSE authors.username
+			END AS author,
+			pages.title,
+			pages.slug,
+			categories.slug,
+			notifications.date_created,
+			notifications.date_read IS NOT NULL
+		FROM
+			notifications,
+			pages,
+			categories,
+			posts
+		LEFT JOIN
+			pseudonyms
+				ON
+					pseudonyms.id = posts.pseudonym_id
+		-- Replies from other servers have no local author
+		LEFT JOIN
+			users authors
+				ON
+					authors.id = posts.user_id
+		WHERE
+			notifications.user_id = $1
+			AND posts.id = notifications.post_id
+			AND pages.id = posts.page_id
+			AND categories.id = pages.category
+		ORDER BY notifications.id DESC
+		LIMIT $2;
+		`,
+		userID,
+		limit,
+	)
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+
+	defer rows.Close()
+
+	notifications = []common.Notification{}
+	for rows.Next() {
+		var row common.Notification
+		if err := rows.Scan(
+			&row.Id,
+			&row.PostID,
+			&row.Author,
+			&row.PageTitle,
+			&row.PageSlug,
+			&row.CategorySlug,
+			&row.Date,
+			&row.Read,
+		); err != nil {
+			log.Fatal(err)
+		}
+		notifications = append(notifications, row)
+	}
+
+	if err = rows.Err(); err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+	}
+	return
+}
+
+func (db DB) CountUnreadNotifications(userID int) (unread int, err error) {
+	err = db.conn.QueryRow(
+		"SELECT count(*) FROM notifications WHERE user_id = $1 AND date_read IS NULL",
+		userID,
+	).Scan(&unread)
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+	}
+	return
+}
+
+func (db DB) MarkNotificationRead(userID, notificationID int) error {
+	result, err := db.conn.Exec(
+		"UPDATE notifications SET date_read = now() WHERE id = $2 AND user_id = $1 AND date_read IS NULL;",
+		userID,
+		notificationID,
+	)
+	if err != nil {
+		common.LogError(err)
+		return common.DatabaseError
+	}
+
+	return checkSingleRow(result, common.NotificationNotFound)
+}
+
+func (db DB) MarkAllNotificationsRead(userID int) (marked int, err error) {
+	result, err := db.conn.Exec(
+		"UPDATE notifications SET date_read = now() WHERE user_id = $1 AND date_read IS NULL;",
+		userID,
+	)
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+	marked = int(rows)
+	return
+}
@@ -0,0 +1,194 @@
+package database
+
+import (
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/comforme/comforme/common"
+)
+
+func (db DB) NewPasskeyChallenge(challenge string, userID int, expires time.Time) (err error) {
+	var user sql.NullInt64
+	if userID != 0 {
+		user = sql.NullInt64{Int64: int64(userID), Valid: true}
+	}
+
+	_, err = db.conn.Exec("DELETE FROM passkey_challenges WHERE expires < now();")
+	if err != nil {
+		common.LogError(err)
+		return common.DatabaseError
+	}
+
+	_, err = db.conn.Exec(
+		"INSERT INTO passkey_challenges (challenge_hash, user_id, expires) VALUES ($1, $2, $3);",
+		common.HashToken(challenge),
+		user,
+		expires,
+	)
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+	}
+	return
+}
+
+// Deletes a challenge that has been answered. Fails unless it was issued to
+// userID (0 for logins) and has not expired.
+func (db DB) TakePasskeyChallenge(challenge string, userID int) error {
+	result, err := db.conn.Exec(
+		"DELETE FROM passkey_challenges WHERE challenge_hash = $1 AND COALESCE(user_id, 0) = $2 AND expires >= now();",
+		common.HashToken(challenge),
+		userID,
+	)
+	if err != nil {
+		common.LogError(err)
+		return common.DatabaseError
+	}
+
+	return checkSingleRow(result, common.PasskeyVerifyFailed)
+}
+
+func (db DB) NewPasskey(userID int, name string, credentialID, publicKey []byte, signCount uint32) (err error) {
+	var count int
+	err = db.conn.QueryRow("SELECT count(*) FROM passkeys WHERE credential_id = $1;", credentialID).Scan(&count)
+	if err != nil {
+		common.LogError(err)
+		return common.DatabaseError
+	}
+	if count > 0 {
+		return common.PasskeyAlreadyAdded
+	}
+
+	_, err = db.conn.Exec(
+		"INSERT INTO passkeys (user_id, credential_id, public_key, sign_count, name) VALUES ($1, $2, $3, $4, $5);",
+		userID,
+		credentialID,
+		publicKey,
+		int64(signCount),
+		name,
+	)
+	if err != nil {
+		log.Printf("Error adding passkey (%s) for user (%d): %s\n", name, userID, err.Error())
+		err = common.DatabaseError
+	}
+	return
+}
+
+func (db DB) GetPasskeys(userID int) (passkeys []common.Passkey, err error) {
+	rows, err := db.conn.Query(
+		"SELECT id, name, date_created, last_used FROM passkeys WHERE user_id = $1 ORDER BY date_created ASC;",
+		userID,
+	)
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+
+	defer rows.Close()
+
+	passkeys = []common.Passkey{}
+	for rows.Next() {
+		var row common.Passkey
+		var lastUsed *time.Time
+		if err := rows.Scan(&row.Id, &row.Name, &row.DateCreated, &lastUsed); err != nil {
+			log.Fatal(err)
+		}
+		row.LastUsed = lastUsed
+		passkeys = append(passkeys, row)
+	}
+
+	if err = rows.Err(); err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+	}
+	return
+}
+
+// The credential IDs of a user's passkeys, so the browser does not register
+// the same authenticator twice.
+func (db DB) GetPasskeyCredentialIDs(userID int) (credentialIDs [][]byte, err error) {
+	rows, err := db.conn.Query("SELECT credential_id FROM passkeys WHERE user_id = $1;", userID)
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+
+	defer rows.Close()
+
+	credentialIDs = [][]byte{}
+	for rows.Next() {
+		var credentialID []byte
+		if err := rows.Scan(&credentialID); err != nil {
+			log.Fatal(err)
+		}
+		credentialIDs = append(credentialIDs, credentialID)
+	}
+
+	if err = rows.Err(); err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+	}
+	return
+}
+
+func (db DB) GetPasskeyCredential(credentialID []byte) (credential common.PasskeyCredential, err error) {
+	var signCount int64
+	err = db.conn.QueryRow(
+		"SELECT id, user_id, public_key, sign_count FROM passkeys WHERE credential_id = $1;",
+		credentialID,
+	).Scan(&credential.Id, &credential.UserID, &credential.PublicKey, &signCount)
+	if err == sql.ErrNoRows {
+		err = common.PasskeyNotFound
+	} else if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+	}
+	credential.SignCount = uint32(signCount)
+	return
+}
+
+func (db DB) UpdatePasskeyUsed(passkeyID int, signCount uint32) error {
+	result, err := db.conn.Exec(
+		"UPDATE passkeys SET sign_count = $2, last_used = now() WHERE id = $1;",
+		passkeyID,
+		int64(signCount),
+	)
+	if err != nil {
+		common.LogError(err)
+		return common.DatabaseError
+	}
+
+	return checkSingleRow(result, common.PasskeyNotFound)
+}
+
+func (db DB) RenamePasskey(userID, passkeyID int, name string) error {
+	result, err := db.conn.Exec(
+		"UPDATE passkeys SET name = $3 WHERE id = $1 AND user_id = $2;",
+		passkeyID,
+		userID,
+		name,
+	)
+	if err != nil {
+		common.LogError(err)
+		return common.DatabaseError
+	}
+
+	return checkSingleRow(result, common.PasskeyNotFound)
+}
+
+func (db DB) DeletePasskey(userID, passkeyID int) error {
+	result, err := db.conn.Exec(
+		"DELETE FROM passkeys WHERE id = $1 AND user_id = $2;",
+		passkeyID,
+		userID,
+	)
+	if err != nil {
+		common.LogError(err)
+		return common.DatabaseError
+	}
+
+	return checkSingleRow(result, common.PasskeyNotFound)
+}
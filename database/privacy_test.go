@@ -0,0 +1,227 @@
+package database
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/comforme/comforme/dbtest"
+)
+
+// Members of one community, one for each membership visibility, and two users
+// looking at them: an outsider and a public member.
+type membershipFixture struct {
+	conn      *sql.DB
+	db        DB
+	community int
+
+	public, membersOnly, private int // Members, by the visibility they chose
+	outsider, insider            int // Viewers
+}
+
+func newMembershipFixture(t *testing.T) (f membershipFixture) {
+	f.conn, _ = dbtest.New(t)
+	f.db = DB{f.conn}
+
+	f.community = dbtest.Community(t, f.conn, "Gardeners")
+	f.public = dbtest.User(t, f.conn, "public")
+	f.membersOnly = dbtest.User(t, f.conn, "membersonly")
+	f.private = dbtest.User(t, f.conn, "private")
+	f.outsider = dbtest.User(t, f.conn, "outsider")
+	f.insider = dbtest.User(t, f.conn, "insider")
+
+	dbtest.Join(t, f.conn, f.public, f.community, "public")
+	dbtest.Join(t, f.conn, f.membersOnly, f.community, "members")
+	dbtest.Join(t, f.conn, f.private, f.community, "private")
+	dbtest.Join(t, f.conn, f.insider, f.community, "public")
+	return
+}
+
+func (f membershipFixture) visibleMembers(t *testing.T, viewerID int) map[int]bool {
+	rows, err := f.conn.Query(
+		"SELECT user_id FROM visible_memberships($1) WHERE community_id = $2",
+		viewerID,
+		f.community,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	members := map[int]bool{}
+	for rows.Next() {
+		var userID int
+		if err := rows.Scan(&userID); err != nil {
+			t.Fatal(err)
+		}
+		members[userID] = true
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return members
+}
+
+func TestVisibleMemberships(t *testing.T) {
+	f := newMembershipFixture(t)
+
+	tests := []struct {
+		name    string
+		viewer  int
+		visible []int
+		hidden  []int
+	}{
+		{"logged out", 0, []int{f.public, f.insider}, []int{f.membersOnly, f.private}},
+		{"outsider", f.outsider, []int{f.public, f.insider}, []int{f.membersOnly, f.private}},
+		{"fellow member", f.insider, []int{f.public, f.insider, f.membersOnly}, []int{f.private}},
+		{"private member", f.private, []int{f.public, f.insider, f.membersOnly, f.private}, nil},
+	}
+	for _, test := range tests {
+		members := f.visibleMembers(t, test.viewer)
+		for _, userID := range test.visible {
+			if !members[userID] {
+				t.Errorf("%s: member %d is hidden", test.name, userID)
+			}
+		}
+		for _, userID := range test.hidden {
+			if members[userID] {
+				t.Errorf("%s: member %d is visible", test.name, userID)
+			}
+		}
+	}
+}
+
+func TestProfileCommunitiesFollowVisibility(t *testing.T) {
+	f := newMembershipFixture(t)
+
+	tests := []struct {
+		name           string
+		viewer, member int
+		visible        bool
+	}{
+		{"own private membership", f.private, f.private, true},
+		{"private membership to a fellow member", f.insider, f.private, false},
+		{"members only membership to a fellow member", f.insider, f.membersOnly, true},
+		{"members only membership to an outsider", f.outsider, f.membersOnly, false},
+		{"public membership to an outsider", f.outsider, f.public, true},
+	}
+	for _, test := range tests {
+		communities, err := f.db.GetVisibleCommunities(test.viewer, test.member)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if visible := len(communities) == 1; visible != test.visible {
+			t.Errorf("%s: got %d communities", test.name, len(communities))
+		}
+	}
+}
+
+func TestCommunityMemberCountFollowsVisibility(t *testing.T) {
+	f := newMembershipFixture(t)
+
+	tests := []struct {
+		name   string
+		viewer int
+		count  int
+	}{
+		{"outsider", f.outsider, 2},
+		{"fellow member", f.insider, 3},
+		{"private member", f.private, 4},
+	}
+	for _, test := range tests {
+		community, err := f.db.GetCommunity(test.viewer, f.community)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if community.MemberCount != test.count {
+			t.Errorf("%s: got %d members, want %d", test.name, community.MemberCount, test.count)
+		}
+	}
+}
+
+func TestCommunityContributionsFollowVisibility(t *testing.T) {
+	f := newMembershipFixture(t)
+	category := dbtest.Category(t, f.conn, "Food")
+	dbtest.Page(t, f.conn, f.public, category, "Public page")
+	dbtest.Page(t, f.conn, f.membersOnly, category, "Members page")
+	dbtest.Page(t, f.conn, f.private, category, "Private page")
+
+	tests := []struct {
+		name   string
+		viewer int
+		titles map[string]bool
+	}{
+		{"outsider", f.outsider, map[string]bool{"Public page": true}},
+		{"fellow member", f.insider, map[string]bool{"Public page": true, "Members page": true}},
+	}
+	for _, test := range tests {
+		contributions, err := f.db.GetCommunityContributions(test.viewer, f.community, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(contributions) != len(test.titles) {
+			t.Errorf("%s: got %d contributions, want %d", test.name, len(contributions), len(test.titles))
+		}
+		for _, contribution := range contributions {
+			if !test.titles[contribution.PageTitle] {
+				t.Errorf("%s: %q is listed", test.name, contribution.PageTitle)
+			}
+		}
+	}
+}
+
+func TestFeedIgnoresPrivateMemberships(t *testing.T) {
+	f := newMembershipFixture(t)
+	category := dbtest.Category(t, f.conn, "Food")
+	dbtest.Page(t, f.conn, f.membersOnly, category, "Members page")
+	dbtest.Page(t, f.conn, f.private, category, "Private page")
+
+	now := time.Now()
+	items, err := f.db.GetFeed(f.insider, now.Add(time.Hour), now.Add(-24*time.Hour), 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0].PageTitle != "Members page" {
+		t.Errorf("got feed %+v, want only the members only member's page", items)
+	}
+}
+
+func TestSuggestCommunitiesIgnoresPrivateMemberships(t *testing.T) {
+	f := newMembershipFixture(t)
+	// Each member is also in a community of their own, which the others could
+	// be suggested through their shared one.
+	publicOther := dbtest.Community(t, f.conn, "Cyclists")
+	privateOther := dbtest.Community(t, f.conn, "Knitters")
+	dbtest.Join(t, f.conn, f.public, publicOther, "public")
+	dbtest.Join(t, f.conn, f.private, privateOther, "public")
+
+	suggestions, err := f.db.SuggestCommunities(f.insider, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(suggestions) != 1 || suggestions[0].Id != publicOther {
+		t.Errorf("got suggestions %+v, want only community %d", suggestions, publicOther)
+	}
+}
+
+func TestPostCommonCommunitiesIgnorePrivateMemberships(t *testing.T) {
+	f := newMembershipFixture(t)
+	category := dbtest.Category(t, f.conn, "Food")
+	page := dbtest.Page(t, f.conn, f.outsider, category, "Bakery")
+	publicPost := dbtest.Post(t, f.conn, f.public, page, "Public member's post")
+	privatePost := dbtest.Post(t, f.conn, f.private, page, "Private member's post")
+
+	posts, err := f.db.GetPostsForPage(f.insider, page)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[int]int{publicPost: 1, privatePost: 0}
+	for _, post := range posts {
+		if post.CommonCategories != want[post.Id] {
+			t.Errorf("post %d: got %d communities in common, want %d", post.Id, post.CommonCategories, want[post.Id])
+		}
+	}
+	if len(posts) != len(want) {
+		t.Errorf("got %d posts, want %d", len(posts), len(want))
+	}
+}
@@ -0,0 +1,231 @@
+package database
+
+import (
+	"log"
+
+	"github.com/comforme/comforme/common"
+)
+
+func (db DB) GetProfile(username string) (profile common.Profile, err error) {
+	err = db.conn.QueryRow(
+		"SELECT id, username, bio FROM users WHERE username = $1 AND NOT tombstone",
+		username,
+	).Scan(
+		&profile.Id,
+		&profile.Username,
+		&profile.Bio,
+	)
+	if err != nil {
+		log.Printf("Error looking up profile of user (%s): %s\n", username, err.Error())
+		err = common.UserNotFound
+	}
+	return
+}
+
+func (db DB) SetBio(userID int, bio string) error {
+	result, err := db.conn.Exec("UPDATE users SET bio = $2 WHERE id = $1;", userID, bio)
+	if err != nil {
+		common.LogError(err)
+		return common.DatabaseError
+	}
+
+	return checkSingleRow(result, common.UserNotFound)
+}
+
+// Lists the communities of userID that viewerID is allowed to see.
+func (db DB) GetVisibleCommunities(viewerID, userID int) (communities []common.Community, err error) {
+	rows, err := db.conn.Query(`
+		SELECT
+			communities.id,
+			communities.name
+		FROM
+			visible_memberships($1) memberships,
+			communities
+		WHERE
+			memberships.user_id = $2
+			AND communities.id = memberships.community_id
+			AND communities.status = 'approved'
+		ORDER BY communities.name ASC;
+		`,
+		viewerID,
+		userID,
+	)
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+
+	defer rows.Close()
+
+	communities = []common.Community{}
+	for rows.Next() {
+		var row common.Community
+		if err := rows.Scan(&row.Id, &row.Name); err != nil {
+			log.Fatal(err)
+		}
+		communities = append(communities, row)
+	}
+
+	if err = rows.Err(); err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+	}
+	return
+}
+
+// Pages and posts made under the user's own name, newest first.
+func (db DB) GetUserContributions(userID, limit, offset int) (contributions []common.Contribution, err error) {
+	rows, err := db.conn.Query(`
+		SELECT
+			contributions.author,
+			contributions.title,
+			contributions.page_slug,
+			contributions.category_slug,
+			contributions.body,
+			contributions.is_page,
+			contributions.date_created
+		FROM
+			(
+				SELECT
+					users.username AS author,
+					pages.title,
+					pages.slug AS page_slug,
+					categories.slug AS category_slug,
+					posts.body,
+					false AS is_page,
+					posts.date_created
+				FROM
+					posts,
+					pages,
+					categories,
+					users
+				WHERE
+					posts.page_id = pages.id
+					AND pages.category = categories.id
+					AND posts.user_id = users.id
+					AND NOT posts.anonymous
+					AND posts.pseudonym_id IS NULL
+					AND users.id = $1
+				UNION ALL
+				SELECT
+					users.username AS author,
+					pages.title,
+					pages.slug AS page_slug,
+					categories.slug AS category_slug,
+					pages.description AS body,
+					true AS is_page,
+					pages.date_created
+				FROM
+					pages,
+					categories,
+					users
+				WHERE
+					pages.category = categories.id
+					AND pages.user_id = users.id
+					AND users.id = $1
+			) contributions
+		ORDER BY contributions.date_created DESC
+		LIMIT $2
+		OFFSET $3;
+		`,
+		userID,
+		limit,
+		offset,
+	)
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+
+	return scanContributions(rows)
+}
+
+func (db DB) NewUserReport(reporterID, reportedID int, reason string) error {
+	_, err := db.conn.Exec(
+		"INSERT INTO user_reports (reporter_id, reported_user_id, reason) VALUES ($1, $2, $3);",
+		reporterID,
+		reportedID,
+		reason,
+	)
+	if err != nil {
+		log.Printf("Error reporting user (%d) by user (%d): %s\n", reportedID, reporterID, err.Error())
+		return common.DatabaseError
+	}
+	return nil
+}
+
+func (db DB) GetOpenReports() (reports []common.UserReport, err error) {
+	rows, err := db.conn.Query(`
+		SELECT
+			user_reports.id,
+			reporters.username,
+			reported.id,
+			reported.username,
+			user_reports.reason,
+			user_reports.date_created
+		FROM
+			user_reports,
+			users reporters,
+			users reported
+		WHERE
+			user_reports.date_resolved IS NULL
+			AND reporters.id = user_reports.reporter_id
+			AND reported.id = user_reports.reported_user_id
+		ORDER BY user_reports.date_created ASC;
+		`,
+	)
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+
+	defer rows.Close()
+
+	reports = []common.UserReport{}
+	for rows.Next() {
+		var row common.UserReport
+		if err := rows.Scan(
+			&row.Id,
+			&row.ReporterName,
+			&row.ReportedUserID,
+			&row.ReportedName,
+			&row.Reason,
+			&row.DateCreated,
+		); err != nil {
+			log.Fatal(err)
+		}
+		reports = append(reports, row)
+	}
+
+	if err = rows.Err(); err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+	}
+	return
+}
+
+// Marks an open report resolved and returns the id of the reported user.
+func (db DB) ResolveReport(reportID, moderatorID int) (reportedID int, err error) {
+	err = db.conn.QueryRow(`
+		UPDATE
+			user_reports
+		SET
+			resolved_by = $2,
+			date_resolved = now()
+		WHERE
+			id = $1
+			AND date_resolved IS NULL
+		RETURNING reported_user_id;
+		`,
+		reportID,
+		moderatorID,
+	).Scan(&reportedID)
+	if err != nil {
+		log.Printf("Error resolving report (%d): %s\n", reportID, err.Error())
+		err = common.ReportNotFound
+	}
+	return
+}
@@ -0,0 +1,54 @@
+package database
+
+import (
+	"log"
+
+	"github.com/comforme/comforme/common"
+)
+
+func (db DB) GetPseudonyms(userID int) (pseudonyms []common.Pseudonym, err error) {
+	rows, err := db.conn.Query(
+		"SELECT id, name FROM pseudonyms WHERE user_id = $1 ORDER BY name ASC;",
+		userID,
+	)
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+
+	defer rows.Close()
+
+	pseudonyms = []common.Pseudonym{}
+	for rows.Next() {
+		var row common.Pseudonym
+		if err := rows.Scan(&row.Id, &row.Name); err != nil {
+			log.Fatal(err)
+		}
+		pseudonyms = append(pseudonyms, row)
+	}
+
+	if err = rows.Err(); err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+	}
+	return
+}
+
+func (db DB) NewPseudonym(userID int, name string) (err error) {
+	err = db.checkUsernameInUse(name)
+	if err != nil {
+		return
+	}
+
+	_, err = db.conn.Exec(
+		"INSERT INTO pseudonyms (user_id, name) VALUES ($1, $2);",
+		userID,
+		name,
+	)
+	if err != nil {
+		log.Printf("Error adding pseudonym (%s) for user (%d): %s\n", name, userID, err.Error())
+		err = common.DatabaseError
+	}
+	return
+}
@@ -0,0 +1,134 @@
+package database
+
+import (
+	"log"
+
+	"github.com/comforme/comforme/common"
+)
+
+func (db DB) SetRole(email string, role common.Role) error {
+	result, err := db.conn.Exec(
+		"UPDATE users SET role = $2 WHERE email = $1;",
+		email,
+		role.String(),
+	)
+	if err != nil {
+		common.LogError(err)
+		return common.DatabaseError
+	}
+
+	return checkSingleRow(result, common.InvalidEmail)
+}
+
+func (db DB) GetUserIDByEmail(email string) (userID int, err error) {
+	err = db.conn.QueryRow("SELECT id FROM users WHERE email = $1", email).Scan(&userID)
+	if err != nil {
+		log.Printf("Error looking up user with email (%s): %s\n", email, err.Error())
+		err = common.InvalidEmail
+	}
+	return
+}
+
+func (db DB) AddCommunityModerator(userID, communityID int) (err error) {
+	_, err = db.conn.Exec(
+		"INSERT INTO community_moderators (user_id, community_id) VALUES ($1, $2)",
+		userID,
+		communityID,
+	)
+	if err != nil {
+		log.Printf("Error making user (%d) a moderator of community (%d): %s\n", userID, communityID, err.Error())
+		err = common.DatabaseError
+	}
+	return
+}
+
+func (db DB) IsCommunityModerator(userID, communityID int) (isModerator bool, err error) {
+	err = db.conn.QueryRow(
+		"SELECT count(*) > 0 FROM community_moderators WHERE user_id = $1 AND community_id = $2",
+		userID,
+		communityID,
+	).Scan(&isModerator)
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+	}
+	return
+}
+
+// Authors whose pages and posts a community moderator may act on: members of
+// the communities they moderate, as far as the moderator can see.
+const communityModeratedAuthors = `
+	SELECT
+		memberships.user_id
+	FROM
+		community_moderators,
+		visible_memberships($1) memberships
+	WHERE
+		community_moderators.user_id = $1
+		AND memberships.community_id = community_moderators.community_id
+`
+
+func (db DB) IsCommunityModeratorOfPage(moderatorID, pageID int) (isModerator bool, err error) {
+	err = db.conn.QueryRow(
+		"SELECT count(*) > 0 FROM pages WHERE id = $2 AND user_id IN ("+communityModeratedAuthors+")",
+		moderatorID,
+		pageID,
+	).Scan(&isModerator)
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+	}
+	return
+}
+
+// Anonymous and pseudonymous posts are left out, as being able to act on one
+// would tell the moderator that its author is a member.
+func (db DB) IsCommunityModeratorOfPost(moderatorID, postID int) (isModerator bool, err error) {
+	err = db.conn.QueryRow(`
+		SELECT count(*) > 0
+		FROM posts
+		WHERE
+			id = $2
+			AND NOT anonymous
+			AND pseudonym_id IS NULL
+			AND user_id IN (`+communityModeratedAuthors+`)`,
+		moderatorID,
+		postID,
+	).Scan(&isModerator)
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+	}
+	return
+}
+
+// The posts on a page that a community moderator may remove.
+func (db DB) GetCommunityModeratedPosts(moderatorID, pageID int) (postIDs map[int]bool, err error) {
+	postIDs = map[int]bool{}
+	rows, err := db.conn.Query(`
+		SELECT id
+		FROM posts
+		WHERE
+			page_id = $2
+			AND NOT anonymous
+			AND pseudonym_id IS NULL
+			AND user_id IN (`+communityModeratedAuthors+`)`,
+		moderatorID,
+		pageID,
+	)
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var postID int
+		if err := rows.Scan(&postID); err != nil {
+			log.Fatal(err)
+		}
+		postIDs[postID] = true
+	}
+	return
+}
@@ -0,0 +1,107 @@
+package database
+
+import (
+	"log"
+
+	"github.com/comforme/comforme/common"
+)
+
+// Lists the newest pages, optionally only those in one category.
+func (db DB) GetLatestPages(categorySlug string, limit, offset int) (pages []common.Page, err error) {
+	rows, err := db.conn.Query(`
+		SELECT
+			pages.id,
+			pages.title,
+			pages.slug,
+			categories.name,
+			categories.slug,
+			pages.description,
+			pages.date_created
+		FROM
+			pages,
+			categories
+		WHERE
+			categories.id = pages.category
+			AND ($1 = '' OR categories.slug = $1)
+		ORDER BY pages.date_created DESC
+		LIMIT $2
+		OFFSET $3;
+		`,
+		categorySlug,
+		limit,
+		offset,
+	)
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+
+	defer rows.Close()
+
+	pages = []common.Page{}
+	for rows.Next() {
+		var row common.Page
+		if err := rows.Scan(
+			&row.Id,
+			&row.Title,
+			&row.PageSlug,
+			&row.Category,
+			&row.CategorySlug,
+			&row.Description,
+			&row.DateCreated,
+		); err != nil {
+			log.Fatal(err)
+		}
+		pages = append(pages, row)
+	}
+
+	if err = rows.Err(); err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+	}
+	return
+}
+
+func (db DB) GetCategoryName(slug string) (name string, err error) {
+	err = db.conn.QueryRow("SELECT name FROM categories WHERE slug = $1", slug).Scan(&name)
+	if err != nil {
+		log.Printf("Error looking up category (%s): %s\n", slug, err.Error())
+		err = common.CategoryNotFound
+	}
+	return
+}
+
+// Lists the newest posts on a page without their authors.
+func (db DB) GetLatestPosts(pageID, limit int) (posts []common.Contribution, err error) {
+	rows, err := db.conn.Query(`
+		SELECT
+			'',
+			pages.title,
+			pages.slug,
+			categories.slug,
+			posts.body,
+			false,
+			posts.date_created
+		FROM
+			posts,
+			pages,
+			categories
+		WHERE
+			posts.page_id = $1
+			AND pages.id = posts.page_id
+			AND categories.id = pages.category
+		ORDER BY posts.date_created DESC
+		LIMIT $2;
+		`,
+		pageID,
+		limit,
+	)
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+
+	return scanContributions(rows)
+}
@@ -0,0 +1,208 @@
+package database
+
+import (
+	"database/sql"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/comforme/comforme/common"
+)
+
+// Looks up the user a bearer token belongs to and records that it was used.
+func (db DB) GetTokenUserInfo(token string) (userInfo common.UserInfo, err error) {
+	var role, scopes string
+	var suspended, resetRequired bool
+	err = db.conn.QueryRow(`
+		UPDATE api_tokens SET last_used = now()
+		FROM users
+		WHERE api_tokens.token_hash = $1 AND api_tokens.user_id = users.id
+		RETURNING users.email, users.username, users.id, users.role, users.suspended, users.reset_required, api_tokens.scopes, users.locale;`,
+		common.HashToken(token),
+	).Scan(&userInfo.Email, &userInfo.Username, &userInfo.UserID, &role, &suspended, &resetRequired, &scopes, &userInfo.Locale)
+	if err == sql.ErrNoRows {
+		err = common.InvalidToken
+		return
+	} else if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+
+	if suspended {
+		log.Printf("Suspended user (%d) tried to use an API token.\n", userInfo.UserID)
+		err = common.AccountSuspended
+		return
+	}
+	if resetRequired {
+		err = common.InvalidToken
+		return
+	}
+
+	userInfo.ViaToken = true
+	userInfo.Scopes = strings.Fields(scopes)
+	userInfo.Role, err = common.ParseRole(role)
+	if err != nil {
+		log.Printf("Unknown role (%s) for user (%d), treating as user.\n", role, userInfo.UserID)
+		userInfo.Role = common.RoleUser
+		err = nil
+	}
+	return
+}
+
+// Adds a token. clientID is 0 for personal access tokens.
+func (db DB) NewAPIToken(userID, clientID int, name, token string, scopes []string) (err error) {
+	var client sql.NullInt64
+	if clientID != 0 {
+		client = sql.NullInt64{Int64: int64(clientID), Valid: true}
+	}
+
+	_, err = db.conn.Exec(
+		"INSERT INTO api_tokens (user_id, client_id, name, token_hash, scopes) VALUES ($1, $2, $3, $4, $5);",
+		userID,
+		client,
+		name,
+		common.HashToken(token),
+		strings.Join(scopes, " "),
+	)
+	if err != nil {
+		log.Printf("Error adding API token (%s) for user (%d): %s\n", name, userID, err.Error())
+		err = common.DatabaseError
+	}
+	return
+}
+
+func (db DB) GetAPITokens(userID int) (tokens []common.APIToken, err error) {
+	rows, err := db.conn.Query(`
+		SELECT
+			api_tokens.id,
+			api_tokens.name,
+			COALESCE(oauth_clients.name, ''),
+			api_tokens.scopes,
+			api_tokens.date_created,
+			api_tokens.last_used
+		FROM api_tokens
+		LEFT JOIN oauth_clients ON oauth_clients.id = api_tokens.client_id
+		WHERE api_tokens.user_id = $1
+		ORDER BY api_tokens.date_created DESC;`,
+		userID,
+	)
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+
+	defer rows.Close()
+
+	tokens = []common.APIToken{}
+	for rows.Next() {
+		var row common.APIToken
+		var scopes string
+		var lastUsed *time.Time
+		if err := rows.Scan(&row.Id, &row.Name, &row.ClientName, &scopes, &row.DateCreated, &lastUsed); err != nil {
+			log.Fatal(err)
+		}
+		row.Scopes = strings.Fields(scopes)
+		row.LastUsed = lastUsed
+		tokens = append(tokens, row)
+	}
+
+	if err = rows.Err(); err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+	}
+	return
+}
+
+func (db DB) DeleteAPIToken(userID, tokenID int) error {
+	result, err := db.conn.Exec(
+		"DELETE FROM api_tokens WHERE id = $1 AND user_id = $2;",
+		tokenID,
+		userID,
+	)
+	if err != nil {
+		common.LogError(err)
+		return common.DatabaseError
+	}
+
+	return checkSingleRow(result, common.TokenNotFound)
+}
+
+func (db DB) NewOAuthClient(clientID, name, redirectURI string) (err error) {
+	_, err = db.conn.Exec(
+		"INSERT INTO oauth_clients (client_id, name, redirect_uri) VALUES ($1, $2, $3);",
+		clientID,
+		name,
+		redirectURI,
+	)
+	if err != nil {
+		log.Printf("Error adding OAuth client (%s): %s\n", name, err.Error())
+		err = common.DatabaseError
+	}
+	return
+}
+
+func (db DB) GetOAuthClient(clientID string) (client common.OAuthClient, err error) {
+	err = db.conn.QueryRow(
+		"SELECT id, client_id, name, redirect_uri FROM oauth_clients WHERE client_id = $1;",
+		clientID,
+	).Scan(&client.Id, &client.ClientID, &client.Name, &client.RedirectURI)
+	if err == sql.ErrNoRows {
+		err = common.OAuthClientNotFound
+	} else if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+	}
+	return
+}
+
+func (db DB) NewOAuthCode(code string, details common.OAuthCode) (err error) {
+	_, err = db.conn.Exec(`
+		INSERT INTO oauth_codes (code_hash, client_id, user_id, redirect_uri, scopes, code_challenge, expires)
+		VALUES ($1, $2, $3, $4, $5, $6, $7);`,
+		common.HashToken(code),
+		details.ClientID,
+		details.UserID,
+		details.RedirectURI,
+		strings.Join(details.Scopes, " "),
+		details.CodeChallenge,
+		details.Expires,
+	)
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+	}
+	return
+}
+
+// Looks up an authorization code and deletes it, so that each code can only
+// be exchanged once.
+func (db DB) TakeOAuthCode(code string) (details common.OAuthCode, err error) {
+	var scopes string
+	err = db.conn.QueryRow(`
+		DELETE FROM oauth_codes WHERE code_hash = $1
+		RETURNING client_id, user_id, redirect_uri, scopes, code_challenge, expires;`,
+		common.HashToken(code),
+	).Scan(&details.ClientID, &details.UserID, &details.RedirectURI, &scopes, &details.CodeChallenge, &details.Expires)
+	if err == sql.ErrNoRows {
+		err = common.InvalidToken
+		return
+	} else if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+
+	details.Scopes = strings.Fields(scopes)
+	return
+}
+
+func (db DB) DeleteExpiredOAuthCodes() (err error) {
+	_, err = db.conn.Exec("DELETE FROM oauth_codes WHERE expires < now();")
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+	}
+	return
+}
@@ -0,0 +1,314 @@
+package database
+
+import (
+	"database/sql"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/comforme/comforme/common"
+)
+
+func (db DB) NewWebhook(url, secret string, events []string, categoryID int) (webhookID int, err error) {
+	var category sql.NullInt64
+	if categoryID != 0 {
+		category = sql.NullInt64{Int64: int64(categoryID), Valid: true}
+	}
+
+	err = db.conn.QueryRow(
+		"INSERT INTO webhooks (url, secret, events, category_id) VALUES ($1, $2, $3, $4) RETURNING id;",
+		url,
+		secret,
+		strings.Join(events, " "),
+		category,
+	).Scan(&webhookID)
+	if err != nil {
+		log.Printf("Error adding webhook (%s): %s\n", url, err.Error())
+		err = common.DatabaseError
+	}
+	return
+}
+
+func scanWebhook(scan func(...interface{}) error) (webhook common.Webhook, err error) {
+	var events string
+	var categoryID sql.NullInt64
+	err = scan(
+		&webhook.Id,
+		&webhook.URL,
+		&webhook.Secret,
+		&events,
+		&categoryID,
+		&webhook.CategoryName,
+		&webhook.Active,
+		&webhook.DateCreated,
+	)
+	webhook.Events = strings.Fields(events)
+	webhook.CategoryID = int(categoryID.Int64)
+	return
+}
+
+const webhookColumns = `
+	webhooks.id,
+	webhooks.url,
+	webhooks.secret,
+	webhooks.events,
+	webhooks.category_id,
+	COALESCE(categories.name, ''),
+	webhooks.active,
+	webhooks.date_created
+	FROM webhooks
+	LEFT JOIN categories ON categories.id = webhooks.category_id`
+
+func (db DB) GetWebhooks() (webhooks []common.Webhook, err error) {
+	rows, err := db.conn.Query("SELECT " + webhookColumns + " ORDER BY webhooks.date_created ASC;")
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+
+	defer rows.Close()
+
+	webhooks = []common.Webhook{}
+	for rows.Next() {
+		webhook, err := scanWebhook(rows.Scan)
+		if err != nil {
+			log.Fatal(err)
+		}
+		webhooks = append(webhooks, webhook)
+	}
+
+	if err = rows.Err(); err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+	}
+	return
+}
+
+func (db DB) GetWebhook(webhookID int) (webhook common.Webhook, err error) {
+	webhook, err = scanWebhook(db.conn.QueryRow("SELECT "+webhookColumns+" WHERE webhooks.id = $1;", webhookID).Scan)
+	if err == sql.ErrNoRows {
+		err = common.WebhookNotFound
+	} else if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+	}
+	return
+}
+
+func (db DB) SetWebhookActive(webhookID int, active bool) error {
+	result, err := db.conn.Exec("UPDATE webhooks SET active = $2 WHERE id = $1;", webhookID, active)
+	if err != nil {
+		common.LogError(err)
+		return common.DatabaseError
+	}
+
+	return checkSingleRow(result, common.WebhookNotFound)
+}
+
+func (db DB) DeleteWebhook(webhookID int) error {
+	result, err := db.conn.Exec("DELETE FROM webhooks WHERE id = $1;", webhookID)
+	if err != nil {
+		common.LogError(err)
+		return common.DatabaseError
+	}
+
+	return checkSingleRow(result, common.WebhookNotFound)
+}
+
+// Queues a delivery of an event to every active webhook subscribed to it.
+// categorySlug is the category the event happened in, or empty if it has
+// none.
+func (db DB) QueueWebhookEvent(event, categorySlug, payload string) (queued int64, err error) {
+	result, err := db.conn.Exec(`
+		INSERT INTO webhook_deliveries (webhook_id, event, payload)
+		SELECT id, $1, $3
+		FROM webhooks
+		WHERE active
+			AND $1 = ANY (string_to_array(events, ' '))
+			AND ($2 = '' OR category_id IS NULL OR category_id = (SELECT id FROM categories WHERE slug = $2));`,
+		event,
+		categorySlug,
+		payload,
+	)
+	if err != nil {
+		common.LogError(err)
+		return 0, common.DatabaseError
+	}
+
+	queued, err = result.RowsAffected()
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+	}
+	return
+}
+
+// Claims up to limit deliveries that are due. Claimed deliveries are pushed
+// back by lease, so that other servers do not send them at the same time and
+// they are retried if this one stops before recording the attempt.
+func (db DB) ClaimDueDeliveries(limit int, lease time.Duration) (deliveries []common.PendingDelivery, err error) {
+	rows, err := db.conn.Query(`
+		WITH due AS (
+			UPDATE webhook_deliveries SET next_attempt = now() + $2::integer * interval '1 second'
+			WHERE id IN (
+				SELECT webhook_deliveries.id
+				FROM webhook_deliveries
+				JOIN webhooks ON webhooks.id = webhook_deliveries.webhook_id
+				WHERE webhook_deliveries.status = 'pending'
+					AND webhook_deliveries.next_attempt <= now()
+					AND webhooks.active
+				ORDER BY webhook_deliveries.next_attempt ASC
+				LIMIT $1
+				FOR UPDATE OF webhook_deliveries SKIP LOCKED
+			)
+			RETURNING id, webhook_id, event, payload, attempts
+		)
+		SELECT due.id, due.webhook_id, due.event, due.payload, due.attempts, webhooks.url, webhooks.secret
+		FROM due
+		JOIN webhooks ON webhooks.id = due.webhook_id;`,
+		limit,
+		int(lease.Seconds()),
+	)
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+
+	defer rows.Close()
+
+	deliveries = []common.PendingDelivery{}
+	for rows.Next() {
+		var row common.PendingDelivery
+		if err := rows.Scan(&row.Id, &row.WebhookID, &row.Event, &row.Payload, &row.Attempts, &row.URL, &row.Secret); err != nil {
+			log.Fatal(err)
+		}
+		deliveries = append(deliveries, row)
+	}
+
+	if err = rows.Err(); err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+	}
+	return
+}
+
+// Records the outcome of sending a delivery. responseStatus is 0 if no
+// response was received.
+func (db DB) RecordDeliveryAttempt(delivery common.WebhookDelivery) error {
+	var responseStatus sql.NullInt64
+	if delivery.ResponseStatus != 0 {
+		responseStatus = sql.NullInt64{Int64: int64(delivery.ResponseStatus), Valid: true}
+	}
+
+	_, err := db.conn.Exec(`
+		UPDATE webhook_deliveries
+		SET status = $2, attempts = $3, next_attempt = $4, last_attempt = now(), response_status = $5, last_error = $6
+		WHERE id = $1;`,
+		delivery.Id,
+		delivery.Status,
+		delivery.Attempts,
+		delivery.NextAttempt,
+		responseStatus,
+		delivery.LastError,
+	)
+	if err != nil {
+		common.LogError(err)
+		return common.DatabaseError
+	}
+	return nil
+}
+
+func (db DB) GetDeliveries(webhookID, limit int) (deliveries []common.WebhookDelivery, err error) {
+	rows, err := db.conn.Query(`
+		SELECT id, webhook_id, event, payload, status, attempts, next_attempt, last_attempt, COALESCE(response_status, 0), last_error, date_created
+		FROM webhook_deliveries
+		WHERE webhook_id = $1
+		ORDER BY date_created DESC, id DESC
+		LIMIT $2;`,
+		webhookID,
+		limit,
+	)
+	if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+		return
+	}
+
+	defer rows.Close()
+
+	deliveries = []common.WebhookDelivery{}
+	for rows.Next() {
+		var row common.WebhookDelivery
+		var lastAttempt *time.Time
+		if err := rows.Scan(
+			&row.Id,
+			&row.WebhookID,
+			&row.Event,
+			&row.Payload,
+			&row.Status,
+			&row.Attempts,
+			&row.NextAttempt,
+			&lastAttempt,
+			&row.ResponseStatus,
+			&row.LastError,
+			&row.DateCreated,
+		); err != nil {
+			log.Fatal(err)
+		}
+		row.LastAttempt = lastAttempt
+		deliveries = append(deliveries, row)
+	}
+
+	if err = rows.Err(); err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+	}
+	return
+}
+
+// Queues a new delivery with the same event and payload as an earlier one,
+// leaving the original in the log.
+func (db DB) ReplayDelivery(webhookID, deliveryID int) (newID int, err error) {
+	err = db.conn.QueryRow(`
+		INSERT INTO webhook_deliveries (webhook_id, event, payload)
+		SELECT webhook_id, event, payload FROM webhook_deliveries WHERE id = $1 AND webhook_id = $2
+		RETURNING id;`,
+		deliveryID,
+		webhookID,
+	).Scan(&newID)
+	if err == sql.ErrNoRows {
+		err = common.DeliveryNotFound
+	} else if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+	}
+	return
+}
+
+// The name a post is shown under: empty for anonymous posts, the pseudonym
+// for pseudonymous ones and otherwise the author's username.
+func (db DB) GetPostDisplayAuthor(postID int) (author string, err error) {
+	err = db.conn.QueryRow(`
+		SELECT
+			CASE
+				WHEN posts.anonymous THEN ''
+				WHEN pseudonyms.id IS NOT NULL THEN pseudonyms.name
+				WHEN posts.remote_author IS NOT NULL THEN posts.remote_author
+				ELSE users.username
+			END
+		FROM posts
+		LEFT JOIN users ON users.id = posts.user_id
+		LEFT JOIN pseudonyms ON pseudonyms.id = posts.pseudonym_id
+		WHERE posts.id = $1;`,
+		postID,
+	).Scan(&author)
+	if err == sql.ErrNoRows {
+		err = common.PostNotFound
+	} else if err != nil {
+		common.LogError(err)
+		err = common.DatabaseError
+	}
+	return
+}
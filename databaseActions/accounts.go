@@ -0,0 +1,137 @@
+package databaseActions
+
+import (
+	"log"
+	"time"
+
+	"github.com/comforme/comforme/common"
+)
+
+// How long users have to change their mind after asking for their account to
+// be deleted.
+const accountDeletionGrace = 14 * 24 * time.Hour
+
+// Schedules the user's own account for deletion once they have entered their
+// password again. baseURL is where the email about it links to.
+func RequestAccountDeletion(userInfo common.UserInfo, password, baseURL string) (scheduled time.Time, err error) {
+	if _, err = db.GetUserID(userInfo.Email, password); err != nil {
+		return
+	}
+
+	scheduled, err = scheduleDeletion(userInfo.UserID, baseURL)
+	if err != nil {
+		return
+	}
+
+	auditSelf(common.AuditDeletionRequested, userInfo, "for "+scheduled.Format("2006-01-02"))
+	return
+}
+
+func CancelAccountDeletion(userInfo common.UserInfo) error {
+	err := db.CancelDeletion(userInfo.UserID)
+	if err != nil {
+		return err
+	}
+
+	auditSelf(common.AuditDeletionCancelled, userInfo, "")
+	return nil
+}
+
+// Schedules another user's account for deletion, with the same grace period
+// as when users ask themselves.
+func ScheduleUserDeletion(userInfo common.UserInfo, userID int, baseURL string) error {
+	if userID == userInfo.UserID {
+		return common.PermissionDenied
+	}
+
+	scheduled, err := scheduleDeletion(userID, baseURL)
+	if err != nil {
+		return err
+	}
+
+	auditModerator(common.AuditDeletionRequested, userInfo, userID, "for %s", scheduled.Format("2006-01-02"))
+	return nil
+}
+
+func CancelUserDeletion(userInfo common.UserInfo, userID int) error {
+	err := db.CancelDeletion(userID)
+	if err != nil {
+		return err
+	}
+
+	auditModerator(common.AuditDeletionCancelled, userInfo, userID, "")
+	return nil
+}
+
+// Deletes an account that is already scheduled for deletion without waiting
+// for the grace period to end.
+func DeleteUserNow(userInfo common.UserInfo, userID int) error {
+	user, err := db.GetUser(userID)
+	if err != nil {
+		return err
+	}
+	if user.DeletionScheduled == nil {
+		return common.DeletionNotScheduled
+	}
+
+	if _, err = db.ScheduleDeletion(userID, 0); err != nil {
+		return err
+	}
+	if err = db.DeleteAccount(userID); err != nil {
+		return err
+	}
+
+	log.Printf("Deleted user (%d).\n", userID)
+	auditModerator(common.AuditAccountDeleted, userInfo, userID, "")
+	return nil
+}
+
+func scheduleDeletion(userID int, baseURL string) (scheduled time.Time, err error) {
+	user, err := db.GetUser(userID)
+	if err != nil {
+		return
+	}
+	if user.DeletionScheduled != nil {
+		err = common.DeletionAlreadyScheduled
+		return
+	}
+
+	log.Printf("Scheduling deletion of user (%d).\n", userID)
+	scheduled, err = db.ScheduleDeletion(userID, accountDeletionGrace)
+	if err != nil {
+		return
+	}
+
+	if err := common.SendDeletionEmail(user.Email, baseURL, scheduled); err != nil {
+		log.Printf("Failed to email user (%d) about their account deletion: %s\n", userID, err.Error())
+	}
+	return
+}
+
+// When a user's account is scheduled to be deleted, or nil if it is not.
+func GetDeletionScheduled(userID int) (*time.Time, error) {
+	user, err := db.GetUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	return user.DeletionScheduled, nil
+}
+
+// Deletes every account whose grace period has ended, and returns how many
+// were deleted.
+func DeleteDueAccounts() (deleted int, err error) {
+	userIDs, err := db.GetDueDeletions()
+	if err != nil {
+		return
+	}
+
+	for _, userID := range userIDs {
+		if err := db.DeleteAccount(userID); err != nil {
+			log.Printf("Error deleting user (%d): %s\n", userID, err.Error())
+			continue
+		}
+		audit(common.AuditAccountDeleted, 0, userID, common.RequestInfo{}, "after the grace period")
+		deleted++
+	}
+	return
+}
@@ -0,0 +1,371 @@
+package databaseActions
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"html"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/comforme/comforme/common"
+)
+
+const actorKeyBits = 2048
+
+// Signals the ActivityPub sender that there are new deliveries.
+var ActivitiesQueued = make(chan struct{}, 1)
+
+func signalActivities() {
+	select {
+	case ActivitiesQueued <- struct{}{}:
+	default:
+	}
+}
+
+// Looks up a category or community actor, generating its key pair the first
+// time.
+func GetLocalActor(categorySlug string, communityID int) (actor common.LocalActor, err error) {
+	if !common.FederationEnabled() {
+		return actor, common.FederationDisabled
+	}
+
+	actor, err = db.GetLocalActor(categorySlug, communityID)
+	if err != nil || actor.Id != 0 {
+		return
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, actorKeyBits)
+	if err != nil {
+		common.LogError(err)
+		return actor, common.DatabaseError
+	}
+	publicKey, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		common.LogError(err)
+		return actor, common.DatabaseError
+	}
+
+	err = db.NewLocalActor(
+		categorySlug,
+		communityID,
+		string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKey})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})),
+	)
+	if err != nil {
+		return
+	}
+
+	return db.GetLocalActor(categorySlug, communityID)
+}
+
+func categoryActorURL(categorySlug string) string {
+	return common.LocalActor{CategorySlug: categorySlug}.URL()
+}
+
+func pageNote(page common.Page) common.Note {
+	pageURL := common.FederationURL(fmt.Sprintf("/page/%s/%s", page.CategorySlug, page.PageSlug))
+	actorURL := categoryActorURL(page.CategorySlug)
+
+	content := fmt.Sprintf("<p><strong>%s</strong></p>", html.EscapeString(page.Title))
+	if page.Description != "" {
+		content += fmt.Sprintf("<p>%s</p>", html.EscapeString(page.Description))
+	}
+	content += fmt.Sprintf(`<p><a href="%s">%s</a></p>`, html.EscapeString(pageURL), html.EscapeString(pageURL))
+
+	return common.Note{
+		ID:           common.FederationURL(common.PageObjectPath(page.Id)),
+		Type:         "Note",
+		AttributedTo: actorURL,
+		Content:      content,
+		URL:          pageURL,
+		Published:    page.DateCreated.UTC(),
+		To:           []string{common.ActivityStreamsPublic},
+		Cc:           []string{actorURL + "/followers"},
+	}
+}
+
+func postNote(postID int, page common.Page, body string, published time.Time) common.Note {
+	actorURL := categoryActorURL(page.CategorySlug)
+
+	paragraphs := []string{}
+	for _, paragraph := range strings.Split(strings.TrimSpace(body), "\n\n") {
+		paragraph = strings.Replace(html.EscapeString(strings.TrimSpace(paragraph)), "\n", "<br>", -1)
+		paragraphs = append(paragraphs, "<p>"+paragraph+"</p>")
+	}
+
+	return common.Note{
+		ID:           common.FederationURL(common.PostObjectPath(postID)),
+		Type:         "Note",
+		AttributedTo: actorURL,
+		InReplyTo:    common.FederationURL(common.PageObjectPath(page.Id)),
+		Content:      strings.Join(paragraphs, ""),
+		URL:          common.FederationURL(fmt.Sprintf("/page/%s/%s#post-%d", page.CategorySlug, page.PageSlug, postID)),
+		Published:    published.UTC(),
+		To:           []string{common.ActivityStreamsPublic},
+		Cc:           []string{actorURL + "/followers"},
+	}
+}
+
+func getPageByID(pageID int) (page common.Page, err error) {
+	categorySlug, pageSlug, err := db.GetSlugs(pageID)
+	if err != nil {
+		return
+	}
+	return db.GetPage(categorySlug, pageSlug)
+}
+
+// Returns the object a page is federated as.
+func GetPageNote(pageID int) (note common.Note, err error) {
+	if !common.FederationEnabled() {
+		return note, common.FederationDisabled
+	}
+
+	page, err := getPageByID(pageID)
+	if err != nil {
+		return note, common.PageNotFound
+	}
+	return pageNote(page), nil
+}
+
+// Returns the object a post is federated as. Only posts made here are
+// found.
+func GetPostNote(postID int) (note common.Note, err error) {
+	if !common.FederationEnabled() {
+		return note, common.FederationDisabled
+	}
+
+	pageID, body, published, err := db.GetLocalPost(postID)
+	if err != nil {
+		return
+	}
+	page, err := getPageByID(pageID)
+	if err != nil {
+		return note, common.PostNotFound
+	}
+	return postNote(postID, page, body, published), nil
+}
+
+// Delivers a new page or post to the followers of every actor it belongs
+// to. The category actor, which the note is attributed to, sends a Create
+// and community actors announce it. Like webhooks, this only gets logged if
+// it fails.
+func federate(note common.Note, categorySlug string, authorID int) {
+	actors, err := db.GetAudienceActors(categorySlug, authorID)
+	if err != nil {
+		log.Printf("Failed to look up actors to federate %s from: %s\n", note.ID, err.Error())
+		return
+	}
+
+	queued := int64(0)
+	for _, actor := range actors {
+		activity := common.Activity{
+			Context:   common.ActivityStreamsContext,
+			ID:        note.ID + "/activity",
+			Type:      "Create",
+			Actor:     actor.URL(),
+			Object:    note,
+			To:        note.To,
+			Cc:        note.Cc,
+			Published: &note.Published,
+		}
+		if actor.CommunityID != 0 {
+			activity.ID = actor.URL() + "/announces" + strings.TrimPrefix(note.ID, common.FederationURL("/ap"))
+			activity.Type = "Announce"
+			activity.Object = note.ID
+			activity.Cc = []string{actor.URL() + "/followers"}
+		}
+
+		body, err := json.Marshal(activity)
+		if err != nil {
+			log.Printf("Error encoding activity %s: %s\n", activity.ID, err.Error())
+			continue
+		}
+
+		count, err := db.QueueActivityForFollowers(actor.Id, string(body))
+		if err != nil {
+			log.Printf("Failed to queue activity %s: %s\n", activity.ID, err.Error())
+			continue
+		}
+		queued += count
+	}
+
+	if queued > 0 {
+		signalActivities()
+	}
+}
+
+func federatePage(pageID int) {
+	if !common.FederationEnabled() {
+		return
+	}
+
+	page, err := getPageByID(pageID)
+	if err != nil {
+		log.Printf("Failed to look up page (%d) to federate: %s\n", pageID, err.Error())
+		return
+	}
+
+	federate(pageNote(page), page.CategorySlug, page.AuthorID)
+}
+
+// authorID is 0 for anonymous and pseudonymous posts, so that they are not
+// announced by the author's communities.
+func federatePost(postID int, page common.Page, body string, authorID int) {
+	if !common.FederationEnabled() {
+		return
+	}
+
+	federate(postNote(postID, page, body, time.Now()), page.CategorySlug, authorID)
+}
+
+// Records a remote follower and accepts the follow.
+func AddFollower(actor common.LocalActor, follower common.RemoteActor, follow json.RawMessage) error {
+	err := db.AddFollower(actor.Id, follower.ID, follower.DeliveryInbox())
+	if err != nil {
+		return err
+	}
+
+	accept, err := json.Marshal(common.Activity{
+		Context: common.ActivityStreamsContext,
+		ID:      fmt.Sprintf("%s/accepts/%d", actor.URL(), time.Now().UnixNano()),
+		Type:    "Accept",
+		Actor:   actor.URL(),
+		Object:  follow,
+	})
+	if err != nil {
+		common.LogError(err)
+		return common.DatabaseError
+	}
+
+	// Accepts go to the follower's own inbox, as it may not share one
+	err = db.QueueActivity(actor.Id, follower.Inbox, string(accept))
+	if err != nil {
+		return err
+	}
+
+	signalActivities()
+	return nil
+}
+
+func RemoveFollower(actor common.LocalActor, followerID string) error {
+	return db.RemoveFollower(actor.Id, followerID)
+}
+
+// Holds a reply to a local page or post for moderation. content must already
+// be plain text.
+func ReceiveReply(author common.RemoteActor, objectID, inReplyTo, content string) error {
+	kind, id, ok := common.ParseObjectID(inReplyTo)
+	if !ok {
+		return common.NotAReply
+	}
+
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return common.ReplyEmpty
+	}
+	if len(content) > common.MaxRemoteReplyLength {
+		return common.ReplyTooLong
+	}
+
+	pageID := id
+	if kind == "posts" {
+		var err error
+		pageID, _, _, err = db.GetLocalPost(id)
+		if err != nil {
+			return common.NotAReply
+		}
+	} else if _, _, err := db.GetSlugs(pageID); err != nil {
+		// The page may have been merged into another one
+		if pageID, err = db.GetMergedPageID(pageID); err != nil {
+			return common.NotAReply
+		}
+	}
+
+	return db.NewRemoteReply(pageID, objectID, author.ID, author.Handle(), content)
+}
+
+// Removes a reply its author deleted.
+func DeleteRemoteObject(actorID, objectID string) error {
+	return db.DeleteRemoteObject(actorID, objectID)
+}
+
+// Claims activity deliveries that are due, with the keys to sign them.
+// Each must be passed to RecordActivityDelivery once it has been tried.
+func ClaimActivityDeliveries(limit int) ([]common.FederationDelivery, error) {
+	deliveries, actors, err := db.ClaimActivityDeliveries(limit, webhookDeliveryLease)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range deliveries {
+		deliveries[i].KeyID = actors[i].URL() + "#main-key"
+		deliveries[i].PrivateKey = actors[i].PrivateKey
+	}
+	return deliveries, nil
+}
+
+// Records the outcome of delivering an activity, retrying it the same way as
+// webhook deliveries.
+func RecordActivityDelivery(delivery common.FederationDelivery, sendErr error) error {
+	attempts := delivery.Attempts + 1
+	if sendErr == nil {
+		return db.RecordActivityDelivery(delivery.Id, common.DeliverySucceeded, attempts, time.Now(), "")
+	}
+
+	status, nextAttempt := retryDelivery(attempts, time.Now())
+	return db.RecordActivityDelivery(delivery.Id, status, attempts, nextAttempt, deliveryError(sendErr))
+}
+
+func GetFollowedActors(userInfo common.UserInfo) ([]common.LocalActor, error) {
+	if !userInfo.Can(common.PermissionModerate) {
+		return nil, common.PermissionDenied
+	}
+	return db.GetFollowedActors()
+}
+
+func GetPendingReplies(userInfo common.UserInfo) ([]common.RemoteReply, error) {
+	if !userInfo.Can(common.PermissionModerate) {
+		return nil, common.PermissionDenied
+	}
+	return db.GetPendingReplies()
+}
+
+// Posts a pending reply on its page.
+func ApproveReply(userInfo common.UserInfo, replyID int) error {
+	if !userInfo.Can(common.PermissionModerate) {
+		return common.PermissionDenied
+	}
+
+	postID, pageID, body, err := db.ApproveRemoteReply(replyID)
+	if err != nil {
+		return err
+	}
+	auditModerator(common.AuditReplyApproved, userInfo, 0, "reply %d as post %d", replyID, postID)
+
+	if err := db.NotifySubscribers(postID); err != nil {
+		log.Printf("Failed to notify subscribers of post (%d): %s\n", postID, err.Error())
+	}
+	if page, err := getPageByID(pageID); err == nil {
+		emitPostEvent(postID, body, page)
+	}
+	return nil
+}
+
+func RejectReply(userInfo common.UserInfo, replyID int) error {
+	if !userInfo.Can(common.PermissionModerate) {
+		return common.PermissionDenied
+	}
+
+	err := db.RejectRemoteReply(replyID)
+	if err != nil {
+		return err
+	}
+
+	auditModerator(common.AuditReplyRejected, userInfo, 0, "reply %d", replyID)
+	return nil
+}
@@ -0,0 +1,166 @@
+package databaseActions
+
+import (
+	"log"
+	"strings"
+
+	"github.com/comforme/comforme/common"
+)
+
+func checkName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if len(common.GenSlug(name)) <= 1 {
+		return name, common.InvalidName
+	}
+	return name, nil
+}
+
+func GetCategories() ([]common.Category, error) {
+	return db.GetCategories()
+}
+
+func CreateCategory(userInfo common.UserInfo, name string) (err error) {
+	name, err = checkName(name)
+	if err != nil {
+		return
+	}
+
+	categoryID, err := db.NewCategory(name, common.GenSlug(name))
+	if err != nil {
+		return
+	}
+
+	auditModerator(common.AuditCategoryCreated, userInfo, 0, "category %d (%s)", categoryID, name)
+	return
+}
+
+// Renaming a category changes its slug, so links to its pages change too.
+func RenameCategory(userInfo common.UserInfo, categoryID int, name string) (err error) {
+	name, err = checkName(name)
+	if err != nil {
+		return
+	}
+
+	err = db.UpdateCategory(categoryID, name, common.GenSlug(name))
+	if err != nil {
+		return
+	}
+
+	auditModerator(common.AuditCategoryRenamed, userInfo, 0, "category %d to %s", categoryID, name)
+	return
+}
+
+func DeleteCategory(userInfo common.UserInfo, categoryID int) (err error) {
+	err = db.DeleteCategory(categoryID)
+	if err != nil {
+		return
+	}
+
+	auditModerator(common.AuditCategoryDeleted, userInfo, 0, "category %d", categoryID)
+	return
+}
+
+func GetCommunities() ([]common.Community, error) {
+	return db.GetCommunities()
+}
+
+func CreateCommunity(userInfo common.UserInfo, name string) (err error) {
+	name, err = checkName(name)
+	if err != nil {
+		return
+	}
+
+	communityID, err := db.NewCommunity(name)
+	if err != nil {
+		return
+	}
+
+	auditModerator(common.AuditCommunityCreated, userInfo, 0, "community %d (%s)", communityID, name)
+	emitCommunityEvent(communityID)
+	return
+}
+
+func RenameCommunity(userInfo common.UserInfo, communityID int, name string) (err error) {
+	name, err = checkName(name)
+	if err != nil {
+		return
+	}
+
+	err = db.RenameCommunity(communityID, name)
+	if err != nil {
+		return
+	}
+
+	auditModerator(common.AuditCommunityRenamed, userInfo, 0, "community %d to %s", communityID, name)
+	return
+}
+
+func DeleteCommunity(userInfo common.UserInfo, communityID int) (err error) {
+	err = db.DeleteCommunity(communityID)
+	if err != nil {
+		return
+	}
+
+	auditModerator(common.AuditCommunityDeleted, userInfo, 0, "community %d", communityID)
+	return
+}
+
+func MergeCommunities(userInfo common.UserInfo, fromID, intoID int) (moved int, err error) {
+	if fromID == intoID {
+		err = common.CannotMergeIntoSelf
+		return
+	}
+
+	moved, err = db.MergeCommunities(fromID, intoID)
+	if err != nil {
+		log.Printf("Error merging community (%d) into (%d): %s\n", fromID, intoID, err.Error())
+		return
+	}
+
+	log.Printf("Merged community (%d) into (%d), moved %d memberships.\n", fromID, intoID, moved)
+	auditModerator(common.AuditCommunitiesMerged, userInfo, 0, "community %d into %d, %d memberships moved", fromID, intoID, moved)
+	return
+}
+
+func SearchUsers(query string) ([]common.User, error) {
+	return db.SearchUsers(strings.TrimSpace(query))
+}
+
+func SetSuspended(userInfo common.UserInfo, userID int, suspended bool) (err error) {
+	if userID == userInfo.UserID {
+		return common.PermissionDenied
+	}
+
+	log.Printf("Setting suspended for user (%d) to %t.\n", userID, suspended)
+	err = db.SetSuspended(userID, suspended)
+	if err != nil {
+		return
+	}
+
+	if suspended {
+		auditModerator(common.AuditUserSuspended, userInfo, userID, "")
+	} else {
+		auditModerator(common.AuditUserUnsuspended, userInfo, userID, "")
+	}
+	return
+}
+
+func ForcePasswordReset(userInfo common.UserInfo, userID int) error {
+	user, err := db.GetUser(userID)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Forcing password reset for user (%d).\n", userID)
+	err = db.ForcePasswordReset(user.Email)
+	if err != nil {
+		return err
+	}
+
+	auditModerator(common.AuditPasswordResetForced, userInfo, userID, "")
+	return nil
+}
+
+func GetSiteStatistics() (common.SiteStatistics, error) {
+	return db.GetSiteStatistics()
+}
@@ -0,0 +1,65 @@
+package databaseActions
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/comforme/comforme/common"
+)
+
+const (
+	maxUserAuditEvents  = 20
+	maxAdminAuditEvents = 100
+
+	// How long audit events keep the IP address and user agent they were
+	// recorded with
+	auditRequestInfoRetention = 90 * 24 * time.Hour
+)
+
+// Records an audit event. Failing to record an event is logged but does not
+// stop the action that caused it.
+func audit(action common.AuditAction, actorID, subjectUserID int, requestInfo common.RequestInfo, details string) {
+	err := db.RecordAuditEvent(common.AuditEvent{
+		Action:        action,
+		ActorID:       actorID,
+		SubjectUserID: subjectUserID,
+		IpAddress:     requestInfo.IpAddress,
+		UserAgent:     requestInfo.UserAgent,
+		Details:       details,
+	})
+	if err != nil {
+		log.Printf("Failed to record audit event (%s) by user (%d): %s\n", action, actorID, err.Error())
+	}
+}
+
+// Records an action a logged in user took on their own account.
+func auditSelf(action common.AuditAction, userInfo common.UserInfo, details string) {
+	audit(action, userInfo.UserID, userInfo.UserID, userInfo.RequestInfo, details)
+}
+
+// Records an action a moderator or admin took.
+func auditModerator(action common.AuditAction, userInfo common.UserInfo, subjectUserID int, format string, args ...interface{}) {
+	audit(action, userInfo.UserID, subjectUserID, userInfo.RequestInfo, fmt.Sprintf(format, args...))
+}
+
+func GetSecurityEvents(userID int) ([]common.AuditEvent, error) {
+	return db.GetUserAuditEvents(userID, common.SecurityAuditActions, maxUserAuditEvents)
+}
+
+func GetAuditEvents(filter common.AuditFilter, username string) ([]common.AuditEvent, error) {
+	if len(username) > 0 {
+		userID, err := db.GetUserIDByUsername(username)
+		if err != nil {
+			return nil, err
+		}
+		filter.UserID = userID
+	}
+	return db.GetAuditEvents(filter, maxAdminAuditEvents)
+}
+
+// Forgets the IP addresses and user agents of audit events older than
+// auditRequestInfoRetention. The events themselves are kept.
+func ClearOldAuditRequestInfo() (int64, error) {
+	return db.ClearAuditRequestInfo(time.Now().Add(-auditRequestInfoRetention))
+}
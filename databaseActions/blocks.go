@@ -0,0 +1,32 @@
+package databaseActions
+
+import (
+	"strings"
+
+	"github.com/comforme/comforme/common"
+)
+
+func GetUserBlocks(userID int) ([]common.BlockedUser, error) {
+	return db.GetUserBlocks(userID)
+}
+
+// Blocks or mutes the user with the given username.
+func BlockUser(userInfo common.UserInfo, username, kind string) error {
+	if kind != common.BlockKindBlock && kind != common.BlockKindMute {
+		return common.InvalidBlockKind
+	}
+
+	blockedID, err := db.GetUserIDByUsername(strings.TrimSpace(username))
+	if err != nil {
+		return err
+	}
+	if blockedID == userInfo.UserID {
+		return common.CannotBlockSelf
+	}
+
+	return db.SetUserBlock(userInfo.UserID, blockedID, kind)
+}
+
+func UnblockUser(userInfo common.UserInfo, blockedID int) error {
+	return db.DeleteUserBlock(userInfo.UserID, blockedID)
+}
@@ -0,0 +1,209 @@
+package databaseActions
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/comforme/comforme/common"
+)
+
+const maxCommunityContributions = 25
+
+// Finds approved and pending communities whose names are close to the given
+// name.
+func FindSimilarCommunities(name string) (similar []common.Community, err error) {
+	communities, err := db.GetCommunityNames()
+	if err != nil {
+		return
+	}
+
+	for _, community := range communities {
+		if common.SimilarNames(name, community.Name) {
+			similar = append(similar, community)
+		}
+	}
+	return
+}
+
+// Submits a new community for moderator approval. If similar communities
+// exist the proposal is only accepted once the user has confirmed that theirs
+// is different, and the similar communities are returned so they can be shown.
+func ProposeCommunity(userInfo common.UserInfo, name, description string, parentID int, confirmed bool) (similar []common.Community, err error) {
+	name, err = checkName(name)
+	if err != nil {
+		return
+	}
+
+	description = strings.TrimSpace(description)
+	if len(description) < common.MinDescriptionLength {
+		err = common.DescriptionTooShort
+		return
+	}
+
+	similar, err = FindSimilarCommunities(name)
+	if err != nil {
+		return
+	}
+	for _, community := range similar {
+		if common.NormalizeName(community.Name) == common.NormalizeName(name) {
+			err = common.CommunityAlreadyExists
+			return
+		}
+	}
+	if len(similar) > 0 && !confirmed {
+		err = common.SimilarCommunitiesExist
+		return
+	}
+
+	if parentID != 0 {
+		parent, parentErr := db.GetCommunity(0, parentID)
+		if parentErr != nil || parent.Status != common.CommunityApproved {
+			err = common.CommunityNotFound
+			return
+		}
+	}
+
+	communityID, err := db.ProposeCommunity(userInfo.UserID, name, description, parentID)
+	if err != nil {
+		return
+	}
+
+	log.Printf("User (%d) proposed community (%d) %s.\n", userInfo.UserID, communityID, name)
+	auditSelf(common.AuditCommunityProposed, userInfo, fmt.Sprintf("community %d (%s)", communityID, name))
+	return
+}
+
+// Arranges a flat list of communities into trees, returning the top level
+// communities with their subcommunities in Children. Communities whose parent
+// is not in the list are treated as top level.
+func BuildCommunityTree(communities []common.Community) []common.Community {
+	present := map[int]bool{}
+	for _, community := range communities {
+		present[community.Id] = true
+	}
+
+	children := map[int][]common.Community{}
+	roots := []common.Community{}
+	for _, community := range communities {
+		if community.ParentID != 0 && present[community.ParentID] && community.ParentID != community.Id {
+			children[community.ParentID] = append(children[community.ParentID], community)
+		} else {
+			roots = append(roots, community)
+		}
+	}
+
+	return attachChildren(roots, children, map[int]bool{})
+}
+
+func attachChildren(communities []common.Community, children map[int][]common.Community, visited map[int]bool) []common.Community {
+	for i := range communities {
+		community := &communities[i]
+		if visited[community.Id] {
+			continue
+		}
+		visited[community.Id] = true
+
+		community.Children = attachChildren(children[community.Id], children, visited)
+		for _, child := range community.Children {
+			if child.IsMember || child.ChildIsMember {
+				community.ChildIsMember = true
+			}
+		}
+	}
+	return communities
+}
+
+func SetCommunityParent(userInfo common.UserInfo, communityID, parentID int) error {
+	if parentID == communityID {
+		return common.InvalidParentCommunity
+	}
+
+	err := db.SetCommunityParent(communityID, parentID)
+	if err != nil {
+		return err
+	}
+
+	auditModerator(common.AuditCommunityMoved, userInfo, 0, "community %d under %d", communityID, parentID)
+	return nil
+}
+
+func GetSubcommunities(communityID int) ([]common.Community, error) {
+	return db.GetSubcommunities(communityID)
+}
+
+func GetCommunity(viewerID, communityID int) (common.Community, error) {
+	return db.GetCommunity(viewerID, communityID)
+}
+
+func GetCommunityContributions(viewerID, communityID int) ([]common.Contribution, error) {
+	return db.GetCommunityContributions(viewerID, communityID, maxCommunityContributions)
+}
+
+// Lists the pending communities the user is allowed to review. Proposals with
+// a parent can be reviewed by that parent's moderators.
+func GetReviewableCommunities(userInfo common.UserInfo) (reviewable []common.Community, err error) {
+	pending, err := db.GetPendingCommunities()
+	if err != nil {
+		return
+	}
+
+	reviewable = []common.Community{}
+	for _, community := range pending {
+		canReview, err := CanModerateCommunity(userInfo, community.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		if canReview {
+			reviewable = append(reviewable, community)
+		}
+	}
+	return
+}
+
+func ReviewCommunity(userInfo common.UserInfo, communityID int, approve bool) error {
+	community, err := db.GetCommunity(0, communityID)
+	if err != nil {
+		return err
+	}
+
+	canReview, err := CanModerateCommunity(userInfo, community.ParentID)
+	if err != nil {
+		return err
+	}
+	if !canReview {
+		log.Printf("User (%d) is not allowed to review community (%d).\n", userInfo.UserID, communityID)
+		return common.PermissionDenied
+	}
+
+	status := common.CommunityRejected
+	action := common.AuditCommunityRejected
+	if approve {
+		status = common.CommunityApproved
+		action = common.AuditCommunityApproved
+	}
+
+	proposerID, err := db.ReviewCommunity(communityID, status)
+	if err != nil {
+		return err
+	}
+	auditModerator(action, userInfo, proposerID, "community %d (%s)", communityID, community.Name)
+
+	// The person who proposed a community is its first member
+	if approve && proposerID != 0 {
+		err = db.AddCommunityMembership(proposerID, communityID)
+		if err != nil {
+			log.Printf("Error adding proposer (%d) to community (%d): %s\n", proposerID, communityID, err.Error())
+		}
+	}
+
+	// Proposed communities only exist once they are approved
+	if approve {
+		emitCommunityEvent(communityID)
+	}
+	return nil
+}
+
+func IsCommunityMember(userID, communityID int) (bool, error) {
+	return db.IsCommunityMember(userID, communityID)
+}
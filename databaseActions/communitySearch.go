@@ -0,0 +1,110 @@
+package databaseActions
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/comforme/comforme/common"
+)
+
+const (
+	maxCommunitySearchResults = 10
+	maxCommunitySuggestions   = 8
+	minCommunityMatchScore    = 0.6
+)
+
+type communityMatch struct {
+	community common.Community
+	score     float64
+}
+
+type byScore []communityMatch
+
+func (matches byScore) Len() int      { return len(matches) }
+func (matches byScore) Swap(i, j int) { matches[i], matches[j] = matches[j], matches[i] }
+func (matches byScore) Less(i, j int) bool {
+	if matches[i].score != matches[j].score {
+		return matches[i].score > matches[j].score
+	}
+	return matches[i].community.Name < matches[j].community.Name
+}
+
+// Fuzzy searches community names and aliases.
+func SearchCommunities(userID int, query string) (results []common.Community, err error) {
+	query = strings.TrimSpace(query)
+	results = []common.Community{}
+	if len(common.NormalizeName(query)) == 0 {
+		return
+	}
+
+	communities, err := db.ListCommunities(userID)
+	if err != nil {
+		return
+	}
+	aliases, err := db.GetCommunityAliases()
+	if err != nil {
+		return
+	}
+
+	names := map[int]string{}
+	for _, community := range communities {
+		names[community.Id] = community.Name
+	}
+
+	matches := []communityMatch{}
+	for _, community := range communities {
+		community.Aliases = aliases[community.Id]
+		community.ParentName = names[community.ParentID]
+
+		score := common.MatchScore(query, community.Name)
+		for _, alias := range community.Aliases {
+			// Alias matches rank just below name matches
+			if aliasScore := common.MatchScore(query, alias) * 0.95; aliasScore > score {
+				score = aliasScore
+			}
+		}
+
+		if score >= minCommunityMatchScore {
+			matches = append(matches, communityMatch{community, score})
+		}
+	}
+
+	sort.Sort(byScore(matches))
+	for i, match := range matches {
+		if i >= maxCommunitySearchResults {
+			break
+		}
+		results = append(results, match.community)
+	}
+	return
+}
+
+func SuggestCommunities(userID int) ([]common.Community, error) {
+	return db.SuggestCommunities(userID, maxCommunitySuggestions)
+}
+
+func GetCommunityAliases() (map[int][]string, error) {
+	return db.GetCommunityAliases()
+}
+
+// Replaces a community's aliases with a comma separated list.
+func SetCommunityAliases(userInfo common.UserInfo, communityID int, aliasList string) error {
+	aliases := []string{}
+	seen := map[string]bool{}
+	for _, alias := range strings.Split(aliasList, ",") {
+		alias = strings.TrimSpace(alias)
+		if len(alias) == 0 || seen[strings.ToLower(alias)] {
+			continue
+		}
+		seen[strings.ToLower(alias)] = true
+		aliases = append(aliases, alias)
+	}
+
+	err := db.SetCommunityAliases(communityID, aliases)
+	if err != nil {
+		return err
+	}
+
+	auditModerator(common.AuditCommunityAliases, userInfo, 0, "community %d aliases set to %s", communityID, strings.Join(aliases, ", "))
+	return nil
+}
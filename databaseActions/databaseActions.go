@@ -1,22 +1,22 @@
 package databaseActions
 
 import (
-	"errors"
 	"fmt"
 	"log"
 	"os"
 
 	"github.com/comforme/comforme/common"
 	"github.com/comforme/comforme/database"
+	"github.com/comforme/comforme/i18n"
 )
 
 // Errors
-var PasswordTooShort = errors.New(fmt.Sprintf("The supplied password is too short. Minimum password length is %d characters.", minPasswordLength))
-var UsernameTooShort = errors.New(fmt.Sprintf("The supplied username is too short. Minimum username length is %d characters.", minUsernameLength))
-var UsernameTooLong = errors.New(fmt.Sprintf("The supplied username is too long. Maximum username length is %d characters.", maxUsernameLength))
-var EmailFailed = errors.New("Sending email failed.")
-var IncorrectPassword = errors.New("Incorrect password.")
-var ShortPassword = errors.New("Password too short.")
+var PasswordTooShort = i18n.NewError("error.password_too_short", minPasswordLength)
+var UsernameTooShort = i18n.NewError("error.username_too_short", minUsernameLength)
+var UsernameTooLong = i18n.NewError("error.username_too_long", maxUsernameLength)
+var EmailFailed = i18n.NewError("error.email_failed")
+var IncorrectPassword = i18n.NewError("error.incorrect_password")
+var ShortPassword = i18n.NewError("error.short_password")
 
 const (
 	minPasswordLength = 6
@@ -34,48 +34,109 @@ func init() {
 	}
 }
 
-func ResetPassword(email, baseURL string) error {
+// Switches to another database, such as a test's own copy.
+func UseDB(other database.DB) {
+	db = other
+}
+
+func ResetPassword(email, baseURL string, requestInfo common.RequestInfo) error {
 	hash, date, err := GenerateResetCode(email)
 	if err != nil {
 		return err
 	}
+
+	userID, err := db.GetUserIDByEmail(email)
+	if err == nil {
+		audit(common.AuditPasswordResetRequested, 0, userID, requestInfo, "")
+	}
 	return common.SendResetEmail(email, date, hash, baseURL)
 }
 
-func CreatePage(userID int, title, description, address, website string, category int) (categorySlug, pageSlug string, err error) {
-	// TODO: Resolve location from address and update lower-level function to accept point
+func CreatePage(userID int, title, description, address, website string, location *common.Location, category int) (categorySlug, pageSlug string, err error) {
+	pageID, categorySlug, pageSlug, err := newPage(userID, title, description, address, website, location, category)
+	if err != nil {
+		return
+	}
+
+	if err := db.SubscribeToPage(userID, pageID); err != nil {
+		log.Printf("Failed to subscribe user (%d) to page (%d): %s\n", userID, pageID, err.Error())
+	}
+
+	emitPageEvent(common.EventPageCreated, pageID)
+	federatePage(pageID)
+	return
+}
+
+// Stores a page without subscribing its author or telling webhooks and
+// followers about it, which CreatePage does and imports do not.
+func newPage(userID int, title, description, address, website string, location *common.Location, category int) (pageID int, categorySlug, pageSlug string, err error) {
 	slug := common.GenSlug(title)
 	if len(slug) <= 1 {
 		err = common.InvalidTitle
 		return
 	}
 
-	pageID, err := db.NewPage(userID, title, slug, description, address, website, category)
+	if location != nil && !location.Valid() {
+		err = common.InvalidLocation
+		return
+	}
+
+	pageID, err = db.NewPage(userID, title, slug, description, address, website, location, category)
 	if err != nil {
 		log.Println("Failed to create page", title)
 		return
 	}
 
 	categorySlug, pageSlug, err = db.GetSlugs(pageID)
+	return
+}
+
+func CreatePost(user_id int, post string, page common.Page, pseudonymID int, anonymous bool) (err error) {
+	blocked, err := db.HasBlocked(page.AuthorID, user_id)
 	if err != nil {
 		return
 	}
+	if blocked {
+		return common.BlockedByPageAuthor
+	}
 
-	return
-}
+	blocked, err = db.BlockedByAny(user_id, common.Mentions(post))
+	if err != nil {
+		return
+	}
+	if blocked {
+		return common.BlockedByMentionedUser
+	}
 
-func CreatePost(user_id int, post string, page common.Page) (err error) {
-	err = db.NewPost(user_id, page.Id, post)
+	postID, err := db.NewPost(user_id, page.Id, post, pseudonymID, anonymous)
 	if err != nil {
 		return
 	}
 
+	// The post is in, so failing to notify only gets logged
+	if err := db.NotifySubscribers(postID); err != nil {
+		log.Printf("Failed to notify subscribers of post (%d): %s\n", postID, err.Error())
+	}
+	if err := db.SubscribeToPage(user_id, page.Id); err != nil {
+		log.Printf("Failed to subscribe user (%d) to page (%d): %s\n", user_id, page.Id, err.Error())
+	}
+
+	emitPostEvent(postID, post, page)
+
+	// Communities would give away who wrote an anonymous or pseudonymous post
+	authorID := user_id
+	if anonymous || pseudonymID != 0 {
+		authorID = 0
+	}
+	federatePost(postID, page, post, authorID)
 	return
 }
 
-func ChangePassword(email, oldPassword, newPassword string) (err error) {
+func ChangePassword(userInfo common.UserInfo, oldPassword, newPassword string) (err error) {
+	email := userInfo.Email
 	_, err = db.GetUserID(email, oldPassword)
 	if err != nil {
+		auditSelf(common.AuditPasswordChangeFailed, userInfo, "incorrect password")
 		return
 	}
 
@@ -90,10 +151,17 @@ func ChangePassword(email, oldPassword, newPassword string) (err error) {
 		return ShortPassword
 	}
 
-	return db.ChangePassword(email, newPassword)
+	err = db.ChangePassword(email, newPassword)
+	if err != nil {
+		return
+	}
+
+	auditSelf(common.AuditPasswordChanged, userInfo, "")
+	return
 }
 
-func SetPassword(email, newPassword string) (err error) {
+// Sets a new password from a password reset link.
+func SetPassword(email, newPassword string, requestInfo common.RequestInfo) (err error) {
 	// Check new password meets requirements
 	if len(newPassword) < minPasswordLength {
 		log.Printf(
@@ -105,7 +173,18 @@ func SetPassword(email, newPassword string) (err error) {
 		return ShortPassword
 	}
 
-	return db.ChangePassword(email, newPassword)
+	err = db.ChangePassword(email, newPassword)
+	if err != nil {
+		return
+	}
+
+	userID, err := db.GetUserIDByEmail(email)
+	if err != nil {
+		return
+	}
+
+	audit(common.AuditPasswordReset, userID, userID, requestInfo, "")
+	return
 }
 
 func Logout(sessionid string) error {
@@ -132,10 +211,17 @@ func ListCategories() (map[string]string, error) {
 	return db.ListCategories()
 }
 
-func Login(email, password string) (sessionid string, err error) {
+func Login(email, password string, requestInfo common.RequestInfo) (sessionid string, err error) {
 	userid, err := db.GetUserID(email, password)
 	if err != nil {
 		log.Printf("Error while logging in user (%s): %s\n", email, err.Error())
+		failedID, lookupErr := db.GetUserIDByEmail(email)
+		if lookupErr != nil {
+			failedID = 0
+		}
+		// The account, if there is one, is the subject. The email is left
+		// out, since audit events outlive the accounts they refer to
+		audit(common.AuditLoginFailed, 0, failedID, requestInfo, "reason: "+err.Error())
 		return
 	}
 
@@ -144,26 +230,31 @@ func Login(email, password string) (sessionid string, err error) {
 		return
 	}
 
+	audit(common.AuditLogin, userid, userid, requestInfo, "")
 	return
 }
 
-func ChangeUsername(email string, newUsername, password string) (err error) {
+func ChangeUsername(userInfo common.UserInfo, newUsername, password string) (err error) {
 	if len(newUsername) < minUsernameLength {
 		err = UsernameTooShort
 		return
 	}
 
-	userid, err := db.GetUserID(email, password)
+	userid, err := db.GetUserID(userInfo.Email, password)
 	if err != nil {
 		return
 	}
 
 	err = db.ChangeUsername(userid, newUsername)
+	if err != nil {
+		return
+	}
 
+	auditSelf(common.AuditUsernameChanged, userInfo, fmt.Sprintf("from %s to %s", userInfo.Username, newUsername))
 	return
 }
 
-func Register2(username, email, password string) (sessionid string, err error) {
+func Register2(username, email, password string, requestInfo common.RequestInfo) (sessionid string, err error) {
 	if !common.ValidEmail(email) {
 		err = common.InvalidEmail
 		return
@@ -196,18 +287,19 @@ func Register2(username, email, password string) (sessionid string, err error) {
 		return
 	}
 
-	sessionid, err = Login(email, password)
+	userid, err := db.GetUserIDByEmail(email)
 	if err != nil {
 		return
 	}
+	audit(common.AuditRegistered, userid, userid, requestInfo, "")
 
-	userid, err := db.GetSessionUserID(sessionid)
+	sessionid, err = Login(email, password, requestInfo)
 	if err != nil {
 		return
 	}
 
 	// Make new users lazy :)
-	err = SetCommunityMembership(userid, 1, true)
+	err = SetCommunityMembership(common.UserInfo{UserID: userid, RequestInfo: requestInfo}, 1, true)
 	return
 }
 
@@ -230,45 +322,78 @@ func Register1(email, baseURL string) (err error) {
 	return
 }
 
-func SetCommunityMembership(userid int, community_id int, value bool) (err error) {
+func SetCommunityMembership(userInfo common.UserInfo, community_id int, value bool) (err error) {
 	if value {
-		err = db.AddCommunityMembership(userid, community_id)
+		err = db.AddCommunityMembership(userInfo.UserID, community_id)
 		if err != nil {
 			return
 		}
+		auditSelf(common.AuditMembershipAdded, userInfo, fmt.Sprintf("community %d", community_id))
 	} else {
-		err = db.DeleteCommunityMembership(userid, community_id)
+		err = db.DeleteCommunityMembership(userInfo.UserID, community_id)
 		if err != nil {
 			return
 		}
+		auditSelf(common.AuditMembershipRemoved, userInfo, fmt.Sprintf("community %d", community_id))
 	}
 
 	return
 }
 
+func SetMembershipVisibility(userInfo common.UserInfo, community_id int, visibility string) (err error) {
+	if !common.ValidMembershipVisibility(visibility) {
+		return common.InvalidVisibility
+	}
+
+	err = db.SetMembershipVisibility(userInfo.UserID, community_id, visibility)
+	if err != nil {
+		return
+	}
+	auditSelf(common.AuditMembershipVisibility, userInfo, fmt.Sprintf("community %d: %s", community_id, visibility))
+
+	return
+}
+
 func OtherSessions(userid int) (num int, err error) {
 	num, err = db.OpenSessions(userid)
 	num--
 	return
 }
 
-func LogoutOtherSessions(sessionid string, userid int) (loggedOut int, err error) {
-	loggedOut, err = db.DeleteOtherSessions(userid, sessionid)
+func LogoutOtherSessions(userInfo common.UserInfo) (loggedOut int, err error) {
+	// Without a session of its own every session would count as another one
+	if userInfo.ViaToken || userInfo.SessionID == "" {
+		err = common.SessionRequired
+		return
+	}
+
+	loggedOut, err = db.DeleteOtherSessions(userInfo.UserID, userInfo.SessionID)
 	if err != nil {
 		log.Printf(
 			"Error deleting other sessions for userid (%d) with sessionid (%s): %s\n",
-			userid,
-			sessionid,
+			userInfo.UserID,
+			userInfo.SessionID,
 			err.Error(),
 		)
 		return
 	}
 
+	auditSelf(common.AuditSessionsRevoked, userInfo, fmt.Sprintf("%d other sessions", loggedOut))
 	return
 }
 
-func SearchPages(sessionid, query string) ([]common.Page, error) {
-	return db.SearchPages(query)
+func SearchPages(userID int, query string) ([]common.Page, error) {
+	return db.SearchPages(userID, query)
+}
+
+func ListCommunities(userid int) ([]common.Community, error) {
+	return db.ListCommunities(userid)
+}
+
+// Lists the newest pages, optionally only those in one category, for paging
+// through them.
+func ListPages(categorySlug string, limit, offset int) ([]common.Page, error) {
+	return db.GetLatestPages(categorySlug, limit, offset)
 }
 
 func GetPages() ([]common.Page, error) {
@@ -295,11 +420,14 @@ func GetPosts(userid int, page common.Page) (posts []common.Post, err error) {
 	return
 }
 
+// Splits the top level communities into four columns. Subcommunities are
+// nested under their parents.
 func GetCommunityColumns(userid int) ([][]common.Community, error) {
 	communities, err := db.ListCommunities(userid)
 	if err != nil {
 		return [][]common.Community{}, err
 	}
+	communities = BuildCommunityTree(communities)
 
 	perCol := len(communities) / 4
 	extra := len(communities) % 4
@@ -350,6 +478,6 @@ func CheckRegisterLink(code, email, date string) bool {
 	return common.CheckSecret(code, email, date)
 }
 
-func GetTopPages() (pages []common.PagePostCount, err error) {
-	return db.GetTopPages()
+func GetSlugs(pageID int) (categorySlug, pageSlug string, err error) {
+	return db.GetSlugs(pageID)
 }
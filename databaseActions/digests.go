@@ -0,0 +1,84 @@
+package databaseActions
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/comforme/comforme/common"
+)
+
+const (
+	maxDigestItems = 10
+
+	unsubscribeLinkPurpose = "unsubscribe"
+)
+
+// Emails every user whose digest is due and returns how many were sent.
+// Users with nothing new are skipped until there is something to send.
+func SendDigests(baseURL string) (sent int, err error) {
+	recipients, err := db.GetDigestRecipients()
+	if err != nil {
+		return
+	}
+
+	for _, recipient := range recipients {
+		digest, err := db.GetDigest(recipient.UserID, recipient.Since, maxDigestItems)
+		if err != nil {
+			log.Printf("Error building digest for user (%d): %s\n", recipient.UserID, err.Error())
+			continue
+		}
+		if digest.IsEmpty() {
+			continue
+		}
+
+		err = common.SendDigestEmail(recipient, digest, UnsubscribeLink(recipient.UserID), baseURL)
+		if err != nil {
+			log.Printf("Error sending digest to user (%d): %s\n", recipient.UserID, err.Error())
+			continue
+		}
+
+		err = db.MarkDigestSent(recipient.UserID)
+		if err != nil {
+			log.Printf("Error marking digest sent for user (%d): %s\n", recipient.UserID, err.Error())
+		}
+		sent++
+	}
+
+	return
+}
+
+// The signed path that turns off a user's digests. It keeps working so that
+// any digest the user still has can be used to unsubscribe.
+func UnsubscribeLink(userID int) string {
+	return fmt.Sprintf(
+		"/unsubscribe?user=%d&sig=%s",
+		userID,
+		common.SignPermanentLink(unsubscribeLinkPurpose, userID),
+	)
+}
+
+func CheckUnsubscribeLink(userID int, signature string) bool {
+	return common.CheckPermanentLink(unsubscribeLinkPurpose, userID, signature)
+}
+
+// The address digests are sent to, to show on the unsubscribe page.
+func GetDigestEmail(userID int) (string, error) {
+	user, err := db.GetUser(userID)
+	return user.Email, err
+}
+
+func UnsubscribeFromDigest(userID int) error {
+	return db.UnsubscribeFromDigest(userID)
+}
+
+func GetDigestFrequency(userID int) (string, error) {
+	return db.GetDigestFrequency(userID)
+}
+
+func SetDigestFrequency(userID int, frequency string) error {
+	if !common.ValidDigestFrequency(frequency) {
+		return common.InvalidDigestFrequency
+	}
+
+	return db.SetDigestFrequency(userID, frequency)
+}
@@ -0,0 +1,150 @@
+package databaseActions
+
+import (
+	"log"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/comforme/comforme/common"
+	"github.com/comforme/comforme/i18n"
+)
+
+const maxSimilarPages = 10
+
+// Finds pages that may be the same place as the one described, for warning
+// before a duplicate is created. categoryID may be 0 if it is not known yet.
+func FindSimilarNewPages(title, address, website string, location *common.Location, categoryID int) ([]common.SimilarPage, error) {
+	return findSimilarPages(common.PageCandidate{
+		Page: common.Page{
+			Title:    title,
+			Address:  address,
+			Website:  website,
+			Location: location,
+		},
+		CategoryID: categoryID,
+	})
+}
+
+// Looks up a page and the pages that may be duplicates of it.
+func FindDuplicatePages(pageID int) (page common.PageCandidate, similar []common.SimilarPage, err error) {
+	page, err = db.GetPageCandidate(pageID)
+	if err != nil {
+		return
+	}
+
+	similar, err = findSimilarPages(page)
+	return
+}
+
+// Titles are only compared with those that share a word with them or start
+// with the same this many letters once normalized, so a typo at the very
+// start of a one-word title goes unnoticed.
+const titlePrefixLength = 3
+
+// Compares a page by title with the pages in its category, and by address,
+// website and location with pages in any category. The most alike pages come
+// first.
+func findSimilarPages(page common.PageCandidate) (similar []common.SimilarPage, err error) {
+	address := common.NormalizeAddress(page.Address)
+	website := common.NormalizeWebsite(page.Website)
+	titlePrefix := []rune(common.NormalizeName(page.Title))
+	if len(titlePrefix) > titlePrefixLength {
+		titlePrefix = titlePrefix[:titlePrefixLength]
+	}
+
+	pages, err := db.GetPageCandidates(page.Id, page.CategoryID, common.TitleWords(page.Title), string(titlePrefix), address, website, page.Location)
+	if err != nil {
+		return
+	}
+
+	for _, other := range pages {
+		var reasons []i18n.Message
+		if (page.CategoryID == 0 || other.CategoryID == page.CategoryID) && common.SimilarTitles(page.Title, other.Title) {
+			reasons = append(reasons, i18n.NewMessage("similar.title"))
+		}
+		if address != "" && common.NormalizeAddress(other.Address) == address {
+			reasons = append(reasons, i18n.NewMessage("similar.address"))
+		}
+		if website != "" && common.NormalizeWebsite(other.Website) == website {
+			reasons = append(reasons, i18n.NewMessage("similar.website"))
+		}
+		if page.Location != nil && other.Location != nil {
+			distance := page.Location.Distance(*other.Location)
+			if distance <= common.NearbyPageDistance {
+				reasons = append(reasons, i18n.NewMessage("similar.distance", distance))
+			}
+		}
+
+		if len(reasons) > 0 {
+			similar = append(similar, common.SimilarPage{Page: other.Page, Reasons: reasons})
+		}
+	}
+
+	sort.SliceStable(similar, func(i, j int) bool {
+		return len(similar[i].Reasons) > len(similar[j].Reasons)
+	})
+	if len(similar) > maxSimilarPages {
+		similar = similar[:maxSimilarPages]
+	}
+	return
+}
+
+// Finds the page a link points to, which may be a path such as
+// /page/medical/some-clinic or a full URL.
+func GetPageByLink(link string) (common.Page, error) {
+	parsed, err := url.Parse(strings.TrimSpace(link))
+	if err != nil {
+		return common.Page{}, common.PageNotFound
+	}
+
+	parts := strings.Split(strings.Trim(parsed.Path, "/"), "/")
+	if len(parts) < 3 || parts[0] != "page" {
+		return common.Page{}, common.PageNotFound
+	}
+	return db.GetPage(parts[1], parts[2])
+}
+
+// Merges one page into another. The merged page's posts, followers and
+// pending remote replies move to the page it is merged into, and links to it
+// redirect there.
+func MergePages(userInfo common.UserInfo, fromID, intoID int) error {
+	if !userInfo.Can(common.PermissionModerate) {
+		return common.PermissionDenied
+	}
+	if fromID == intoID {
+		return common.CannotMergePageIntoSelf
+	}
+
+	from, err := db.GetPageCandidate(fromID)
+	if err != nil {
+		return err
+	}
+	into, err := db.GetPageCandidate(intoID)
+	if err != nil {
+		return err
+	}
+
+	err = db.MergePages(fromID, intoID)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Merged page (%d) into page (%d).\n", fromID, intoID)
+	auditModerator(common.AuditPagesMerged, userInfo, 0, "page %d (%s) into %d (%s)", fromID, from.Title, intoID, into.Title)
+	return nil
+}
+
+// Finds where a page that was merged into another page is now, as a path.
+func GetPageRedirect(categorySlug, pageSlug string) (string, error) {
+	newCategorySlug, newPageSlug, err := db.GetPageRedirect(categorySlug, pageSlug)
+	if err != nil {
+		return "", err
+	}
+	return "/page/" + newCategorySlug + "/" + newPageSlug, nil
+}
+
+// Finds the id of the page a merged page is now part of.
+func GetMergedPageID(oldPageID int) (int, error) {
+	return db.GetMergedPageID(oldPageID)
+}
@@ -0,0 +1,128 @@
+package databaseActions
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/comforme/comforme/common"
+)
+
+const (
+	exportLinkPurpose  = "export"
+	exportLinkLifetime = 7 * 24 * time.Hour
+	exportBuildLease   = 10 * time.Minute
+	maxListedExports   = 5
+)
+
+// Signals the export builder that there is an export to build.
+var ExportsQueued = make(chan struct{}, 1)
+
+// Asks for an archive of the user's data to be built and emailed to them.
+// baseURL is where the download link in the email points.
+func RequestDataExport(userInfo common.UserInfo, baseURL string) error {
+	_, err := db.NewDataExport(userInfo.UserID, baseURL)
+	if err != nil {
+		return err
+	}
+
+	auditSelf(common.AuditExportRequested, userInfo, "")
+	select {
+	case ExportsQueued <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func GetDataExports(userID int) ([]common.DataExport, error) {
+	return db.GetDataExports(userID, maxListedExports)
+}
+
+// The signed path an export can be downloaded from until it expires.
+func ExportLink(export common.DataExport) string {
+	if export.Expires == nil {
+		return ""
+	}
+	return fmt.Sprintf(
+		"/settings/export/%d?expires=%d&sig=%s",
+		export.Id,
+		export.Expires.Unix(),
+		common.SignLink(exportLinkPurpose, export.Id, *export.Expires),
+	)
+}
+
+// Claims an export that is waiting to be built. found is false if there are
+// none.
+func ClaimDataExport() (common.DataExport, bool, error) {
+	return db.ClaimDataExport(exportBuildLease)
+}
+
+// Collects everything about a user that goes into their export.
+func GetExportData(userID int) (data common.ExportData, err error) {
+	if data.Profile, err = db.GetExportProfile(userID); err != nil {
+		return
+	}
+	if data.Pseudonyms, err = db.GetExportPseudonyms(userID); err != nil {
+		return
+	}
+	if data.Memberships, err = db.GetExportMemberships(userID); err != nil {
+		return
+	}
+	if data.Pages, err = db.GetExportPages(userID); err != nil {
+		return
+	}
+	if data.Posts, err = db.GetExportPosts(userID); err != nil {
+		return
+	}
+	if data.Sessions, err = db.GetExportSessions(userID); err != nil {
+		return
+	}
+	data.AuditEvents, err = db.GetExportAuditEvents(userID)
+	return
+}
+
+// Stores a built archive and emails the user a link to it.
+func CompleteDataExport(export common.DataExport, archive []byte) error {
+	expires := time.Now().Add(exportLinkLifetime).Truncate(time.Second)
+	err := db.CompleteDataExport(export.Id, archive, expires)
+	if err != nil {
+		return err
+	}
+
+	user, err := db.GetUser(export.UserID)
+	if err != nil {
+		return err
+	}
+
+	export.Expires = &expires
+	err = common.SendExportEmail(user.Email, export.BaseURL+ExportLink(export), expires)
+	if err != nil {
+		// The export can still be downloaded from the settings page
+		log.Printf("Failed to email data export (%d) to user (%d): %s\n", export.Id, export.UserID, err.Error())
+	}
+	return nil
+}
+
+func FailDataExport(export common.DataExport, buildErr error) error {
+	return db.FailDataExport(export.Id, buildErr.Error())
+}
+
+// Returns the archive a signed export link points to. The link only works
+// for the user the export belongs to.
+func DownloadDataExport(userInfo common.UserInfo, exportID int, expires time.Time, signature string) ([]byte, error) {
+	if !common.CheckLink(exportLinkPurpose, exportID, expires, signature) {
+		return nil, common.ExportLinkInvalid
+	}
+
+	archive, err := db.GetDataExportArchive(exportID, userInfo.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	auditSelf(common.AuditExportDownloaded, userInfo, fmt.Sprintf("export %d", exportID))
+	return archive, nil
+}
+
+func ExpireDataExports() (int64, error) {
+	return db.ExpireDataExports()
+}
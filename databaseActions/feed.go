@@ -0,0 +1,39 @@
+package databaseActions
+
+import (
+	"time"
+
+	"github.com/comforme/comforme/common"
+)
+
+const (
+	feedPageSize = 20
+
+	// How far back the feed and trending pages look
+	feedWindow     = time.Hour * 24 * 30
+	trendingWindow = time.Hour * 24 * 7
+)
+
+// Returns the part of the user's feed starting at offset, as it was at asOf,
+// and whether there is more after it.
+func GetFeed(userID int, asOf time.Time, offset int) (items []common.FeedItem, more bool, err error) {
+	if offset < 0 {
+		offset = 0
+	}
+
+	items, err = db.GetFeed(userID, asOf, asOf.Add(-feedWindow), feedPageSize+1, offset)
+	if err != nil {
+		return
+	}
+
+	if len(items) > feedPageSize {
+		items = items[:feedPageSize]
+		more = true
+	}
+	return
+}
+
+// The pages with the most posts in the last week.
+func GetTrendingPages(userID int) ([]common.PagePostCount, error) {
+	return db.GetTopPages(userID, time.Now().Add(-trendingWindow))
+}
@@ -0,0 +1,136 @@
+package databaseActions
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/comforme/comforme/common"
+)
+
+const oidcLoginLifetime = 10 * time.Minute
+
+// Records the start of an OpenID Connect login and returns the state to send
+// to the provider along with the nonce and PKCE code verifier to use.
+func StartOIDCLogin(provider string, linkUserID int, returnTo string) (state string, login common.OIDCLogin, err error) {
+	secrets := make([]string, 3)
+	for i := range secrets {
+		secrets[i], err = common.NewToken()
+		if err != nil {
+			common.LogError(err)
+			return "", login, common.DatabaseError
+		}
+	}
+
+	state = secrets[0]
+	login = common.OIDCLogin{
+		Provider:     provider,
+		Nonce:        secrets[1],
+		CodeVerifier: secrets[2],
+		LinkUserID:   linkUserID,
+		ReturnTo:     returnTo,
+		Expires:      time.Now().Add(oidcLoginLifetime),
+	}
+	err = db.NewOIDCLogin(state, login)
+	return
+}
+
+// Looks up the login a provider sent the user back from. Each state can
+// only be used once.
+func TakeOIDCLogin(state string) (login common.OIDCLogin, err error) {
+	login, err = db.TakeOIDCLogin(state)
+	if err == nil && time.Now().After(login.Expires) {
+		err = common.InvalidLink
+	}
+	return
+}
+
+// Logs in with an identity from an external provider. Identities that are
+// not linked yet are linked to the account with the same email, but only if
+// the provider verified it. Returns common.UserNotFound if there is no such
+// account, in which case the user should register.
+func ExternalLogin(identity common.ExternalIdentity, requestInfo common.RequestInfo) (sessionid string, err error) {
+	userID, suspended, err := db.GetIdentityUser(identity.Provider, identity.Subject)
+	if err == common.IdentityNotFound {
+		if !identity.EmailVerified {
+			return "", common.EmailNotVerified
+		}
+
+		userID, err = db.GetUserIDByEmail(identity.Email)
+		if err != nil {
+			return "", common.UserNotFound
+		}
+
+		suspended, err = db.GetUserSuspended(userID)
+		if err != nil {
+			return
+		}
+		if !suspended {
+			err = db.NewIdentity(userID, identity)
+			if err != nil {
+				return
+			}
+			audit(common.AuditIdentityLinked, userID, userID, requestInfo, identity.Provider+", by verified email")
+		}
+	} else if err != nil {
+		return
+	}
+
+	if suspended {
+		audit(common.AuditLoginFailed, 0, userID, requestInfo, fmt.Sprintf("via %s, reason: %s", identity.Provider, common.AccountSuspended.Error()))
+		return "", common.AccountSuspended
+	}
+
+	sessionid, err = db.NewSession(userID)
+	if err != nil {
+		return
+	}
+
+	audit(common.AuditLogin, userID, userID, requestInfo, "via "+identity.Provider)
+	return
+}
+
+// Links an identity to a logged in user from their settings.
+func LinkIdentity(userID int, identity common.ExternalIdentity, requestInfo common.RequestInfo) error {
+	err := db.NewIdentity(userID, identity)
+	if err != nil {
+		return err
+	}
+
+	audit(common.AuditIdentityLinked, userID, userID, requestInfo, identity.Provider)
+	return nil
+}
+
+// The link that finishes registration for an email address a provider has
+// already verified, so that no email needs to be sent.
+func VerifiedRegistrationLink(email string) (string, error) {
+	err := db.CheckEmailInUse(email)
+	if err != nil {
+		return "", err
+	}
+
+	hash, date, err := common.GenerateSecret(email)
+	if err != nil {
+		return "", err
+	}
+
+	return "/register?" + url.Values{
+		"email": {email},
+		"date":  {date},
+		"code":  {hash},
+	}.Encode(), nil
+}
+
+func GetIdentities(userID int) ([]common.Identity, error) {
+	return db.GetIdentities(userID)
+}
+
+func UnlinkIdentity(userInfo common.UserInfo, identityID int) error {
+	provider, err := db.DeleteIdentity(userInfo.UserID, identityID)
+	if err != nil {
+		return err
+	}
+
+	auditSelf(common.AuditIdentityUnlinked, userInfo, provider)
+	return nil
+}
@@ -0,0 +1,203 @@
+package databaseActions
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/comforme/comforme/common"
+)
+
+// Imports pages as the logged in admin. Rows are checked the same way as
+// pages made with the new page form, and pages already on the site are
+// skipped so that a file can be imported again. A dry run only reports what
+// would happen. defaultCategory is used for rows without a category, and may
+// be 0 if every row has one.
+func ImportPages(userInfo common.UserInfo, rows []common.ImportRow, defaultCategory int, dryRun bool) (report common.ImportReport, err error) {
+	if !userInfo.Can(common.PermissionImportPages) {
+		err = common.PermissionDenied
+		return
+	}
+
+	report, err = importPages(userInfo.UserID, rows, defaultCategory, dryRun)
+	if err != nil || dryRun {
+		return
+	}
+
+	auditModerator(common.AuditPagesImported, userInfo, 0, "%s", importSummary(report))
+	return
+}
+
+// Imports pages as the user with the given email, for the import command.
+func ImportPagesAs(email string, rows []common.ImportRow, defaultCategory int, dryRun bool) (report common.ImportReport, err error) {
+	userID, err := db.GetUserIDByEmail(email)
+	if err != nil {
+		return
+	}
+
+	report, err = importPages(userID, rows, defaultCategory, dryRun)
+	if err != nil || dryRun {
+		return
+	}
+
+	audit(common.AuditPagesImported, 0, userID, common.RequestInfo{}, importSummary(report)+", from the command line")
+	return
+}
+
+func importSummary(report common.ImportReport) string {
+	return fmt.Sprintf("%d created, %d duplicates, %d invalid", report.Created, report.Duplicates, report.Invalid)
+}
+
+// Finds the category an import names by its name, slug or id.
+func FindCategory(name string) (int, error) {
+	categoryIDs, err := getCategoryIDs()
+	if err != nil {
+		return 0, err
+	}
+
+	categoryID, ok := categoryIDs[strings.ToLower(name)]
+	if !ok {
+		return 0, common.ImportCategoryUnknown
+	}
+	return categoryID, nil
+}
+
+// Every category keyed by its lower case name, its slug and its id.
+func getCategoryIDs() (map[string]int, error) {
+	categories, err := db.GetCategories()
+	if err != nil {
+		return nil, err
+	}
+
+	categoryIDs := map[string]int{}
+	for _, category := range categories {
+		categoryIDs[strings.ToLower(category.Name)] = category.Id
+		categoryIDs[category.Slug] = category.Id
+		categoryIDs[strconv.Itoa(category.Id)] = category.Id
+	}
+	return categoryIDs, nil
+}
+
+func importPages(userID int, rows []common.ImportRow, defaultCategory int, dryRun bool) (report common.ImportReport, err error) {
+	categoryIDs, err := getCategoryIDs()
+	if err != nil {
+		return
+	}
+
+	report.DryRun = dryRun
+	report.Results = make([]common.ImportResult, 0, len(rows))
+	seenPages := map[string]bool{}
+	seenSlugs := map[string]bool{}
+	for _, row := range rows {
+		result := importPage(userID, row, categoryIDs, defaultCategory, dryRun, seenPages, seenSlugs)
+		switch result.Status {
+		case common.ImportCreated:
+			report.Created++
+		case common.ImportValid:
+			report.Valid++
+		case common.ImportDuplicate:
+			report.Duplicates++
+		case common.ImportInvalid:
+			report.Invalid++
+		}
+		report.Results = append(report.Results, result)
+	}
+
+	log.Printf(
+		"Imported pages for user (%d), dry run %t: %d created, %d valid, %d duplicates, %d invalid.\n",
+		userID,
+		dryRun,
+		report.Created,
+		report.Valid,
+		report.Duplicates,
+		report.Invalid,
+	)
+	return
+}
+
+// Normalizes a field the way FindDuplicatePage compares it.
+func duplicateKey(field string) string {
+	return strings.ToLower(strings.Trim(field, " "))
+}
+
+// seenPages and seenSlugs hold the pages earlier in the same import, which
+// are not on the site yet in a dry run.
+func importPage(userID int, row common.ImportRow, categoryIDs map[string]int, defaultCategory int, dryRun bool, seenPages, seenSlugs map[string]bool) common.ImportResult {
+	result := common.ImportResult{Row: row.Row, Title: row.Title}
+	invalid := func(err error) common.ImportResult {
+		result.Status = common.ImportInvalid
+		result.Error = err
+		return result
+	}
+
+	categoryID := defaultCategory
+	if row.Category != "" {
+		var ok bool
+		if categoryID, ok = categoryIDs[strings.ToLower(row.Category)]; !ok {
+			return invalid(common.ImportCategoryUnknown)
+		}
+	}
+	if categoryID == 0 {
+		return invalid(common.ImportCategoryMissing)
+	}
+
+	slug := common.GenSlug(row.Title)
+	if len(slug) <= 1 {
+		return invalid(common.InvalidTitle)
+	}
+	if len(row.Description) < common.MinDescriptionLength {
+		return invalid(common.DescriptionTooShort)
+	}
+	location, err := common.ParseLocation(row.Location)
+	if err != nil {
+		return invalid(err)
+	}
+
+	key := duplicateKey(row.Title) + "\n" + duplicateKey(row.Address) + "\n" + duplicateKey(row.Website)
+	if seenPages[key] {
+		result.Status = common.ImportDuplicate
+		result.Error = common.ImportDuplicateInFile
+		return result
+	}
+	seenPages[key] = true
+
+	categorySlug, pageSlug, found, err := db.FindDuplicatePage(row.Title, row.Address, row.Website)
+	if err != nil {
+		return invalid(err)
+	}
+	if found {
+		result.Status = common.ImportDuplicate
+		result.Path = "/page/" + categorySlug + "/" + pageSlug
+		return result
+	}
+
+	// A different page with the same title in the same category
+	slugKey := strconv.Itoa(categoryID) + "/" + slug
+	if seenSlugs[slugKey] {
+		return invalid(common.PageAlreadyExists)
+	}
+	seenSlugs[slugKey] = true
+	taken, err := db.PageSlugTaken(categoryID, slug)
+	if err != nil {
+		return invalid(err)
+	}
+	if taken {
+		return invalid(common.PageAlreadyExists)
+	}
+
+	if dryRun {
+		result.Status = common.ImportValid
+		return result
+	}
+
+	// Imports can be thousands of pages, so the importer is not subscribed to
+	// them and they are not sent to webhooks or followers one by one.
+	_, categorySlug, pageSlug, err = newPage(userID, row.Title, row.Description, row.Address, row.Website, location, categoryID)
+	if err != nil {
+		return invalid(err)
+	}
+	result.Status = common.ImportCreated
+	result.Path = "/page/" + categorySlug + "/" + pageSlug
+	return result
+}
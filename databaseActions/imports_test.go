@@ -0,0 +1,63 @@
+package databaseActions
+
+import (
+	"testing"
+
+	"github.com/comforme/comforme/common"
+	"github.com/comforme/comforme/database"
+	"github.com/comforme/comforme/dbtest"
+)
+
+func TestImportPages(t *testing.T) {
+	conn, dsn := dbtest.New(t)
+	testDB, err := database.NewDB(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	UseDB(testDB)
+
+	admin := dbtest.User(t, conn, "admin")
+	category := dbtest.Category(t, conn, "Medical")
+	existing := dbtest.Page(t, conn, admin, category, "Old Clinic")
+	if _, err := conn.Exec("UPDATE pages SET address = '1 Main St' WHERE id = $1", existing); err != nil {
+		t.Fatal(err)
+	}
+
+	description := "A clinic that was imported by a test."
+	rows := []common.ImportRow{
+		{Row: 1, Title: "New Clinic", Description: description, Address: "2 Main St"},
+		// The same page again, differing only in case and spaces
+		{Row: 2, Title: " new clinic ", Description: description, Address: "2 MAIN ST "},
+		{Row: 3, Title: "old clinic", Description: description, Address: " 1 Main St"},
+		{Row: 4, Title: "Far Clinic", Description: description, Location: "somewhere"},
+		{Row: 5, Title: "Mapped Clinic", Description: description, Location: "40.7128, -74.0060"},
+	}
+	report, err := importPages(admin, rows, category, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{common.ImportCreated, common.ImportDuplicate, common.ImportDuplicate, common.ImportInvalid, common.ImportCreated}
+	for i, result := range report.Results {
+		if result.Status != want[i] {
+			t.Errorf("row %d: got %s (%v), want %s", result.Row, result.Status, result.Error, want[i])
+		}
+	}
+	if report.Results[1].Error != common.ImportDuplicateInFile {
+		t.Errorf("row 2: got error %v", report.Results[1].Error)
+	}
+	if report.Results[3].Error != common.InvalidLocation {
+		t.Errorf("row 4: got error %v", report.Results[3].Error)
+	}
+	if page, err := GetPage("medical", "mapped-clinic"); err != nil || page.Location == nil {
+		t.Errorf("row 5: got location %v (%v)", page.Location, err)
+	}
+
+	var subscriptions int
+	if err := conn.QueryRow("SELECT count(*) FROM page_subscriptions WHERE user_id = $1", admin).Scan(&subscriptions); err != nil {
+		t.Fatal(err)
+	}
+	if subscriptions != 0 {
+		t.Errorf("importer subscribed to %d pages", subscriptions)
+	}
+}
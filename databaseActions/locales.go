@@ -0,0 +1,19 @@
+package databaseActions
+
+import (
+	"github.com/comforme/comforme/common"
+	"github.com/comforme/comforme/i18n"
+)
+
+// The user's language setting, or "" to follow their browser.
+func GetLocale(userID int) (string, error) {
+	return db.GetLocale(userID)
+}
+
+func SetLocale(userID int, locale string) error {
+	if locale != "" && !i18n.Supported(locale) {
+		return common.InvalidLocale
+	}
+
+	return db.SetLocale(userID, locale)
+}
@@ -0,0 +1,34 @@
+package databaseActions
+
+import (
+	"github.com/comforme/comforme/common"
+)
+
+const maxNotifications = 50
+
+func GetNotifications(userID int) ([]common.Notification, error) {
+	return db.GetNotifications(userID, maxNotifications)
+}
+
+func CountUnreadNotifications(userID int) (int, error) {
+	return db.CountUnreadNotifications(userID)
+}
+
+func MarkNotificationRead(userID, notificationID int) error {
+	return db.MarkNotificationRead(userID, notificationID)
+}
+
+func MarkAllNotificationsRead(userID int) (int, error) {
+	return db.MarkAllNotificationsRead(userID)
+}
+
+func IsSubscribed(userID, pageID int) (bool, error) {
+	return db.IsSubscribed(userID, pageID)
+}
+
+func SetPageSubscription(userID, pageID int, subscribed bool) error {
+	if subscribed {
+		return db.SubscribeToPage(userID, pageID)
+	}
+	return db.UnsubscribeFromPage(userID, pageID)
+}
@@ -0,0 +1,128 @@
+package databaseActions
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/comforme/comforme/common"
+)
+
+const passkeyChallengeLifetime = 5 * time.Minute
+
+// Issues a challenge for the browser to have signed. userID is the user
+// registering a passkey, or 0 when logging in.
+func NewPasskeyChallenge(userID int) (challenge string, err error) {
+	token, err := common.NewToken()
+	if err != nil {
+		common.LogError(err)
+		return "", common.DatabaseError
+	}
+
+	challenge = base64.RawURLEncoding.EncodeToString([]byte(token))
+	err = db.NewPasskeyChallenge(challenge, userID, time.Now().Add(passkeyChallengeLifetime))
+	return
+}
+
+func GetPasskeys(userID int) ([]common.Passkey, error) {
+	return db.GetPasskeys(userID)
+}
+
+func GetPasskeyCredentialIDs(userID int) ([][]byte, error) {
+	return db.GetPasskeyCredentialIDs(userID)
+}
+
+func GetPasskeyCredential(credentialID []byte) (common.PasskeyCredential, error) {
+	return db.GetPasskeyCredential(credentialID)
+}
+
+func checkPasskeyName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if len(name) == 0 {
+		return "", common.PasskeyNameRequired
+	}
+	if len(name) > common.MaxPasskeyNameLen {
+		return "", common.PasskeyNameTooLong
+	}
+	return name, nil
+}
+
+// Saves a passkey once the browser's response to challenge has been verified.
+func AddPasskey(userInfo common.UserInfo, challenge, name string, credentialID, publicKey []byte, signCount uint32) error {
+	name, err := checkPasskeyName(name)
+	if err != nil {
+		return err
+	}
+
+	err = db.TakePasskeyChallenge(challenge, userInfo.UserID)
+	if err != nil {
+		return err
+	}
+
+	err = db.NewPasskey(userInfo.UserID, name, credentialID, publicKey, signCount)
+	if err != nil {
+		return err
+	}
+
+	auditSelf(common.AuditPasskeyAdded, userInfo, name)
+	return nil
+}
+
+// Logs in with a passkey once its signature over challenge has been verified.
+func PasskeyLogin(challenge string, credential common.PasskeyCredential, signCount uint32, requestInfo common.RequestInfo) (sessionid string, err error) {
+	err = db.TakePasskeyChallenge(challenge, 0)
+	if err != nil {
+		return
+	}
+
+	// Authenticators that keep a counter increase it on every use, so a
+	// counter that has gone backwards means the key has been copied
+	if signCount != 0 || credential.SignCount != 0 {
+		if signCount <= credential.SignCount {
+			audit(common.AuditLoginFailed, 0, credential.UserID, requestInfo, fmt.Sprintf("via passkey %d, reason: signature counter went backwards", credential.Id))
+			return "", common.PasskeyCloned
+		}
+	}
+
+	suspended, err := db.GetUserSuspended(credential.UserID)
+	if err != nil {
+		return
+	}
+	if suspended {
+		audit(common.AuditLoginFailed, 0, credential.UserID, requestInfo, "via passkey, reason: "+common.AccountSuspended.Error())
+		return "", common.AccountSuspended
+	}
+
+	err = db.UpdatePasskeyUsed(credential.Id, signCount)
+	if err != nil {
+		return
+	}
+
+	sessionid, err = db.NewSession(credential.UserID)
+	if err != nil {
+		return
+	}
+
+	audit(common.AuditLogin, credential.UserID, credential.UserID, requestInfo, "via passkey")
+	return
+}
+
+func RenamePasskey(userInfo common.UserInfo, passkeyID int, name string) error {
+	name, err := checkPasskeyName(name)
+	if err != nil {
+		return err
+	}
+
+	return db.RenamePasskey(userInfo.UserID, passkeyID, name)
+}
+
+func RemovePasskey(userInfo common.UserInfo, passkeyID int) error {
+	err := db.DeletePasskey(userInfo.UserID, passkeyID)
+	if err != nil {
+		return err
+	}
+
+	auditSelf(common.AuditPasskeyRemoved, userInfo, fmt.Sprintf("passkey %d", passkeyID))
+	return nil
+}
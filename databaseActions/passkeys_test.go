@@ -0,0 +1,89 @@
+package databaseActions
+
+import (
+	"testing"
+
+	"github.com/comforme/comforme/common"
+	"github.com/comforme/comforme/database"
+	"github.com/comforme/comforme/dbtest"
+)
+
+func TestPasskeyLoginSignCount(t *testing.T) {
+	conn, dsn := dbtest.New(t)
+	testDB, err := database.NewDB(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	UseDB(testDB)
+
+	userID := dbtest.User(t, conn, "passkeys")
+	userInfo := common.UserInfo{UserID: userID}
+
+	tests := []struct {
+		name      string
+		stored    uint32 // Count saved when the passkey was last used
+		signCount uint32
+		err       error
+	}{
+		{"no counter", 0, 0, nil},
+		{"counter increased", 5, 6, nil},
+		{"counter started", 0, 1, nil},
+		{"counter repeated", 5, 5, common.PasskeyCloned},
+		{"counter went backwards", 5, 3, common.PasskeyCloned},
+		{"counter reset", 5, 0, common.PasskeyCloned},
+	}
+
+	for i, test := range tests {
+		credentialID := []byte{byte(i)}
+		challenge, err := NewPasskeyChallenge(userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := AddPasskey(userInfo, challenge, test.name, credentialID, []byte{0xa0}, test.stored); err != nil {
+			t.Fatal(err)
+		}
+		credential, err := GetPasskeyCredential(credentialID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		challenge, err = NewPasskeyChallenge(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = PasskeyLogin(challenge, credential, test.signCount, common.RequestInfo{})
+		if err != test.err {
+			t.Errorf("%s: got %v, want %v", test.name, err, test.err)
+			continue
+		}
+
+		// A refused login leaves the stored count alone
+		want := test.signCount
+		if err != nil {
+			want = test.stored
+		}
+		credential, err = GetPasskeyCredential(credentialID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if credential.SignCount != want {
+			t.Errorf("%s: stored count is %d, want %d", test.name, credential.SignCount, want)
+		}
+	}
+
+	// Each challenge only works once
+	challenge, err := NewPasskeyChallenge(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	credential, err := GetPasskeyCredential([]byte{0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := PasskeyLogin(challenge, credential, 0, common.RequestInfo{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := PasskeyLogin(challenge, credential, 0, common.RequestInfo{}); err != common.PasskeyVerifyFailed {
+		t.Errorf("reused challenge: got %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+package databaseActions
+
+import (
+	"log"
+	"strings"
+
+	"github.com/comforme/comforme/common"
+)
+
+const profileContributionsPerPage = 20
+
+// Looks up a profile for the viewer. Users who have blocked the viewer are
+// reported as not found.
+func GetProfile(viewerID int, username string) (profile common.Profile, err error) {
+	profile, err = db.GetProfile(username)
+	if err != nil {
+		return
+	}
+
+	blocked, err := db.HasBlocked(profile.Id, viewerID)
+	if err != nil {
+		return
+	}
+	if blocked {
+		log.Printf("User (%d) has blocked user (%d), hiding profile.\n", profile.Id, viewerID)
+		err = common.UserNotFound
+	}
+	return
+}
+
+func HasBlocked(userID, otherID int) (bool, error) {
+	return db.HasBlocked(userID, otherID)
+}
+
+func GetVisibleCommunities(viewerID, userID int) ([]common.Community, error) {
+	return db.GetVisibleCommunities(viewerID, userID)
+}
+
+// Returns one page of the user's contributions, counting from 1, and whether
+// there are more after it.
+func GetUserContributions(userID, page int) (contributions []common.Contribution, more bool, err error) {
+	if page < 1 {
+		page = 1
+	}
+
+	contributions, err = db.GetUserContributions(
+		userID,
+		profileContributionsPerPage+1,
+		(page-1)*profileContributionsPerPage,
+	)
+	if err != nil {
+		return
+	}
+
+	if len(contributions) > profileContributionsPerPage {
+		contributions = contributions[:profileContributionsPerPage]
+		more = true
+	}
+	return
+}
+
+func SetBio(userInfo common.UserInfo, bio string) error {
+	bio = strings.TrimSpace(bio)
+	if len(bio) > common.MaxBioLength {
+		return common.BioTooLong
+	}
+
+	return db.SetBio(userInfo.UserID, bio)
+}
+
+func ReportUser(userInfo common.UserInfo, reportedID int, reason string) error {
+	reason = strings.TrimSpace(reason)
+	if len(reason) < common.MinDescriptionLength {
+		return common.ReportReasonTooShort
+	}
+	if reportedID == userInfo.UserID {
+		return common.CannotReportSelf
+	}
+
+	err := db.NewUserReport(userInfo.UserID, reportedID, reason)
+	if err != nil {
+		return err
+	}
+
+	audit(common.AuditUserReported, userInfo.UserID, reportedID, userInfo.RequestInfo, "")
+	emitReportEvent(reportedID, reason)
+	return nil
+}
+
+func GetOpenReports(userInfo common.UserInfo) ([]common.UserReport, error) {
+	if !userInfo.Can(common.PermissionModerate) {
+		return nil, common.PermissionDenied
+	}
+
+	return db.GetOpenReports()
+}
+
+func ResolveReport(userInfo common.UserInfo, reportID int) error {
+	if !userInfo.Can(common.PermissionModerate) {
+		return common.PermissionDenied
+	}
+
+	reportedID, err := db.ResolveReport(reportID, userInfo.UserID)
+	if err != nil {
+		return err
+	}
+
+	auditModerator(common.AuditReportResolved, userInfo, reportedID, "report %d", reportID)
+	return nil
+}
@@ -0,0 +1,21 @@
+package databaseActions
+
+import (
+	"strings"
+
+	"github.com/comforme/comforme/common"
+)
+
+func GetPseudonyms(userID int) ([]common.Pseudonym, error) {
+	return db.GetPseudonyms(userID)
+}
+
+// Pseudonyms follow the same rules as usernames and cannot clash with one.
+func NewPseudonym(userID int, name string) error {
+	name = strings.TrimSpace(name)
+	if len(name) < minUsernameLength {
+		return UsernameTooShort
+	}
+
+	return db.NewPseudonym(userID, name)
+}
@@ -0,0 +1,134 @@
+package databaseActions
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/comforme/comforme/common"
+)
+
+// Grants a site wide role to the user with the given email.
+func GrantRole(email, roleName string) error {
+	role, err := common.ParseRole(roleName)
+	if err != nil {
+		return err
+	}
+
+	userID, err := db.GetUserIDByEmail(email)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Granting role (%s) to user (%s).\n", role, email)
+	err = db.SetRole(email, role)
+	if err != nil {
+		return err
+	}
+
+	audit(common.AuditRoleGranted, 0, userID, common.RequestInfo{}, fmt.Sprintf("%s, from the command line", role))
+	return nil
+}
+
+// Makes the user with the given email a moderator of a single community.
+func GrantCommunityModerator(email string, communityID int) error {
+	userID, err := db.GetUserIDByEmail(email)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Making user (%s) a moderator of community (%d).\n", email, communityID)
+	err = db.AddCommunityModerator(userID, communityID)
+	if err != nil {
+		return err
+	}
+
+	audit(common.AuditCommunityModerator, 0, userID, common.RequestInfo{}, fmt.Sprintf("community %d, from the command line", communityID))
+	return nil
+}
+
+func CanModerateCommunity(userInfo common.UserInfo, communityID int) (bool, error) {
+	if userInfo.Can(common.PermissionModerate) {
+		return true, nil
+	}
+	return db.IsCommunityModerator(userInfo.UserID, communityID)
+}
+
+// Authors can edit their own pages, and community moderators the pages of
+// members of their communities.
+func CanEditPage(userInfo common.UserInfo, page common.Page) bool {
+	if page.AuthorID == userInfo.UserID || userInfo.Can(common.PermissionEditAnyPage) {
+		return true
+	}
+
+	isModerator, err := db.IsCommunityModeratorOfPage(userInfo.UserID, page.Id)
+	if err != nil {
+		log.Printf("Error checking if user (%d) moderates page (%d): %s\n", userInfo.UserID, page.Id, err.Error())
+		return false
+	}
+	return isModerator
+}
+
+func CanModeratePost(userInfo common.UserInfo, postID int) (bool, error) {
+	if userInfo.Can(common.PermissionModerate) {
+		return true, nil
+	}
+	return db.IsCommunityModeratorOfPost(userInfo.UserID, postID)
+}
+
+// The posts on a page that the user may remove as a community moderator.
+// Site moderators may remove any of them.
+func GetModeratedPosts(userInfo common.UserInfo, pageID int) (map[int]bool, error) {
+	return db.GetCommunityModeratedPosts(userInfo.UserID, pageID)
+}
+
+func EditPage(userInfo common.UserInfo, page common.Page, title, description, address, website string, location *common.Location, category int) error {
+	if !CanEditPage(userInfo, page) {
+		log.Printf("User (%d) is not allowed to edit page (%d).\n", userInfo.UserID, page.Id)
+		return common.PermissionDenied
+	}
+
+	if len(common.GenSlug(title)) <= 1 {
+		return common.InvalidTitle
+	}
+	if location != nil && !location.Valid() {
+		return common.InvalidLocation
+	}
+
+	err := db.UpdatePage(page.Id, title, description, address, website, location, category)
+	if err != nil {
+		return err
+	}
+
+	// Edits to other users' pages are moderator actions
+	if page.AuthorID != userInfo.UserID {
+		auditModerator(common.AuditPageEdited, userInfo, page.AuthorID, "page %d (%s)", page.Id, page.Title)
+	}
+
+	emitPageEvent(common.EventPageUpdated, page.Id)
+	return nil
+}
+
+func RemovePost(userInfo common.UserInfo, postID int) error {
+	canModerate, err := CanModeratePost(userInfo, postID)
+	if err != nil {
+		return err
+	}
+	if !canModerate {
+		log.Printf("User (%d) is not allowed to remove post (%d).\n", userInfo.UserID, postID)
+		return common.PermissionDenied
+	}
+
+	log.Printf("User (%d) removing post (%d).\n", userInfo.UserID, postID)
+	authorID, err := db.GetPostAuthorID(postID)
+	if err != nil {
+		return err
+	}
+
+	err = db.DeletePost(postID)
+	if err != nil {
+		return err
+	}
+
+	auditModerator(common.AuditPostRemoved, userInfo, authorID, "post %d", postID)
+	return nil
+}
@@ -0,0 +1,44 @@
+package databaseActions
+
+import (
+	"github.com/comforme/comforme/common"
+)
+
+const syndicationLength = 20
+
+// Public feeds are read without logging in, so they are built for a viewer
+// with no memberships, who can only see public ones.
+const anonymousViewerID = 0
+
+// Lists the newest pages site-wide, or in one category if categorySlug is set.
+func GetLatestPages(categorySlug string) (pages []common.Page, categoryName string, err error) {
+	if categorySlug != "" {
+		categoryName, err = db.GetCategoryName(categorySlug)
+		if err != nil {
+			return
+		}
+	}
+
+	pages, err = db.GetLatestPages(categorySlug, syndicationLength, 0)
+	return
+}
+
+// Lists the newest contributions by members who made their membership of the
+// community public.
+func GetPublicCommunityContributions(communityID int) (community common.Community, contributions []common.Contribution, err error) {
+	community, err = db.GetCommunity(0, communityID)
+	if err != nil {
+		return
+	}
+	if community.Status != common.CommunityApproved {
+		err = common.CommunityNotFound
+		return
+	}
+
+	contributions, err = db.GetCommunityContributions(anonymousViewerID, communityID, syndicationLength)
+	return
+}
+
+func GetLatestPosts(page common.Page) ([]common.Contribution, error) {
+	return db.GetLatestPosts(page.Id, syndicationLength)
+}
@@ -0,0 +1,198 @@
+package databaseActions
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/comforme/comforme/common"
+)
+
+const (
+	oauthClientIDLength = 24
+	oauthCodeLifetime   = 10 * time.Minute
+
+	minCodeVerifierLength = 43
+	maxCodeVerifierLength = 128
+)
+
+func GetTokenUserInfo(token string) (common.UserInfo, error) {
+	return db.GetTokenUserInfo(token)
+}
+
+func GetAPITokens(userID int) ([]common.APIToken, error) {
+	return db.GetAPITokens(userID)
+}
+
+// Creates a personal access token. The token is only ever shown to the user
+// once, since only its hash is kept.
+func CreateAPIToken(userInfo common.UserInfo, name string, scopes []string) (token string, err error) {
+	if userInfo.ViaToken {
+		return "", common.SessionRequired
+	}
+
+	name = strings.TrimSpace(name)
+	if len(name) == 0 {
+		return "", common.TokenNameRequired
+	}
+	if len(name) > common.MaxTokenNameLen {
+		return "", common.TokenNameTooLong
+	}
+
+	scopes, err = common.ParseScopes(strings.Join(scopes, " "))
+	if err != nil {
+		return "", err
+	}
+
+	token, err = common.NewToken()
+	if err != nil {
+		common.LogError(err)
+		return "", common.DatabaseError
+	}
+
+	err = db.NewAPIToken(userInfo.UserID, 0, name, token, scopes)
+	if err != nil {
+		return "", err
+	}
+
+	auditSelf(common.AuditTokenCreated, userInfo, fmt.Sprintf("%s (%s)", name, strings.Join(scopes, " ")))
+	return token, nil
+}
+
+// Revokes a personal access token or an application's access.
+func RevokeAPIToken(userInfo common.UserInfo, tokenID int) error {
+	if userInfo.ViaToken {
+		return common.SessionRequired
+	}
+
+	err := db.DeleteAPIToken(userInfo.UserID, tokenID)
+	if err != nil {
+		return err
+	}
+
+	auditSelf(common.AuditTokenRevoked, userInfo, fmt.Sprintf("token %d", tokenID))
+	return nil
+}
+
+// Registers an application that can ask users for access. Returns its client
+// ID.
+func RegisterOAuthClient(name, redirectURI string) (clientID string, err error) {
+	name = strings.TrimSpace(name)
+	if len(name) == 0 {
+		return "", common.TokenNameRequired
+	}
+	if len(name) > common.MaxTokenNameLen {
+		return "", common.TokenNameTooLong
+	}
+
+	err = checkRedirectURI(redirectURI)
+	if err != nil {
+		return "", err
+	}
+
+	clientID = common.RandSeq(oauthClientIDLength)
+	err = db.NewOAuthClient(clientID, name, redirectURI)
+	return
+}
+
+// Codes are sent to the redirect URI in the clear, so it has to use https
+// unless it stays on the user's machine.
+func checkRedirectURI(redirectURI string) error {
+	parsed, err := url.Parse(redirectURI)
+	if err != nil || !parsed.IsAbs() || parsed.Fragment != "" {
+		return common.InvalidRedirectURI
+	}
+
+	host := parsed.Host
+	if i := strings.LastIndex(host, ":"); i != -1 {
+		host = host[:i]
+	}
+	if parsed.Scheme == "https" || (parsed.Scheme == "http" && (host == "localhost" || host == "127.0.0.1")) {
+		return nil
+	}
+	return common.InvalidRedirectURI
+}
+
+func GetOAuthClient(clientID string) (common.OAuthClient, error) {
+	return db.GetOAuthClient(clientID)
+}
+
+// Records that the user allowed the client access and returns the
+// authorization code to send it. codeChallenge is the PKCE S256 challenge.
+func AuthorizeOAuthClient(userInfo common.UserInfo, client common.OAuthClient, scopes []string, codeChallenge string) (code string, err error) {
+	if userInfo.ViaToken {
+		return "", common.SessionRequired
+	}
+
+	code, err = common.NewToken()
+	if err != nil {
+		common.LogError(err)
+		return "", common.DatabaseError
+	}
+
+	// Codes are short lived, so clear out the ones nobody used
+	err = db.DeleteExpiredOAuthCodes()
+	if err != nil {
+		return "", err
+	}
+
+	err = db.NewOAuthCode(code, common.OAuthCode{
+		ClientID:      client.Id,
+		UserID:        userInfo.UserID,
+		RedirectURI:   client.RedirectURI,
+		Scopes:        scopes,
+		CodeChallenge: codeChallenge,
+		Expires:       time.Now().Add(oauthCodeLifetime),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	auditSelf(common.AuditOAuthAuthorized, userInfo, fmt.Sprintf("%s (%s)", client.Name, strings.Join(scopes, " ")))
+	return code, nil
+}
+
+// Exchanges an authorization code for an access token, checking the PKCE code
+// verifier against the challenge sent when the code was issued.
+func ExchangeOAuthCode(clientID, code, redirectURI, codeVerifier string) (token string, scopes []string, err error) {
+	if len(codeVerifier) < minCodeVerifierLength || len(codeVerifier) > maxCodeVerifierLength {
+		return "", nil, common.InvalidCodeVerifier
+	}
+
+	client, err := db.GetOAuthClient(clientID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	details, err := db.TakeOAuthCode(code)
+	if err == common.InvalidToken {
+		return "", nil, common.InvalidGrant
+	} else if err != nil {
+		return "", nil, err
+	}
+
+	sum := sha256.Sum256([]byte(codeVerifier))
+	challenge := base64.RawURLEncoding.EncodeToString(sum[:])
+	if details.ClientID != client.Id ||
+		details.RedirectURI != redirectURI ||
+		time.Now().After(details.Expires) ||
+		subtle.ConstantTimeCompare([]byte(challenge), []byte(details.CodeChallenge)) != 1 {
+		return "", nil, common.InvalidGrant
+	}
+
+	token, err = common.NewToken()
+	if err != nil {
+		common.LogError(err)
+		return "", nil, common.DatabaseError
+	}
+
+	err = db.NewAPIToken(details.UserID, client.Id, client.Name, token, details.Scopes)
+	if err != nil {
+		return "", nil, err
+	}
+	return token, details.Scopes, nil
+}
@@ -0,0 +1,319 @@
+package databaseActions
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/comforme/comforme/common"
+)
+
+const (
+	maxWebhookDeliveries  = 50
+	webhookDeliveryLease  = 5 * time.Minute
+	maxDeliveryAttempts   = 8
+	firstDeliveryRetry    = 30 * time.Second
+	maxResponseErrorBytes = 512
+)
+
+// Signals the webhook sender that there are new deliveries, so that it does
+// not have to wait for its next poll.
+var WebhooksQueued = make(chan struct{}, 1)
+
+// The body sent to webhooks.
+type webhookPayload struct {
+	Event string      `json:"event"`
+	Date  time.Time   `json:"date"`
+	Data  interface{} `json:"data"`
+}
+
+type webhookPage struct {
+	Id           int    `json:"id"`
+	Title        string `json:"title"`
+	Slug         string `json:"slug"`
+	Category     string `json:"category"`
+	CategorySlug string `json:"categorySlug"`
+	Description  string `json:"description"`
+	Address      string `json:"address"`
+	Website      string `json:"website"`
+	URL          string `json:"url"`
+}
+
+type webhookPost struct {
+	Id     int         `json:"id"`
+	Author string      `json:"author"` // Empty for anonymous posts
+	Body   string      `json:"body"`
+	Page   webhookPage `json:"page"`
+}
+
+type webhookCommunity struct {
+	Id          int    `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	ParentID    int    `json:"parentId,omitempty"`
+}
+
+// The reporter is left out, as they are from the reported user's view.
+type webhookReport struct {
+	ReportedUsername string `json:"reportedUsername"`
+	Reason           string `json:"reason"`
+}
+
+func newWebhookPage(page common.Page) webhookPage {
+	return webhookPage{
+		Id:           page.Id,
+		Title:        page.Title,
+		Slug:         page.PageSlug,
+		Category:     page.Category,
+		CategorySlug: page.CategorySlug,
+		Description:  page.Description,
+		Address:      page.Address,
+		Website:      page.Website,
+		URL:          fmt.Sprintf("/page/%s/%s", page.CategorySlug, page.PageSlug),
+	}
+}
+
+// Queues an event for every webhook subscribed to it. Events are sent after
+// the action that caused them has happened, so failing to queue one is only
+// logged.
+func emit(event, categorySlug string, data interface{}) {
+	payload, err := json.Marshal(webhookPayload{event, time.Now().UTC(), data})
+	if err != nil {
+		log.Printf("Error encoding %s webhook payload: %s\n", event, err.Error())
+		return
+	}
+
+	queued, err := db.QueueWebhookEvent(event, categorySlug, string(payload))
+	if err != nil {
+		log.Printf("Failed to queue %s webhooks: %s\n", event, err.Error())
+		return
+	}
+
+	if queued > 0 {
+		select {
+		case WebhooksQueued <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func emitPageEvent(event string, pageID int) {
+	categorySlug, pageSlug, err := db.GetSlugs(pageID)
+	if err != nil {
+		log.Printf("Failed to look up page (%d) for %s webhooks: %s\n", pageID, event, err.Error())
+		return
+	}
+	page, err := db.GetPage(categorySlug, pageSlug)
+	if err != nil {
+		log.Printf("Failed to look up page (%d) for %s webhooks: %s\n", pageID, event, err.Error())
+		return
+	}
+
+	emit(event, categorySlug, newWebhookPage(page))
+}
+
+func emitPostEvent(postID int, body string, page common.Page) {
+	author, err := db.GetPostDisplayAuthor(postID)
+	if err != nil {
+		log.Printf("Failed to look up post (%d) for webhooks: %s\n", postID, err.Error())
+		return
+	}
+
+	emit(common.EventPostCreated, page.CategorySlug, webhookPost{postID, author, body, newWebhookPage(page)})
+}
+
+func emitCommunityEvent(communityID int) {
+	community, err := db.GetCommunity(0, communityID)
+	if err != nil {
+		log.Printf("Failed to look up community (%d) for webhooks: %s\n", communityID, err.Error())
+		return
+	}
+
+	emit(common.EventCommunityCreated, "", webhookCommunity{community.Id, community.Name, community.Description, community.ParentID})
+}
+
+func emitReportEvent(reportedID int, reason string) {
+	reported, err := db.GetUser(reportedID)
+	if err != nil {
+		log.Printf("Failed to look up user (%d) for webhooks: %s\n", reportedID, err.Error())
+		return
+	}
+
+	emit(common.EventReportFiled, "", webhookReport{reported.Username, reason})
+}
+
+func GetWebhooks(userInfo common.UserInfo) ([]common.Webhook, error) {
+	if !userInfo.Can(common.PermissionManageWebhooks) {
+		return nil, common.PermissionDenied
+	}
+	return db.GetWebhooks()
+}
+
+func GetWebhook(userInfo common.UserInfo, webhookID int) (common.Webhook, error) {
+	if !userInfo.Can(common.PermissionManageWebhooks) {
+		return common.Webhook{}, common.PermissionDenied
+	}
+	return db.GetWebhook(webhookID)
+}
+
+func checkWebhookURL(webhookURL string) error {
+	parsed, err := url.Parse(webhookURL)
+	if err != nil || !parsed.IsAbs() || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return common.InvalidWebhookURL
+	}
+	return nil
+}
+
+// Registers a webhook. The secret used to sign its deliveries is generated
+// and returned.
+func CreateWebhook(userInfo common.UserInfo, webhookURL string, events []string, categoryID int) (secret string, err error) {
+	if !userInfo.Can(common.PermissionManageWebhooks) {
+		return "", common.PermissionDenied
+	}
+
+	webhookURL = strings.TrimSpace(webhookURL)
+	err = checkWebhookURL(webhookURL)
+	if err != nil {
+		return
+	}
+
+	if len(events) == 0 {
+		return "", common.InvalidWebhookEvent
+	}
+	supported := common.Webhook{Events: common.WebhookEvents}
+	for _, event := range events {
+		if !supported.Has(event) {
+			return "", common.InvalidWebhookEvent
+		}
+	}
+
+	secret, err = common.NewToken()
+	if err != nil {
+		common.LogError(err)
+		return "", common.DatabaseError
+	}
+
+	webhookID, err := db.NewWebhook(webhookURL, secret, events, categoryID)
+	if err != nil {
+		return "", err
+	}
+
+	auditModerator(common.AuditWebhookCreated, userInfo, 0, "webhook %d (%s): %s", webhookID, webhookURL, strings.Join(events, " "))
+	return secret, nil
+}
+
+func SetWebhookActive(userInfo common.UserInfo, webhookID int, active bool) error {
+	if !userInfo.Can(common.PermissionManageWebhooks) {
+		return common.PermissionDenied
+	}
+
+	err := db.SetWebhookActive(webhookID, active)
+	if err != nil {
+		return err
+	}
+
+	state := "disabled"
+	if active {
+		state = "enabled"
+		select {
+		case WebhooksQueued <- struct{}{}:
+		default:
+		}
+	}
+	auditModerator(common.AuditWebhookChanged, userInfo, 0, "webhook %d %s", webhookID, state)
+	return nil
+}
+
+func DeleteWebhook(userInfo common.UserInfo, webhookID int) error {
+	if !userInfo.Can(common.PermissionManageWebhooks) {
+		return common.PermissionDenied
+	}
+
+	err := db.DeleteWebhook(webhookID)
+	if err != nil {
+		return err
+	}
+
+	auditModerator(common.AuditWebhookDeleted, userInfo, 0, "webhook %d", webhookID)
+	return nil
+}
+
+func GetDeliveries(userInfo common.UserInfo, webhookID int) ([]common.WebhookDelivery, error) {
+	if !userInfo.Can(common.PermissionManageWebhooks) {
+		return nil, common.PermissionDenied
+	}
+	return db.GetDeliveries(webhookID, maxWebhookDeliveries)
+}
+
+// Sends a delivery's event again as a new delivery.
+func ReplayDelivery(userInfo common.UserInfo, webhookID, deliveryID int) error {
+	if !userInfo.Can(common.PermissionManageWebhooks) {
+		return common.PermissionDenied
+	}
+
+	newID, err := db.ReplayDelivery(webhookID, deliveryID)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case WebhooksQueued <- struct{}{}:
+	default:
+	}
+	auditModerator(common.AuditWebhookReplayed, userInfo, 0, "webhook %d delivery %d as %d", webhookID, deliveryID, newID)
+	return nil
+}
+
+// Claims deliveries that are due to be sent. Each must be passed to
+// RecordDeliveryAttempt once it has been tried.
+func ClaimDueDeliveries(limit int) ([]common.PendingDelivery, error) {
+	return db.ClaimDueDeliveries(limit, webhookDeliveryLease)
+}
+
+// Records the outcome of sending a delivery, scheduling a retry with
+// exponential backoff if it failed and has attempts left.
+func RecordDeliveryAttempt(delivery common.PendingDelivery, responseStatus int, sendErr error) error {
+	attempt := delivery.WebhookDelivery
+	attempt.Attempts++
+	attempt.ResponseStatus = responseStatus
+
+	if sendErr == nil {
+		attempt.Status = common.DeliverySucceeded
+		attempt.NextAttempt = time.Now()
+		attempt.LastError = ""
+	} else {
+		attempt.Status, attempt.NextAttempt = retryDelivery(attempt.Attempts, time.Now())
+		attempt.LastError = deliveryError(sendErr)
+	}
+
+	return db.RecordDeliveryAttempt(attempt)
+}
+
+// Works out what happens to a delivery that has failed attempts times: it is
+// retried after a delay that doubles each time, until it runs out of attempts.
+func retryDelivery(attempts int, now time.Time) (status string, nextAttempt time.Time) {
+	if attempts >= maxDeliveryAttempts {
+		return common.DeliveryFailed, now
+	}
+	return common.DeliveryPending, now.Add(firstDeliveryRetry << uint(attempts-1))
+}
+
+// Keeps the start of the error from a failed delivery for admins to look at.
+// It includes part of the response body, which can be anything, but Postgres
+// only accepts valid UTF-8 text.
+func deliveryError(err error) string {
+	message := strings.ToValidUTF8(err.Error(), "\uFFFD")
+	if len(message) <= maxResponseErrorBytes {
+		return message
+	}
+
+	end := maxResponseErrorBytes
+	for !utf8.RuneStart(message[end]) {
+		end--
+	}
+	return message[:end]
+}
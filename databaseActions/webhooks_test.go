@@ -0,0 +1,59 @@
+package databaseActions
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/comforme/comforme/common"
+)
+
+func TestRetryDelivery(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		attempts int
+		status   string
+		delay    time.Duration
+	}{
+		{1, common.DeliveryPending, 30 * time.Second},
+		{2, common.DeliveryPending, time.Minute},
+		{3, common.DeliveryPending, 2 * time.Minute},
+		{7, common.DeliveryPending, 32 * time.Minute},
+		{8, common.DeliveryFailed, 0},
+		{9, common.DeliveryFailed, 0},
+	}
+
+	for _, test := range tests {
+		status, nextAttempt := retryDelivery(test.attempts, now)
+		if status != test.status || nextAttempt.Sub(now) != test.delay {
+			t.Errorf("after %d attempts: got %s in %s, want %s in %s", test.attempts, status, nextAttempt.Sub(now), test.status, test.delay)
+		}
+	}
+}
+
+func TestDeliveryError(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    string
+	}{
+		{"short", "500 Internal Server Error: oops", "500 Internal Server Error: oops"},
+		{"invalid UTF-8", "502 Bad Gateway: \xff\xfe gateway", "502 Bad Gateway: \uFFFD gateway"},
+		{"long", strings.Repeat("a", 600), strings.Repeat("a", maxResponseErrorBytes)},
+		// A three byte character straddling the limit is left out whole
+		{"long with multibyte", strings.Repeat("a", 511) + "€€", strings.Repeat("a", 511)},
+		{"long and invalid", strings.Repeat("\xff", 600), strings.Repeat("\uFFFD", 1)},
+	}
+
+	for _, test := range tests {
+		got := deliveryError(errors.New(test.message))
+		if got != test.want {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+		if !utf8.ValidString(got) || len(got) > maxResponseErrorBytes {
+			t.Errorf("%s: got %d bytes of invalid or overlong text", test.name, len(got))
+		}
+	}
+}
@@ -0,0 +1,103 @@
+// Package dbtest gives tests that need Postgres a database of their own.
+package dbtest
+
+import (
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	_ "github.com/lib/pq"
+)
+
+var schemas int32
+
+// Connects to an empty copy of the database with every migration applied.
+// Each call gets its own Postgres schema, which is dropped when the test
+// ends. Tests are skipped unless TEST_DATABASE_URL points at a database they
+// may create schemas in.
+func New(t *testing.T) (conn *sql.DB, dsn string) {
+	base := os.Getenv("TEST_DATABASE_URL")
+	if base == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	admin, err := sql.Open("postgres", base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema := fmt.Sprintf("test_%d_%d", os.Getpid(), atomic.AddInt32(&schemas, 1))
+	if _, err = admin.Exec("CREATE SCHEMA " + schema); err != nil {
+		admin.Close()
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if _, err := admin.Exec("DROP SCHEMA " + schema + " CASCADE"); err != nil {
+			t.Error(err)
+		}
+		admin.Close()
+	})
+
+	dsn = withSearchPath(base, schema)
+	conn, err = sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	for _, file := range schemaFiles(t) {
+		contents, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = conn.Exec(withoutSeedRows(string(contents))); err != nil {
+			t.Fatalf("%s: %s", filepath.Base(file), err)
+		}
+	}
+	return
+}
+
+// schema.sql followed by the migrations in the order they are applied.
+func schemaFiles(t *testing.T) []string {
+	_, thisFile, _, _ := runtime.Caller(0)
+	root := filepath.Dir(filepath.Dir(thisFile))
+
+	migrations, err := filepath.Glob(filepath.Join(root, "migrations", "*.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return append([]string{filepath.Join(root, "schema.sql")}, migrations...)
+}
+
+// Leaves out the sample communities and categories in schema.sql, which
+// name the public schema. Tests make the rows they need.
+func withoutSeedRows(script string) string {
+	lines := strings.Split(script, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "INSERT INTO public.") {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
+
+// Points a connection string at schema, as the default for unqualified names.
+func withSearchPath(dsn, schema string) string {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		parsed, err := url.Parse(dsn)
+		if err == nil {
+			query := parsed.Query()
+			query.Set("search_path", schema)
+			parsed.RawQuery = query.Encode()
+			return parsed.String()
+		}
+	}
+	return dsn + " search_path=" + schema
+}
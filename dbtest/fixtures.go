@@ -0,0 +1,103 @@
+package dbtest
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+)
+
+// Rows for tests to work with, made with plain SQL so that setting up a test
+// does not depend on the code it tests.
+
+func insert(t *testing.T, conn *sql.DB, query string, args ...interface{}) (id int) {
+	if err := conn.QueryRow(query+" RETURNING id", args...).Scan(&id); err != nil {
+		t.Fatal(err)
+	}
+	return
+}
+
+func exec(t *testing.T, conn *sql.DB, query string, args ...interface{}) {
+	if _, err := conn.Exec(query, args...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func User(t *testing.T, conn *sql.DB, username string) int {
+	return insert(t, conn,
+		"INSERT INTO users (username, email, password, reset_required) VALUES ($1, $1 || '@example.com', '!', false)",
+		username,
+	)
+}
+
+func Community(t *testing.T, conn *sql.DB, name string) int {
+	return insert(t, conn, "INSERT INTO communities (name) VALUES ($1)", name)
+}
+
+// Adds userID to a community with the given membership visibility.
+func Join(t *testing.T, conn *sql.DB, userID, communityID int, visibility string) {
+	exec(t, conn,
+		"INSERT INTO community_memberships (user_id, community_id, visibility) VALUES ($1, $2, $3)",
+		userID,
+		communityID,
+		visibility,
+	)
+}
+
+func Category(t *testing.T, conn *sql.DB, name string) int {
+	return insert(t, conn, "INSERT INTO categories (name, slug) VALUES ($1, lower($1))", name)
+}
+
+func Page(t *testing.T, conn *sql.DB, authorID, categoryID int, title string) int {
+	return insert(t, conn, `
+		INSERT INTO pages (title, slug, category, description, user_id, address, website)
+		VALUES ($1, $2, $3, 'A page made by a test.', $4, '', '')`,
+		title,
+		strings.ToLower(strings.Replace(title, " ", "-", -1)),
+		categoryID,
+		authorID,
+	)
+}
+
+// A post shown under the author's username.
+func Post(t *testing.T, conn *sql.DB, authorID, pageID int, body string) int {
+	return insert(t, conn,
+		"INSERT INTO posts (user_id, page_id, body) VALUES ($1, $2, $3)",
+		authorID,
+		pageID,
+		body,
+	)
+}
+
+func AnonymousPost(t *testing.T, conn *sql.DB, authorID, pageID int, body string) int {
+	return insert(t, conn,
+		"INSERT INTO posts (user_id, page_id, body, anonymous) VALUES ($1, $2, $3, true)",
+		authorID,
+		pageID,
+		body,
+	)
+}
+
+func PseudonymousPost(t *testing.T, conn *sql.DB, authorID, pageID int, pseudonym, body string) int {
+	pseudonymID := insert(t, conn,
+		"INSERT INTO pseudonyms (user_id, name) VALUES ($1, $2)",
+		authorID,
+		pseudonym,
+	)
+	return insert(t, conn,
+		"INSERT INTO posts (user_id, page_id, body, pseudonym_id) VALUES ($1, $2, $3, $4)",
+		authorID,
+		pageID,
+		body,
+		pseudonymID,
+	)
+}
+
+// Blocks or mutes blockedID for userID. kind is "block" or "mute".
+func Block(t *testing.T, conn *sql.DB, userID, blockedID int, kind string) {
+	exec(t, conn,
+		"INSERT INTO user_blocks (user_id, blocked_user_id, kind) VALUES ($1, $2, $3)",
+		userID,
+		blockedID,
+		kind,
+	)
+}
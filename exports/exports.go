@@ -0,0 +1,223 @@
+package exports
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/comforme/comforme/common"
+	"github.com/comforme/comforme/databaseActions"
+)
+
+const pollInterval = 5 * time.Minute
+
+// Builds requested data exports until the process exits. Exports are picked
+// up when they are requested and otherwise every pollInterval, which is also
+// when expired archives are dropped.
+func Run() {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		buildPending()
+
+		if expired, err := databaseActions.ExpireDataExports(); err != nil {
+			log.Printf("Error expiring data exports: %s\n", err.Error())
+		} else if expired > 0 {
+			log.Printf("Dropped %d expired data exports.\n", expired)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-databaseActions.ExportsQueued:
+		}
+	}
+}
+
+func buildPending() {
+	for {
+		export, found, err := databaseActions.ClaimDataExport()
+		if err != nil {
+			log.Printf("Error claiming data export: %s\n", err.Error())
+			return
+		}
+		if !found {
+			return
+		}
+
+		archive, err := build(export.UserID)
+		if err != nil {
+			log.Printf("Error building data export (%d): %s\n", export.Id, err.Error())
+			err = databaseActions.FailDataExport(export, err)
+		} else {
+			err = databaseActions.CompleteDataExport(export, archive)
+		}
+		if err != nil {
+			log.Printf("Error saving data export (%d): %s\n", export.Id, err.Error())
+		}
+	}
+}
+
+type auditEvent struct {
+	Date      time.Time `json:"date"`
+	Action    string    `json:"action"`
+	ByYou     bool      `json:"byYou"`
+	IpAddress string    `json:"ipAddress,omitempty"`
+	UserAgent string    `json:"userAgent,omitempty"`
+	Details   string    `json:"details,omitempty"`
+}
+
+const readme = `This archive holds the personal data %s has about you, as of %s.
+
+Each kind of data is given as JSON, and lists are also given as CSV:
+
+profile.json          Your account and pseudonyms
+memberships.*         The communities you belong to
+pages.*               The pages you created
+posts.*               Everything you posted, including anonymously
+sessions.*            When your current sessions started
+audit_events.*        Security and moderation events involving you
+
+Events other people did to your account do not say who did them.
+`
+
+// Builds the ZIP archive of a user's data.
+func build(userID int) ([]byte, error) {
+	data, err := databaseActions.GetExportData(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]auditEvent, len(data.AuditEvents))
+	for i, event := range data.AuditEvents {
+		events[i] = auditEvent{
+			Date:      event.Date,
+			Action:    string(event.Action),
+			ByYou:     event.ActorID == userID,
+			IpAddress: event.IpAddress,
+			UserAgent: event.UserAgent,
+			Details:   event.Details,
+		}
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	files := []struct {
+		name    string
+		content func() ([]byte, error)
+	}{
+		{"README.txt", func() ([]byte, error) {
+			return []byte(fmt.Sprintf(readme, common.SiteName, time.Now().UTC().Format(time.RFC1123))), nil
+		}},
+		{"profile.json", jsonFile(map[string]interface{}{
+			"profile":    data.Profile,
+			"pseudonyms": data.Pseudonyms,
+		})},
+		{"memberships.json", jsonFile(data.Memberships)},
+		{"memberships.csv", csvFile([]string{"community_id", "community", "visibility"}, len(data.Memberships), func(i int) []string {
+			membership := data.Memberships[i]
+			return []string{strconv.Itoa(membership.CommunityID), membership.Community, membership.Visibility}
+		})},
+		{"pages.json", jsonFile(data.Pages)},
+		{"pages.csv", csvFile([]string{"id", "title", "category", "description", "address", "website", "url", "date_created"}, len(data.Pages), func(i int) []string {
+			page := data.Pages[i]
+			return []string{strconv.Itoa(page.Id), page.Title, page.Category, page.Description, page.Address, page.Website, page.URL, formatTime(page.DateCreated)}
+		})},
+		{"posts.json", jsonFile(data.Posts)},
+		{"posts.csv", csvFile([]string{"id", "page_title", "page_url", "body", "posted_as", "date_created"}, len(data.Posts), func(i int) []string {
+			post := data.Posts[i]
+			return []string{strconv.Itoa(post.Id), post.PageTitle, post.PageURL, post.Body, post.PostedAs, formatTime(post.DateCreated)}
+		})},
+		{"sessions.json", jsonFile(data.Sessions)},
+		{"sessions.csv", csvFile([]string{"date_created"}, len(data.Sessions), func(i int) []string {
+			return []string{formatTime(data.Sessions[i].DateCreated)}
+		})},
+		{"audit_events.json", jsonFile(events)},
+		{"audit_events.csv", csvFile([]string{"date", "action", "by_you", "ip_address", "user_agent", "details"}, len(events), func(i int) []string {
+			event := events[i]
+			return []string{formatTime(event.Date), event.Action, strconv.FormatBool(event.ByYou), event.IpAddress, event.UserAgent, event.Details}
+		})},
+	}
+
+	for _, file := range files {
+		content, err := file.content()
+		if err != nil {
+			return nil, err
+		}
+		writer, err := archive.Create(file.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := writer.Write(content); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+func jsonFile(value interface{}) func() ([]byte, error) {
+	return func() ([]byte, error) {
+		return json.MarshalIndent(value, "", "\t")
+	}
+}
+
+func csvFile(header []string, rows int, row func(int) []string) func() ([]byte, error) {
+	return func() ([]byte, error) {
+		var buf bytes.Buffer
+		writer := csv.NewWriter(&buf)
+		writer.Write(header)
+		for i := 0; i < rows; i++ {
+			writer.Write(row(i))
+		}
+		writer.Flush()
+		return buf.Bytes(), writer.Error()
+	}
+}
+
+// Serves an export from the signed link emailed to its owner, who must be
+// logged in.
+func DownloadHandler(res http.ResponseWriter, req *http.Request, ps httprouter.Params, userInfo common.UserInfo) {
+	exportID, err := strconv.Atoi(ps.ByName("id"))
+	if err != nil {
+		http.NotFound(res, req)
+		return
+	}
+	expires, err := strconv.ParseInt(req.URL.Query().Get("expires"), 10, 64)
+	if err != nil {
+		http.Error(res, common.ExportLinkInvalid.Error(), http.StatusForbidden)
+		return
+	}
+
+	archive, err := databaseActions.DownloadDataExport(userInfo, exportID, time.Unix(expires, 0), req.URL.Query().Get("sig"))
+	if err == common.ExportLinkInvalid {
+		http.Error(res, err.Error(), http.StatusForbidden)
+		return
+	} else if err == common.ExportNotFound {
+		http.NotFound(res, req)
+		return
+	} else if err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	res.Header().Set("Content-Type", "application/zip")
+	res.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-data-%d.zip"`, common.SiteName, exportID))
+	res.Header().Set("Cache-Control", "no-store")
+	res.Write(archive)
+}
@@ -0,0 +1,191 @@
+package feeds
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/comforme/comforme/common"
+	"github.com/comforme/comforme/databaseActions"
+)
+
+// Feeds are public. They never name authors and only include community
+// content from members who made their membership public.
+
+type entry struct {
+	Title   string
+	Path    string
+	Content string
+	Date    time.Time
+}
+
+type feed struct {
+	Title   string
+	Path    string // Page the feed is about
+	Entries []entry
+}
+
+func PagesFeedHandler(res http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	pages, _, err := databaseActions.GetLatestPages("")
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeFeed(res, req, feed{
+		Title:   "New resources on " + common.SiteName,
+		Path:    "/",
+		Entries: pageEntries(pages),
+	})
+}
+
+func CategoryFeedHandler(res http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	pages, categoryName, err := databaseActions.GetLatestPages(ps.ByName("category"))
+	if err == common.CategoryNotFound {
+		http.NotFound(res, req)
+		return
+	} else if err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeFeed(res, req, feed{
+		Title:   fmt.Sprintf("New %s resources on %s", categoryName, common.SiteName),
+		Path:    "/",
+		Entries: pageEntries(pages),
+	})
+}
+
+func CommunityFeedHandler(res http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	communityID, err := strconv.Atoi(ps.ByName("id"))
+	if err != nil {
+		http.NotFound(res, req)
+		return
+	}
+
+	community, contributions, err := databaseActions.GetPublicCommunityContributions(communityID)
+	if err == common.CommunityNotFound {
+		http.NotFound(res, req)
+		return
+	} else if err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	entries := []entry{}
+	for _, contribution := range contributions {
+		title := "New post on " + contribution.PageTitle
+		if contribution.IsPage {
+			title = contribution.PageTitle
+		}
+		entries = append(entries, entry{
+			Title:   title,
+			Path:    fmt.Sprintf("/page/%s/%s", contribution.CategorySlug, contribution.PageSlug),
+			Content: contribution.Body,
+			Date:    contribution.Date,
+		})
+	}
+
+	writeFeed(res, req, feed{
+		Title:   fmt.Sprintf("%s on %s", community.Name, common.SiteName),
+		Path:    fmt.Sprintf("/community/%d", community.Id),
+		Entries: entries,
+	})
+}
+
+func PageFeedHandler(res http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	page, err := databaseActions.GetPage(ps.ByName("category"), ps.ByName("slug"))
+	if err != nil {
+		if path, redirectErr := databaseActions.GetPageRedirect(ps.ByName("category"), ps.ByName("slug")); redirectErr == nil {
+			http.Redirect(res, req, path+"/feed", http.StatusMovedPermanently)
+			return
+		}
+		http.NotFound(res, req)
+		return
+	}
+
+	posts, err := databaseActions.GetLatestPosts(page)
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	path := fmt.Sprintf("/page/%s/%s", page.CategorySlug, page.PageSlug)
+	entries := []entry{}
+	for _, post := range posts {
+		entries = append(entries, entry{
+			Title:   "New post on " + page.Title,
+			Path:    path,
+			Content: post.Body,
+			Date:    post.Date,
+		})
+	}
+
+	writeFeed(res, req, feed{
+		Title:   page.Title,
+		Path:    path,
+		Entries: entries,
+	})
+}
+
+func pageEntries(pages []common.Page) []entry {
+	entries := []entry{}
+	for _, page := range pages {
+		entries = append(entries, entry{
+			Title:   page.Title,
+			Path:    fmt.Sprintf("/page/%s/%s", page.CategorySlug, page.PageSlug),
+			Content: page.Description,
+			Date:    page.DateCreated,
+		})
+	}
+	return entries
+}
+
+// Writes the feed as Atom, or RSS 2.0 when asked for with ?format=rss.
+// Conditional requests are answered by http.ServeContent using the newest
+// entry's date and a hash of the body.
+func writeFeed(res http.ResponseWriter, req *http.Request, f feed) {
+	baseURL := common.GetBaseURL(req)
+
+	var updated time.Time
+	for _, e := range f.Entries {
+		if e.Date.After(updated) {
+			updated = e.Date
+		}
+	}
+
+	var document interface{}
+	if req.URL.Query().Get("format") == "rss" {
+		res.Header().Set("Content-Type", "application/rss+xml; charset=utf-8")
+		document = newRSS(baseURL, req.URL.RequestURI(), f, updated)
+	} else {
+		res.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+		document = newAtom(baseURL, req.URL.RequestURI(), f, updated)
+	}
+
+	body, err := xml.MarshalIndent(document, "", "\t")
+	if err != nil {
+		log.Println("Error encoding feed:", err)
+		http.Error(res, common.DatabaseError.Error(), http.StatusInternalServerError)
+		return
+	}
+	body = append([]byte(xml.Header), body...)
+
+	hash := sha1.Sum(body)
+	res.Header().Set("ETag", `"`+hex.EncodeToString(hash[:])+`"`)
+	res.Header().Set("Cache-Control", "public, max-age=300")
+	http.ServeContent(res, req, "", updated, bytes.NewReader(body))
+}
+
+// Entries have no ids of their own, so one is made from the link and date.
+func entryID(baseURL string, e entry) string {
+	return fmt.Sprintf("%s%s#%d", baseURL, e.Path, e.Date.UnixNano())
+}
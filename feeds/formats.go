@@ -0,0 +1,114 @@
+package feeds
+
+import (
+	"encoding/xml"
+	"time"
+
+	"github.com/comforme/comforme/common"
+)
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	Id      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Author  atomAuthor  `xml:"author"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomEntry struct {
+	Title   string      `xml:"title"`
+	Id      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Link    atomLink    `xml:"link"`
+	Content atomContent `xml:"content"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+func newAtom(baseURL, self string, f feed, updated time.Time) atomFeed {
+	atom := atomFeed{
+		Title:   f.Title,
+		Id:      baseURL + self,
+		Updated: updated.Format(time.RFC3339),
+		Author:  atomAuthor{common.SiteName},
+		Links: []atomLink{
+			{baseURL + self, "self"},
+			{baseURL + f.Path, "alternate"},
+		},
+	}
+	for _, e := range f.Entries {
+		atom.Entries = append(atom.Entries, atomEntry{
+			Title:   e.Title,
+			Id:      entryID(baseURL, e),
+			Updated: e.Date.Format(time.RFC3339),
+			Link:    atomLink{baseURL + e.Path, "alternate"},
+			Content: atomContent{"text", e.Content},
+		})
+	}
+	return atom
+}
+
+type rssDocument struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	Guid        rssGuid `xml:"guid"`
+	Description string  `xml:"description"`
+	PubDate     string  `xml:"pubDate"`
+}
+
+type rssGuid struct {
+	IsPermaLink string `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+func newRSS(baseURL, self string, f feed, updated time.Time) rssDocument {
+	rss := rssDocument{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:       f.Title,
+			Link:        baseURL + f.Path,
+			Description: f.Title,
+		},
+	}
+	if !updated.IsZero() {
+		rss.Channel.LastBuildDate = updated.Format(time.RFC1123Z)
+	}
+	for _, e := range f.Entries {
+		rss.Channel.Items = append(rss.Channel.Items, rssItem{
+			Title:       e.Title,
+			Link:        baseURL + e.Path,
+			Guid:        rssGuid{"false", entryID(baseURL, e)},
+			Description: e.Content,
+			PubDate:     e.Date.Format(time.RFC1123Z),
+		})
+	}
+	return rss
+}
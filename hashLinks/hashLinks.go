@@ -4,49 +4,60 @@ import (
 	"fmt"
 	"html/template"
 	"net/http"
+	"strconv"
 
 	"github.com/julienschmidt/httprouter"
 
 	"github.com/comforme/comforme/common"
 	"github.com/comforme/comforme/databaseActions"
+	"github.com/comforme/comforme/i18n"
 	"github.com/comforme/comforme/templates"
 )
 
 var messageTemplate *template.Template
 var registerTemplate *template.Template
 var resetTemplate *template.Template
+var unsubscribeTemplate *template.Template
 
 func init() {
 	// Message page template
-	messageTemplate = template.Must(template.New("siteLayout").Parse(templates.SiteLayout))
+	messageTemplate = template.Must(template.New("siteLayout").Funcs(i18n.Funcs).Parse(templates.SiteLayout))
 	template.Must(messageTemplate.New("nav").Parse(templates.NavlessBar))
 	template.Must(messageTemplate.New("wizardContent").Parse(""))
 	template.Must(messageTemplate.New("content").Parse(templates.HashLink))
 
 	// Register page template
-	registerTemplate = template.Must(template.New("siteLayout").Parse(templates.SiteLayout))
+	registerTemplate = template.Must(template.New("siteLayout").Funcs(i18n.Funcs).Parse(templates.SiteLayout))
 	template.Must(registerTemplate.New("nav").Parse(templates.NavlessBar))
 	template.Must(registerTemplate.New("wizardContent").Parse(registerTemplateText))
 	template.Must(registerTemplate.New("content").Parse(templates.HashLink))
 
 	// Reset page template
-	resetTemplate = template.Must(template.New("siteLayout").Parse(templates.SiteLayout))
+	resetTemplate = template.Must(template.New("siteLayout").Funcs(i18n.Funcs).Parse(templates.SiteLayout))
 	template.Must(resetTemplate.New("nav").Parse(templates.NavlessBar))
 	template.Must(resetTemplate.New("wizardContent").Parse(resetTemplateText))
 	template.Must(resetTemplate.New("content").Parse(templates.HashLink))
+
+	// Unsubscribe page template
+	unsubscribeTemplate = template.Must(template.New("siteLayout").Funcs(i18n.Funcs).Parse(templates.SiteLayout))
+	template.Must(unsubscribeTemplate.New("nav").Parse(templates.NavlessBar))
+	template.Must(unsubscribeTemplate.New("wizardContent").Parse(unsubscribeTemplateText))
+	template.Must(unsubscribeTemplate.New("content").Parse(templates.HashLink))
 }
 
 func ResetHandler(res http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 	res.Header().Set("cache-control", "private, max-age=0, no-cache")
 
 	data := map[string]interface{}{}
-	data["pageTitle"] = "Password Reset"
+	locale := i18n.RequestLocale(req)
+	data["locale"] = locale
+	data["pageTitle"] = i18n.Translate(locale, "links.reset_title")
 	data["siteName"] = common.SiteName
 
 	if !common.CheckParam(req.URL.Query(), "email") ||
 		!common.CheckParam(req.URL.Query(), "date") ||
 		!common.CheckParam(req.URL.Query(), "code") {
-		data["errorMsg"] = common.InvalidLink.Error()
+		data["errorMsg"] = i18n.ErrorMessage(locale, common.InvalidLink)
 	} else {
 		email := req.URL.Query()["email"][0]
 		data["email"] = email
@@ -59,7 +70,7 @@ func ResetHandler(res http.ResponseWriter, req *http.Request, ps httprouter.Para
 			email,
 			date,
 		) {
-			data["errorMsg"] = common.InvalidLink.Error()
+			data["errorMsg"] = i18n.ErrorMessage(locale, common.InvalidLink)
 		} else {
 			// Reset user's password
 			if req.Method == "POST" {
@@ -67,17 +78,17 @@ func ResetHandler(res http.ResponseWriter, req *http.Request, ps httprouter.Para
 				newPasswordAgain := req.PostFormValue("newPasswordAgain")
 
 				if len(newPassword) == 0 {
-					data["errorMsg"] = "Required field left blank."
+					data["errorMsg"] = i18n.Translate(locale, "links.required_field")
 				} else if newPassword != newPasswordAgain {
-					data["errorMsg"] = "Passwords do not match."
+					data["errorMsg"] = i18n.Translate(locale, "links.passwords_differ")
 				} else {
-					err := databaseActions.SetPassword(email, newPassword)
+					err := databaseActions.SetPassword(email, newPassword, common.GetRequestInfo(req))
 					if err != nil {
-						data["errorMsg"] = err.Error()
+						data["errorMsg"] = i18n.ErrorMessage(locale, err)
 					} else { // No error
-						sessionid, err := databaseActions.Login(email, newPassword)
+						sessionid, err := databaseActions.Login(email, newPassword, common.GetRequestInfo(req))
 						if err != nil {
-							data["errorMsg"] = err.Error()
+							data["errorMsg"] = i18n.ErrorMessage(locale, err)
 						} else { // No error
 							common.SetSessionCookie(res, sessionid)
 
@@ -101,13 +112,15 @@ func RegisterHandler(res http.ResponseWriter, req *http.Request, ps httprouter.P
 	res.Header().Set("cache-control", "private, max-age=0, no-cache")
 
 	data := map[string]interface{}{}
-	data["pageTitle"] = "Registration"
+	locale := i18n.RequestLocale(req)
+	data["locale"] = locale
+	data["pageTitle"] = i18n.Translate(locale, "links.register_title")
 	data["siteName"] = common.SiteName
 
 	if !common.CheckParam(req.URL.Query(), "email") ||
 		!common.CheckParam(req.URL.Query(), "date") ||
 		!common.CheckParam(req.URL.Query(), "code") {
-		data["errorMsg"] = common.InvalidLink.Error()
+		data["errorMsg"] = i18n.ErrorMessage(locale, common.InvalidLink)
 	} else {
 		email := req.URL.Query()["email"][0]
 		data["email"] = email
@@ -120,7 +133,7 @@ func RegisterHandler(res http.ResponseWriter, req *http.Request, ps httprouter.P
 			email,
 			date,
 		) {
-			data["errorMsg"] = common.InvalidLink.Error()
+			data["errorMsg"] = i18n.ErrorMessage(locale, common.InvalidLink)
 		} else {
 			// Register user (for real this time)
 
@@ -130,13 +143,13 @@ func RegisterHandler(res http.ResponseWriter, req *http.Request, ps httprouter.P
 				newPassword := req.PostFormValue("newPassword")
 				newPasswordAgain := req.PostFormValue("newPasswordAgain")
 				if len(username) == 0 || len(newPassword) == 0 {
-					data["errorMsg"] = "Required field(s) left blank."
+					data["errorMsg"] = i18n.Translate(locale, "links.required_fields")
 				} else if newPassword != newPasswordAgain {
-					data["errorMsg"] = "Passwords do not match."
+					data["errorMsg"] = i18n.Translate(locale, "links.passwords_differ")
 				} else {
-					sessionid, err := databaseActions.Register2(username, email, newPassword)
+					sessionid, err := databaseActions.Register2(username, email, newPassword, common.GetRequestInfo(req))
 					if err != nil {
-						data["errorMsg"] = err.Error()
+						data["errorMsg"] = i18n.ErrorMessage(locale, err)
 					} else { // No error
 						common.SetSessionCookie(res, sessionid)
 
@@ -156,12 +169,54 @@ func RegisterHandler(res http.ResponseWriter, req *http.Request, ps httprouter.P
 	return
 }
 
+// Turns off email digests. The link only shows a button so that mail scanners
+// following it do not unsubscribe anyone.
+func UnsubscribeHandler(res http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	res.Header().Set("cache-control", "private, max-age=0, no-cache")
+
+	data := map[string]interface{}{}
+	locale := i18n.RequestLocale(req)
+	data["locale"] = locale
+	data["pageTitle"] = i18n.Translate(locale, "links.unsubscribe_title")
+	data["siteName"] = common.SiteName
+
+	userID, err := strconv.Atoi(req.URL.Query().Get("user"))
+	if err != nil || !common.CheckParam(req.URL.Query(), "sig") {
+		data["errorMsg"] = i18n.ErrorMessage(locale, common.InvalidLink)
+	} else {
+		data["formAction"] = req.URL.RequestURI()
+
+		if !databaseActions.CheckUnsubscribeLink(userID, req.URL.Query().Get("sig")) {
+			data["errorMsg"] = i18n.ErrorMessage(locale, common.InvalidLink)
+		} else if data["email"], err = databaseActions.GetDigestEmail(userID); err != nil {
+			data["errorMsg"] = i18n.ErrorMessage(locale, err)
+		} else {
+			if req.Method == "POST" {
+				err := databaseActions.UnsubscribeFromDigest(userID)
+				if err != nil {
+					data["errorMsg"] = i18n.ErrorMessage(locale, err)
+				} else {
+					data["successMsg"] = i18n.Translate(locale, "links.unsubscribed")
+				}
+				common.ExecTemplate(messageTemplate, res, data)
+				return
+			}
+
+			common.ExecTemplate(unsubscribeTemplate, res, data)
+			return
+		}
+	}
+
+	common.ExecTemplate(messageTemplate, res, data)
+	return
+}
+
 const registerTemplateText = `					<form method="post" action="{{.formAction}}">
-						<h2>Finish Registering</h2>
+						<h2>{{t $.locale "links.finish_registering"}}</h2>
 						<div class="row">
 							<div class="large-4 medium-6 columns left">
 								<label>
-									Email
+									{{t $.locale "links.email"}}
 									<input type="email" value="{{.email}}" disabled>
 								</label>
 							</div>
@@ -169,7 +224,7 @@ const registerTemplateText = `					<form method="post" action="{{.formAction}}">
 						<div class="row">
 							<div class="large-4 medium-6 columns left">
 								<label>
-									Username (between 3 and 20 characters)
+									{{t $.locale "links.username"}}
 									<input type="text" name="username" {{if .username}} value="{{.username}}"{{end}}>
 								</label>
 							</div>
@@ -177,27 +232,27 @@ const registerTemplateText = `					<form method="post" action="{{.formAction}}">
 						<div class="row">
 							<div class="large-4 medium-6 columns left">
 								<label>
-									New password (must be at least 6 characters)
+									{{t $.locale "links.new_password_min"}}
 									<input type="password" name="newPassword">
 								</label>
 							</div>
 							<div class="large-4 medium-6 columns left">
 								<label>
-									New password (again)
+									{{t $.locale "links.new_password_again"}}
 									<input type="password" name="newPasswordAgain">
 								</label>
 							</div>
 						</div>
-						<button type="submit" name="continue" value="true">Continue</button>
+						<button type="submit" name="continue" value="true">{{t $.locale "links.continue"}}</button>
 					</form>
 				`
 
 const resetTemplateText = `					<form method="post" action="{{.formAction}}">
-						<h2>Password Reset</h2>
+						<h2>{{t $.locale "links.reset_title"}}</h2>
 						<div class="row">
 							<div class="large-4 medium-6 columns left">
 								<label>
-									Email
+									{{t $.locale "links.email"}}
 									<input type="email" value="{{.email}}" disabled>
 								</label>
 							</div>
@@ -205,17 +260,24 @@ const resetTemplateText = `					<form method="post" action="{{.formAction}}">
 						<div class="row">
 							<div class="large-4 medium-6 columns left">
 								<label>
-									New password
+									{{t $.locale "links.new_password"}}
 									<input type="password" name="newPassword">
 								</label>
 							</div>
 							<div class="large-4 medium-6 columns left">
 								<label>
-									New password (again)
+									{{t $.locale "links.new_password_again"}}
 									<input type="password" name="newPasswordAgain">
 								</label>
 							</div>
 						</div>
-						<button type="submit" name="resetPassword" value="true">Update Password</button>
+						<button type="submit" name="resetPassword" value="true">{{t $.locale "links.update_password"}}</button>
+					</form>
+				`
+
+const unsubscribeTemplateText = `					<form method="post" action="{{.formAction}}">
+						<h2>{{t $.locale "links.stop_digests"}}</h2>
+						<p>{{t $.locale "links.stop_digests_confirm" .email}}</p>
+						<button type="submit" name="unsubscribe" value="true">{{t $.locale "links.unsubscribe"}}</button>
 					</form>
 				`
@@ -4,18 +4,20 @@ import (
 	"html/template"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/julienschmidt/httprouter"
 
 	"github.com/comforme/comforme/common"
 	"github.com/comforme/comforme/databaseActions"
+	"github.com/comforme/comforme/i18n"
 	"github.com/comforme/comforme/templates"
 )
 
 var homeTemplate *template.Template
 
 func init() {
-	homeTemplate = template.Must(template.New("siteLayout").Parse(templates.SiteLayout))
+	homeTemplate = template.Must(template.New("siteLayout").Funcs(i18n.Funcs).Parse(templates.SiteLayout))
 	template.Must(homeTemplate.New("nav").Parse(templates.NavBar))
 	template.Must(homeTemplate.New("searchBar").Parse(templates.SearchBar))
 	template.Must(homeTemplate.New("content").Parse(homeTemplateText))
@@ -24,11 +26,24 @@ func init() {
 func HomeHandler(res http.ResponseWriter, req *http.Request, ps httprouter.Params, userInfo common.UserInfo) {
 	data := map[string]interface{}{}
 	data["siteName"] = common.SiteName
-	topPages, err := databaseActions.GetTopPages()
+	data["locale"] = userInfo.Locale
+
+	asOf := time.Now()
+	feed, more, err := databaseActions.GetFeed(userInfo.UserID, asOf, 0)
 	if err != nil {
-		log.Println("Failed to retrieve top results:", err)
-	} else {
-		data["topPages"] = topPages
+		log.Println("Failed to retrieve feed:", err)
+	}
+	data["feed"] = feed
+	data["moreFeed"] = more
+	data["feedAsOf"] = asOf.Format(time.RFC3339Nano)
+
+	// Users without communities, or whose communities are quiet, see what is
+	// popular across the site instead.
+	if len(feed) == 0 {
+		data["trendingPages"], err = databaseActions.GetTrendingPages(userInfo.UserID)
+		if err != nil {
+			log.Println("Failed to retrieve trending pages:", err)
+		}
 	}
 
 	common.ExecTemplate(homeTemplate, res, data)
@@ -38,26 +53,45 @@ const homeTemplateText = `
 <div class="content">
 	<div class="row">
 		<div class="columns">
-			<h1>Search</h1>
+			<h1>{{t $.locale "home.search"}}</h1>
 		</div>
 	</div>
 	<div class="row">
 		<div class="columns">
 			{{template "searchBar" .}}
-			<p>{{.Top}}</p>
 		</div>
 	</div>
 	<div class="row">
 		<div class="columns left">
-			Lost? <a href="/tour">Take the tour</a> again.
+			{{t $.locale "home.lost"}} <a href="/tour">{{t $.locale "home.take_tour"}}</a>.
 		</div>
+	</div>{{if .feed}}
+	<div class="row">
+		<div class="columns">
+			<h2>{{t $.locale "home.from_communities"}}</h2>
+		</div>
+	</div>
+	<div class="row" id="feed" data-as-of="{{.feedAsOf}}" data-offset="{{len .feed}}"{{if .moreFeed}} data-more="true"{{end}} data-anonymous="{{t $.locale "feed.anonymous_member"}}" data-added="{{t $.locale "feed.added"}}" data-posted-on="{{t $.locale "feed.posted_on"}}">{{range .feed}}
+		<div class="columns feed-item">
+			<p>
+				<strong>{{if not .Author}}{{t $.locale "feed.anonymous_member"}}{{else if .Pseudonymous}}{{.Author}}{{else}}<a href="/user/{{.Author}}">{{.Author}}</a>{{end}}</strong>
+				{{if .IsPage}}{{t $.locale "feed.added"}}{{else}}{{t $.locale "feed.posted_on"}}{{end}}
+				<a href="/page/{{.CategorySlug}}/{{.PageSlug}}">{{.PageTitle}}</a>
+				<small>{{.Date.Format "2006-01-02 15:04"}}</small>
+			</p>
+			<p>{{.Body}}</p>
+		</div>{{end}}
 	</div>
+	<script src="/static/js/feed.js"></script>{{else}}
 	<div class="row">
 		<div class="columns left">
-			<h2>Top Resources:</h2>
-		</div>{{range .topPages}}
+			<h2>{{t $.locale "home.trending"}}</h2>
+		</div>{{range .trendingPages}}
 		<div class="columns left large-3 medium-4 small-6 xsmall-12">
 			<h3><a href="/page/{{.CategorySlug}}/{{.PageSlug}}">{{.Title}}</a></h3>
-		</div>{{ end }}
-	</div>
+		</div>{{else}}
+		<div class="columns left">
+			<p>{{t $.locale "home.nothing_yet"}} <a href="/newPage">{{t $.locale "home.get_started"}}</a>.</p>
+		</div>{{end}}
+	</div>{{end}}
 </div>`
@@ -0,0 +1,645 @@
+package i18n
+
+var english = map[string]string{
+	// Shared
+	"common.requires_javascript": "This site requires JavaScript to function!",
+
+	// Navigation and search
+	"nav.menu":           "Menu",
+	"nav.home":           "Home",
+	"nav.add_resource":   "Add Resource",
+	"nav.notifications":  "Notifications",
+	"nav.settings":       "Settings",
+	"nav.log_out":        "Log Out",
+	"search.placeholder": "Page Search",
+	"search.button":      "Search",
+	"tour.title":         "Tour",
+
+	// Communities
+	"communities.yours":               "Your Communities",
+	"communities.check_all":           "Check all that apply. Don't see yours?",
+	"communities.propose":             "Propose a new community",
+	"communities.search_placeholder":  "Find a community",
+	"communities.suggestions":         "People in your communities are also in:",
+	"communities.visibility_title":    "Who can see that you are a member",
+	"communities.visibility_public":   "Visible to everyone",
+	"communities.visibility_members":  "Visible to members",
+	"communities.visibility_private":  "Only me",
+	"communities.more_specific.one":   "%d more specific",
+	"communities.more_specific.other": "%d more specific",
+
+	// Login
+	"login.title":                "Login",
+	"login.welcome":              "Welcome to %s!",
+	"login.sign_up":              "Sign Up",
+	"login.log_in":               "Log In",
+	"login.email":                "Email",
+	"login.password":             "Password",
+	"login.reset_password":       "Reset Password",
+	"login.passkey":              "Log In with a Passkey",
+	"login.with_provider":        "Log In with %s",
+	"login.about_title":          "What is %s?",
+	"login.about":                "is a community-rated and identity-oriented social network/service listing. Users can find accepting communities and services based on a wide array of keywords. Users can also start their own communities categorized by aforementioned keywords. %s makes it easier for an individual to find communities and services which accept them for who they are.",
+	"login.registration_success": "Registration successful. Please check your email.",
+	"login.reset_success":        "Password reset successful. Please check your email.",
+
+	// Pages
+	"page.edit":                   "Edit Page",
+	"page.feed":                   "Feed of New Posts",
+	"page.find_duplicates":        "Find Duplicates",
+	"page.follow":                 "Follow",
+	"page.following":              "Following",
+	"page.address":                "Address:",
+	"page.website":                "Website:",
+	"page.post_your_thoughts":     "Post Your Thoughts",
+	"page.comment_label":          "Comment:",
+	"page.post_as":                "Post as",
+	"page.anonymous":              "Anonymous",
+	"page.comment":                "Comment",
+	"page.anonymous_member.one":   "Anonymous member of %d of your communities",
+	"page.anonymous_member.other": "Anonymous member of %d of your communities",
+	"page.remove_post":            "Remove Post",
+	"page.muted_post":             "Post from a muted user",
+	"page.post_too_short":         "Post must be at least %d characters long.",
+	"page.post_added":             "Post successfully added.",
+	"page_form.title":             "Resource page title",
+	"page_form.description":       "Unbiased description of resource",
+	"page_form.address":           "Physical address of resource (if applicable)",
+	"page_form.website":           "Resource's website (if applicable)",
+	"page_form.location":          "Latitude, longitude (if applicable)",
+	"page_form.title_too_short":   "Title must be more than 1 character long.",
+	"page_form.invalid_category":  "Invalid category.",
+	"new_page.legend":             "Create a Resource New Page",
+	"new_page.similar":            "Similar pages",
+	"new_page.in_category":        "in %s",
+	"new_page.confirm_different":  "My page is about a different place.",
+	"new_page.submit":             "Submit",
+	"edit_page.title":             "Edit Page",
+	"edit_page.legend":            "Edit Resource Page",
+	"edit_page.cancel":            "Cancel",
+	"edit_page.save":              "Save",
+	"similar.title":               "similar title",
+	"similar.address":             "same address",
+	"similar.website":             "same website",
+	"similar.distance":            "%.0f meters away",
+
+	// Settings
+	"settings.title":                    "Settings",
+	"settings.user_information":         "User Information",
+	"settings.email":                    "Email:",
+	"settings.username":                 "Username:",
+	"settings.bio":                      "Bio",
+	"settings.update_profile":           "Update Profile",
+	"settings.profile_updated":          "Profile updated.",
+	"settings.password_change":          "Password Change",
+	"settings.old_password":             "Old password",
+	"settings.new_password":             "New password",
+	"settings.new_password_again":       "New password (again)",
+	"settings.update_password":          "Update Password",
+	"settings.password_required":        "Both old and new password required to change password",
+	"settings.passwords_differ":         "Passwords do not match.",
+	"settings.password_changed":         "Password changed.",
+	"settings.password_change_required": "Password change required.",
+	"settings.sessions":                 "Sessions",
+	"settings.open_sessions.one":        "You currently have %d session open in addition to this one.",
+	"settings.open_sessions.other":      "You currently have %d sessions open in addition to this one.",
+	"settings.logout_sessions":          "Logout Other Sessions",
+	"settings.username_change":          "Username Change",
+	"settings.password":                 "Password",
+	"settings.new_username":             "New username",
+	"settings.update_username":          "Update Username",
+	"settings.username_changed":         "Username changed.",
+	"settings.digest":                   "Email Digest",
+	"settings.digest_help":              "A summary of new pages and posts in your communities and on pages you follow.",
+	"settings.digest_daily":             "Daily",
+	"settings.digest_weekly":            "Weekly",
+	"settings.digest_never":             "Never",
+	"settings.update_digest":            "Update Email Preferences",
+	"settings.digest_updated":           "Email preferences updated.",
+	"settings.language":                 "Language",
+	"settings.language_automatic":       "Automatic (from your browser)",
+	"settings.update_language":          "Update Language",
+	"settings.language_updated":         "Language updated.",
+	"settings.pseudonyms":               "Pseudonyms",
+	"settings.pseudonyms_help":          "Post under another name. Other users cannot see which pseudonyms are yours.",
+	"settings.no_pseudonyms":            "You have no pseudonyms.",
+	"settings.new_pseudonym":            "New pseudonym",
+	"settings.add_pseudonym":            "Add Pseudonym",
+	"settings.pseudonym_added":          "Pseudonym added.",
+	"settings.blocks":                   "Blocked and Muted Users",
+	"settings.blocks_help":              "Blocked users' pages and posts are hidden from you, and they cannot post on your pages or mention you. Muted users' posts are collapsed.",
+	"settings.username_column":          "Username",
+	"settings.block_type":               "Type",
+	"settings.since":                    "Since",
+	"settings.muted":                    "Muted",
+	"settings.blocked":                  "Blocked",
+	"settings.unmute":                   "Unmute",
+	"settings.unblock":                  "Unblock",
+	"settings.no_blocks":                "You have not blocked or muted anyone.",
+	"settings.block":                    "Block",
+	"settings.mute":                     "Mute",
+	"settings.add_block":                "Add",
+	"settings.user_blocked":             "User blocked.",
+	"settings.user_muted":               "User muted.",
+	"settings.user_unblocked":           "User unblocked.",
+	"settings.passkeys":                 "Passkeys",
+	"settings.passkeys_help":            "Log in with your fingerprint, face, screen lock or security key instead of your password.",
+	"settings.name":                     "Name",
+	"settings.added":                    "Added",
+	"settings.last_used":                "Last Used",
+	"settings.never_used":               "Never",
+	"settings.rename":                   "Rename",
+	"settings.remove":                   "Remove",
+	"settings.no_passkeys":              "You have no passkeys.",
+	"settings.passkey_name":             "Passkey name",
+	"settings.passkey_name_example":     "e.g. My phone",
+	"settings.add_passkey":              "Add Passkey",
+	"settings.passkey_removed":          "Passkey removed.",
+	"settings.passkey_renamed":          "Passkey renamed.",
+	"settings.identities":               "Linked Accounts",
+	"settings.identities_help":          "Log in with an account from another site. Accounts are also linked automatically when you log in with one that has the same verified email address.",
+	"settings.provider":                 "Provider",
+	"settings.email_column":             "Email",
+	"settings.linked":                   "Linked",
+	"settings.unlink":                   "Unlink",
+	"settings.no_identities":            "You have not linked any accounts.",
+	"settings.link_provider":            "Link %s",
+	"settings.identity_removed":         "Account unlinked.",
+	"settings.tokens":                   "API Tokens and Applications",
+	"settings.tokens_help":              "Personal access tokens let your own scripts use the API as you. Applications you authorize are listed here too.",
+	"settings.api_reference":            "API reference",
+	"settings.access":                   "Access",
+	"settings.created":                  "Created",
+	"settings.application":              "application",
+	"settings.revoke":                   "Revoke",
+	"settings.no_tokens":                "You have no tokens.",
+	"settings.token_name":               "Token name",
+	"settings.scope_read":               "Read",
+	"settings.scope_write":              "Write",
+	"settings.create_token":             "Create Token",
+	"settings.token_created":            "Token created. Copy it now, it will not be shown again.",
+	"settings.token_revoked":            "Access revoked.",
+	"settings.export":                   "Download My Data",
+	"settings.export_help":              "Get a copy of your profile, communities, pages, posts, sessions and security activity. We'll email you a download link, which works for a week.",
+	"settings.requested":                "Requested",
+	"settings.status":                   "Status",
+	"settings.size":                     "Size",
+	"settings.bytes.one":                "%d byte",
+	"settings.bytes.other":              "%d bytes",
+	"settings.download":                 "Download",
+	"settings.request_export":           "Request Data Export",
+	"settings.export_requested":         "We'll email you a link when your data is ready.",
+	"settings.security":                 "Recent Security Activity",
+	"settings.date":                     "Date",
+	"settings.event":                    "Event",
+	"settings.ip_address":               "IP Address",
+	"settings.browser":                  "Browser",
+	"settings.no_security_events":       "No recent activity.",
+	"settings.delete_account":           "Delete Account",
+	"settings.deletion_pending":         "Your account will be deleted on %s. Until then you can change your mind.",
+	"settings.cancel_deletion":          "Cancel Deletion",
+	"settings.delete_help":              "Your email address, sessions, tokens and community memberships will be deleted two weeks after you ask. Pages and posts you wrote will stay, shown as written by a deleted user. You may want to download your data first.",
+	"settings.delete_my_account":        "Delete My Account",
+	"settings.deletion_scheduled":       "Your account will be deleted on %s.",
+	"settings.deletion_cancelled":       "Your account will not be deleted.",
+	"settings.administration":           "Administration",
+	"settings.admin_dashboard":          "Admin Dashboard",
+	"settings.reports":                  "Reports",
+
+	// Data export statuses
+	"export.pending":  "Pending",
+	"export.building": "Building",
+	"export.ready":    "Ready",
+	"export.failed":   "Failed",
+	"export.expired":  "Expired",
+
+	// Security events shown in settings
+	"audit.login":                      "Logged in",
+	"audit.login.failed":               "Failed login",
+	"audit.registered":                 "Registered",
+	"audit.password.changed":           "Password changed",
+	"audit.password.change_failed":     "Failed password change",
+	"audit.password.reset_requested":   "Password reset requested",
+	"audit.password.reset":             "Password reset",
+	"audit.username.changed":           "Username changed",
+	"audit.sessions.revoked":           "Other sessions logged out",
+	"audit.user.suspended":             "Account suspended",
+	"audit.user.unsuspended":           "Account unsuspended",
+	"audit.password.reset_forced":      "Password reset by a moderator",
+	"audit.role.granted":               "Role changed",
+	"audit.token.created":              "API token created",
+	"audit.token.revoked":              "API token revoked",
+	"audit.oauth.authorized":           "Application authorized",
+	"audit.identity.linked":            "Account linked",
+	"audit.identity.unlinked":          "Account unlinked",
+	"audit.passkey.added":              "Passkey added",
+	"audit.passkey.removed":            "Passkey removed",
+	"audit.export.requested":           "Data export requested",
+	"audit.export.downloaded":          "Data export downloaded",
+	"audit.account.deletion_requested": "Account deletion requested",
+	"audit.account.deletion_cancelled": "Account deletion cancelled",
+
+	// Home
+	"home.search":           "Search",
+	"home.lost":             "Lost?",
+	"home.take_tour":        "Take the tour again",
+	"home.from_communities": "From Your Communities",
+	"home.trending":         "Trending This Week:",
+	"home.nothing_yet":      "Nothing yet.",
+	"home.get_started":      "Add a resource to get things started",
+	"feed.anonymous_member": "An anonymous member",
+	"feed.added":            "added",
+	"feed.posted_on":        "posted on",
+
+	// Profiles
+	"profile.report_thanks":    "Thank you. The moderators will look into your report.",
+	"profile.blocked":          "You have blocked this user.",
+	"profile.manage_blocks":    "Manage blocked users",
+	"profile.communities":      "Communities:",
+	"profile.contributions":    "Contributions",
+	"profile.added":            "Added",
+	"profile.posted_on":        "Posted on",
+	"profile.no_contributions": "No contributions yet.",
+	"profile.newer":            "Newer",
+	"profile.older":            "Older",
+	"profile.report":           "Report %s",
+	"profile.report_reason":    "What is the problem?",
+	"profile.report_submit":    "Report to Moderators",
+
+	// Notifications
+	"notifications.title":         "Notifications",
+	"notifications.mark_all_read": "Mark All as Read",
+	"notifications.mark_read":     "Mark as Read",
+	"notifications.none":          "No notifications yet. You will be notified of new posts on pages you create, post on or follow.",
+
+	// Community pages
+	"community.feed":                 "Feed of Public Members' Contributions",
+	"community.part_of":              "Part of",
+	"community.members.one":          "%d member",
+	"community.members.other":        "%d members",
+	"community.subcommunities":       "Subcommunities:",
+	"community.recent_contributions": "Recent Contributions",
+	"community.no_contributions":     "No contributions yet.",
+	"propose.title":                  "Propose a Community",
+	"propose.legend":                 "Propose a New Community",
+	"propose.success":                "Thank you! Your community will be visible once a moderator approves it.",
+	"propose.similar":                "Similar communities",
+	"propose.awaiting_approval":      "(awaiting approval)",
+	"propose.confirm_different":      "My community is different from these.",
+	"propose.name":                   "Community name",
+	"propose.description":            "Who is this community for?",
+	"propose.parent":                 "Part of (optional)",
+	"propose.no_parent":              "None",
+	"propose.submit":                 "Submit for Approval",
+	"pending.title":                  "Pending Communities",
+	"pending.approved":               "Community approved.",
+	"pending.rejected":               "Community rejected.",
+	"pending.name":                   "Name",
+	"pending.description":            "Description",
+	"pending.part_of":                "Part of",
+	"pending.proposed_by":            "Proposed by",
+	"pending.approve":                "Approve",
+	"pending.reject":                 "Reject",
+	"pending.none":                   "Nothing to review.",
+
+	// Admin
+	"admin.nav":                         "Admin:",
+	"admin.nav.statistics":              "Statistics",
+	"admin.nav.users":                   "Users",
+	"admin.nav.categories":              "Categories",
+	"admin.nav.import":                  "Import",
+	"admin.nav.communities":             "Communities",
+	"admin.nav.reports":                 "Reports",
+	"admin.nav.federation":              "Federation",
+	"admin.nav.audit":                   "Audit Log",
+	"admin.nav.webhooks":                "Webhooks",
+	"admin.add":                         "Add",
+	"admin.rename":                      "Rename",
+	"admin.save":                        "Save",
+	"admin.delete":                      "Delete",
+	"admin.merge":                       "Merge",
+	"admin.approve":                     "Approve",
+	"admin.reject":                      "Reject",
+	"admin.none":                        "None",
+	"admin.name":                        "Name",
+	"admin.date":                        "Date",
+	"admin.user":                        "User",
+	"admin.pages":                       "Pages",
+	"admin.category":                    "Category",
+	"admin.every_category":              "Every category",
+	"admin.statistics.title":            "Site Statistics",
+	"admin.statistics.admin":            "Admin",
+	"admin.statistics.users":            "Users",
+	"admin.statistics.suspended_users":  "Suspended users",
+	"admin.statistics.sessions":         "Open sessions",
+	"admin.statistics.pages":            "Pages",
+	"admin.statistics.posts":            "Posts",
+	"admin.statistics.this_week":        "%d this week",
+	"admin.statistics.categories":       "Categories",
+	"admin.statistics.communities":      "Communities",
+	"admin.statistics.memberships":      "Community memberships",
+	"admin.categories.title":            "Categories",
+	"admin.categories.created":          "Category created.",
+	"admin.categories.renamed":          "Category renamed.",
+	"admin.categories.deleted":          "Category deleted.",
+	"admin.categories.new":              "New category name",
+	"admin.categories.slug":             "Slug",
+	"admin.communities.title":           "Communities",
+	"admin.communities.created":         "Community created.",
+	"admin.communities.renamed":         "Community renamed.",
+	"admin.communities.deleted":         "Community deleted.",
+	"admin.communities.moved":           "Community moved.",
+	"admin.communities.aliases_saved":   "Aliases saved.",
+	"admin.communities.merged.one":      "Communities merged. %d membership moved.",
+	"admin.communities.merged.other":    "Communities merged. %d memberships moved.",
+	"admin.communities.new":             "New community name",
+	"admin.communities.merge_legend":    "Merge Duplicate Communities",
+	"admin.communities.into":            "Into",
+	"admin.communities.aliases":         "Aliases",
+	"admin.communities.part_of":         "Part of",
+	"admin.communities.members":         "Members",
+	"admin.communities.comma_separated": "Comma separated",
+	"admin.communities.move":            "Move",
+	"admin.users.title":                 "Users",
+	"admin.users.suspended":             "User suspended.",
+	"admin.users.unsuspended":           "User unsuspended.",
+	"admin.users.reset_forced":          "User will be required to change their password.",
+	"admin.users.deletion_scheduled":    "User will be deleted in two weeks unless they cancel.",
+	"admin.users.deletion_cancelled":    "User will not be deleted.",
+	"admin.users.deleted":               "User deleted.",
+	"admin.users.search":                "Username or email",
+	"admin.users.search_button":         "Search",
+	"admin.users.username":              "Username",
+	"admin.users.email":                 "Email",
+	"admin.users.role":                  "Role",
+	"admin.users.status":                "Status",
+	"admin.users.status_suspended":      "Suspended",
+	"admin.users.status_active":         "Active",
+	"admin.users.status_reset_required": ", password change required",
+	"admin.users.status_deleted_on":     ", deleted on %s",
+	"admin.users.unsuspend":             "Unsuspend",
+	"admin.users.suspend":               "Suspend",
+	"admin.users.force_reset":           "Force Password Reset",
+	"admin.users.cancel_deletion":       "Cancel Deletion",
+	"admin.users.delete_now":            "Delete Now",
+	"admin.users.none":                  "No users found.",
+	"admin.audit.title":                 "Audit Log",
+	"admin.audit.action_placeholder":    "Action (e.g. login.failed)",
+	"admin.audit.user_placeholder":      "Username",
+	"admin.audit.ip_placeholder":        "IP address",
+	"admin.audit.filter":                "Filter",
+	"admin.audit.action":                "Action",
+	"admin.audit.actor":                 "Actor",
+	"admin.audit.ip":                    "IP",
+	"admin.audit.details":               "Details",
+	"admin.audit.none":                  "No events found.",
+	"admin.audit.older":                 "Older Events",
+	"admin.reports.title":               "Reports",
+	"admin.reports.resolved":            "Report resolved.",
+	"admin.reports.reported_by":         "Reported by",
+	"admin.reports.reason":              "Reason",
+	"admin.reports.manage":              "manage",
+	"admin.reports.resolve":             "Resolve",
+	"admin.reports.none":                "No open reports.",
+	"admin.webhooks.title":              "Webhooks",
+	"admin.webhooks.added":              "Webhook added.",
+	"admin.webhooks.updated":            "Webhook updated.",
+	"admin.webhooks.deleted":            "Webhook deleted.",
+	"admin.webhooks.secret":             "Signing secret for the new webhook. It will not be shown again:",
+	"admin.webhooks.legend":             "Add Webhook",
+	"admin.webhooks.url":                "URL",
+	"admin.webhooks.events":             "Events",
+	"admin.webhooks.category":           "Pages and posts in",
+	"admin.webhooks.added_on":           "Added",
+	"admin.webhooks.disabled":           "(disabled)",
+	"admin.webhooks.disable":            "Disable",
+	"admin.webhooks.enable":             "Enable",
+	"admin.webhooks.none":               "No webhooks.",
+	"admin.webhook.title":               "Webhook",
+	"admin.webhook.heading":             "Webhook Deliveries",
+	"admin.webhook.replayed":            "Delivery queued again.",
+	"admin.webhook.event":               "Event",
+	"admin.webhook.status":              "Status",
+	"admin.webhook.attempts":            "Attempts",
+	"admin.webhook.last_attempt":        "Last attempt",
+	"admin.webhook.response":            "Response",
+	"admin.webhook.payload":             "Payload",
+	"admin.webhook.next":                "(next %s)",
+	"admin.webhook.replay":              "Replay",
+	"admin.webhook.none":                "No deliveries yet.",
+	"delivery.pending":                  "Pending",
+	"delivery.succeeded":                "Succeeded",
+	"delivery.failed":                   "Failed",
+	"admin.federation.title":            "Federation",
+	"admin.federation.approved":         "Reply posted.",
+	"admin.federation.rejected":         "Reply rejected.",
+	"admin.federation.off":              "Federation is off. Set FEDERATION_DOMAIN to turn it on.",
+	"admin.federation.replies":          "Replies Awaiting Moderation",
+	"admin.federation.from":             "From",
+	"admin.federation.on":               "On",
+	"admin.federation.reply":            "Reply",
+	"admin.federation.no_replies":       "No replies waiting.",
+	"admin.federation.actors":           "Followed Actors",
+	"admin.federation.actor":            "Actor",
+	"admin.federation.address":          "Address",
+	"admin.federation.followers":        "Followers",
+	"admin.federation.no_actors":        "Nobody follows any categories or communities yet.",
+	"admin.import.title":                "Import Pages",
+	"admin.import.finished":             "Import finished.",
+	"admin.import.help":                 "Import resource pages from a CSV file with a header row or a JSON array of objects. Pages with the same title, address and website as one already on the site are skipped, so the same file can be imported again. A location is written as latitude, longitude.",
+	"admin.import.file":                 "File",
+	"admin.import.using":                "Using %s. Choose another file to replace it.",
+	"admin.import.previewed_file":       "the previewed file",
+	"admin.import.format":               "Format",
+	"admin.import.detect":               "Detect",
+	"admin.import.columns":              "Columns",
+	"admin.import.columns_help":         "Leave a field blank to read it from the column with the same name.",
+	"admin.import.default_category":     "Category of rows without one",
+	"admin.import.preview":              "Preview",
+	"admin.import.submit.one":           "Import %d Page",
+	"admin.import.submit.other":         "Import %d Pages",
+	"admin.import.preview_summary":      "Preview: %d to create",
+	"admin.import.created_summary":      "%d created",
+	"admin.import.summary":              ", %d duplicates, %d invalid",
+	"admin.import.row":                  "Row",
+	"admin.import.page_title":           "Title",
+	"admin.import.result":               "Result",
+	"import.created":                    "Created",
+	"import.valid":                      "Valid",
+	"import.duplicate":                  "Duplicate",
+	"import.invalid":                    "Invalid",
+	"admin.duplicates.title":            "Duplicate Pages",
+	"admin.duplicates.merged":           "Page merged into this one.",
+	"admin.duplicates.intro":            "Pages that may be the same place as",
+	"admin.duplicates.in_category":      "in %s",
+	"admin.duplicates.help":             "Merging moves every post and follower to the page that is kept, and links to the merged page redirect there.",
+	"admin.duplicates.page":             "Page",
+	"admin.duplicates.address":          "Address",
+	"admin.duplicates.website":          "Website",
+	"admin.duplicates.why":              "Why",
+	"admin.duplicates.merge_from":       "Merge into this page",
+	"admin.duplicates.merge_into":       "Merge this page into it",
+	"admin.duplicates.none":             "No likely duplicates found.",
+	"admin.duplicates.link":             "Link to another page to merge this page into",
+
+	// Email links
+	"links.reset_title":          "Password Reset",
+	"links.register_title":       "Registration",
+	"links.unsubscribe_title":    "Unsubscribe",
+	"links.required_field":       "Required field left blank.",
+	"links.required_fields":      "Required field(s) left blank.",
+	"links.passwords_differ":     "Passwords do not match.",
+	"links.unsubscribed":         "You will no longer receive digest emails. You can turn them back on in your settings.",
+	"links.finish_registering":   "Finish Registering",
+	"links.email":                "Email",
+	"links.username":             "Username (between 3 and 20 characters)",
+	"links.new_password_min":     "New password (must be at least 6 characters)",
+	"links.new_password":         "New password",
+	"links.new_password_again":   "New password (again)",
+	"links.continue":             "Continue",
+	"links.update_password":      "Update Password",
+	"links.stop_digests":         "Stop Digest Emails",
+	"links.stop_digests_confirm": "Stop sending activity digests to %s?",
+	"links.unsubscribe":          "Unsubscribe",
+
+	// API
+	"api.validation_failed":        "Some fields are invalid.",
+	"api.not_logged_in":            "Not logged in.",
+	"api.password_change_required": "Password change required.",
+	"api.not_found":                "Not found.",
+	"api.unsupported_media_type":   "Request body must be JSON.",
+	"api.invalid_json":             "Request body is not valid JSON.",
+	"api.limit":                    "Must be a number from 1 to %d.",
+	"api.offset":                   "Must be a number no less than 0.",
+	"api.title_too_short":          "Title must be more than 1 character long.",
+	"api.invalid_category":         "Invalid category.",
+	"api.post_too_short":           "Post must be at least %d characters long.",
+	"api.anonymous_pseudonym":      "A post cannot be both anonymous and under a pseudonym.",
+	"api.invalid_visibility":       "Must be public, members or private.",
+	"api.query_required":           "A search query is required.",
+
+	// Scripts
+	"passkey.failed":           "The passkey could not be used. Please try again.",
+	"passkey.unsupported":      "This browser does not support passkeys.",
+	"passkey.not_added":        "No passkey was added.",
+	"passkey.cancelled":        "Logging in with a passkey was cancelled.",
+	"page.remove_post_confirm": "Remove this post?",
+	"communities.none_found":   "No communities found.",
+	"communities.in_parent":    "in",
+
+	// Applications and external logins
+	"oauth.title":                "Authorize Application",
+	"oauth.heading":              "Authorize %s",
+	"oauth.would_like":           "would like to:",
+	"oauth.scope_read":           "See pages, posts and communities as you",
+	"oauth.scope_write":          "Create pages and posts and join or leave communities as you",
+	"oauth.logged_in_as":         "You are logged in as %s. If you allow this you will be sent to %s. You can revoke access at any time in your",
+	"oauth.settings":             "settings",
+	"oauth.allow":                "Allow",
+	"oauth.deny":                 "Deny",
+	"oauth.redirect_mismatch":    "The redirect URI does not match the one registered for %s.",
+	"oidc.back":                  "Back to Log In",
+	"search.title":               "Search",
+	"search.results_for":         "Results for",
+	"search.no_matches":          "No matches found for \"%s\"",
+	"search.add_resource_prompt": "Would you like to",
+	"search.add_resource":        "add a new resource",
+
+	// Errors
+	"error.permission_denied":            "You do not have permission to do that.",
+	"error.invalid_role":                 "Invalid role. Valid roles are user, trusted, moderator and admin.",
+	"error.import_format_unknown":        "Imports must be CSV or JSON.",
+	"error.import_empty":                 "There are no pages to import.",
+	"error.import_too_large":             "Imports can have at most %d pages. Please split the file.",
+	"error.import_no_title_column":       "No column is mapped to the page title.",
+	"error.import_category_unknown":      "Unknown category.",
+	"error.import_category_missing":      "No category given and no default category chosen.",
+	"error.import_duplicate_in_file":     "This page appears earlier in the file.",
+	"error.cannot_merge_page_into_self":  "A page cannot be merged into itself.",
+	"error.similar_pages_exist":          "Similar pages already exist. Please check that yours is different.",
+	"error.invalid_location":             "Location must be a latitude and longitude, such as 40.7128, -74.0060.",
+	"error.invalid_token":                "Invalid or revoked API token.",
+	"error.insufficient_scope":           "This API token does not allow that.",
+	"error.session_required":             "This page cannot be used with an API token.",
+	"error.invalid_scope":                "Invalid scope. Valid scopes are read and write.",
+	"error.token_name_too_long":          "Token name is too long.",
+	"error.token_name_required":          "Please name the token.",
+	"error.token_not_found":              "Token not found.",
+	"error.oauth_client_not_found":       "Unknown application.",
+	"error.invalid_redirect_uri":         "Redirect URI must be an absolute https URL, or http on localhost.",
+	"error.invalid_grant":                "Invalid, expired or already used authorization code.",
+	"error.invalid_code_verifier":        "Code verifier must be 43 to 128 characters long.",
+	"error.export_rate_limited":          "You can request one data export a day. Please try again tomorrow.",
+	"error.export_not_found":             "Export not found.",
+	"error.export_link_invalid":          "This download link is invalid or has expired.",
+	"error.federation_disabled":          "Federation is not enabled.",
+	"error.actor_not_found":              "Actor not found.",
+	"error.reply_not_found":              "Reply not found.",
+	"error.not_a_reply":                  "Not a reply to a page or post here.",
+	"error.reply_empty":                  "Reply is empty.",
+	"error.reply_too_long":               "Replies must be at most %d characters long.",
+	"error.invalid_webhook_url":          "Webhook URL must be an absolute http or https URL.",
+	"error.invalid_webhook_event":        "Please choose at least one valid event.",
+	"error.webhook_not_found":            "Webhook not found.",
+	"error.delivery_not_found":           "Delivery not found.",
+	"error.passkey_not_found":            "Passkey not found.",
+	"error.passkey_name_required":        "Please name the passkey.",
+	"error.passkey_name_too_long":        "Passkey name is too long.",
+	"error.passkey_already_added":        "That passkey has already been added.",
+	"error.passkey_verify_failed":        "The passkey could not be verified. Please try again.",
+	"error.passkey_cloned":               "This passkey may have been copied, so it cannot be used to log in. Please remove it and add it again.",
+	"error.email_failed":                 "Sending email failed.",
+	"error.email_in_use":                 "You have already registered with this email address.",
+	"error.username_in_use":              "This username is in use. Please select a different one.",
+	"error.invalid_username_or_password": "Invalid username or password.",
+	"error.database_error":               "Unknown database error.",
+	"error.invalid_session_id":           "Invalid sessionid.",
+	"error.invalid_email":                "The provided email address is not valid.",
+	"error.invalid_ip_address":           "There is something wrong with your IP address.",
+	"error.invalid_title":                "Invalid page title.",
+	"error.page_already_exists":          "A page with this category and title already exists.",
+	"error.page_not_found":               "Page not found.",
+	"error.post_not_found":               "Post not found.",
+	"error.invalid_link":                 "Invalid link. It may have expired or possibly you already used it.",
+	"error.account_suspended":            "This account has been suspended.",
+	"error.user_not_found":               "User not found.",
+	"error.category_not_found":           "Category not found.",
+	"error.category_already_exists":      "A category with this name already exists.",
+	"error.category_not_empty":           "This category still has pages. Move them to another category first.",
+	"error.community_not_found":          "Community not found.",
+	"error.community_already_exists":     "A community with this name already exists.",
+	"error.invalid_name":                 "Names must be more than 1 character long.",
+	"error.cannot_merge_into_self":       "A community cannot be merged into itself.",
+	"error.cannot_merge_into_child":      "A community cannot be merged into one of its subcommunities.",
+	"error.similar_communities_exist":    "Similar communities already exist. Please check that yours is different.",
+	"error.community_not_pending":        "This community has already been reviewed.",
+	"error.invalid_parent_community":     "A community cannot be placed inside itself or one of its subcommunities.",
+	"error.description_too_short":        "Description must be at least %d characters long.",
+	"error.invalid_visibility":           "Invalid visibility.",
+	"error.not_a_community_member":       "You are not a member of this community.",
+	"error.pseudonym_not_found":          "Pseudonym not found.",
+	"error.cannot_block_self":            "You cannot block yourself.",
+	"error.invalid_block_kind":           "Invalid block type.",
+	"error.blocked_by_page_author":       "The author of this page is not accepting posts from you.",
+	"error.blocked_by_mentioned_user":    "Your post mentions a user who is not accepting mentions from you.",
+	"error.bio_too_long":                 "Bio must be at most %d characters long.",
+	"error.report_reason_too_short":      "Please describe the problem in at least %d characters.",
+	"error.cannot_report_self":           "You cannot report yourself.",
+	"error.report_not_found":             "Report not found or already resolved.",
+	"error.notification_not_found":       "Notification not found.",
+	"error.invalid_digest_frequency":     "Invalid digest frequency.",
+	"error.unknown_provider":             "Unknown login provider.",
+	"error.identity_in_use":              "That account is already linked to another user.",
+	"error.provider_already_linked":      "You have already linked an account from that provider.",
+	"error.identity_not_found":           "Linked account not found.",
+	"error.email_not_verified":           "Your email address has not been verified by the provider, so it cannot be used to find your account. Log in with your password and link the account from your settings.",
+	"error.external_login_failed":        "Logging in with the provider failed. Please try again.",
+	"error.deletion_already_scheduled":   "This account is already scheduled for deletion.",
+	"error.deletion_not_scheduled":       "This account is not scheduled for deletion.",
+	"error.password_too_short":           "The supplied password is too short. Minimum password length is %d characters.",
+	"error.username_too_short":           "The supplied username is too short. Minimum username length is %d characters.",
+	"error.username_too_long":            "The supplied username is too long. Maximum username length is %d characters.",
+	"error.incorrect_password":           "Incorrect password.",
+	"error.short_password":               "Password too short.",
+	"error.invalid_locale":               "Unknown language.",
+}
@@ -0,0 +1,216 @@
+package i18n
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const DefaultLocale = "en"
+
+type Locale struct {
+	Code string
+	Name string // In its own language, for the language picker
+}
+
+// Locales in the order they are offered to users.
+var Locales = []Locale{
+	{Code: "en", Name: "English"},
+	{Code: "es", Name: "Español"},
+}
+
+// Message catalogs by locale, keyed by message key. Plural messages have one
+// entry per form, such as "key.one" and "key.other".
+var catalogs = map[string]map[string]string{
+	"en": english,
+	"es": spanish,
+}
+
+// Picks the plural form of a count. Locales without a rule use English's.
+var pluralRules = map[string]func(n int) string{
+	"en": oneOther,
+	"es": oneOther,
+}
+
+func oneOther(n int) string {
+	if n == 1 {
+		return "one"
+	}
+	return "other"
+}
+
+func Supported(locale string) bool {
+	_, ok := catalogs[locale]
+	return ok
+}
+
+// Looks up key in the locale's catalog, falling back to English and then to
+// the key itself, and formats it with args like fmt.Sprintf.
+func Translate(locale, key string, args ...interface{}) string {
+	message, ok := catalogs[locale][key]
+	if !ok {
+		message, ok = catalogs[DefaultLocale][key]
+	}
+	if !ok {
+		return key
+	}
+
+	if len(args) == 0 {
+		return message
+	}
+	return fmt.Sprintf(message, args...)
+}
+
+// Translates the form of key for the count n. n is the first format
+// argument, followed by args.
+func Plural(locale, key string, n int, args ...interface{}) string {
+	rule, ok := pluralRules[locale]
+	if !ok {
+		rule = oneOther
+	}
+
+	form := key + "." + rule(n)
+	if _, ok := catalogs[locale][form]; !ok {
+		if _, ok := catalogs[DefaultLocale][form]; !ok {
+			form = key + ".other"
+		}
+	}
+	return Translate(locale, form, append([]interface{}{n}, args...)...)
+}
+
+// Chooses the locale for a request. A supported preferred locale wins,
+// then the languages in an Accept-Language header by quality, matching
+// "es-MX" to "es" if there is no closer match.
+func Negotiate(acceptLanguage, preferred string) string {
+	if Supported(preferred) {
+		return preferred
+	}
+
+	type language struct {
+		tag     string
+		quality float64
+	}
+	var languages []language
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(part, ";")
+		tag := strings.ToLower(strings.TrimSpace(fields[0]))
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		quality := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				q, err := strconv.ParseFloat(param[len("q="):], 64)
+				if err == nil {
+					quality = q
+				}
+			}
+		}
+		if quality > 0 {
+			languages = append(languages, language{tag, quality})
+		}
+	}
+	sort.SliceStable(languages, func(i, j int) bool {
+		return languages[i].quality > languages[j].quality
+	})
+
+	for _, language := range languages {
+		if Supported(language.tag) {
+			return language.tag
+		}
+		if i := strings.Index(language.tag, "-"); i > 0 && Supported(language.tag[:i]) {
+			return language.tag[:i]
+		}
+	}
+	return DefaultLocale
+}
+
+// The locale for a visitor who is not logged in.
+func RequestLocale(req *http.Request) string {
+	return Negotiate(req.Header.Get("Accept-Language"), "")
+}
+
+// A message key and its arguments, for text that is made before the
+// reader's locale is known. It prints in English.
+type Message struct {
+	Key  string
+	Args []interface{}
+}
+
+func NewMessage(key string, args ...interface{}) Message {
+	return Message{Key: key, Args: args}
+}
+
+func (m Message) In(locale string) string {
+	return Translate(locale, m.Key, m.Args...)
+}
+
+func (m Message) String() string {
+	return m.In(DefaultLocale)
+}
+
+// An error whose message is looked up in the reader's language. Error()
+// gives the English message, for logs and for callers that do not know the
+// reader's locale.
+type Error struct {
+	Message
+}
+
+func NewError(key string, args ...interface{}) *Error {
+	return &Error{NewMessage(key, args...)}
+}
+
+func (e *Error) Error() string {
+	return e.String()
+}
+
+// The message of err in the given locale. Errors without a message key are
+// shown as they are.
+func ErrorMessage(locale string, err error) string {
+	if keyed, ok := err.(*Error); ok {
+		return keyed.In(locale)
+	}
+	return err.Error()
+}
+
+// Template functions. Templates pass the locale along explicitly, as it is
+// missing (and so English) on pages that have not set one:
+//
+//	{{t $.locale "nav.home"}}
+//	{{plural $.locale "settings.open_sessions" .openSessions}}
+//	{{msg $.locale .reason}}
+//	{{error $.locale .err}}
+var Funcs = template.FuncMap{
+	"t": func(locale interface{}, key string, args ...interface{}) string {
+		return Translate(localeString(locale), key, args...)
+	},
+	"plural": func(locale interface{}, key string, n int, args ...interface{}) string {
+		return Plural(localeString(locale), key, n, args...)
+	},
+	"msg": func(locale interface{}, message Message) string {
+		return message.In(localeString(locale))
+	},
+	"error": func(locale interface{}, err error) string {
+		return ErrorMessage(localeString(locale), err)
+	},
+	"lang": func(locale interface{}) string {
+		return localeString(locale)
+	},
+	// Bundles the locale with a value for templates that are not passed the
+	// page's data, such as recursive ones.
+	"withLocale": func(locale, value interface{}) map[string]interface{} {
+		return map[string]interface{}{"locale": locale, "value": value}
+	},
+}
+
+func localeString(locale interface{}) string {
+	if code, ok := locale.(string); ok && Supported(code) {
+		return code
+	}
+	return DefaultLocale
+}
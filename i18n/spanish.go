@@ -0,0 +1,645 @@
+package i18n
+
+var spanish = map[string]string{
+	// Shared
+	"common.requires_javascript": "¡Este sitio necesita JavaScript para funcionar!",
+
+	// Navigation and search
+	"nav.menu":           "Menú",
+	"nav.home":           "Inicio",
+	"nav.add_resource":   "Añadir recurso",
+	"nav.notifications":  "Notificaciones",
+	"nav.settings":       "Configuración",
+	"nav.log_out":        "Cerrar sesión",
+	"search.placeholder": "Buscar páginas",
+	"search.button":      "Buscar",
+	"tour.title":         "Recorrido",
+
+	// Communities
+	"communities.yours":               "Tus comunidades",
+	"communities.check_all":           "Marca todas las que correspondan. ¿No ves la tuya?",
+	"communities.propose":             "Propón una comunidad nueva",
+	"communities.search_placeholder":  "Busca una comunidad",
+	"communities.suggestions":         "Las personas de tus comunidades también están en:",
+	"communities.visibility_title":    "Quién puede ver que eres miembro",
+	"communities.visibility_public":   "Visible para todos",
+	"communities.visibility_members":  "Visible para los miembros",
+	"communities.visibility_private":  "Solo yo",
+	"communities.more_specific.one":   "%d más específica",
+	"communities.more_specific.other": "%d más específicas",
+
+	// Login
+	"login.title":                "Iniciar sesión",
+	"login.welcome":              "¡Te damos la bienvenida a %s!",
+	"login.sign_up":              "Registrarse",
+	"login.log_in":               "Iniciar sesión",
+	"login.email":                "Correo electrónico",
+	"login.password":             "Contraseña",
+	"login.reset_password":       "Restablecer contraseña",
+	"login.passkey":              "Iniciar sesión con una llave de acceso",
+	"login.with_provider":        "Iniciar sesión con %s",
+	"login.about_title":          "¿Qué es %s?",
+	"login.about":                "es una red social y un directorio de servicios valorado por la comunidad y centrado en la identidad. Puedes encontrar comunidades y servicios que te acepten a partir de una gran variedad de palabras clave, y también crear tus propias comunidades clasificadas por esas palabras clave. %s hace más fácil encontrar comunidades y servicios que te aceptan tal como eres.",
+	"login.registration_success": "Te has registrado. Revisa tu correo electrónico.",
+	"login.reset_success":        "Se ha restablecido la contraseña. Revisa tu correo electrónico.",
+
+	// Pages
+	"page.edit":                   "Editar página",
+	"page.feed":                   "Canal de publicaciones nuevas",
+	"page.find_duplicates":        "Buscar duplicados",
+	"page.follow":                 "Seguir",
+	"page.following":              "Siguiendo",
+	"page.address":                "Dirección:",
+	"page.website":                "Sitio web:",
+	"page.post_your_thoughts":     "Comparte tu opinión",
+	"page.comment_label":          "Comentario:",
+	"page.post_as":                "Publicar como",
+	"page.anonymous":              "Anónimo",
+	"page.comment":                "Comentar",
+	"page.anonymous_member.one":   "Miembro anónimo de %d de tus comunidades",
+	"page.anonymous_member.other": "Miembro anónimo de %d de tus comunidades",
+	"page.remove_post":            "Eliminar publicación",
+	"page.muted_post":             "Publicación de un usuario silenciado",
+	"page.post_too_short":         "La publicación debe tener al menos %d caracteres.",
+	"page.post_added":             "Publicación añadida.",
+	"page_form.title":             "Título de la página del recurso",
+	"page_form.description":       "Descripción imparcial del recurso",
+	"page_form.address":           "Dirección física del recurso (si corresponde)",
+	"page_form.website":           "Sitio web del recurso (si corresponde)",
+	"page_form.location":          "Latitud, longitud (si corresponde)",
+	"page_form.title_too_short":   "El título debe tener más de 1 carácter.",
+	"page_form.invalid_category":  "Categoría no válida.",
+	"new_page.legend":             "Crear una página de recurso nueva",
+	"new_page.similar":            "Páginas parecidas",
+	"new_page.in_category":        "en %s",
+	"new_page.confirm_different":  "Mi página trata de otro lugar.",
+	"new_page.submit":             "Enviar",
+	"edit_page.title":             "Editar página",
+	"edit_page.legend":            "Editar página de recurso",
+	"edit_page.cancel":            "Cancelar",
+	"edit_page.save":              "Guardar",
+	"similar.title":               "título parecido",
+	"similar.address":             "misma dirección",
+	"similar.website":             "mismo sitio web",
+	"similar.distance":            "a %.0f metros",
+
+	// Settings
+	"settings.title":                    "Configuración",
+	"settings.user_information":         "Información del usuario",
+	"settings.email":                    "Correo electrónico:",
+	"settings.username":                 "Nombre de usuario:",
+	"settings.bio":                      "Biografía",
+	"settings.update_profile":           "Actualizar perfil",
+	"settings.profile_updated":          "Perfil actualizado.",
+	"settings.password_change":          "Cambio de contraseña",
+	"settings.old_password":             "Contraseña actual",
+	"settings.new_password":             "Contraseña nueva",
+	"settings.new_password_again":       "Contraseña nueva (otra vez)",
+	"settings.update_password":          "Actualizar contraseña",
+	"settings.password_required":        "Para cambiar la contraseña hacen falta la contraseña actual y la nueva",
+	"settings.passwords_differ":         "Las contraseñas no coinciden.",
+	"settings.password_changed":         "Contraseña cambiada.",
+	"settings.password_change_required": "Tienes que cambiar la contraseña.",
+	"settings.sessions":                 "Sesiones",
+	"settings.open_sessions.one":        "Tienes %d sesión abierta además de esta.",
+	"settings.open_sessions.other":      "Tienes %d sesiones abiertas además de esta.",
+	"settings.logout_sessions":          "Cerrar las demás sesiones",
+	"settings.username_change":          "Cambio de nombre de usuario",
+	"settings.password":                 "Contraseña",
+	"settings.new_username":             "Nombre de usuario nuevo",
+	"settings.update_username":          "Actualizar nombre de usuario",
+	"settings.username_changed":         "Nombre de usuario cambiado.",
+	"settings.digest":                   "Resumen por correo",
+	"settings.digest_help":              "Un resumen de las páginas y publicaciones nuevas en tus comunidades y en las páginas que sigues.",
+	"settings.digest_daily":             "Diario",
+	"settings.digest_weekly":            "Semanal",
+	"settings.digest_never":             "Nunca",
+	"settings.update_digest":            "Actualizar preferencias de correo",
+	"settings.digest_updated":           "Preferencias de correo actualizadas.",
+	"settings.language":                 "Idioma",
+	"settings.language_automatic":       "Automático (según tu navegador)",
+	"settings.update_language":          "Actualizar idioma",
+	"settings.language_updated":         "Idioma actualizado.",
+	"settings.pseudonyms":               "Seudónimos",
+	"settings.pseudonyms_help":          "Publica con otro nombre. Los demás usuarios no pueden ver qué seudónimos son tuyos.",
+	"settings.no_pseudonyms":            "No tienes seudónimos.",
+	"settings.new_pseudonym":            "Seudónimo nuevo",
+	"settings.add_pseudonym":            "Añadir seudónimo",
+	"settings.pseudonym_added":          "Seudónimo añadido.",
+	"settings.blocks":                   "Usuarios bloqueados y silenciados",
+	"settings.blocks_help":              "Las páginas y publicaciones de los usuarios bloqueados se te ocultan, y no pueden publicar en tus páginas ni mencionarte. Las publicaciones de los usuarios silenciados aparecen contraídas.",
+	"settings.username_column":          "Nombre de usuario",
+	"settings.block_type":               "Tipo",
+	"settings.since":                    "Desde",
+	"settings.muted":                    "Silenciado",
+	"settings.blocked":                  "Bloqueado",
+	"settings.unmute":                   "Dejar de silenciar",
+	"settings.unblock":                  "Desbloquear",
+	"settings.no_blocks":                "No has bloqueado ni silenciado a nadie.",
+	"settings.block":                    "Bloquear",
+	"settings.mute":                     "Silenciar",
+	"settings.add_block":                "Añadir",
+	"settings.user_blocked":             "Usuario bloqueado.",
+	"settings.user_muted":               "Usuario silenciado.",
+	"settings.user_unblocked":           "Usuario desbloqueado.",
+	"settings.passkeys":                 "Llaves de acceso",
+	"settings.passkeys_help":            "Inicia sesión con tu huella, tu cara, el bloqueo de pantalla o una llave de seguridad en lugar de tu contraseña.",
+	"settings.name":                     "Nombre",
+	"settings.added":                    "Añadida",
+	"settings.last_used":                "Último uso",
+	"settings.never_used":               "Nunca",
+	"settings.rename":                   "Cambiar nombre",
+	"settings.remove":                   "Quitar",
+	"settings.no_passkeys":              "No tienes llaves de acceso.",
+	"settings.passkey_name":             "Nombre de la llave de acceso",
+	"settings.passkey_name_example":     "p. ej. Mi teléfono",
+	"settings.add_passkey":              "Añadir llave de acceso",
+	"settings.passkey_removed":          "Llave de acceso quitada.",
+	"settings.passkey_renamed":          "Llave de acceso renombrada.",
+	"settings.identities":               "Cuentas vinculadas",
+	"settings.identities_help":          "Inicia sesión con una cuenta de otro sitio. Las cuentas también se vinculan automáticamente cuando inicias sesión con una que tiene la misma dirección de correo verificada.",
+	"settings.provider":                 "Proveedor",
+	"settings.email_column":             "Correo electrónico",
+	"settings.linked":                   "Vinculada",
+	"settings.unlink":                   "Desvincular",
+	"settings.no_identities":            "No has vinculado ninguna cuenta.",
+	"settings.link_provider":            "Vincular %s",
+	"settings.identity_removed":         "Cuenta desvinculada.",
+	"settings.tokens":                   "Tokens de API y aplicaciones",
+	"settings.tokens_help":              "Los tokens de acceso personal permiten que tus propios scripts usen la API en tu nombre. Aquí también aparecen las aplicaciones que autorizas.",
+	"settings.api_reference":            "Referencia de la API",
+	"settings.access":                   "Acceso",
+	"settings.created":                  "Creado",
+	"settings.application":              "aplicación",
+	"settings.revoke":                   "Revocar",
+	"settings.no_tokens":                "No tienes tokens.",
+	"settings.token_name":               "Nombre del token",
+	"settings.scope_read":               "Lectura",
+	"settings.scope_write":              "Escritura",
+	"settings.create_token":             "Crear token",
+	"settings.token_created":            "Token creado. Cópialo ahora, no se volverá a mostrar.",
+	"settings.token_revoked":            "Acceso revocado.",
+	"settings.export":                   "Descargar mis datos",
+	"settings.export_help":              "Obtén una copia de tu perfil, comunidades, páginas, publicaciones, sesiones y actividad de seguridad. Te enviaremos por correo un enlace de descarga que funciona durante una semana.",
+	"settings.requested":                "Solicitada",
+	"settings.status":                   "Estado",
+	"settings.size":                     "Tamaño",
+	"settings.bytes.one":                "%d byte",
+	"settings.bytes.other":              "%d bytes",
+	"settings.download":                 "Descargar",
+	"settings.request_export":           "Solicitar exportación de datos",
+	"settings.export_requested":         "Te enviaremos un enlace por correo cuando tus datos estén listos.",
+	"settings.security":                 "Actividad de seguridad reciente",
+	"settings.date":                     "Fecha",
+	"settings.event":                    "Evento",
+	"settings.ip_address":               "Dirección IP",
+	"settings.browser":                  "Navegador",
+	"settings.no_security_events":       "No hay actividad reciente.",
+	"settings.delete_account":           "Eliminar cuenta",
+	"settings.deletion_pending":         "Tu cuenta se eliminará el %s. Hasta entonces puedes cambiar de opinión.",
+	"settings.cancel_deletion":          "Cancelar eliminación",
+	"settings.delete_help":              "Tu dirección de correo, sesiones, tokens y membresías en comunidades se eliminarán dos semanas después de que lo pidas. Las páginas y publicaciones que escribiste se mantendrán, como escritas por un usuario eliminado. Puede que quieras descargar tus datos antes.",
+	"settings.delete_my_account":        "Eliminar mi cuenta",
+	"settings.deletion_scheduled":       "Tu cuenta se eliminará el %s.",
+	"settings.deletion_cancelled":       "Tu cuenta no se eliminará.",
+	"settings.administration":           "Administración",
+	"settings.admin_dashboard":          "Panel de administración",
+	"settings.reports":                  "Denuncias",
+
+	// Data export statuses
+	"export.pending":  "Pendiente",
+	"export.building": "Preparándose",
+	"export.ready":    "Lista",
+	"export.failed":   "Falló",
+	"export.expired":  "Caducada",
+
+	// Security events shown in settings
+	"audit.login":                      "Inicio de sesión",
+	"audit.login.failed":               "Inicio de sesión fallido",
+	"audit.registered":                 "Registro",
+	"audit.password.changed":           "Contraseña cambiada",
+	"audit.password.change_failed":     "Cambio de contraseña fallido",
+	"audit.password.reset_requested":   "Restablecimiento de contraseña solicitado",
+	"audit.password.reset":             "Contraseña restablecida",
+	"audit.username.changed":           "Nombre de usuario cambiado",
+	"audit.sessions.revoked":           "Otras sesiones cerradas",
+	"audit.user.suspended":             "Cuenta suspendida",
+	"audit.user.unsuspended":           "Suspensión de la cuenta retirada",
+	"audit.password.reset_forced":      "Contraseña restablecida por un moderador",
+	"audit.role.granted":               "Rol cambiado",
+	"audit.token.created":              "Token de API creado",
+	"audit.token.revoked":              "Token de API revocado",
+	"audit.oauth.authorized":           "Aplicación autorizada",
+	"audit.identity.linked":            "Cuenta vinculada",
+	"audit.identity.unlinked":          "Cuenta desvinculada",
+	"audit.passkey.added":              "Llave de acceso añadida",
+	"audit.passkey.removed":            "Llave de acceso quitada",
+	"audit.export.requested":           "Exportación de datos solicitada",
+	"audit.export.downloaded":          "Exportación de datos descargada",
+	"audit.account.deletion_requested": "Eliminación de la cuenta solicitada",
+	"audit.account.deletion_cancelled": "Eliminación de la cuenta cancelada",
+
+	// Home
+	"home.search":           "Buscar",
+	"home.lost":             "¿Perdido?",
+	"home.take_tour":        "Haz el recorrido otra vez",
+	"home.from_communities": "De tus comunidades",
+	"home.trending":         "Tendencias de esta semana:",
+	"home.nothing_yet":      "Todavía no hay nada.",
+	"home.get_started":      "Agrega un recurso para empezar",
+	"feed.anonymous_member": "Un miembro anónimo",
+	"feed.added":            "agregó",
+	"feed.posted_on":        "publicó en",
+
+	// Profiles
+	"profile.report_thanks":    "Gracias. Los moderadores revisarán tu denuncia.",
+	"profile.blocked":          "Bloqueaste a este usuario.",
+	"profile.manage_blocks":    "Administrar usuarios bloqueados",
+	"profile.communities":      "Comunidades:",
+	"profile.contributions":    "Contribuciones",
+	"profile.added":            "Agregó",
+	"profile.posted_on":        "Publicó en",
+	"profile.no_contributions": "Todavía no hay contribuciones.",
+	"profile.newer":            "Más recientes",
+	"profile.older":            "Más antiguas",
+	"profile.report":           "Denunciar a %s",
+	"profile.report_reason":    "¿Cuál es el problema?",
+	"profile.report_submit":    "Denunciar a los moderadores",
+
+	// Notifications
+	"notifications.title":         "Notificaciones",
+	"notifications.mark_all_read": "Marcar todas como leídas",
+	"notifications.mark_read":     "Marcar como leída",
+	"notifications.none":          "Todavía no hay notificaciones. Se te avisará de nuevas publicaciones en las páginas que crees, en las que publiques o que sigas.",
+
+	// Community pages
+	"community.feed":                 "Feed de las contribuciones de miembros públicos",
+	"community.part_of":              "Parte de",
+	"community.members.one":          "%d miembro",
+	"community.members.other":        "%d miembros",
+	"community.subcommunities":       "Subcomunidades:",
+	"community.recent_contributions": "Contribuciones recientes",
+	"community.no_contributions":     "Todavía no hay contribuciones.",
+	"propose.title":                  "Proponer una comunidad",
+	"propose.legend":                 "Proponer una nueva comunidad",
+	"propose.success":                "¡Gracias! Tu comunidad será visible cuando un moderador la apruebe.",
+	"propose.similar":                "Comunidades parecidas",
+	"propose.awaiting_approval":      "(pendiente de aprobación)",
+	"propose.confirm_different":      "Mi comunidad es distinta de estas.",
+	"propose.name":                   "Nombre de la comunidad",
+	"propose.description":            "¿Para quién es esta comunidad?",
+	"propose.parent":                 "Parte de (opcional)",
+	"propose.no_parent":              "Ninguna",
+	"propose.submit":                 "Enviar para aprobación",
+	"pending.title":                  "Comunidades pendientes",
+	"pending.approved":               "Comunidad aprobada.",
+	"pending.rejected":               "Comunidad rechazada.",
+	"pending.name":                   "Nombre",
+	"pending.description":            "Descripción",
+	"pending.part_of":                "Parte de",
+	"pending.proposed_by":            "Propuesta por",
+	"pending.approve":                "Aprobar",
+	"pending.reject":                 "Rechazar",
+	"pending.none":                   "Nada que revisar.",
+
+	// Admin
+	"admin.nav":                         "Administración:",
+	"admin.nav.statistics":              "Estadísticas",
+	"admin.nav.users":                   "Usuarios",
+	"admin.nav.categories":              "Categorías",
+	"admin.nav.import":                  "Importar",
+	"admin.nav.communities":             "Comunidades",
+	"admin.nav.reports":                 "Denuncias",
+	"admin.nav.federation":              "Federación",
+	"admin.nav.audit":                   "Registro de auditoría",
+	"admin.nav.webhooks":                "Webhooks",
+	"admin.add":                         "Añadir",
+	"admin.rename":                      "Renombrar",
+	"admin.save":                        "Guardar",
+	"admin.delete":                      "Eliminar",
+	"admin.merge":                       "Fusionar",
+	"admin.approve":                     "Aprobar",
+	"admin.reject":                      "Rechazar",
+	"admin.none":                        "Ninguna",
+	"admin.name":                        "Nombre",
+	"admin.date":                        "Fecha",
+	"admin.user":                        "Usuario",
+	"admin.pages":                       "Páginas",
+	"admin.category":                    "Categoría",
+	"admin.every_category":              "Todas las categorías",
+	"admin.statistics.title":            "Estadísticas del sitio",
+	"admin.statistics.admin":            "Administración",
+	"admin.statistics.users":            "Usuarios",
+	"admin.statistics.suspended_users":  "Usuarios suspendidos",
+	"admin.statistics.sessions":         "Sesiones abiertas",
+	"admin.statistics.pages":            "Páginas",
+	"admin.statistics.posts":            "Publicaciones",
+	"admin.statistics.this_week":        "%d esta semana",
+	"admin.statistics.categories":       "Categorías",
+	"admin.statistics.communities":      "Comunidades",
+	"admin.statistics.memberships":      "Membresías de comunidades",
+	"admin.categories.title":            "Categorías",
+	"admin.categories.created":          "Categoría creada.",
+	"admin.categories.renamed":          "Categoría renombrada.",
+	"admin.categories.deleted":          "Categoría eliminada.",
+	"admin.categories.new":              "Nombre de la nueva categoría",
+	"admin.categories.slug":             "Slug",
+	"admin.communities.title":           "Comunidades",
+	"admin.communities.created":         "Comunidad creada.",
+	"admin.communities.renamed":         "Comunidad renombrada.",
+	"admin.communities.deleted":         "Comunidad eliminada.",
+	"admin.communities.moved":           "Comunidad movida.",
+	"admin.communities.aliases_saved":   "Alias guardados.",
+	"admin.communities.merged.one":      "Comunidades fusionadas. Se movió %d membresía.",
+	"admin.communities.merged.other":    "Comunidades fusionadas. Se movieron %d membresías.",
+	"admin.communities.new":             "Nombre de la nueva comunidad",
+	"admin.communities.merge_legend":    "Fusionar comunidades duplicadas",
+	"admin.communities.into":            "En",
+	"admin.communities.aliases":         "Alias",
+	"admin.communities.part_of":         "Parte de",
+	"admin.communities.members":         "Miembros",
+	"admin.communities.comma_separated": "Separados por comas",
+	"admin.communities.move":            "Mover",
+	"admin.users.title":                 "Usuarios",
+	"admin.users.suspended":             "Usuario suspendido.",
+	"admin.users.unsuspended":           "Suspensión del usuario levantada.",
+	"admin.users.reset_forced":          "Se pedirá al usuario que cambie su contraseña.",
+	"admin.users.deletion_scheduled":    "El usuario será eliminado en dos semanas a menos que lo cancele.",
+	"admin.users.deletion_cancelled":    "El usuario no será eliminado.",
+	"admin.users.deleted":               "Usuario eliminado.",
+	"admin.users.search":                "Nombre de usuario o correo electrónico",
+	"admin.users.search_button":         "Buscar",
+	"admin.users.username":              "Nombre de usuario",
+	"admin.users.email":                 "Correo electrónico",
+	"admin.users.role":                  "Rol",
+	"admin.users.status":                "Estado",
+	"admin.users.status_suspended":      "Suspendido",
+	"admin.users.status_active":         "Activo",
+	"admin.users.status_reset_required": ", debe cambiar su contraseña",
+	"admin.users.status_deleted_on":     ", se eliminará el %s",
+	"admin.users.unsuspend":             "Levantar suspensión",
+	"admin.users.suspend":               "Suspender",
+	"admin.users.force_reset":           "Forzar cambio de contraseña",
+	"admin.users.cancel_deletion":       "Cancelar eliminación",
+	"admin.users.delete_now":            "Eliminar ahora",
+	"admin.users.none":                  "No se encontraron usuarios.",
+	"admin.audit.title":                 "Registro de auditoría",
+	"admin.audit.action_placeholder":    "Acción (p. ej. login.failed)",
+	"admin.audit.user_placeholder":      "Nombre de usuario",
+	"admin.audit.ip_placeholder":        "Dirección IP",
+	"admin.audit.filter":                "Filtrar",
+	"admin.audit.action":                "Acción",
+	"admin.audit.actor":                 "Autor",
+	"admin.audit.ip":                    "IP",
+	"admin.audit.details":               "Detalles",
+	"admin.audit.none":                  "No se encontraron eventos.",
+	"admin.audit.older":                 "Eventos anteriores",
+	"admin.reports.title":               "Denuncias",
+	"admin.reports.resolved":            "Denuncia resuelta.",
+	"admin.reports.reported_by":         "Denunciado por",
+	"admin.reports.reason":              "Motivo",
+	"admin.reports.manage":              "gestionar",
+	"admin.reports.resolve":             "Resolver",
+	"admin.reports.none":                "No hay denuncias abiertas.",
+	"admin.webhooks.title":              "Webhooks",
+	"admin.webhooks.added":              "Webhook añadido.",
+	"admin.webhooks.updated":            "Webhook actualizado.",
+	"admin.webhooks.deleted":            "Webhook eliminado.",
+	"admin.webhooks.secret":             "Secreto de firma del nuevo webhook. No se volverá a mostrar:",
+	"admin.webhooks.legend":             "Añadir webhook",
+	"admin.webhooks.url":                "URL",
+	"admin.webhooks.events":             "Eventos",
+	"admin.webhooks.category":           "Páginas y publicaciones en",
+	"admin.webhooks.added_on":           "Añadido",
+	"admin.webhooks.disabled":           "(desactivado)",
+	"admin.webhooks.disable":            "Desactivar",
+	"admin.webhooks.enable":             "Activar",
+	"admin.webhooks.none":               "No hay webhooks.",
+	"admin.webhook.title":               "Webhook",
+	"admin.webhook.heading":             "Entregas del webhook",
+	"admin.webhook.replayed":            "Entrega puesta en cola de nuevo.",
+	"admin.webhook.event":               "Evento",
+	"admin.webhook.status":              "Estado",
+	"admin.webhook.attempts":            "Intentos",
+	"admin.webhook.last_attempt":        "Último intento",
+	"admin.webhook.response":            "Respuesta",
+	"admin.webhook.payload":             "Contenido",
+	"admin.webhook.next":                "(siguiente %s)",
+	"admin.webhook.replay":              "Reenviar",
+	"admin.webhook.none":                "Todavía no hay entregas.",
+	"delivery.pending":                  "Pendiente",
+	"delivery.succeeded":                "Entregada",
+	"delivery.failed":                   "Fallida",
+	"admin.federation.title":            "Federación",
+	"admin.federation.approved":         "Respuesta publicada.",
+	"admin.federation.rejected":         "Respuesta rechazada.",
+	"admin.federation.off":              "La federación está desactivada. Define FEDERATION_DOMAIN para activarla.",
+	"admin.federation.replies":          "Respuestas pendientes de moderación",
+	"admin.federation.from":             "De",
+	"admin.federation.on":               "En",
+	"admin.federation.reply":            "Respuesta",
+	"admin.federation.no_replies":       "No hay respuestas pendientes.",
+	"admin.federation.actors":           "Actores seguidos",
+	"admin.federation.actor":            "Actor",
+	"admin.federation.address":          "Dirección",
+	"admin.federation.followers":        "Seguidores",
+	"admin.federation.no_actors":        "Todavía nadie sigue ninguna categoría ni comunidad.",
+	"admin.import.title":                "Importar páginas",
+	"admin.import.finished":             "Importación terminada.",
+	"admin.import.help":                 "Importa páginas de recursos desde un archivo CSV con una fila de encabezado o un arreglo JSON de objetos. Las páginas con el mismo título, dirección y sitio web que una ya existente se omiten, así que el mismo archivo se puede importar de nuevo. La ubicación se escribe como latitud, longitud.",
+	"admin.import.file":                 "Archivo",
+	"admin.import.using":                "Usando %s. Elige otro archivo para reemplazarlo.",
+	"admin.import.previewed_file":       "el archivo previsualizado",
+	"admin.import.format":               "Formato",
+	"admin.import.detect":               "Detectar",
+	"admin.import.columns":              "Columnas",
+	"admin.import.columns_help":         "Deja un campo en blanco para leerlo de la columna con el mismo nombre.",
+	"admin.import.default_category":     "Categoría de las filas que no tienen",
+	"admin.import.preview":              "Vista previa",
+	"admin.import.submit.one":           "Importar %d página",
+	"admin.import.submit.other":         "Importar %d páginas",
+	"admin.import.preview_summary":      "Vista previa: %d por crear",
+	"admin.import.created_summary":      "%d creadas",
+	"admin.import.summary":              ", %d duplicadas, %d no válidas",
+	"admin.import.row":                  "Fila",
+	"admin.import.page_title":           "Título",
+	"admin.import.result":               "Resultado",
+	"import.created":                    "Creada",
+	"import.valid":                      "Válida",
+	"import.duplicate":                  "Duplicada",
+	"import.invalid":                    "No válida",
+	"admin.duplicates.title":            "Páginas duplicadas",
+	"admin.duplicates.merged":           "Página fusionada con esta.",
+	"admin.duplicates.intro":            "Páginas que pueden ser el mismo lugar que",
+	"admin.duplicates.in_category":      "en %s",
+	"admin.duplicates.help":             "Al fusionar, todas las publicaciones y seguidores pasan a la página que se conserva, y los enlaces a la página fusionada redirigen a ella.",
+	"admin.duplicates.page":             "Página",
+	"admin.duplicates.address":          "Dirección",
+	"admin.duplicates.website":          "Sitio web",
+	"admin.duplicates.why":              "Motivo",
+	"admin.duplicates.merge_from":       "Fusionar con esta página",
+	"admin.duplicates.merge_into":       "Fusionar esta página con ella",
+	"admin.duplicates.none":             "No se encontraron posibles duplicados.",
+	"admin.duplicates.link":             "Enlace a otra página con la que fusionar esta",
+
+	// Email links
+	"links.reset_title":          "Restablecer contraseña",
+	"links.register_title":       "Registro",
+	"links.unsubscribe_title":    "Cancelar suscripción",
+	"links.required_field":       "Falta un campo obligatorio.",
+	"links.required_fields":      "Faltan campos obligatorios.",
+	"links.passwords_differ":     "Las contraseñas no coinciden.",
+	"links.unsubscribed":         "Ya no recibirás correos de resumen. Puedes volver a activarlos en tu configuración.",
+	"links.finish_registering":   "Terminar el registro",
+	"links.email":                "Correo electrónico",
+	"links.username":             "Nombre de usuario (entre 3 y 20 caracteres)",
+	"links.new_password_min":     "Nueva contraseña (al menos 6 caracteres)",
+	"links.new_password":         "Nueva contraseña",
+	"links.new_password_again":   "Nueva contraseña (otra vez)",
+	"links.continue":             "Continuar",
+	"links.update_password":      "Actualizar contraseña",
+	"links.stop_digests":         "Dejar de recibir resúmenes",
+	"links.stop_digests_confirm": "¿Dejar de enviar resúmenes de actividad a %s?",
+	"links.unsubscribe":          "Cancelar suscripción",
+
+	// API
+	"api.validation_failed":        "Algunos campos no son válidos.",
+	"api.not_logged_in":            "No has iniciado sesión.",
+	"api.password_change_required": "Debes cambiar tu contraseña.",
+	"api.not_found":                "No encontrado.",
+	"api.unsupported_media_type":   "El cuerpo de la solicitud debe ser JSON.",
+	"api.invalid_json":             "El cuerpo de la solicitud no es JSON válido.",
+	"api.limit":                    "Debe ser un número del 1 al %d.",
+	"api.offset":                   "Debe ser un número no menor que 0.",
+	"api.title_too_short":          "El título debe tener más de 1 carácter.",
+	"api.invalid_category":         "Categoría no válida.",
+	"api.post_too_short":           "La publicación debe tener al menos %d caracteres.",
+	"api.anonymous_pseudonym":      "Una publicación no puede ser anónima y tener un seudónimo a la vez.",
+	"api.invalid_visibility":       "Debe ser public, members o private.",
+	"api.query_required":           "Se necesita un término de búsqueda.",
+
+	// Scripts
+	"passkey.failed":           "No se pudo usar la llave de acceso. Inténtalo de nuevo.",
+	"passkey.unsupported":      "Este navegador no admite llaves de acceso.",
+	"passkey.not_added":        "No se añadió ninguna llave de acceso.",
+	"passkey.cancelled":        "Se canceló el inicio de sesión con la llave de acceso.",
+	"page.remove_post_confirm": "¿Eliminar esta publicación?",
+	"communities.none_found":   "No se encontraron comunidades.",
+	"communities.in_parent":    "en",
+
+	// Applications and external logins
+	"oauth.title":                "Autorizar aplicación",
+	"oauth.heading":              "Autorizar %s",
+	"oauth.would_like":           "quiere:",
+	"oauth.scope_read":           "Ver páginas, publicaciones y comunidades en tu nombre",
+	"oauth.scope_write":          "Crear páginas y publicaciones y unirse a comunidades o dejarlas en tu nombre",
+	"oauth.logged_in_as":         "Has iniciado sesión como %s. Si lo permites, se te enviará a %s. Puedes revocar el acceso en cualquier momento en tu",
+	"oauth.settings":             "configuración",
+	"oauth.allow":                "Permitir",
+	"oauth.deny":                 "Denegar",
+	"oauth.redirect_mismatch":    "La URI de redirección no coincide con la registrada para %s.",
+	"oidc.back":                  "Volver a iniciar sesión",
+	"search.title":               "Buscar",
+	"search.results_for":         "Resultados para",
+	"search.no_matches":          "No se encontraron resultados para \"%s\"",
+	"search.add_resource_prompt": "¿Quieres",
+	"search.add_resource":        "añadir un nuevo recurso",
+
+	// Errors
+	"error.permission_denied":            "No tienes permiso para hacer eso.",
+	"error.invalid_role":                 "Rol no válido. Los roles válidos son user, trusted, moderator y admin.",
+	"error.import_format_unknown":        "Las importaciones deben ser CSV o JSON.",
+	"error.import_empty":                 "No hay páginas que importar.",
+	"error.import_too_large":             "Las importaciones pueden tener como máximo %d páginas. Divide el archivo.",
+	"error.import_no_title_column":       "Ninguna columna corresponde al título de la página.",
+	"error.import_category_unknown":      "Categoría desconocida.",
+	"error.import_category_missing":      "No se indicó ninguna categoría y no se eligió una categoría predeterminada.",
+	"error.import_duplicate_in_file":     "Esta página aparece antes en el archivo.",
+	"error.cannot_merge_page_into_self":  "Una página no se puede fusionar consigo misma.",
+	"error.similar_pages_exist":          "Ya existen páginas parecidas. Comprueba que la tuya es distinta.",
+	"error.invalid_location":             "La ubicación debe ser una latitud y una longitud, como 40.7128, -74.0060.",
+	"error.invalid_token":                "Token de API no válido o revocado.",
+	"error.insufficient_scope":           "Este token de API no permite eso.",
+	"error.session_required":             "Esta página no se puede usar con un token de API.",
+	"error.invalid_scope":                "Alcance no válido. Los alcances válidos son read y write.",
+	"error.token_name_too_long":          "El nombre del token es demasiado largo.",
+	"error.token_name_required":          "Ponle un nombre al token.",
+	"error.token_not_found":              "No se encontró el token.",
+	"error.oauth_client_not_found":       "Aplicación desconocida.",
+	"error.invalid_redirect_uri":         "La URI de redirección debe ser una URL https absoluta, o http en localhost.",
+	"error.invalid_grant":                "Código de autorización no válido, caducado o ya usado.",
+	"error.invalid_code_verifier":        "El verificador de código debe tener entre 43 y 128 caracteres.",
+	"error.export_rate_limited":          "Puedes solicitar una exportación de datos al día. Vuelve a intentarlo mañana.",
+	"error.export_not_found":             "No se encontró la exportación.",
+	"error.export_link_invalid":          "Este enlace de descarga no es válido o ha caducado.",
+	"error.federation_disabled":          "La federación no está activada.",
+	"error.actor_not_found":              "No se encontró el actor.",
+	"error.reply_not_found":              "No se encontró la respuesta.",
+	"error.not_a_reply":                  "No es una respuesta a una página o publicación de aquí.",
+	"error.reply_empty":                  "La respuesta está vacía.",
+	"error.reply_too_long":               "Las respuestas pueden tener como máximo %d caracteres.",
+	"error.invalid_webhook_url":          "La URL del webhook debe ser una URL http o https absoluta.",
+	"error.invalid_webhook_event":        "Elige al menos un evento válido.",
+	"error.webhook_not_found":            "No se encontró el webhook.",
+	"error.delivery_not_found":           "No se encontró la entrega.",
+	"error.passkey_not_found":            "No se encontró la llave de acceso.",
+	"error.passkey_name_required":        "Ponle un nombre a la llave de acceso.",
+	"error.passkey_name_too_long":        "El nombre de la llave de acceso es demasiado largo.",
+	"error.passkey_already_added":        "Esa llave de acceso ya se ha añadido.",
+	"error.passkey_verify_failed":        "No se pudo verificar la llave de acceso. Vuelve a intentarlo.",
+	"error.passkey_cloned":               "Puede que esta llave de acceso se haya copiado, así que no se puede usar para iniciar sesión. Quítala y vuelve a añadirla.",
+	"error.email_failed":                 "No se pudo enviar el correo.",
+	"error.email_in_use":                 "Ya te has registrado con esta dirección de correo.",
+	"error.username_in_use":              "Este nombre de usuario ya está en uso. Elige otro.",
+	"error.invalid_username_or_password": "Nombre de usuario o contraseña no válidos.",
+	"error.database_error":               "Error desconocido de la base de datos.",
+	"error.invalid_session_id":           "Identificador de sesión no válido.",
+	"error.invalid_email":                "La dirección de correo indicada no es válida.",
+	"error.invalid_ip_address":           "Hay algún problema con tu dirección IP.",
+	"error.invalid_title":                "Título de página no válido.",
+	"error.page_already_exists":          "Ya existe una página con esta categoría y este título.",
+	"error.page_not_found":               "No se encontró la página.",
+	"error.post_not_found":               "No se encontró la publicación.",
+	"error.invalid_link":                 "Enlace no válido. Puede que haya caducado o que ya lo hayas usado.",
+	"error.account_suspended":            "Esta cuenta está suspendida.",
+	"error.user_not_found":               "No se encontró el usuario.",
+	"error.category_not_found":           "No se encontró la categoría.",
+	"error.category_already_exists":      "Ya existe una categoría con este nombre.",
+	"error.category_not_empty":           "Esta categoría todavía tiene páginas. Muévelas antes a otra categoría.",
+	"error.community_not_found":          "No se encontró la comunidad.",
+	"error.community_already_exists":     "Ya existe una comunidad con este nombre.",
+	"error.invalid_name":                 "Los nombres deben tener más de 1 carácter.",
+	"error.cannot_merge_into_self":       "Una comunidad no se puede fusionar consigo misma.",
+	"error.cannot_merge_into_child":      "Una comunidad no se puede fusionar con una de sus subcomunidades.",
+	"error.similar_communities_exist":    "Ya existen comunidades parecidas. Comprueba que la tuya es distinta.",
+	"error.community_not_pending":        "Esta comunidad ya se ha revisado.",
+	"error.invalid_parent_community":     "Una comunidad no se puede colocar dentro de sí misma ni de una de sus subcomunidades.",
+	"error.description_too_short":        "La descripción debe tener al menos %d caracteres.",
+	"error.invalid_visibility":           "Visibilidad no válida.",
+	"error.not_a_community_member":       "No eres miembro de esta comunidad.",
+	"error.pseudonym_not_found":          "No se encontró el seudónimo.",
+	"error.cannot_block_self":            "No puedes bloquearte a ti mismo.",
+	"error.invalid_block_kind":           "Tipo de bloqueo no válido.",
+	"error.blocked_by_page_author":       "El autor de esta página no acepta publicaciones tuyas.",
+	"error.blocked_by_mentioned_user":    "Tu publicación menciona a un usuario que no acepta menciones tuyas.",
+	"error.bio_too_long":                 "La biografía puede tener como máximo %d caracteres.",
+	"error.report_reason_too_short":      "Describe el problema con al menos %d caracteres.",
+	"error.cannot_report_self":           "No puedes denunciarte a ti mismo.",
+	"error.report_not_found":             "No se encontró la denuncia o ya se resolvió.",
+	"error.notification_not_found":       "No se encontró la notificación.",
+	"error.invalid_digest_frequency":     "Frecuencia de resumen no válida.",
+	"error.invalid_locale":               "Idioma desconocido.",
+	"error.unknown_provider":             "Proveedor de inicio de sesión desconocido.",
+	"error.identity_in_use":              "Esa cuenta ya está vinculada a otro usuario.",
+	"error.provider_already_linked":      "Ya has vinculado una cuenta de ese proveedor.",
+	"error.identity_not_found":           "No se encontró la cuenta vinculada.",
+	"error.email_not_verified":           "El proveedor no ha verificado tu dirección de correo, así que no se puede usar para encontrar tu cuenta. Inicia sesión con tu contraseña y vincula la cuenta desde tu configuración.",
+	"error.external_login_failed":        "No se pudo iniciar sesión con el proveedor. Vuelve a intentarlo.",
+	"error.deletion_already_scheduled":   "Esta cuenta ya tiene programada su eliminación.",
+	"error.deletion_not_scheduled":       "Esta cuenta no tiene programada su eliminación.",
+	"error.password_too_short":           "La contraseña es demasiado corta. La longitud mínima es de %d caracteres.",
+	"error.username_too_short":           "El nombre de usuario es demasiado corto. La longitud mínima es de %d caracteres.",
+	"error.username_too_long":            "El nombre de usuario es demasiado largo. La longitud máxima es de %d caracteres.",
+	"error.incorrect_password":           "Contraseña incorrecta.",
+	"error.short_password":               "Contraseña demasiado corta.",
+}
@@ -0,0 +1,213 @@
+package imports
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/comforme/comforme/common"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+// Spreadsheet programs often start CSV files with a byte order mark.
+const bom = "\ufeff"
+
+// Maps page fields to the CSV columns or JSON keys holding them. Fields that
+// are not mapped are read from the column named after the field, if there is
+// one. Column names are matched ignoring case.
+type Mapping map[string]string
+
+// Parses a mapping written as field=column pairs separated by commas, such as
+// "title=Name,address=Street Address".
+func ParseMapping(spec string) (Mapping, error) {
+	mapping := Mapping{}
+	for _, pair := range strings.Split(spec, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("Invalid mapping %q. Use field=column.", pair)
+		}
+		mapping[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+	return mapping, mapping.check()
+}
+
+func (mapping Mapping) check() error {
+	for field := range mapping {
+		if !isField(field) {
+			return fmt.Errorf("Unknown field %q. Fields are %s.", field, strings.Join(common.ImportFields, ", "))
+		}
+	}
+	return nil
+}
+
+func isField(name string) bool {
+	for _, field := range common.ImportFields {
+		if field == name {
+			return true
+		}
+	}
+	return false
+}
+
+// The column each field is read from.
+func (mapping Mapping) columns() map[string]string {
+	columns := map[string]string{}
+	for _, field := range common.ImportFields {
+		column := mapping[field]
+		if column == "" {
+			column = field
+		}
+		columns[field] = normalize(column)
+	}
+	return columns
+}
+
+func normalize(column string) string {
+	return strings.ToLower(strings.TrimSpace(strings.TrimPrefix(column, bom)))
+}
+
+// Guesses the format of an uploaded file from its name, or else from its
+// content.
+func DetectFormat(filename string, content []byte) string {
+	switch strings.ToLower(path.Ext(filename)) {
+	case ".csv":
+		return FormatCSV
+	case ".json":
+		return FormatJSON
+	}
+	trimmed := bytes.TrimSpace(bytes.TrimPrefix(content, []byte(bom)))
+	if bytes.HasPrefix(trimmed, []byte("[")) {
+		return FormatJSON
+	}
+	return FormatCSV
+}
+
+// Reads pages from a CSV file with a header row, or from a JSON array of
+// objects.
+func Parse(content []byte, format string, mapping Mapping) (rows []common.ImportRow, err error) {
+	if err = mapping.check(); err != nil {
+		return
+	}
+
+	var records []map[string]string
+	switch format {
+	case FormatCSV:
+		records, err = readCSV(content)
+	case FormatJSON:
+		records, err = readJSON(content)
+	default:
+		err = common.ImportFormatUnknown
+	}
+	if err != nil {
+		return
+	}
+
+	if len(records) == 0 {
+		err = common.ImportEmpty
+		return
+	}
+	if len(records) > common.MaxImportRows {
+		err = common.ImportTooLarge
+		return
+	}
+
+	// Every CSV record has every column, so mistakes in the mapping can be
+	// caught up front
+	columns := mapping.columns()
+	if format == FormatCSV {
+		if _, ok := records[0][columns["title"]]; !ok {
+			err = common.ImportNoTitleColumn
+			return
+		}
+		for field, column := range mapping {
+			if _, ok := records[0][columns[field]]; !ok && column != "" {
+				err = fmt.Errorf("There is no %q column for the %s.", column, field)
+				return
+			}
+		}
+	}
+
+	rows = make([]common.ImportRow, len(records))
+	for i, record := range records {
+		rows[i] = common.ImportRow{
+			Row:         i + 1,
+			Title:       strings.TrimSpace(record[columns["title"]]),
+			Description: strings.TrimSpace(record[columns["description"]]),
+			Address:     strings.TrimSpace(record[columns["address"]]),
+			Website:     strings.TrimSpace(record[columns["website"]]),
+			Location:    strings.TrimSpace(record[columns["location"]]),
+			Category:    strings.TrimSpace(record[columns["category"]]),
+		}
+	}
+	return
+}
+
+func readCSV(content []byte) ([]map[string]string, error) {
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	lines, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("Could not read the CSV file: %s", err.Error())
+	}
+	if len(lines) == 0 {
+		return nil, nil
+	}
+
+	header := make([]string, len(lines[0]))
+	for i, column := range lines[0] {
+		header[i] = normalize(column)
+	}
+
+	records := make([]map[string]string, 0, len(lines)-1)
+	for _, line := range lines[1:] {
+		record := map[string]string{}
+		for i, column := range header {
+			if i < len(line) {
+				record[column] = line[i]
+			} else {
+				record[column] = ""
+			}
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func readJSON(content []byte) ([]map[string]string, error) {
+	var objects []map[string]interface{}
+	if err := json.Unmarshal(bytes.TrimPrefix(content, []byte(bom)), &objects); err != nil {
+		return nil, fmt.Errorf("Could not read the JSON file. It must be an array of objects: %s", err.Error())
+	}
+
+	records := make([]map[string]string, len(objects))
+	for i, object := range objects {
+		record := map[string]string{}
+		for key, value := range object {
+			switch value := value.(type) {
+			case nil:
+				record[normalize(key)] = ""
+			case string:
+				record[normalize(key)] = value
+			case float64:
+				record[normalize(key)] = strconv.FormatFloat(value, 'f', -1, 64)
+			default:
+				record[normalize(key)] = fmt.Sprint(value)
+			}
+		}
+		records[i] = record
+	}
+	return records, nil
+}
@@ -8,6 +8,8 @@ import (
 
 	"github.com/comforme/comforme/common"
 	"github.com/comforme/comforme/databaseActions"
+	"github.com/comforme/comforme/i18n"
+	"github.com/comforme/comforme/oidc"
 	"github.com/comforme/comforme/recaptcha"
 	"github.com/comforme/comforme/templates"
 )
@@ -15,11 +17,8 @@ import (
 var loginTemplate *template.Template
 var recaptchaPublicKey string
 
-const registrationSuccess = "Registration successful. Please check your email."
-const resetSuccess = "Password reset successful. Please check your email."
-
 func init() {
-	loginTemplate = template.Must(template.New("siteLayout").Parse(templates.SiteLayout))
+	loginTemplate = template.Must(template.New("siteLayout").Funcs(i18n.Funcs).Parse(templates.SiteLayout))
 	template.Must(loginTemplate.New("nav").Parse(""))
 	template.Must(loginTemplate.New("content").Parse(loginTemplateText))
 	recaptchaPublicKey = os.Getenv("RECAPTCHA_PUBLIC_KEY")
@@ -28,12 +27,15 @@ func init() {
 
 func LoginHandler(res http.ResponseWriter, req *http.Request) {
 	data := map[string]interface{}{}
+	locale := i18n.RequestLocale(req)
 
-	data["formAction"] = req.URL.Path
-	data["pageTitle"] = "Login"
+	data["locale"] = locale
+	data["formAction"] = req.URL.RequestURI()
+	data["pageTitle"] = i18n.Translate(locale, "login.title")
 	data["recaptchaPublicKey"] = recaptchaPublicKey
 	data["siteName"] = common.SiteName
 	data["siteLongName"] = common.SiteLongName
+	data["oidcProviders"] = oidc.Providers()
 
 	if req.Method == "POST" {
 		isSignup := req.PostFormValue("sign-up") == "true"
@@ -55,14 +57,14 @@ func LoginHandler(res http.ResponseWriter, req *http.Request) {
 			)
 			if err != nil {
 				log.Println("reCaptcha failed:", err)
-				data["formError"] = err.Error()
+				data["formError"] = i18n.ErrorMessage(locale, err)
 			} else {
 				log.Println("reCaptcha success:", err)
 				err = databaseActions.Register1(email, common.GetBaseURL(req))
 				if err != nil {
-					data["formError"] = err.Error()
+					data["formError"] = i18n.ErrorMessage(locale, err)
 				} else { // No error
-					data["successMsg"] = registrationSuccess
+					data["successMsg"] = i18n.Translate(locale, "login.registration_success")
 				}
 			}
 		} else if isLogin {
@@ -70,14 +72,14 @@ func LoginHandler(res http.ResponseWriter, req *http.Request) {
 
 			password := req.PostFormValue("password")
 
-			sessionid, err := databaseActions.Login(email, password)
+			sessionid, err := databaseActions.Login(email, password, common.GetRequestInfo(req))
 			if err != nil {
-				data["formError"] = err.Error()
+				data["formError"] = i18n.ErrorMessage(locale, err)
 			} else { // No error
 				common.SetSessionCookie(res, sessionid)
 
 				// Redirect to intended page
-				http.Redirect(res, req, req.URL.Path, http.StatusFound)
+				http.Redirect(res, req, req.URL.RequestURI(), http.StatusFound)
 				return // Not needed, may reduce load on server
 			}
 		} else if isReset {
@@ -92,14 +94,14 @@ func LoginHandler(res http.ResponseWriter, req *http.Request) {
 			)
 			if err != nil {
 				log.Println("reCaptcha failed:", err)
-				data["formError"] = err.Error()
+				data["formError"] = i18n.ErrorMessage(locale, err)
 			} else {
 				log.Println("reCaptcha success:", err)
-				err := databaseActions.ResetPassword(email, common.GetBaseURL(req))
+				err := databaseActions.ResetPassword(email, common.GetBaseURL(req), common.GetRequestInfo(req))
 				if err != nil {
-					data["formError"] = err.Error()
+					data["formError"] = i18n.ErrorMessage(locale, err)
 				} else {
-					data["successMsg"] = resetSuccess
+					data["successMsg"] = i18n.Translate(locale, "login.reset_success")
 				}
 			}
 		}
@@ -110,7 +112,7 @@ func LoginHandler(res http.ResponseWriter, req *http.Request) {
 
 const loginTemplateText = `
     <div class="content sign-up-and-log-in">
-		<h1 class="text-center">Welcome to {{.siteLongName}}!</h1>
+		<h1 class="text-center">{{t $.locale "login.welcome" .siteLongName}}</h1>
 		<div class="row">
 			<div class="large-3 medium-3 show-for-medium-up columns">&nbsp;</div>
 			<div class="large-6 medium-6 columns" style="min-width: 320px;">{{if .formError}}
@@ -122,53 +124,57 @@ const loginTemplateText = `
 				</div>{{end}}
 				<section class="login-tabs sign-up-and-log-in">
 					<dl class="tabs" data-tab>
-						<dd{{if not .loginSelected}} class="active"{{end}}><a href="#sign-up-form">Sign Up</a></dd>
-						<dd{{if .loginSelected}} class="active"{{end}}><a href="#log-in-form">Log In</a></dd>
+						<dd{{if not .loginSelected}} class="active"{{end}}><a href="#sign-up-form">{{t $.locale "login.sign_up"}}</a></dd>
+						<dd{{if .loginSelected}} class="active"{{end}}><a href="#log-in-form">{{t $.locale "login.log_in"}}</a></dd>
 					</dl>
 					<div class="tabs-content">
 						<div class="content{{if not .loginSelected}} active{{end}}" id="sign-up-form">
 							<form method="post" action="{{.formAction}}">
 								<noscript>
-									<small class="error">This site requires JavaScript to function!</small>
+									<small class="error">{{t $.locale "common.requires_javascript"}}</small>
 								</noscript>
 								<div{{if .registerEmailError}} class="error"{{end}}>
-									<input type="email" name="email" placeholder="Email"{{if .email}} value="{{.email}}"{{end}}>{{if .registerEmailError}}
+									<input type="email" name="email" placeholder="{{t $.locale "login.email"}}"{{if .email}} value="{{.email}}"{{end}}>{{if .registerEmailError}}
 									<small class="error">{{.registerEmailError}}</small>{{end}}
 								</div>
 								<div class="g-recaptcha" data-sitekey="{{.recaptchaPublicKey}}"></div>
 								<div>
-									<button type="submit" class="button expand" name="sign-up" value="true">Sign Up</button>
-									<button type="submit" class="button tiny expand" name="reset-password" value="true">Reset Password</button>
+									<button type="submit" class="button expand" name="sign-up" value="true">{{t $.locale "login.sign_up"}}</button>
+									<button type="submit" class="button tiny expand" name="reset-password" value="true">{{t $.locale "login.reset_password"}}</button>
 								</div>
 							</form>
 						</div>
 						<div class="content{{if .loginSelected}} active{{end}}" id="log-in-form">
 							<form method="post" action="{{.formAction}}">
 								<div{{if .loginError}} class="error"{{end}}>
-									<input type="email" name="email" placeholder="Email"{{if .email}} value="{{.email}}"{{end}}>{{if .loginError}}
+									<input type="email" name="email" placeholder="{{t $.locale "login.email"}}"{{if .email}} value="{{.email}}"{{end}}>{{if .loginError}}
 									<small class="error">{{.loginError}}</small>{{end}}
 								</div>
 								<div{{if .loginError}} class="error"{{end}}>
-									<input type="password" name="password" placeholder="Password">{{if .loginError}}
+									<input type="password" name="password" placeholder="{{t $.locale "login.password"}}">{{if .loginError}}
 									<small class="error">{{.loginError}}</small>{{end}}
 								</div>
 								<div>
-									<button type="submit" class="button expand" name="log-in" value="true">Log In</button>
+									<button type="submit" class="button expand" name="log-in" value="true">{{t $.locale "login.log_in"}}</button>
 								</div>
 							</form>
+							<div class="alert-box alert" id="passkey-error" data-failed="{{t $.locale "passkey.failed"}}" data-unsupported="{{t $.locale "passkey.unsupported"}}" data-not-added="{{t $.locale "passkey.not_added"}}" data-cancelled="{{t $.locale "passkey.cancelled"}}" style="display: none;"></div>
+							<button type="button" class="button secondary expand" onclick="logInWithPasskey()">{{t $.locale "login.passkey"}}</button>{{range .oidcProviders}}
+							<a href="/login/oidc/{{.Name}}?return={{$.formAction}}" class="button secondary expand">{{t $.locale "login.with_provider" .Label}}</a>{{end}}
 						</div>
 					</div>
 				</section>
 			</div>
 			<div class="large-2 medium-2 show-for-medium-up columns">&nbsp;</div>
 			<div class="large-12 columns">
-				<h2>What is {{.siteName}}?</h2>
+				<h2>{{t $.locale "login.about_title" .siteName}}</h2>
 					<div>
-						<p>{{.siteName}}{{if not (eq .siteLongName .siteName)}} ({{.siteLongName}}){{end}} is a community-rated and identity-oriented social network/service listing. Users can find accepting communities and services based on a wide array of keywords. Users can also start their own communities categorized by aforementioned keywords. {{.siteName}} makes it easier for an individual to find communities and services which accept them for who they are.</a></p>
+						<p>{{.siteName}}{{if not (eq .siteLongName .siteName)}} ({{.siteLongName}}){{end}} {{t $.locale "login.about" .siteName}}</a></p>
 					</div>
 				</div>
 			</div>
 		</div>
 	</div>
+	<script src="/static/js/passkeys.js"></script>
 	<a href="https://github.com/comforme/comforme"><img style="position: absolute; top: 0; right: 0; border: 0;" src="https://camo.githubusercontent.com/38ef81f8aca64bb9a64448d0d70f1308ef5341ab/68747470733a2f2f73332e616d617a6f6e6177732e636f6d2f6769746875622f726962626f6e732f666f726b6d655f72696768745f6461726b626c75655f3132313632312e706e67" alt="Fork me on GitHub" data-canonical-src="https://s3.amazonaws.com/github/ribbons/forkme_right_darkblue_121621.png"></a>
 `
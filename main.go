@@ -8,20 +8,40 @@ import (
 
 	"github.com/julienschmidt/httprouter"
 
+	"github.com/comforme/comforme/accounts"
+	"github.com/comforme/comforme/activitypub"
+	"github.com/comforme/comforme/admin"
 	"github.com/comforme/comforme/ajax"
+	"github.com/comforme/comforme/api"
 	"github.com/comforme/comforme/algoliaUtil"
+	"github.com/comforme/comforme/commands"
+	"github.com/comforme/comforme/common"
+	"github.com/comforme/comforme/communities"
+	"github.com/comforme/comforme/exports"
+	"github.com/comforme/comforme/feeds"
 	"github.com/comforme/comforme/hashLinks"
 	"github.com/comforme/comforme/home"
 	"github.com/comforme/comforme/logout"
+	"github.com/comforme/comforme/notifications"
+	"github.com/comforme/comforme/oauth"
+	"github.com/comforme/comforme/oidc"
 	"github.com/comforme/comforme/pages"
+	"github.com/comforme/comforme/profiles"
 	"github.com/comforme/comforme/requireLogin"
 	"github.com/comforme/comforme/search"
 	"github.com/comforme/comforme/settings"
 	"github.com/comforme/comforme/static"
 	"github.com/comforme/comforme/tour"
+	"github.com/comforme/comforme/webauthn"
+	"github.com/comforme/comforme/webhooks"
 )
 
 func main() {
+	// Run administrative commands such as granting the first admin role
+	if commands.Run(os.Args[1:]) {
+		return
+	}
+
 	log.Println("Starting server on port " + os.Getenv("PORT") + "...")
 	dir, err := os.Getwd()
 	if err != nil {
@@ -40,6 +60,42 @@ func main() {
 		requireLogin.RequireLogin(settings.SettingsHandler),
 	)
 
+	router.GET(
+		"/settings/export/:id",
+		requireLogin.RequireLogin(exports.DownloadHandler),
+	)
+
+	router.POST(
+		"/settings/identities/:provider",
+		requireLogin.RequireLogin(oidc.LinkHandler),
+	)
+
+	router.POST(
+		"/webauthn/register/options",
+		requireLogin.RequireLogin(webauthn.RegisterOptionsHandler),
+	)
+	router.POST(
+		"/webauthn/register",
+		requireLogin.RequireLogin(webauthn.RegisterHandler),
+	)
+	router.POST(
+		"/webauthn/login/options",
+		webauthn.LoginOptionsHandler,
+	)
+	router.POST(
+		"/webauthn/login",
+		webauthn.LoginHandler,
+	)
+
+	router.GET(
+		"/login/oidc/:provider",
+		oidc.LoginHandler,
+	)
+	router.GET(
+		"/login/oidc/:provider/callback",
+		oidc.CallbackHandler,
+	)
+
 	router.GET(
 		"/tour",
 		requireLogin.RequireLogin(tour.TourHandler),
@@ -67,6 +123,15 @@ func main() {
 		hashLinks.ResetHandler,
 	)
 
+	router.GET(
+		"/unsubscribe",
+		hashLinks.UnsubscribeHandler,
+	)
+	router.POST(
+		"/unsubscribe",
+		hashLinks.UnsubscribeHandler,
+	)
+
 	router.GET(
 		"/newPage",
 		requireLogin.RequireLogin(pages.NewPageHandler),
@@ -85,6 +150,86 @@ func main() {
 		requireLogin.RequireLogin(pages.PageHandler),
 	)
 
+	router.GET(
+		"/page/:category/:slug/edit",
+		requireLogin.RequireLogin(pages.EditPageHandler),
+	)
+	router.POST(
+		"/page/:category/:slug/edit",
+		requireLogin.RequireLogin(pages.EditPageHandler),
+	)
+
+	router.GET(
+		"/page/:category/:slug/feed",
+		feeds.PageFeedHandler,
+	)
+
+	router.GET(
+		"/feeds/pages",
+		feeds.PagesFeedHandler,
+	)
+	router.GET(
+		"/feeds/category/:category",
+		feeds.CategoryFeedHandler,
+	)
+	router.GET(
+		"/feeds/community/:id",
+		feeds.CommunityFeedHandler,
+	)
+
+	router.GET(
+		"/community/:id",
+		requireLogin.RequireLogin(communities.CommunityHandler),
+	)
+
+	router.GET(
+		"/communities/new",
+		requireLogin.RequireLogin(communities.ProposeHandler),
+	)
+	router.POST(
+		"/communities/new",
+		requireLogin.RequireLogin(communities.ProposeHandler),
+	)
+
+	router.GET(
+		"/communities/pending",
+		requireLogin.RequireLogin(communities.PendingHandler),
+	)
+	router.POST(
+		"/communities/pending",
+		requireLogin.RequireLogin(communities.PendingHandler),
+	)
+
+	router.GET(
+		"/user/:username",
+		requireLogin.RequireLogin(profiles.ProfileHandler),
+	)
+	router.POST(
+		"/user/:username",
+		requireLogin.RequireLogin(profiles.ProfileHandler),
+	)
+
+	api.Register(router)
+	activitypub.Register(router)
+
+	router.GET(
+		"/oauth/authorize",
+		requireLogin.RequireLogin(oauth.AuthorizeHandler),
+	)
+	router.POST(
+		"/oauth/authorize",
+		requireLogin.RequireLogin(oauth.AuthorizeHandler),
+	)
+	router.POST(
+		"/oauth/token",
+		oauth.TokenHandler,
+	)
+
+	router.GET(
+		"/notifications",
+		requireLogin.RequireLogin(notifications.NotificationsHandler),
+	)
+
 	router.GET(
 		"/search",
 		requireLogin.RequireLogin(search.SearchHandler),
@@ -94,6 +239,96 @@ func main() {
 		requireLogin.RequireLogin(search.SearchHandler),
 	)
 
+	router.GET(
+		"/admin",
+		requireLogin.RequirePermission(common.PermissionViewStatistics, admin.DashboardHandler),
+	)
+
+	router.GET(
+		"/admin/users",
+		requireLogin.RequirePermission(common.PermissionManageUsers, admin.UsersHandler),
+	)
+	router.POST(
+		"/admin/users",
+		requireLogin.RequirePermission(common.PermissionManageUsers, admin.UsersHandler),
+	)
+
+	router.GET(
+		"/admin/categories",
+		requireLogin.RequirePermission(common.PermissionManageCategories, admin.CategoriesHandler),
+	)
+	router.POST(
+		"/admin/categories",
+		requireLogin.RequirePermission(common.PermissionManageCategories, admin.CategoriesHandler),
+	)
+
+	router.GET(
+		"/admin/communities",
+		requireLogin.RequirePermission(common.PermissionManageCommunities, admin.CommunitiesHandler),
+	)
+	router.POST(
+		"/admin/communities",
+		requireLogin.RequirePermission(common.PermissionManageCommunities, admin.CommunitiesHandler),
+	)
+
+	router.GET(
+		"/admin/audit",
+		requireLogin.RequirePermission(common.PermissionViewAuditLog, admin.AuditHandler),
+	)
+
+	router.GET(
+		"/admin/reports",
+		requireLogin.RequirePermission(common.PermissionModerate, admin.ReportsHandler),
+	)
+	router.POST(
+		"/admin/reports",
+		requireLogin.RequirePermission(common.PermissionModerate, admin.ReportsHandler),
+	)
+
+	router.GET(
+		"/admin/federation",
+		requireLogin.RequirePermission(common.PermissionModerate, admin.FederationHandler),
+	)
+	router.POST(
+		"/admin/federation",
+		requireLogin.RequirePermission(common.PermissionModerate, admin.FederationHandler),
+	)
+
+	router.GET(
+		"/admin/duplicates/:id",
+		requireLogin.RequirePermission(common.PermissionModerate, admin.DuplicatesHandler),
+	)
+	router.POST(
+		"/admin/duplicates/:id",
+		requireLogin.RequirePermission(common.PermissionModerate, admin.DuplicatesHandler),
+	)
+
+	router.GET(
+		"/admin/import",
+		requireLogin.RequirePermission(common.PermissionImportPages, admin.ImportHandler),
+	)
+	router.POST(
+		"/admin/import",
+		requireLogin.RequirePermission(common.PermissionImportPages, admin.ImportHandler),
+	)
+
+	router.GET(
+		"/admin/webhooks",
+		requireLogin.RequirePermission(common.PermissionManageWebhooks, admin.WebhooksHandler),
+	)
+	router.POST(
+		"/admin/webhooks",
+		requireLogin.RequirePermission(common.PermissionManageWebhooks, admin.WebhooksHandler),
+	)
+	router.GET(
+		"/admin/webhooks/:id",
+		requireLogin.RequirePermission(common.PermissionManageWebhooks, admin.WebhookHandler),
+	)
+	router.POST(
+		"/admin/webhooks/:id",
+		requireLogin.RequirePermission(common.PermissionManageWebhooks, admin.WebhookHandler),
+	)
+
 	router.GET(
 		"/static/*filepath",
 		static.StaticHandler,
@@ -128,6 +363,13 @@ func main() {
     log.Printf("%s\n", err.Error())
   }
 	
+	// Send webhook and federation deliveries, build data exports and delete
+	// accounts in the background
+	go webhooks.Run()
+	go activitypub.Run()
+	go exports.Run()
+	go accounts.Run()
+
 	// Start the server
 	log.Fatal(http.ListenAndServe(":"+os.Getenv("PORT"), router))
 
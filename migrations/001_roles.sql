@@ -0,0 +1,12 @@
+-- Site wide roles and community scoped moderators.
+
+ALTER TABLE users ADD COLUMN role VARCHAR(16) NOT NULL DEFAULT 'user';
+
+CREATE TABLE community_moderators (
+	user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	community_id INTEGER NOT NULL REFERENCES communities (id) ON DELETE CASCADE,
+	PRIMARY KEY (user_id, community_id)
+);
+
+-- Posts need a stable identifier so moderators can act on them.
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS id SERIAL UNIQUE;
@@ -0,0 +1,3 @@
+-- Lets admins suspend accounts.
+
+ALTER TABLE users ADD COLUMN suspended BOOLEAN NOT NULL DEFAULT false;
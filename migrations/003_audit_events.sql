@@ -0,0 +1,31 @@
+-- Append-only log of security and moderation events.
+-- User ids are deliberately not foreign keys so rows never have to change.
+
+CREATE TABLE audit_events (
+	id BIGSERIAL PRIMARY KEY,
+	date_created TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+	action VARCHAR(64) NOT NULL,
+	actor_id INTEGER,
+	subject_user_id INTEGER,
+	ip_address VARCHAR(64) NOT NULL DEFAULT '',
+	user_agent TEXT NOT NULL DEFAULT '',
+	details TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX audit_events_subject_user_id ON audit_events (subject_user_id, id);
+CREATE INDEX audit_events_actor_id ON audit_events (actor_id, id);
+CREATE INDEX audit_events_action ON audit_events (action, id);
+
+CREATE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_events_append_only
+	BEFORE UPDATE OR DELETE ON audit_events
+	FOR EACH ROW EXECUTE PROCEDURE audit_events_append_only();
+
+CREATE TRIGGER audit_events_no_truncate
+	BEFORE TRUNCATE ON audit_events
+	FOR EACH STATEMENT EXECUTE PROCEDURE audit_events_append_only();
@@ -0,0 +1,9 @@
+-- Lets users propose new communities for moderators to approve.
+
+ALTER TABLE communities ADD COLUMN description TEXT NOT NULL DEFAULT '';
+ALTER TABLE communities ADD COLUMN parent_id INTEGER REFERENCES communities (id) ON DELETE SET NULL;
+ALTER TABLE communities ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'approved';
+ALTER TABLE communities ADD COLUMN created_by INTEGER REFERENCES users (id) ON DELETE SET NULL;
+ALTER TABLE communities ADD COLUMN date_created TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now();
+
+CREATE INDEX communities_status ON communities (status);
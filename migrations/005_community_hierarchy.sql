@@ -0,0 +1,38 @@
+-- Every community paired with itself and each of its ancestors. depth is the
+-- number of steps from the community up to the ancestor.
+
+CREATE VIEW community_ancestors (community_id, ancestor_id, depth) AS
+	WITH RECURSIVE ancestors (community_id, ancestor_id, depth) AS (
+		SELECT id, id, 0 FROM communities
+		UNION ALL
+		SELECT
+			ancestors.community_id,
+			communities.parent_id,
+			ancestors.depth + 1
+		FROM
+			ancestors,
+			communities
+		WHERE
+			communities.id = ancestors.ancestor_id
+			AND communities.parent_id IS NOT NULL
+			AND ancestors.depth < 16 -- Guards against cycles
+	)
+	SELECT community_id, ancestor_id, depth FROM ancestors;
+
+-- The communities each user belongs to either directly (depth 0) or through
+-- a more specific community (depth > 0).
+CREATE VIEW inherited_memberships (user_id, community_id, depth) AS
+	SELECT
+		community_memberships.user_id,
+		community_ancestors.ancestor_id,
+		min(community_ancestors.depth)
+	FROM
+		community_memberships,
+		community_ancestors
+	WHERE
+		community_ancestors.community_id = community_memberships.community_id
+	GROUP BY
+		community_memberships.user_id,
+		community_ancestors.ancestor_id;
+
+CREATE INDEX communities_parent_id ON communities (parent_id);
@@ -0,0 +1,7 @@
+-- Other names a community is known by, used when searching for communities.
+
+CREATE TABLE community_aliases (
+	community_id INTEGER NOT NULL REFERENCES communities (id) ON DELETE CASCADE,
+	alias VARCHAR(255) NOT NULL,
+	PRIMARY KEY (community_id, alias)
+);
@@ -0,0 +1,54 @@
+-- Who may see that someone belongs to a community:
+--   public:  anyone
+--   members: only other members of the same community
+--   private: nobody, the membership is only used to personalize the member's
+--            own view of the site
+
+ALTER TABLE community_memberships ADD COLUMN visibility VARCHAR(16) NOT NULL DEFAULT 'public';
+
+-- The memberships the viewer is allowed to know about: all of their own, and
+-- other people's that are public or shared with the viewer as a fellow member.
+-- Every query that reveals someone else's memberships, even as a count or a
+-- ranking, must read from this instead of community_memberships.
+CREATE FUNCTION visible_memberships (viewer INTEGER)
+	RETURNS TABLE (user_id INTEGER, community_id INTEGER) AS $$
+	SELECT
+		memberships.user_id,
+		memberships.community_id
+	FROM
+		community_memberships memberships
+	WHERE
+		memberships.user_id = viewer
+		OR memberships.visibility = 'public'
+		OR (
+			memberships.visibility = 'members'
+			AND EXISTS (
+				SELECT 1
+				FROM community_memberships mine
+				WHERE
+					mine.user_id = viewer
+					AND mine.community_id = memberships.community_id
+			)
+		);
+$$ LANGUAGE SQL STABLE;
+
+-- inherited_memberships restricted to what the viewer may see.
+CREATE FUNCTION visible_inherited_memberships (viewer INTEGER)
+	RETURNS TABLE (user_id INTEGER, community_id INTEGER, depth INTEGER) AS $$
+	SELECT
+		memberships.user_id,
+		community_ancestors.ancestor_id,
+		min(community_ancestors.depth)
+	FROM
+		visible_memberships(viewer) memberships,
+		community_ancestors
+	WHERE
+		community_ancestors.community_id = memberships.community_id
+	GROUP BY
+		memberships.user_id,
+		community_ancestors.ancestor_id;
+$$ LANGUAGE SQL STABLE;
+
+-- inherited_memberships ignores visibility, so it must not be used to compare
+-- two users any more.
+DROP VIEW inherited_memberships;
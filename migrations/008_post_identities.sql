@@ -0,0 +1,15 @@
+-- Alternate display names a user can post under. They share a namespace with
+-- usernames so that nobody can post as someone else.
+
+CREATE TABLE pseudonyms (
+	id SERIAL PRIMARY KEY,
+	user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	name VARCHAR(255) NOT NULL UNIQUE,
+	date_created TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+-- A post is shown under the author's username, one of their pseudonyms, or
+-- anonymously. posts.user_id always holds the real author so that moderators
+-- can act on it, but it must never be shown for the latter two.
+ALTER TABLE posts ADD COLUMN pseudonym_id INTEGER REFERENCES pseudonyms (id);
+ALTER TABLE posts ADD COLUMN anonymous BOOLEAN NOT NULL DEFAULT false;
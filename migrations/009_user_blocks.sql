@@ -0,0 +1,14 @@
+-- Users a user does not want to hear from. Blocking hides the other user's
+-- pages and posts and stops them from replying to or mentioning the blocker,
+-- muting only collapses their posts.
+
+CREATE TABLE user_blocks (
+	user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	blocked_user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	kind VARCHAR(8) NOT NULL CHECK (kind IN ('block', 'mute')),
+	date_created TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+	PRIMARY KEY (user_id, blocked_user_id),
+	CHECK (user_id <> blocked_user_id)
+);
+
+CREATE INDEX user_blocks_blocked_user_id ON user_blocks (blocked_user_id);
@@ -0,0 +1,15 @@
+-- Public profiles and reports of users sent to the moderators.
+
+ALTER TABLE users ADD COLUMN bio TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE user_reports (
+	id SERIAL PRIMARY KEY,
+	reporter_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	reported_user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	reason TEXT NOT NULL,
+	date_created TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+	resolved_by INTEGER REFERENCES users (id) ON DELETE SET NULL,
+	date_resolved TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX user_reports_open ON user_reports (date_created) WHERE date_resolved IS NULL;
@@ -0,0 +1,20 @@
+-- Pages a user follows. Users follow the pages they create or post on.
+
+CREATE TABLE page_subscriptions (
+	user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	page_id INTEGER NOT NULL REFERENCES pages (id) ON DELETE CASCADE,
+	date_created TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+	PRIMARY KEY (user_id, page_id)
+);
+
+-- One row per new post on a followed page. The author is looked up through
+-- the post so that anonymous and pseudonymous posts stay that way.
+CREATE TABLE notifications (
+	id SERIAL PRIMARY KEY,
+	user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	post_id INTEGER NOT NULL REFERENCES posts (id) ON DELETE CASCADE,
+	date_created TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+	date_read TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX notifications_unread ON notifications (user_id) WHERE date_read IS NULL;
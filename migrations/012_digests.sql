@@ -0,0 +1,6 @@
+-- How often each user gets an email digest of activity in their communities,
+-- and when the last one went out.
+
+ALTER TABLE users ADD COLUMN digest_frequency VARCHAR(8) NOT NULL DEFAULT 'weekly'
+	CHECK (digest_frequency IN ('never', 'daily', 'weekly'));
+ALTER TABLE users ADD COLUMN digest_last_sent TIMESTAMP WITH TIME ZONE;
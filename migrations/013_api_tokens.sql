@@ -0,0 +1,38 @@
+-- Third-party applications allowed to ask users for access with OAuth2.
+-- Clients are public: they prove possession of the authorization code with
+-- PKCE instead of a client secret.
+CREATE TABLE oauth_clients (
+	id SERIAL PRIMARY KEY,
+	client_id VARCHAR(64) NOT NULL UNIQUE,
+	name VARCHAR(128) NOT NULL,
+	redirect_uri TEXT NOT NULL,
+	date_created TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+-- Bearer tokens for the API. Personal access tokens have no client; tokens
+-- issued to an OAuth2 client are named after it. Only a SHA-256 hash of each
+-- token is kept.
+CREATE TABLE api_tokens (
+	id SERIAL PRIMARY KEY,
+	user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	client_id INTEGER REFERENCES oauth_clients (id) ON DELETE CASCADE,
+	name VARCHAR(128) NOT NULL,
+	token_hash CHAR(64) NOT NULL UNIQUE,
+	scopes TEXT NOT NULL,
+	date_created TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+	last_used TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX api_tokens_user ON api_tokens (user_id);
+
+-- Authorization codes waiting to be exchanged for a token. Each is deleted
+-- when it is used.
+CREATE TABLE oauth_codes (
+	code_hash CHAR(64) PRIMARY KEY,
+	client_id INTEGER NOT NULL REFERENCES oauth_clients (id) ON DELETE CASCADE,
+	user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	redirect_uri TEXT NOT NULL,
+	scopes TEXT NOT NULL,
+	code_challenge VARCHAR(128) NOT NULL,
+	expires TIMESTAMP WITH TIME ZONE NOT NULL
+);
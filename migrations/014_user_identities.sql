@@ -0,0 +1,27 @@
+-- Accounts at external OpenID Connect providers that users can log in with.
+-- An account is identified by the provider's issuer-scoped subject, never by
+-- email, since email addresses can change hands.
+CREATE TABLE user_identities (
+	id SERIAL PRIMARY KEY,
+	user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	provider VARCHAR(64) NOT NULL,
+	subject VARCHAR(255) NOT NULL,
+	email VARCHAR(255) NOT NULL,
+	date_created TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+	last_used TIMESTAMP WITH TIME ZONE,
+	UNIQUE (provider, subject),
+	UNIQUE (user_id, provider)
+);
+
+-- Logins in progress, from the redirect to the provider until it sends the
+-- user back. link_user_id is set when a logged in user is linking an
+-- identity from their settings.
+CREATE TABLE oidc_logins (
+	state_hash CHAR(64) PRIMARY KEY,
+	provider VARCHAR(64) NOT NULL,
+	nonce VARCHAR(128) NOT NULL,
+	code_verifier VARCHAR(128) NOT NULL,
+	link_user_id INTEGER REFERENCES users (id) ON DELETE CASCADE,
+	return_to TEXT NOT NULL,
+	expires TIMESTAMP WITH TIME ZONE NOT NULL
+);
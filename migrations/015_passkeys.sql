@@ -0,0 +1,22 @@
+-- WebAuthn credentials users can log in with instead of a password. The
+-- public key is kept as the COSE_Key the authenticator sent.
+CREATE TABLE passkeys (
+	id SERIAL PRIMARY KEY,
+	user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	credential_id BYTEA NOT NULL UNIQUE,
+	public_key BYTEA NOT NULL,
+	sign_count BIGINT NOT NULL DEFAULT 0,
+	name VARCHAR(128) NOT NULL,
+	date_created TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+	last_used TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX passkeys_user ON passkeys (user_id);
+
+-- Challenges sent to the browser, each of which can be answered once.
+-- user_id is set when registering a passkey and NULL when logging in.
+CREATE TABLE passkey_challenges (
+	challenge_hash CHAR(64) PRIMARY KEY,
+	user_id INTEGER REFERENCES users (id) ON DELETE CASCADE,
+	expires TIMESTAMP WITH TIME ZONE NOT NULL
+);
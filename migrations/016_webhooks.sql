@@ -0,0 +1,33 @@
+-- Endpoints that are sent platform events. A webhook limited to a category
+-- only gets page and post events from that category, and every other event
+-- it subscribes to.
+CREATE TABLE webhooks (
+	id SERIAL PRIMARY KEY,
+	url TEXT NOT NULL,
+	secret VARCHAR(128) NOT NULL,
+	events TEXT NOT NULL,
+	category_id INTEGER REFERENCES categories (id) ON DELETE CASCADE,
+	active BOOLEAN NOT NULL DEFAULT true,
+	date_created TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+-- One row per event sent to a webhook, kept as a delivery log. Pending
+-- deliveries are retried with backoff until they succeed or run out of
+-- attempts.
+CREATE TABLE webhook_deliveries (
+	id SERIAL PRIMARY KEY,
+	webhook_id INTEGER NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+	event VARCHAR(32) NOT NULL,
+	payload TEXT NOT NULL,
+	status VARCHAR(16) NOT NULL DEFAULT 'pending'
+		CHECK (status IN ('pending', 'succeeded', 'failed')),
+	attempts INTEGER NOT NULL DEFAULT 0,
+	next_attempt TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+	last_attempt TIMESTAMP WITH TIME ZONE,
+	response_status INTEGER,
+	last_error TEXT NOT NULL DEFAULT '',
+	date_created TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE INDEX webhook_deliveries_pending ON webhook_deliveries (next_attempt) WHERE status = 'pending';
+CREATE INDEX webhook_deliveries_webhook ON webhook_deliveries (webhook_id, date_created);
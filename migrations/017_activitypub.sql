@@ -0,0 +1,63 @@
+-- ActivityPub actors for categories and communities, created the first time
+-- one is looked up. Each has its own key pair for signing deliveries.
+CREATE TABLE ap_actors (
+	id SERIAL PRIMARY KEY,
+	category_id INTEGER UNIQUE REFERENCES categories (id) ON DELETE CASCADE,
+	community_id INTEGER UNIQUE REFERENCES communities (id) ON DELETE CASCADE,
+	public_key TEXT NOT NULL,
+	private_key TEXT NOT NULL,
+	date_created TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+	CHECK ((category_id IS NULL) <> (community_id IS NULL))
+);
+
+-- Remote actors following a local one. inbox is the follower's shared inbox
+-- when it has one, so that a server gets each activity once.
+CREATE TABLE ap_followers (
+	id SERIAL PRIMARY KEY,
+	actor_id INTEGER NOT NULL REFERENCES ap_actors (id) ON DELETE CASCADE,
+	follower TEXT NOT NULL,
+	inbox TEXT NOT NULL,
+	date_created TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+	UNIQUE (actor_id, follower)
+);
+
+-- Signed activities waiting to be delivered to a remote inbox. Like webhook
+-- deliveries they are retried with backoff.
+CREATE TABLE ap_deliveries (
+	id SERIAL PRIMARY KEY,
+	actor_id INTEGER NOT NULL REFERENCES ap_actors (id) ON DELETE CASCADE,
+	inbox TEXT NOT NULL,
+	activity TEXT NOT NULL,
+	status VARCHAR(16) NOT NULL DEFAULT 'pending'
+		CHECK (status IN ('pending', 'succeeded', 'failed')),
+	attempts INTEGER NOT NULL DEFAULT 0,
+	next_attempt TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+	last_error TEXT NOT NULL DEFAULT '',
+	date_created TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE INDEX ap_deliveries_pending ON ap_deliveries (next_attempt) WHERE status = 'pending';
+
+-- Replies from remote servers to pages and posts. They wait here until a
+-- moderator approves them, which turns them into posts.
+CREATE TABLE ap_replies (
+	id SERIAL PRIMARY KEY,
+	page_id INTEGER NOT NULL REFERENCES pages (id) ON DELETE CASCADE,
+	object_id TEXT NOT NULL UNIQUE,
+	actor TEXT NOT NULL,
+	author VARCHAR(255) NOT NULL,
+	content TEXT NOT NULL,
+	status VARCHAR(16) NOT NULL DEFAULT 'pending'
+		CHECK (status IN ('pending', 'approved', 'rejected')),
+	date_created TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE INDEX ap_replies_pending ON ap_replies (date_created) WHERE status = 'pending';
+
+-- Posts made by remote actors have no local author. remote_author is the
+-- name they are shown under, such as alice@example.com.
+ALTER TABLE posts ALTER COLUMN user_id DROP NOT NULL;
+ALTER TABLE posts ADD COLUMN remote_author VARCHAR(255);
+ALTER TABLE posts ADD COLUMN remote_actor TEXT;
+ALTER TABLE posts ADD COLUMN remote_object TEXT UNIQUE;
+ALTER TABLE posts ADD CHECK ((user_id IS NULL) = (remote_author IS NOT NULL));
@@ -0,0 +1,19 @@
+-- Archives of a user's personal data, built in the background when they ask
+-- for one. The archive is dropped once the download link expires.
+CREATE TABLE data_exports (
+	id SERIAL PRIMARY KEY,
+	user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	status VARCHAR(16) NOT NULL DEFAULT 'pending'
+		CHECK (status IN ('pending', 'building', 'ready', 'failed', 'expired')),
+	base_url TEXT NOT NULL,
+	archive BYTEA,
+	size INTEGER NOT NULL DEFAULT 0,
+	date_requested TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+	date_claimed TIMESTAMP WITH TIME ZONE,
+	date_completed TIMESTAMP WITH TIME ZONE,
+	expires TIMESTAMP WITH TIME ZONE,
+	last_error TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX data_exports_user ON data_exports (user_id, date_requested);
+CREATE INDEX data_exports_pending ON data_exports (date_requested) WHERE status IN ('pending', 'building');
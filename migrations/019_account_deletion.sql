@@ -0,0 +1,15 @@
+-- Accounts are deleted once deletion_scheduled passes, and until then their
+-- owner can cancel.
+ALTER TABLE users ADD COLUMN deletion_scheduled TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX users_deletion_scheduled ON users (deletion_scheduled) WHERE deletion_scheduled IS NOT NULL;
+
+-- Pages and posts of deleted accounts are moved to a single tombstone user so
+-- that what they contributed stays. Nobody can log in as it: its password is
+-- not a valid hash and it is suspended.
+ALTER TABLE users ADD COLUMN tombstone BOOLEAN NOT NULL DEFAULT false;
+
+CREATE UNIQUE INDEX users_tombstone ON users (tombstone) WHERE tombstone;
+
+INSERT INTO users (username, email, password, reset_required, suspended, digest_frequency, tombstone)
+	VALUES ('deleted user', 'deleted-user@invalid', '!', false, true, 'never', true);
@@ -0,0 +1,3 @@
+-- Imports skip pages that already exist with the same title, address and
+-- website, ignoring case and surrounding spaces.
+CREATE INDEX pages_import_key ON pages (lower(trim(title)), lower(trim(address)), lower(trim(website)));
@@ -0,0 +1,13 @@
+-- Where pages that were merged into another page used to be, so that old
+-- links and replies from other servers still reach the page they were merged
+-- into. Merging that page again moves its redirects along with it.
+CREATE TABLE page_redirects (
+	category_id INTEGER NOT NULL REFERENCES categories (id) ON DELETE CASCADE,
+	slug TEXT NOT NULL,
+	old_page_id INTEGER NOT NULL UNIQUE,
+	page_id INTEGER NOT NULL REFERENCES pages (id) ON DELETE CASCADE,
+	date_created TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+	PRIMARY KEY (category_id, slug)
+);
+
+CREATE INDEX page_redirects_page ON page_redirects (page_id);
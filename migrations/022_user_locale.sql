@@ -0,0 +1,4 @@
+-- The language each user picked in their settings. Empty means negotiate it
+-- from the browser's Accept-Language header.
+
+ALTER TABLE users ADD COLUMN locale VARCHAR(16) NOT NULL DEFAULT '';
@@ -0,0 +1,7 @@
+-- Digests are only sent to users who ask for them. Weekly used to be the
+-- default, so nobody who has it can be told apart from someone who chose it;
+-- everyone is opted out and can turn digests back on in their settings.
+
+ALTER TABLE users ALTER COLUMN digest_frequency SET DEFAULT 'never';
+
+UPDATE users SET digest_frequency = 'never' WHERE digest_frequency = 'weekly';
@@ -0,0 +1,35 @@
+-- Addresses and websites normalized the same way as common.NormalizeAddress
+-- and common.NormalizeWebsite, so that possible duplicates of a page can be
+-- looked up without reading every page. Keep them in step with those
+-- functions.
+CREATE FUNCTION normalize_address(address TEXT) RETURNS TEXT AS $$
+	SELECT coalesce(string_agg(
+		CASE word
+			WHEN 'apartment' THEN 'apt'
+			WHEN 'avenue' THEN 'ave'
+			WHEN 'boulevard' THEN 'blvd'
+			WHEN 'drive' THEN 'dr'
+			WHEN 'east' THEN 'e'
+			WHEN 'highway' THEN 'hwy'
+			WHEN 'lane' THEN 'ln'
+			WHEN 'north' THEN 'n'
+			WHEN 'place' THEN 'pl'
+			WHEN 'road' THEN 'rd'
+			WHEN 'south' THEN 's'
+			WHEN 'street' THEN 'st'
+			WHEN 'suite' THEN 'ste'
+			WHEN 'west' THEN 'w'
+			ELSE word
+		END,
+		' ' ORDER BY position
+	), '')
+	FROM regexp_split_to_table(lower(address), '[^[:alnum:]]+') WITH ORDINALITY AS words (word, position)
+	WHERE word <> '';
+$$ LANGUAGE SQL IMMUTABLE;
+
+CREATE FUNCTION normalize_website(website TEXT) RETURNS TEXT AS $$
+	SELECT rtrim(regexp_replace(lower(btrim(website, E' \t\r\n')), '^(https://)?(http://)?(www\.)?', ''), '/');
+$$ LANGUAGE SQL IMMUTABLE;
+
+CREATE INDEX pages_normalized_address ON pages (normalize_address(address));
+CREATE INDEX pages_normalized_website ON pages (normalize_website(website));
@@ -0,0 +1,18 @@
+-- Audit events keep the IP address and user agent of the request that
+-- caused them for a limited time. Clearing them is the one change allowed
+-- to an event; anything else is still refused.
+CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+	IF TG_OP = 'UPDATE'
+		AND NEW.ip_address = '' AND NEW.user_agent = ''
+		AND (NEW.id, NEW.date_created, NEW.action, NEW.actor_id, NEW.subject_user_id, NEW.details)
+			IS NOT DISTINCT FROM (OLD.id, OLD.date_created, OLD.action, OLD.actor_id, OLD.subject_user_id, OLD.details)
+	THEN
+		RETURN NEW;
+	END IF;
+
+	RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE INDEX audit_events_request_info ON audit_events (date_created) WHERE ip_address <> '' OR user_agent <> '';
@@ -0,0 +1,2 @@
+-- Pages near each other are looked up as possible duplicates.
+CREATE INDEX pages_location ON pages USING gist (location);
@@ -0,0 +1,62 @@
+package notifications
+
+import (
+	"html/template"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/comforme/comforme/common"
+	"github.com/comforme/comforme/databaseActions"
+	"github.com/comforme/comforme/i18n"
+	"github.com/comforme/comforme/templates"
+)
+
+var notificationsTemplate *template.Template
+
+func init() {
+	notificationsTemplate = template.Must(template.New("siteLayout").Funcs(i18n.Funcs).Parse(templates.SiteLayout))
+	template.Must(notificationsTemplate.New("nav").Parse(templates.NavBar))
+	template.Must(notificationsTemplate.New("content").Parse(notificationsTemplateText))
+}
+
+func NotificationsHandler(res http.ResponseWriter, req *http.Request, ps httprouter.Params, userInfo common.UserInfo) {
+	data := map[string]interface{}{}
+	data["siteName"] = common.SiteName
+	data["locale"] = userInfo.Locale
+	data["pageTitle"] = i18n.Translate(userInfo.Locale, "notifications.title")
+
+	var err error
+	data["notifications"], err = databaseActions.GetNotifications(userInfo.UserID)
+	if err != nil {
+		data["errorMsg"] = i18n.ErrorMessage(userInfo.Locale, err)
+	}
+
+	common.ExecTemplate(notificationsTemplate, res, data)
+}
+
+const notificationsTemplateText = `
+	<div class="content">
+		<div class="row">
+			<div class="columns">
+				<h1><i class="fi-mail"></i> {{t $.locale "notifications.title"}}</h1>{{if .errorMsg}}
+				<div class="alert-box alert">{{.errorMsg}}</div>{{end}}
+				<button class="button small secondary" onclick="markAllNotificationsRead()">{{t $.locale "notifications.mark_all_read"}}</button>
+			</div>
+		</div>
+		<div class="row">{{range .notifications}}
+			<div class="columns notification{{if not .Read}} unread{{end}}" id="notification-{{.Id}}">
+				<p>
+					<strong>{{if .Author}}{{.Author}}{{else}}{{t $.locale "feed.anonymous_member"}}{{end}}</strong>
+					{{t $.locale "feed.posted_on"}}
+					<a href="/page/{{.CategorySlug}}/{{.PageSlug}}#post-{{.PostID}}" onclick="return openNotification({{.Id}}, this.href)">{{.PageTitle}}</a>
+					<small>{{.Date.Format "2006-01-02 15:04"}}</small>{{if not .Read}}
+					<a class="tiny mark-read" onclick="markNotificationRead({{.Id}})" title="{{t $.locale "notifications.mark_read"}}"><i class="fi-check"></i></a>{{end}}
+				</p>
+			</div>{{else}}
+			<div class="columns">
+				<p>{{t $.locale "notifications.none"}}</p>
+			</div>{{end}}
+		</div>
+	</div>
+`
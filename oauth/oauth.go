@@ -0,0 +1,268 @@
+// Package oauth is an OAuth2 authorization server for the API. Only the
+// authorization code flow with PKCE (RFC 7636) is supported, for public
+// clients registered with the add-oauth-client command.
+package oauth
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/comforme/comforme/common"
+	"github.com/comforme/comforme/databaseActions"
+	"github.com/comforme/comforme/i18n"
+	"github.com/comforme/comforme/templates"
+)
+
+var authorizeTemplate *template.Template
+var errorTemplate *template.Template
+
+func init() {
+	authorizeTemplate = template.Must(template.New("siteLayout").Funcs(i18n.Funcs).Parse(templates.SiteLayout))
+	template.Must(authorizeTemplate.New("nav").Parse(templates.NavBar))
+	template.Must(authorizeTemplate.New("content").Parse(authorizeTemplateText))
+
+	errorTemplate = template.Must(template.New("siteLayout").Funcs(i18n.Funcs).Parse(templates.SiteLayout))
+	template.Must(errorTemplate.New("nav").Parse(templates.NavBar))
+	template.Must(errorTemplate.New("content").Parse(errorTemplateText))
+}
+
+// Message keys describing what each scope allows.
+var scopeDescriptions = map[string]string{
+	common.ScopeRead:  "oauth.scope_read",
+	common.ScopeWrite: "oauth.scope_write",
+}
+
+// An authorization request that passed validation.
+type authorizeRequest struct {
+	client        common.OAuthClient
+	scopes        []string
+	state         string
+	codeChallenge string
+}
+
+// Sends the user back to the client with the given query parameters.
+func redirectToClient(res http.ResponseWriter, req *http.Request, redirectURI string, params url.Values) {
+	target, err := url.Parse(redirectURI)
+	if err != nil {
+		http.Error(res, common.InvalidRedirectURI.Error(), http.StatusBadRequest)
+		return
+	}
+
+	query := target.Query()
+	for name, values := range params {
+		query[name] = values
+	}
+	target.RawQuery = query.Encode()
+	http.Redirect(res, req, target.String(), http.StatusFound)
+}
+
+// Checks the query parameters of an authorization request. Problems with the
+// client or redirect URI are shown to the user, since it would not be safe to
+// redirect, and the rest are sent back to the client.
+func parseAuthorizeRequest(res http.ResponseWriter, req *http.Request, locale string, data map[string]interface{}) (request authorizeRequest, ok bool) {
+	query := req.URL.Query()
+
+	client, err := databaseActions.GetOAuthClient(query.Get("client_id"))
+	if err != nil {
+		data["errorMsg"] = i18n.ErrorMessage(locale, err)
+		common.ExecTemplate(errorTemplate, res, data)
+		return request, false
+	}
+	if redirectURI := query.Get("redirect_uri"); redirectURI != "" && redirectURI != client.RedirectURI {
+		data["errorMsg"] = i18n.Translate(locale, "oauth.redirect_mismatch", client.Name)
+		common.ExecTemplate(errorTemplate, res, data)
+		return request, false
+	}
+
+	request.client = client
+	request.state = query.Get("state")
+	fail := func(code, description string) {
+		params := url.Values{"error": {code}, "error_description": {description}}
+		if request.state != "" {
+			params.Set("state", request.state)
+		}
+		redirectToClient(res, req, client.RedirectURI, params)
+	}
+
+	if query.Get("response_type") != "code" {
+		fail("unsupported_response_type", "Only the code response type is supported.")
+		return request, false
+	}
+
+	request.codeChallenge = query.Get("code_challenge")
+	if request.codeChallenge == "" || query.Get("code_challenge_method") != "S256" {
+		fail("invalid_request", "A code_challenge with code_challenge_method S256 is required.")
+		return request, false
+	}
+
+	scope := query.Get("scope")
+	if scope == "" {
+		scope = common.ScopeRead
+	}
+	request.scopes, err = common.ParseScopes(scope)
+	if err != nil {
+		fail("invalid_scope", err.Error())
+		return request, false
+	}
+
+	return request, true
+}
+
+// Ties the consent form to the user's session, so that other sites cannot
+// submit it for them.
+func consentToken(userInfo common.UserInfo, client common.OAuthClient) string {
+	return common.HashToken(userInfo.SessionID + " " + client.ClientID)
+}
+
+func AuthorizeHandler(res http.ResponseWriter, req *http.Request, ps httprouter.Params, userInfo common.UserInfo) {
+	data := map[string]interface{}{}
+	data["siteName"] = common.SiteName
+	data["locale"] = userInfo.Locale
+	data["pageTitle"] = i18n.Translate(userInfo.Locale, "oauth.title")
+
+	request, ok := parseAuthorizeRequest(res, req, userInfo.Locale, data)
+	if !ok {
+		return
+	}
+
+	if req.Method == "POST" {
+		consent := req.PostFormValue("consent")
+		if subtle.ConstantTimeCompare([]byte(consent), []byte(consentToken(userInfo, request.client))) != 1 {
+			data["errorMsg"] = i18n.ErrorMessage(userInfo.Locale, common.InvalidLink)
+			common.ExecTemplate(errorTemplate, res, data)
+			return
+		}
+
+		params := url.Values{}
+		if request.state != "" {
+			params.Set("state", request.state)
+		}
+
+		if req.PostFormValue("decision") != "allow" {
+			params.Set("error", "access_denied")
+			redirectToClient(res, req, request.client.RedirectURI, params)
+			return
+		}
+
+		code, err := databaseActions.AuthorizeOAuthClient(userInfo, request.client, request.scopes, request.codeChallenge)
+		if err != nil {
+			log.Println("Error authorizing OAuth client:", err)
+			params.Set("error", "server_error")
+		} else {
+			params.Set("code", code)
+		}
+		redirectToClient(res, req, request.client.RedirectURI, params)
+		return
+	}
+
+	scopes := []string{}
+	for _, scope := range request.scopes {
+		scopes = append(scopes, i18n.Translate(userInfo.Locale, scopeDescriptions[scope]))
+	}
+
+	data["formAction"] = req.URL.RequestURI()
+	data["clientName"] = request.client.Name
+	if redirectURI, err := url.Parse(request.client.RedirectURI); err == nil {
+		data["redirectHost"] = redirectURI.Host
+	}
+	data["scopes"] = scopes
+	data["consent"] = consentToken(userInfo, request.client)
+	data["username"] = userInfo.Username
+	common.ExecTemplate(authorizeTemplate, res, data)
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	Scope       string `json:"scope"`
+}
+
+type tokenError struct {
+	Error       string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+}
+
+func writeTokenJSON(res http.ResponseWriter, status int, value interface{}) {
+	encoded, _ := json.Marshal(value)
+	res.Header().Set("Content-Type", "application/json; charset=utf-8")
+	res.Header().Set("Cache-Control", "no-store")
+	res.Header().Set("Pragma", "no-cache")
+	res.WriteHeader(status)
+	fmt.Fprintln(res, string(encoded))
+}
+
+// Exchanges an authorization code for an access token (RFC 6749 section 4.1.3).
+func TokenHandler(res http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	if req.PostFormValue("grant_type") != "authorization_code" {
+		writeTokenJSON(res, http.StatusBadRequest, tokenError{"unsupported_grant_type", "Only authorization_code is supported."})
+		return
+	}
+
+	code := req.PostFormValue("code")
+	clientID := req.PostFormValue("client_id")
+	if code == "" || clientID == "" {
+		writeTokenJSON(res, http.StatusBadRequest, tokenError{"invalid_request", "code and client_id are required."})
+		return
+	}
+
+	token, scopes, err := databaseActions.ExchangeOAuthCode(
+		clientID,
+		code,
+		req.PostFormValue("redirect_uri"),
+		req.PostFormValue("code_verifier"),
+	)
+	switch err {
+	case nil:
+		writeTokenJSON(res, http.StatusOK, tokenResponse{token, "Bearer", strings.Join(scopes, " ")})
+	case common.OAuthClientNotFound:
+		writeTokenJSON(res, http.StatusUnauthorized, tokenError{"invalid_client", err.Error()})
+	case common.InvalidCodeVerifier:
+		writeTokenJSON(res, http.StatusBadRequest, tokenError{"invalid_request", err.Error()})
+	case common.InvalidGrant:
+		writeTokenJSON(res, http.StatusBadRequest, tokenError{"invalid_grant", err.Error()})
+	default:
+		writeTokenJSON(res, http.StatusInternalServerError, tokenError{"server_error", err.Error()})
+	}
+}
+
+const authorizeTemplateText = `
+	<div class="content">
+		<div class="row">
+			<div class="large-6 medium-8 columns">
+				<h1><i class="fi-key"></i> {{t $.locale "oauth.heading" .clientName}}</h1>
+				<p><strong>{{.clientName}}</strong> {{t $.locale "oauth.would_like"}}</p>
+				<ul>{{range .scopes}}
+					<li>{{.}}</li>{{end}}
+				</ul>
+				<p>
+					{{t $.locale "oauth.logged_in_as" .username .redirectHost}}
+					<a href="/settings">{{t $.locale "oauth.settings"}}</a>.
+				</p>
+				<form action="{{.formAction}}" method="post">
+					<input type="hidden" name="consent" value="{{.consent}}">
+					<button type="submit" name="decision" value="allow">{{t $.locale "oauth.allow"}}</button>
+					<button type="submit" name="decision" value="deny" class="secondary">{{t $.locale "oauth.deny"}}</button>
+				</form>
+			</div>
+		</div>
+	</div>
+`
+
+const errorTemplateText = `
+	<div class="content">
+		<div class="row">
+			<div class="columns">
+				<h1>{{t $.locale "oauth.title"}}</h1>
+				<div class="alert-box alert">{{.errorMsg}}</div>
+			</div>
+		</div>
+	</div>
+`
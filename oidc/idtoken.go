@@ -0,0 +1,211 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/comforme/comforme/common"
+)
+
+// How far the provider's clock may be from ours.
+const clockSkew = time.Minute
+
+// A public key from the provider's JWKS (RFC 7517).
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+
+	// EC
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+type claims struct {
+	Issuer        string      `json:"iss"`
+	Subject       string      `json:"sub"`
+	Audience      audience    `json:"aud"`
+	AuthorizedBy  string      `json:"azp"`
+	Expires       float64     `json:"exp"`
+	IssuedAt      float64     `json:"iat"`
+	Nonce         string      `json:"nonce"`
+	Email         string      `json:"email"`
+	EmailVerified interface{} `json:"email_verified"`
+}
+
+// The aud claim is either a string or a list of them.
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*a = audience(list)
+	return nil
+}
+
+func (a audience) contains(clientID string) bool {
+	for _, aud := range a {
+		if aud == clientID {
+			return true
+		}
+	}
+	return false
+}
+
+func decodeSegment(segment string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(segment, "="))
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	b, err := decodeSegment(value)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// Checks a signature over signed. Only RS256 and ES256 are accepted, which
+// rules out unsigned tokens and tokens "signed" with a public key as an HMAC
+// secret.
+func verifySignature(alg string, key jsonWebKey, signed, signature []byte) error {
+	hash := sha256.Sum256(signed)
+
+	switch alg {
+	case "RS256":
+		if key.Kty != "RSA" {
+			return errors.New("key type does not match algorithm")
+		}
+		n, err := decodeBigInt(key.N)
+		if err != nil {
+			return err
+		}
+		e, err := decodeBigInt(key.E)
+		if err != nil {
+			return err
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return errors.New("invalid RSA exponent")
+		}
+		publicKey := &rsa.PublicKey{N: n, E: int(e.Int64())}
+		return rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, hash[:], signature)
+	case "ES256":
+		if key.Kty != "EC" || key.Crv != "P-256" {
+			return errors.New("key type does not match algorithm")
+		}
+		x, err := decodeBigInt(key.X)
+		if err != nil {
+			return err
+		}
+		y, err := decodeBigInt(key.Y)
+		if err != nil {
+			return err
+		}
+		if len(signature) != 64 {
+			return errors.New("invalid ES256 signature length")
+		}
+		publicKey := &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(publicKey, hash[:], r, s) {
+			return errors.New("invalid signature")
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported signing algorithm %q", alg)
+}
+
+// Verifies an ID token as described in OpenID Connect Core section 3.1.3.7
+// and returns who it identifies.
+func (p *Provider) VerifyIDToken(idToken, nonce string) (identity common.ExternalIdentity, err error) {
+	parts := strings.Split(idToken, ".")
+	if len(parts) != 3 {
+		return identity, errors.New("malformed ID token")
+	}
+
+	headerJSON, err := decodeSegment(parts[0])
+	if err != nil {
+		return identity, err
+	}
+	var h header
+	if err = json.Unmarshal(headerJSON, &h); err != nil {
+		return identity, err
+	}
+
+	signature, err := decodeSegment(parts[2])
+	if err != nil {
+		return identity, err
+	}
+	key, err := p.key(h.Kid)
+	if err != nil {
+		return identity, err
+	}
+	err = verifySignature(h.Alg, key, []byte(parts[0]+"."+parts[1]), signature)
+	if err != nil {
+		return identity, err
+	}
+
+	payload, err := decodeSegment(parts[1])
+	if err != nil {
+		return identity, err
+	}
+	var c claims
+	if err = json.Unmarshal(payload, &c); err != nil {
+		return identity, err
+	}
+
+	now := time.Now()
+	switch {
+	case strings.TrimRight(c.Issuer, "/") != p.Issuer:
+		return identity, fmt.Errorf("ID token issuer is %s", c.Issuer)
+	case !c.Audience.contains(p.ClientID):
+		return identity, errors.New("ID token is for another client")
+	case len(c.Audience) > 1 && c.AuthorizedBy != p.ClientID:
+		return identity, errors.New("ID token was issued to another client")
+	case now.After(time.Unix(int64(c.Expires), 0).Add(clockSkew)):
+		return identity, errors.New("ID token has expired")
+	case time.Unix(int64(c.IssuedAt), 0).After(now.Add(clockSkew)):
+		return identity, errors.New("ID token was issued in the future")
+	case subtle.ConstantTimeCompare([]byte(c.Nonce), []byte(nonce)) != 1:
+		return identity, errors.New("ID token nonce does not match")
+	case c.Subject == "":
+		return identity, errors.New("ID token has no subject")
+	}
+
+	// Some providers send email_verified as a string
+	verified := c.EmailVerified == true || c.EmailVerified == "true"
+
+	return common.ExternalIdentity{
+		Provider:      p.Name,
+		Subject:       c.Subject,
+		Email:         c.Email,
+		EmailVerified: verified && c.Email != "",
+	}, nil
+}
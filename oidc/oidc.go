@@ -0,0 +1,184 @@
+// Package oidc lets users log in with external OpenID Connect providers,
+// using the authorization code flow with PKCE.
+package oidc
+
+import (
+	"crypto/subtle"
+	"html/template"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/comforme/comforme/common"
+	"github.com/comforme/comforme/databaseActions"
+	"github.com/comforme/comforme/i18n"
+	"github.com/comforme/comforme/templates"
+)
+
+const (
+	stateCookie     = "oidc_state"
+	stateCookiePath = "/login/oidc/"
+	stateCookieAge  = 10 * 60
+)
+
+var messageTemplate *template.Template
+
+func init() {
+	messageTemplate = template.Must(template.New("siteLayout").Funcs(i18n.Funcs).Parse(templates.SiteLayout))
+	template.Must(messageTemplate.New("nav").Parse(templates.NavlessBar))
+	template.Must(messageTemplate.New("wizardContent").Parse(messageTemplateText))
+	template.Must(messageTemplate.New("content").Parse(templates.HashLink))
+}
+
+func showError(res http.ResponseWriter, req *http.Request, err error) {
+	locale := i18n.RequestLocale(req)
+	data := map[string]interface{}{}
+	data["siteName"] = common.SiteName
+	data["locale"] = locale
+	data["pageTitle"] = i18n.Translate(locale, "login.log_in")
+	data["errorMsg"] = i18n.ErrorMessage(locale, err)
+	common.ExecTemplate(messageTemplate, res, data)
+}
+
+func redirectURI(req *http.Request, provider *Provider) string {
+	return common.GetBaseURL(req) + stateCookiePath + provider.Name + "/callback"
+}
+
+// Only paths on this site may be returned to, so the login cannot be used to
+// send users elsewhere.
+func localPath(path string) string {
+	if !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") || strings.HasPrefix(path, "/\\") {
+		return "/"
+	}
+	return path
+}
+
+// Sends the user to the provider. The state is also kept in a cookie, so that
+// the login can only be finished in the browser that started it.
+func startLogin(res http.ResponseWriter, req *http.Request, provider *Provider, linkUserID int, returnTo string) {
+	state, login, err := databaseActions.StartOIDCLogin(provider.Name, linkUserID, returnTo)
+	if err != nil {
+		showError(res, req, err)
+		return
+	}
+
+	authURL, err := provider.AuthURL(redirectURI(req, provider), state, login.Nonce, login.CodeVerifier)
+	if err != nil {
+		log.Printf("Error discovering OpenID Connect provider %s: %s\n", provider.Name, err.Error())
+		showError(res, req, common.ExternalLoginFailed)
+		return
+	}
+
+	http.SetCookie(res, &http.Cookie{
+		Name:     stateCookie,
+		Value:    state,
+		Path:     stateCookiePath,
+		MaxAge:   stateCookieAge,
+		HttpOnly: true,
+	})
+	http.Redirect(res, req, authURL, http.StatusFound)
+}
+
+// Starts logging in with a provider. The return parameter is the page to go
+// to afterwards.
+func LoginHandler(res http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	res.Header().Set("cache-control", "private, max-age=0, no-cache")
+
+	provider := GetProvider(ps.ByName("provider"))
+	if provider == nil {
+		showError(res, req, common.UnknownProvider)
+		return
+	}
+
+	startLogin(res, req, provider, 0, localPath(req.URL.Query().Get("return")))
+}
+
+// Starts linking a provider's account to the logged in user.
+func LinkHandler(res http.ResponseWriter, req *http.Request, ps httprouter.Params, userInfo common.UserInfo) {
+	provider := GetProvider(ps.ByName("provider"))
+	if provider == nil {
+		showError(res, req, common.UnknownProvider)
+		return
+	}
+
+	startLogin(res, req, provider, userInfo.UserID, "/settings")
+}
+
+// Where the provider sends the user back to.
+func CallbackHandler(res http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	res.Header().Set("cache-control", "private, max-age=0, no-cache")
+
+	provider := GetProvider(ps.ByName("provider"))
+	if provider == nil {
+		showError(res, req, common.UnknownProvider)
+		return
+	}
+
+	query := req.URL.Query()
+	state := query.Get("state")
+	cookie, err := req.Cookie(stateCookie)
+	if err != nil || state == "" || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(state)) != 1 {
+		showError(res, req, common.InvalidLink)
+		return
+	}
+	http.SetCookie(res, &http.Cookie{Name: stateCookie, Path: stateCookiePath, MaxAge: -1})
+
+	login, err := databaseActions.TakeOIDCLogin(state)
+	if err != nil || login.Provider != provider.Name {
+		showError(res, req, common.InvalidLink)
+		return
+	}
+
+	if errorCode := query.Get("error"); errorCode != "" {
+		log.Printf("OpenID Connect provider %s returned error %s: %s\n", provider.Name, errorCode, query.Get("error_description"))
+		showError(res, req, common.ExternalLoginFailed)
+		return
+	}
+
+	idToken, err := provider.Exchange(query.Get("code"), redirectURI(req, provider), login.CodeVerifier)
+	if err != nil {
+		log.Printf("Error exchanging code with OpenID Connect provider %s: %s\n", provider.Name, err.Error())
+		showError(res, req, common.ExternalLoginFailed)
+		return
+	}
+
+	identity, err := provider.VerifyIDToken(idToken, login.Nonce)
+	if err != nil {
+		log.Printf("Invalid ID token from OpenID Connect provider %s: %s\n", provider.Name, err.Error())
+		showError(res, req, common.ExternalLoginFailed)
+		return
+	}
+
+	if login.LinkUserID != 0 {
+		err = databaseActions.LinkIdentity(login.LinkUserID, identity, common.GetRequestInfo(req))
+		if err != nil {
+			showError(res, req, err)
+			return
+		}
+		http.Redirect(res, req, login.ReturnTo, http.StatusFound)
+		return
+	}
+
+	sessionid, err := databaseActions.ExternalLogin(identity, common.GetRequestInfo(req))
+	if err == common.UserNotFound {
+		// The provider has verified the email, so skip straight to the
+		// second step of registration
+		registerURL, err := databaseActions.VerifiedRegistrationLink(identity.Email)
+		if err != nil {
+			showError(res, req, err)
+			return
+		}
+		http.Redirect(res, req, registerURL, http.StatusFound)
+		return
+	} else if err != nil {
+		showError(res, req, err)
+		return
+	}
+
+	common.SetSessionCookie(res, sessionid)
+	http.Redirect(res, req, login.ReturnTo, http.StatusFound)
+}
+
+const messageTemplateText = `<a href="/" class="button">{{t $.locale "oidc.back"}}</a>`
@@ -0,0 +1,322 @@
+package oidc
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	httpTimeout     = 10 * time.Second
+	maxResponseSize = 1 << 20
+	jwksMinRefresh  = time.Minute
+)
+
+var httpClient = &http.Client{Timeout: httpTimeout}
+
+// An OpenID Connect provider. Make one with NewProvider.
+type Provider struct {
+	Name         string
+	Label        string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+
+	client      *http.Client
+	mutex       sync.Mutex
+	metadata    *metadata
+	keys        map[string]jsonWebKey
+	keysFetched time.Time
+}
+
+// How to reach a provider and who we are to it.
+type Config struct {
+	Name         string
+	Label        string // Shown on the login page, Name if empty
+	Issuer       string
+	ClientID     string
+	ClientSecret string // Only for confidential clients
+
+	// The provider's endpoints. They are found through discovery on the
+	// issuer unless all three are given.
+	AuthorizationEndpoint string
+	TokenEndpoint         string
+	JWKSURI               string
+
+	Client *http.Client // Defaults to one with a timeout
+}
+
+// The parts of the discovery document that are used.
+type metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+func NewProvider(config Config) (*Provider, error) {
+	if config.Name == "" || config.Issuer == "" || config.ClientID == "" {
+		return nil, errors.New("a name, issuer and client ID are required")
+	}
+
+	provider := &Provider{
+		Name:         strings.ToLower(config.Name),
+		Label:        config.Label,
+		Issuer:       strings.TrimRight(config.Issuer, "/"),
+		ClientID:     config.ClientID,
+		ClientSecret: config.ClientSecret,
+		client:       config.Client,
+	}
+	if provider.Label == "" {
+		provider.Label = config.Name
+	}
+	if provider.client == nil {
+		provider.client = httpClient
+	}
+	if config.AuthorizationEndpoint != "" && config.TokenEndpoint != "" && config.JWKSURI != "" {
+		provider.metadata = &metadata{
+			Issuer:                provider.Issuer,
+			AuthorizationEndpoint: config.AuthorizationEndpoint,
+			TokenEndpoint:         config.TokenEndpoint,
+			JWKSURI:               config.JWKSURI,
+		}
+	}
+	return provider, nil
+}
+
+var providers []*Provider
+
+func init() {
+	providers = ProvidersFromEnv()
+}
+
+// Reads the providers from the environment:
+//
+//	OIDC_PROVIDERS=<name> <name> ...
+//	OIDC_<NAME>_ISSUER=https://issuer.example.com
+//	OIDC_<NAME>_CLIENT_ID=...
+//	OIDC_<NAME>_CLIENT_SECRET=... (optional, for confidential clients)
+//	OIDC_<NAME>_LABEL=Example (optional, shown on the login page)
+//	OIDC_<NAME>_AUTHORIZATION_ENDPOINT, OIDC_<NAME>_TOKEN_ENDPOINT and
+//	OIDC_<NAME>_JWKS_URI (optional, for providers without discovery)
+func ProvidersFromEnv() (list []*Provider) {
+	for _, name := range strings.Fields(os.Getenv("OIDC_PROVIDERS")) {
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		provider, err := NewProvider(Config{
+			Name:                  name,
+			Label:                 os.Getenv(prefix + "LABEL"),
+			Issuer:                os.Getenv(prefix + "ISSUER"),
+			ClientID:              os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret:          os.Getenv(prefix + "CLIENT_SECRET"),
+			AuthorizationEndpoint: os.Getenv(prefix + "AUTHORIZATION_ENDPOINT"),
+			TokenEndpoint:         os.Getenv(prefix + "TOKEN_ENDPOINT"),
+			JWKSURI:               os.Getenv(prefix + "JWKS_URI"),
+		})
+		if err != nil {
+			log.Printf("Skipping OpenID Connect provider %s: %sISSUER and %sCLIENT_ID are required.\n", name, prefix, prefix)
+			continue
+		}
+		list = append(list, provider)
+	}
+	return
+}
+
+// Replaces the configured providers.
+func SetProviders(list []*Provider) {
+	providers = list
+}
+
+// The configured providers, in the order they were listed.
+func Providers() []*Provider {
+	return providers
+}
+
+func GetProvider(name string) *Provider {
+	for _, provider := range providers {
+		if provider.Name == name {
+			return provider
+		}
+	}
+	return nil
+}
+
+func (p *Provider) getJSON(target string, value interface{}) error {
+	res, err := p.client.Get(target)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", target, res.Status)
+	}
+	return json.NewDecoder(io.LimitReader(res.Body, maxResponseSize)).Decode(value)
+}
+
+// Fetches the discovery document the first time it is needed.
+func (p *Provider) discover() (*metadata, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.metadata != nil {
+		return p.metadata, nil
+	}
+
+	var m metadata
+	err := p.getJSON(p.Issuer+"/.well-known/openid-configuration", &m)
+	if err != nil {
+		return nil, err
+	}
+
+	// The issuer in the document has to be the one configured, or tokens
+	// could be accepted from somewhere else
+	if strings.TrimRight(m.Issuer, "/") != p.Issuer {
+		return nil, fmt.Errorf("discovery document is for issuer %s, not %s", m.Issuer, p.Issuer)
+	}
+	if m.AuthorizationEndpoint == "" || m.TokenEndpoint == "" || m.JWKSURI == "" {
+		return nil, errors.New("discovery document is missing endpoints")
+	}
+
+	p.metadata = &m
+	return p.metadata, nil
+}
+
+// Looks up a signing key, fetching the key set again if the provider may
+// have rotated its keys.
+func (p *Provider) key(kid string) (jsonWebKey, error) {
+	m, err := p.discover()
+	if err != nil {
+		return jsonWebKey{}, err
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	key, ok := p.findKey(kid)
+	if ok {
+		return key, nil
+	}
+	if time.Since(p.keysFetched) < jwksMinRefresh {
+		return jsonWebKey{}, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	err = p.getJSON(m.JWKSURI, &set)
+	if err != nil {
+		return jsonWebKey{}, err
+	}
+
+	p.keys = map[string]jsonWebKey{}
+	for _, key := range set.Keys {
+		if key.Use == "" || key.Use == "sig" {
+			p.keys[key.Kid] = key
+		}
+	}
+	p.keysFetched = time.Now()
+
+	key, ok = p.findKey(kid)
+	if !ok {
+		return jsonWebKey{}, fmt.Errorf("unknown signing key %q", kid)
+	}
+	return key, nil
+}
+
+// Tokens without a key ID can only be checked if there is a single key.
+func (p *Provider) findKey(kid string) (jsonWebKey, bool) {
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+	key, ok := p.keys[kid]
+	return key, ok
+}
+
+// The URL to send the user to, asking for an authorization code.
+func (p *Provider) AuthURL(redirectURI, state, nonce, codeVerifier string) (string, error) {
+	m, err := p.discover()
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256([]byte(codeVerifier))
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.ClientID},
+		"redirect_uri":          {redirectURI},
+		"scope":                 {"openid email"},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(sum[:])},
+		"code_challenge_method": {"S256"},
+	}
+
+	separator := "?"
+	if strings.Contains(m.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return m.AuthorizationEndpoint + separator + params.Encode(), nil
+}
+
+// Exchanges an authorization code for the user's ID token.
+func (p *Provider) Exchange(code, redirectURI, codeVerifier string) (idToken string, err error) {
+	m, err := p.discover()
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {redirectURI},
+		"code_verifier": {codeVerifier},
+	}
+	if p.ClientSecret == "" {
+		form.Set("client_id", p.ClientID)
+	}
+
+	req, err := http.NewRequest("POST", m.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.ClientID), url.QueryEscape(p.ClientSecret))
+	}
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	var body struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	err = json.NewDecoder(io.LimitReader(res.Body, maxResponseSize)).Decode(&body)
+	if err != nil {
+		return "", fmt.Errorf("token endpoint returned %s: %s", res.Status, err)
+	}
+	if res.StatusCode != http.StatusOK || body.Error != "" {
+		return "", fmt.Errorf("token endpoint returned %s: %s %s", res.Status, body.Error, body.ErrorDescription)
+	}
+	if body.IDToken == "" {
+		return "", errors.New("token endpoint did not return an ID token")
+	}
+	return body.IDToken, nil
+}
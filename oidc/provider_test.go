@@ -0,0 +1,343 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	testClientID = "comforme"
+	testKeyID    = "key-1"
+	testNonce    = "nonce-1"
+	testVerifier = "a-code-verifier-that-is-long-enough-for-pkce-0123456789"
+)
+
+// A stand-in identity provider. It hands out one authorization code per
+// AuthURL it is shown and signs ID tokens with claims set by the test.
+type fakeIdP struct {
+	t      *testing.T
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mutex         sync.Mutex
+	challenges    map[string]string // Code challenges by code
+	discoveryHits int
+	claims        map[string]interface{}
+	issuer        string // Issuer in the discovery document, the server's if empty
+}
+
+func newFakeIdP(t *testing.T) *fakeIdP {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	idp := &fakeIdP{t: t, key: key, challenges: map[string]string{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", idp.discovery)
+	mux.HandleFunc("/jwks", idp.jwks)
+	mux.HandleFunc("/token", idp.token)
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+func (idp *fakeIdP) discovery(res http.ResponseWriter, req *http.Request) {
+	idp.mutex.Lock()
+	idp.discoveryHits++
+	issuer := idp.issuer
+	idp.mutex.Unlock()
+
+	if issuer == "" {
+		issuer = idp.server.URL
+	}
+	json.NewEncoder(res).Encode(map[string]string{
+		"issuer":                 issuer,
+		"authorization_endpoint": idp.server.URL + "/authorize",
+		"token_endpoint":         idp.server.URL + "/token",
+		"jwks_uri":               idp.server.URL + "/jwks",
+	})
+}
+
+func (idp *fakeIdP) jwks(res http.ResponseWriter, req *http.Request) {
+	json.NewEncoder(res).Encode(map[string]interface{}{
+		"keys": []map[string]string{{
+			"kid": testKeyID,
+			"kty": "RSA",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(idp.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(idp.key.E)).Bytes()),
+		}},
+	})
+}
+
+// Stands in for the user logging in: reads the code challenge from an
+// authorization URL and returns the code the provider would redirect with.
+func (idp *fakeIdP) authorize(authURL string) (code string) {
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		idp.t.Fatal(err)
+	}
+	query := parsed.Query()
+	if query.Get("code_challenge_method") != "S256" {
+		idp.t.Fatalf("got code challenge method %q", query.Get("code_challenge_method"))
+	}
+
+	idp.mutex.Lock()
+	defer idp.mutex.Unlock()
+	code = "code-" + query.Get("state")
+	idp.challenges[code] = query.Get("code_challenge")
+	return
+}
+
+func (idp *fakeIdP) token(res http.ResponseWriter, req *http.Request) {
+	req.ParseForm()
+	tokenError := func(code string) {
+		res.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(res).Encode(map[string]string{"error": code})
+	}
+	if req.PostForm.Get("grant_type") != "authorization_code" || req.PostForm.Get("client_id") != testClientID {
+		tokenError("invalid_request")
+		return
+	}
+
+	idp.mutex.Lock()
+	challenge, ok := idp.challenges[req.PostForm.Get("code")]
+	delete(idp.challenges, req.PostForm.Get("code"))
+	idp.mutex.Unlock()
+
+	sum := sha256.Sum256([]byte(req.PostForm.Get("code_verifier")))
+	if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != challenge {
+		tokenError("invalid_grant")
+		return
+	}
+	json.NewEncoder(res).Encode(map[string]string{"id_token": idp.sign("RS256", idp.claims)})
+}
+
+// Valid claims for the test client, to be changed by each test.
+func (idp *fakeIdP) validClaims() map[string]interface{} {
+	now := time.Now()
+	return map[string]interface{}{
+		"iss":            idp.server.URL,
+		"sub":            "user-1",
+		"aud":            testClientID,
+		"exp":            now.Add(time.Hour).Unix(),
+		"iat":            now.Unix(),
+		"nonce":          testNonce,
+		"email":          "user@example.com",
+		"email_verified": true,
+	}
+}
+
+// Makes an ID token. Anything but RS256 is signed as HS256 with the public
+// key, as an attacker would.
+func (idp *fakeIdP) sign(alg string, claims map[string]interface{}) string {
+	encode := func(value interface{}) string {
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			idp.t.Fatal(err)
+		}
+		return base64.RawURLEncoding.EncodeToString(encoded)
+	}
+	signed := encode(map[string]string{"alg": alg, "kid": testKeyID}) + "." + encode(claims)
+
+	var signature []byte
+	switch alg {
+	case "RS256":
+		hash := sha256.Sum256([]byte(signed))
+		var err error
+		signature, err = rsa.SignPKCS1v15(rand.Reader, idp.key, crypto.SHA256, hash[:])
+		if err != nil {
+			idp.t.Fatal(err)
+		}
+	case "none":
+	default:
+		mac := hmac.New(sha256.New, idp.key.N.Bytes())
+		mac.Write([]byte(signed))
+		signature = mac.Sum(nil)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func (idp *fakeIdP) provider(t *testing.T) *Provider {
+	provider, err := NewProvider(Config{
+		Name:     "Fake",
+		Issuer:   idp.server.URL + "/",
+		ClientID: testClientID,
+		Client:   idp.server.Client(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return provider
+}
+
+func TestNewProviderRequiresIssuerAndClient(t *testing.T) {
+	for _, config := range []Config{
+		{Name: "fake", ClientID: testClientID},
+		{Name: "fake", Issuer: "https://idp.example.com"},
+		{Issuer: "https://idp.example.com", ClientID: testClientID},
+	} {
+		if _, err := NewProvider(config); err == nil {
+			t.Errorf("%+v was accepted", config)
+		}
+	}
+}
+
+func TestDiscovery(t *testing.T) {
+	idp := newFakeIdP(t)
+	provider := idp.provider(t)
+	if provider.Name != "fake" || provider.Label != "Fake" {
+		t.Errorf("got name %q and label %q", provider.Name, provider.Label)
+	}
+
+	for i := 0; i < 2; i++ {
+		authURL, err := provider.AuthURL("https://comfor.me/callback", "state-1", testNonce, testVerifier)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(authURL, idp.server.URL+"/authorize?") {
+			t.Errorf("got authorization URL %s", authURL)
+		}
+	}
+	if idp.discoveryHits != 1 {
+		t.Errorf("discovery document fetched %d times, want once", idp.discoveryHits)
+	}
+}
+
+func TestDiscoveryRejectsOtherIssuer(t *testing.T) {
+	idp := newFakeIdP(t)
+	idp.issuer = "https://attacker.example.com"
+
+	_, err := idp.provider(t).AuthURL("https://comfor.me/callback", "state-1", testNonce, testVerifier)
+	if err == nil {
+		t.Error("discovery document for another issuer was accepted")
+	}
+}
+
+func TestConfiguredEndpointsSkipDiscovery(t *testing.T) {
+	idp := newFakeIdP(t)
+	idp.claims = idp.validClaims()
+	provider, err := NewProvider(Config{
+		Name:                  "fake",
+		Issuer:                idp.server.URL,
+		ClientID:              testClientID,
+		AuthorizationEndpoint: idp.server.URL + "/authorize",
+		TokenEndpoint:         idp.server.URL + "/token",
+		JWKSURI:               idp.server.URL + "/jwks",
+		Client:                idp.server.Client(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authURL, err := provider.AuthURL("https://comfor.me/callback", "state-1", testNonce, testVerifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idToken, err := provider.Exchange(idp.authorize(authURL), "https://comfor.me/callback", testVerifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = provider.VerifyIDToken(idToken, testNonce); err != nil {
+		t.Error(err)
+	}
+	if idp.discoveryHits != 0 {
+		t.Errorf("discovery document fetched %d times", idp.discoveryHits)
+	}
+}
+
+func TestExchange(t *testing.T) {
+	idp := newFakeIdP(t)
+	idp.claims = idp.validClaims()
+	provider := idp.provider(t)
+
+	authURL, err := provider.AuthURL("https://comfor.me/callback", "state-1", testNonce, testVerifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := idp.authorize(authURL)
+
+	idToken, err := provider.Exchange(code, "https://comfor.me/callback", testVerifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	identity, err := provider.VerifyIDToken(idToken, testNonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if identity.Provider != "fake" || identity.Subject != "user-1" || identity.Email != "user@example.com" || !identity.EmailVerified {
+		t.Errorf("got identity %+v", identity)
+	}
+
+	if _, err = provider.Exchange(code, "https://comfor.me/callback", testVerifier); err == nil {
+		t.Error("a code was exchanged twice")
+	}
+}
+
+func TestExchangeRequiresCodeVerifier(t *testing.T) {
+	idp := newFakeIdP(t)
+	idp.claims = idp.validClaims()
+	provider := idp.provider(t)
+
+	authURL, err := provider.AuthURL("https://comfor.me/callback", "state-1", testNonce, testVerifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = provider.Exchange(idp.authorize(authURL), "https://comfor.me/callback", "another-verifier")
+	if err == nil {
+		t.Error("code exchanged with the wrong code verifier")
+	}
+}
+
+func TestVerifyIDTokenRejectsBadTokens(t *testing.T) {
+	idp := newFakeIdP(t)
+	provider := idp.provider(t)
+
+	valid := idp.sign("RS256", idp.validClaims())
+	if _, err := provider.VerifyIDToken(valid, testNonce); err != nil {
+		t.Fatalf("valid token rejected: %s", err)
+	}
+
+	withClaim := func(name string, value interface{}) string {
+		claims := idp.validClaims()
+		claims[name] = value
+		return idp.sign("RS256", claims)
+	}
+	tests := []struct {
+		name  string
+		token string
+		nonce string
+	}{
+		{"another issuer", withClaim("iss", "https://attacker.example.com"), testNonce},
+		{"another audience", withClaim("aud", "another-client"), testNonce},
+		{"several audiences", withClaim("aud", []string{testClientID, "another-client"}), testNonce},
+		{"expired", withClaim("exp", time.Now().Add(-time.Hour).Unix()), testNonce},
+		{"issued in the future", withClaim("iat", time.Now().Add(time.Hour).Unix()), testNonce},
+		{"another nonce", valid, "another-nonce"},
+		{"no nonce", withClaim("nonce", ""), testNonce},
+		{"no subject", withClaim("sub", ""), testNonce},
+		{"unsigned", idp.sign("none", idp.validClaims()), testNonce},
+		{"signed with the public key", idp.sign("HS256", idp.validClaims()), testNonce},
+		{"tampered with", strings.Replace(valid, ".", ".e30", 1), testNonce},
+		{"malformed", "not-a-token", testNonce},
+	}
+	for _, test := range tests {
+		if _, err := provider.VerifyIDToken(test.token, test.nonce); err == nil {
+			t.Errorf("%s: token accepted", test.name)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package pages
+
+import (
+	"html/template"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/comforme/comforme/common"
+	"github.com/comforme/comforme/databaseActions"
+	"github.com/comforme/comforme/i18n"
+	"github.com/comforme/comforme/templates"
+)
+
+var editPageTemplate *template.Template
+
+func init() {
+	editPageTemplate = template.Must(template.New("siteLayout").Funcs(i18n.Funcs).Parse(templates.SiteLayout))
+	template.Must(editPageTemplate.New("nav").Parse(templates.NavBar))
+	template.Must(editPageTemplate.New("content").Parse(editPageTemplateText))
+	template.Must(editPageTemplate.New("dropdown").Parse(templates.Dropdown))
+}
+
+func EditPageHandler(res http.ResponseWriter, req *http.Request, ps httprouter.Params, userInfo common.UserInfo) {
+	data := map[string]interface{}{}
+	data["siteName"] = common.SiteName
+	data["locale"] = userInfo.Locale
+	data["formAction"] = req.URL.Path
+	data["pageTitle"] = i18n.Translate(userInfo.Locale, "edit_page.title")
+
+	categorySlug := ps.ByName("category")
+	pageSlug := ps.ByName("slug")
+	pageURL := "/page/" + categorySlug + "/" + pageSlug
+	data["pageURL"] = pageURL
+
+	page, err := databaseActions.GetPage(categorySlug, pageSlug)
+	if err != nil {
+		http.NotFound(res, req)
+		log.Printf("Error looking up page (%s): %s\n", req.URL.Path, err.Error())
+		return
+	}
+
+	if !databaseActions.CanEditPage(userInfo, page) {
+		log.Printf("User (%d) denied editing page (%d).\n", userInfo.UserID, page.Id)
+		http.Error(res, common.PermissionDenied.Error(), http.StatusForbidden)
+		return
+	}
+
+	title := page.Title
+	description := page.Description
+	address := page.Address
+	website := page.Website
+	location := ""
+	if page.Location != nil {
+		location = page.Location.String()
+	}
+	if req.Method == "POST" {
+		title = req.PostFormValue("title")
+		description = req.PostFormValue("description")
+		address = req.PostFormValue("address")
+		website = req.PostFormValue("website")
+		location = req.PostFormValue("location")
+	}
+
+	data["title"] = title
+	data["description"] = description
+	data["address"] = address
+	data["website"] = website
+	data["location"] = location
+
+	data["categoryDropdown"] = map[string]interface{}{}
+	data["categoryDropdown"].(map[string]interface{})["name"] = "category"
+	options, err := databaseActions.ListCategories()
+	if err != nil {
+		data["errorMsg"] = i18n.ErrorMessage(userInfo.Locale, err)
+		goto render
+	}
+	data["categoryDropdown"].(map[string]interface{})["options"] = options
+	if req.Method == "POST" {
+		data["categoryDropdown"].(map[string]interface{})["selected"] = req.PostFormValue("category")
+	} else {
+		for id, name := range options {
+			if name == page.Category {
+				data["categoryDropdown"].(map[string]interface{})["selected"] = id
+			}
+		}
+	}
+
+	if req.Method == "POST" {
+		if len(title) <= 1 {
+			data["errorMsg"] = i18n.Translate(userInfo.Locale, "page_form.title_too_short")
+			goto render
+		}
+		if len(description) < common.MinDescriptionLength {
+			data["errorMsg"] = common.DescriptionTooShort.In(userInfo.Locale)
+			goto render
+		}
+
+		category, err := strconv.ParseInt(req.PostFormValue("category"), 0, 0)
+		if err != nil || category < 0 {
+			log.Println("Invalid category:", req.PostFormValue("category"))
+			data["errorMsg"] = i18n.Translate(userInfo.Locale, "page_form.invalid_category")
+			goto render
+		}
+
+		point, err := common.ParseLocation(location)
+		if err != nil {
+			data["errorMsg"] = i18n.ErrorMessage(userInfo.Locale, err)
+			goto render
+		}
+
+		err = databaseActions.EditPage(userInfo, page, title, description, address, website, point, int(category))
+		if err == nil {
+			log.Printf("Updated %s!\n", title)
+			categorySlug, pageSlug, err := databaseActions.GetSlugs(page.Id)
+			if err != nil {
+				data["errorMsg"] = i18n.ErrorMessage(userInfo.Locale, err)
+				goto render
+			}
+			http.Redirect(res, req, "/page/"+categorySlug+"/"+pageSlug, http.StatusFound)
+			return
+		} else {
+			data["errorMsg"] = i18n.ErrorMessage(userInfo.Locale, err)
+		}
+	}
+
+render:
+	common.ExecTemplate(editPageTemplate, res, data)
+}
+
+const editPageTemplateText = `
+<div class="row">
+	<div class="large-centered medium-centered large-8 medium-8 columns">
+		<div class="content" id="edit-page-form">{{if .successMsg}}
+			<div class="alert-box success">{{.successMsg}}</div>{{end}}{{if .errorMsg}}
+			<div class="alert-box alert">{{.errorMsg}}</div>{{end}}
+			<form method="POST" action="{{.formAction}}" align="center">
+				<fieldset>
+					<legend>{{t $.locale "edit_page.legend"}}</legend>
+					<div>
+						<input type="text" name="title" placeholder="{{t $.locale "page_form.title"}}"{{if .title}} value="{{ .title }}"{{end}} align="center" />
+					</div>
+					<div>
+						<textarea name="description" placeholder="{{t $.locale "page_form.description"}}" rows="15">{{if .description}}{{ .description }}{{end}}</textarea>
+					</div>
+					<div>
+						<input type="text" name="address" placeholder="{{t $.locale "page_form.address"}}"{{if .address}} value="{{ .address }}"{{end}} />
+					</div>
+					<div>
+						<input type="text" name="website" placeholder="{{t $.locale "page_form.website"}}"{{if .website}} value="{{ .website }}"{{end}} />
+					</div>
+					<div>
+						<input type="text" name="location" placeholder="{{t $.locale "page_form.location"}}"{{if .location}} value="{{ .location }}"{{end}} />
+					</div>
+					<div>
+						{{template "dropdown" .categoryDropdown}}
+					</div>
+					<div style="text-align:center">
+						<a href="{{.pageURL}}" class="button secondary">{{t $.locale "edit_page.cancel"}}</a>
+						<button type="submit" class="button" name="save" value="true">{{t $.locale "edit_page.save"}}</button>
+					</div>
+				</fieldset>
+			</form>
+		</div>
+	</div>
+</div>
+`
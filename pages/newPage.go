@@ -1,7 +1,6 @@
 package pages
 
 import (
-	"fmt"
 	"html/template"
 	"log"
 	"net/http"
@@ -11,13 +10,14 @@ import (
 
 	"github.com/comforme/comforme/common"
 	"github.com/comforme/comforme/databaseActions"
+	"github.com/comforme/comforme/i18n"
 	"github.com/comforme/comforme/templates"
 )
 
 var newPageTemplate *template.Template
 
 func init() {
-	newPageTemplate = template.Must(template.New("siteLayout").Parse(templates.SiteLayout))
+	newPageTemplate = template.Must(template.New("siteLayout").Funcs(i18n.Funcs).Parse(templates.SiteLayout))
 	template.Must(newPageTemplate.New("nav").Parse(templates.NavBar))
 	template.Must(newPageTemplate.New("content").Parse(newPageTemplateText))
 	template.Must(newPageTemplate.New("dropdown").Parse(templates.Dropdown))
@@ -26,22 +26,25 @@ func init() {
 func NewPageHandler(res http.ResponseWriter, req *http.Request, ps httprouter.Params, userInfo common.UserInfo) {
 	data := map[string]interface{}{}
 	data["siteName"] = common.SiteName
+	data["locale"] = userInfo.Locale
 	data["formAction"] = req.URL.Path
 	title := req.PostFormValue("title")
 	description := req.PostFormValue("description")
 	address := req.PostFormValue("address")
 	website := req.PostFormValue("website")
+	location := req.PostFormValue("location")
 
 	data["title"] = title
 	data["description"] = description
 	data["address"] = address
 	data["website"] = website
+	data["location"] = location
 
 	data["categoryDropdown"] = map[string]interface{}{}
 	data["categoryDropdown"].(map[string]interface{})["name"] = "category"
 	options, err := databaseActions.ListCategories()
 	if err != nil {
-		data["errorMsg"] = err.Error()
+		data["errorMsg"] = i18n.ErrorMessage(userInfo.Locale, err)
 		goto render
 	}
 	data["categoryDropdown"].(map[string]interface{})["options"] = options
@@ -50,28 +53,48 @@ func NewPageHandler(res http.ResponseWriter, req *http.Request, ps httprouter.Pa
 	if req.Method == "POST" {
 
 		if len(title) <= 1 {
-			data["errorMsg"] = "Title must be more than 1 character long."
+			data["errorMsg"] = i18n.Translate(userInfo.Locale, "page_form.title_too_short")
 			goto render
 		}
 		if len(description) < common.MinDescriptionLength {
-			data["errorMsg"] = fmt.Sprintf("Description must be at least %d characters long.", common.MinDescriptionLength)
+			data["errorMsg"] = common.DescriptionTooShort.In(userInfo.Locale)
 			goto render
 		}
 
 		category, err := strconv.ParseInt(req.PostFormValue("category"), 0, 0)
 		if err != nil || category < 0 {
 			log.Println("Invalid category:", req.PostFormValue("category"))
-			data["errorMsg"] = "Invalid category."
+			data["errorMsg"] = i18n.Translate(userInfo.Locale, "page_form.invalid_category")
 			goto render
 		}
 
-		categorySlug, pageSlug, err := databaseActions.CreatePage(userInfo.UserID, title, description, address, website, int(category))
+		point, err := common.ParseLocation(location)
+		if err != nil {
+			data["errorMsg"] = i18n.ErrorMessage(userInfo.Locale, err)
+			goto render
+		}
+
+		// Warn about likely duplicates until the user confirms theirs is different
+		if req.PostFormValue("confirmed") != "true" {
+			similar, err := databaseActions.FindSimilarNewPages(title, address, website, point, int(category))
+			if err != nil {
+				data["errorMsg"] = i18n.ErrorMessage(userInfo.Locale, err)
+				goto render
+			}
+			if len(similar) > 0 {
+				data["errorMsg"] = common.SimilarPagesExist.In(userInfo.Locale)
+				data["similar"] = similar
+				goto render
+			}
+		}
+
+		categorySlug, pageSlug, err := databaseActions.CreatePage(userInfo.UserID, title, description, address, website, point, int(category))
 		if err == nil {
 			log.Printf("Created %s!\n", title)
 			http.Redirect(res, req, "/page/"+categorySlug+"/"+pageSlug, http.StatusFound)
 			return
 		} else {
-			data["errorMsg"] = err.Error()
+			data["errorMsg"] = i18n.ErrorMessage(userInfo.Locale, err)
 		}
 	}
 
@@ -87,24 +110,37 @@ const newPageTemplateText = `
 			<div class="alert-box alert">{{.errorMsg}}</div>{{end}}
 			<form method="POST" action="{{.formAction}}" align="center">
 				<fieldset>
-					<legend>Create a Resource New Page</legend>
+					<legend>{{t $.locale "new_page.legend"}}</legend>{{if .similar}}
+					<div class="panel" align="left">
+						<h5>{{t $.locale "new_page.similar"}}</h5>
+						<ul>{{range .similar}}
+							<li><a href="/page/{{.CategorySlug}}/{{.PageSlug}}">{{.Title}}</a> {{t $.locale "new_page.in_category" .Category}} ({{range $i, $reason := .Reasons}}{{if $i}}, {{end}}{{msg $.locale $reason}}{{end}})</li>{{end}}
+						</ul>
+						<label>
+							<input type="checkbox" name="confirmed" value="true">
+							{{t $.locale "new_page.confirm_different"}}
+						</label>
+					</div>{{end}}
+					<div>
+						<input type="text" name="title" placeholder="{{t $.locale "page_form.title"}}"{{if .title}} value="{{ .title }}"{{end}} align="center" />
+					</div>
 					<div>
-						<input type="text" name="title" placeholder="Resource page title"{{if .title}} value="{{ .title }}"{{end}} align="center" />
+						<textarea name="description" placeholder="{{t $.locale "page_form.description"}}" rows="15">{{if .description}}{{ .description }}{{end}}</textarea>
 					</div>
 					<div>
-						<textarea name="description" placeholder="Unbiased description of resource" rows="15">{{if .description}}{{ .description }}{{end}}</textarea>
+						<input type="text" name="address" placeholder="{{t $.locale "page_form.address"}}"{{if .address}} value="{{ .address }}"{{end}} />
 					</div>
 					<div>
-						<input type="text" name="address" placeholder="Physical address of resource (if applicable)"{{if .address}} value="{{ .address }}"{{end}} />
+						<input type="text" name="website" placeholder="{{t $.locale "page_form.website"}}"{{if .website}} value="{{ .website }}"{{end}} />
 					</div>
 					<div>
-						<input type="text" name="website" placeholder="Resource's website (if applicable)"{{if .website}} value="{{ .website }}"{{end}} />
+						<input type="text" name="location" placeholder="{{t $.locale "page_form.location"}}"{{if .location}} value="{{ .location }}"{{end}} />
 					</div>
 					<div>
 						{{template "dropdown" .categoryDropdown}}
 					</div>
 					<div style="text-align:center">
-						<button type="submit" class="button" name="sign-up" value="true">Submit</button>
+						<button type="submit" class="button" name="sign-up" value="true">{{t $.locale "new_page.submit"}}</button>
 					</div>
 				</fieldset>
 			</form>
@@ -1,22 +1,23 @@
 package pages
 
 import (
-	"fmt"
 	"html/template"
 	"log"
 	"net/http"
+	"strconv"
 
 	"github.com/julienschmidt/httprouter"
 
 	"github.com/comforme/comforme/common"
 	"github.com/comforme/comforme/databaseActions"
+	"github.com/comforme/comforme/i18n"
 	"github.com/comforme/comforme/templates"
 )
 
 var pageTemplate *template.Template
 
 func init() {
-	pageTemplate = template.Must(template.New("siteLayout").Parse(templates.SiteLayout))
+	pageTemplate = template.Must(template.New("siteLayout").Funcs(i18n.Funcs).Parse(templates.SiteLayout))
 	template.Must(pageTemplate.New("nav").Parse(templates.NavBar))
 	template.Must(pageTemplate.New("fullPage").Parse(templates.SearchBar))
 	template.Must(pageTemplate.New("content").Parse(pageTemplateText))
@@ -25,6 +26,7 @@ func init() {
 func PageHandler(res http.ResponseWriter, req *http.Request, ps httprouter.Params, userInfo common.UserInfo) {
 	data := map[string]interface{}{}
 	data["siteName"] = common.SiteName
+	data["locale"] = userInfo.Locale
 
 	data["formAction"] = req.URL.Path
 
@@ -34,27 +36,53 @@ func PageHandler(res http.ResponseWriter, req *http.Request, ps httprouter.Param
 	log.Printf("Looking up page with category (%s) and slug (%s)...\n", category, slug)
 	page, err := databaseActions.GetPage(category, slug)
 	if err != nil {
+		// The page may have been merged into another one
+		if path, redirectErr := databaseActions.GetPageRedirect(category, slug); redirectErr == nil {
+			http.Redirect(res, req, path, http.StatusMovedPermanently)
+			return
+		}
 		http.NotFound(res, req)
 		log.Printf("Error looking up page (%s): %s\n", req.URL.Path, err.Error())
 		return
 	}
 
 	data["page"] = page
+	data["username"] = userInfo.Username
+	data["canEdit"] = databaseActions.CanEditPage(userInfo, page)
+	data["canModerate"] = userInfo.Can(common.PermissionModerate)
+	data["moderatedPosts"], err = databaseActions.GetModeratedPosts(userInfo, page.Id)
+	if err != nil {
+		log.Printf("Error looking up moderated posts for page (%d): %s\n", page.Id, err.Error())
+	}
 
 	if req.Method == "POST" {
 		thoughts := req.PostFormValue("post-your-thoughts")
 		if len(thoughts) < common.MinDescriptionLength {
-			data["errorMsg"] = fmt.Sprintf("Post must be at least %d characters long.", common.MinDescriptionLength)
+			data["errorMsg"] = i18n.Translate(userInfo.Locale, "page.post_too_short", common.MinDescriptionLength)
 			data["thoughts"] = thoughts
 			goto renderPosts
 		}
 
-		err = databaseActions.CreatePost(userInfo.UserID, thoughts, page)
+		// Either empty for the username, "anonymous" or a pseudonym id
+		postAs := req.PostFormValue("post-as")
+		anonymous := postAs == "anonymous"
+		pseudonymID := 0
+		if postAs != "" && !anonymous {
+			id, err := strconv.Atoi(postAs)
+			if err != nil {
+				data["errorMsg"] = common.PseudonymNotFound.In(userInfo.Locale)
+				data["thoughts"] = thoughts
+				goto renderPosts
+			}
+			pseudonymID = id
+		}
+
+		err = databaseActions.CreatePost(userInfo.UserID, thoughts, page, pseudonymID, anonymous)
 
 		if err == nil {
-			data["successMsg"] = "Post successfully added."
+			data["successMsg"] = i18n.Translate(userInfo.Locale, "page.post_added")
 		} else {
-			data["errorMsg"] = err.Error()
+			data["errorMsg"] = i18n.ErrorMessage(userInfo.Locale, err)
 			data["thoughts"] = thoughts
 		}
 	}
@@ -70,6 +98,16 @@ renderPosts:
 
 	data["posts"] = posts
 
+	data["subscribed"], err = databaseActions.IsSubscribed(userInfo.UserID, page.Id)
+	if err != nil {
+		log.Printf("Error checking subscription to page (%d): %s\n", page.Id, err.Error())
+	}
+
+	data["pseudonyms"], err = databaseActions.GetPseudonyms(userInfo.UserID)
+	if err != nil {
+		log.Printf("Error looking up pseudonyms for user (%d): %s\n", userInfo.UserID, err.Error())
+	}
+
 	common.ExecTemplate(pageTemplate, res, data)
 }
 
@@ -77,7 +115,11 @@ const pageTemplateText = `
 	<div class="content">
 		<div class="row">
 			<div class="columns">
-				<h1><a href="{{.formAction}}">{{.page.Title}}</a></h1>
+				<h1><a href="{{.formAction}}">{{.page.Title}}</a>{{if .canEdit}} <small><a href="{{.formAction}}/edit" title="{{t $.locale "page.edit"}}"><i class="fi-pencil"></i></a></small>{{end}} <small><a href="{{.formAction}}/feed" title="{{t $.locale "page.feed"}}"><i class="fi-rss"></i></a></small>{{if .canModerate}} <small><a href="/admin/duplicates/{{.page.Id}}" title="{{t $.locale "page.find_duplicates"}}"><i class="fi-page-multiple"></i></a></small>{{end}}</h1>
+				<p>
+					<a id="follow-page" class="button tiny secondary" onclick="setPageSubscription({{.page.Id}}, true)"{{if .subscribed}} style="display: none"{{end}}><i class="fi-plus"></i> {{t $.locale "page.follow"}}</a>
+					<a id="unfollow-page" class="button tiny secondary" onclick="setPageSubscription({{.page.Id}}, false)"{{if not .subscribed}} style="display: none"{{end}}><i class="fi-check"></i> {{t $.locale "page.following"}}</a>
+				</p>
 				<p>
 					{{.page.Description}}
 				</p>
@@ -86,14 +128,14 @@ const pageTemplateText = `
 		<div class="row">
 			<div class="columns">
 				<p>
-					<strong>Address:</strong> <span>{{.page.Address}}</span>
+					<strong>{{t $.locale "page.address"}}</strong> <span>{{.page.Address}}</span>
 				</p>
 			</div>
 		</div>{{end}}{{if .page.Website}}
 		<div class="row">
 			<div class="columns">
 				<p>
-					<strong>Website:</strong> <span>{{.page.Website}}</span>
+					<strong>{{t $.locale "page.website"}}</strong> <span>{{.page.Website}}</span>
 				</p>
 			</div>
 		</div>{{end}}
@@ -104,17 +146,27 @@ const pageTemplateText = `
 				<form method="post" action="{{.action}}">
 					<fieldset>
 						<legend>
-							Post Your Thoughts
+							{{t $.locale "page.post_your_thoughts"}}
 						</legend>
 						<div class="row">
 							<div class="columns">
-								<label for="post-your-thoughts">Comment:</label>
+								<label for="post-your-thoughts">{{t $.locale "page.comment_label"}}</label>
 								<textarea name="post-your-thoughts" id="post-your-thoughts">{{if .thoughts}}{{.thoughts}}{{end}}</textarea>
 							</div>
 						</div>
 						<div class="row">
-							<div class="columns text-right">
-								<button type="submit">Comment</button>
+							<div class="large-4 medium-6 columns">
+								<label>
+									{{t $.locale "page.post_as"}}
+									<select name="post-as">
+										<option value="">{{.username}}</option>{{range .pseudonyms}}
+										<option value="{{.Id}}">{{.Name}}</option>{{end}}
+										<option value="anonymous">{{t $.locale "page.anonymous"}}</option>
+									</select>
+								</label>
+							</div>
+							<div class="large-8 medium-6 columns text-right">
+								<button type="submit">{{t $.locale "page.comment"}}</button>
 							</div>
 						</div>
 					</fieldset>
@@ -122,19 +174,27 @@ const pageTemplateText = `
 			</div>
 		</div>
 		<div class="row">{{range $post_number, $post := $.posts}}
-			<div class="columns">
+			<div class="columns" id="post-{{$post.Id}}">
 				<p>
 					<strong>
-						{{$post.Author}} ({{$post.CommonCategories}})
+						{{if $post.Anonymous}}{{plural $.locale "page.anonymous_member" $post.CommonCategories}}{{else if $post.Pseudonymous}}{{$post.Author}} ({{$post.CommonCategories}}){{else if $post.RemoteActor}}<a href="{{$post.RemoteActor}}" rel="nofollow">{{$post.Author}}</a>{{else}}<a href="/user/{{$post.Author}}">{{$post.Author}}</a> ({{$post.CommonCategories}}){{end}}
 					</strong>
 					<small>
 						{{$post.Date}}
-					</small>
-				</p>
+					</small>{{if or $.canModerate (index $.moderatedPosts $post.Id)}}
+					<a class="tiny" onclick="removePost({{$post.Id}}, this)" title="{{t $.locale "page.remove_post"}}" data-confirm="{{t $.locale "page.remove_post_confirm"}}"><i class="fi-trash"></i></a>{{end}}
+				</p>{{if $post.Muted}}
+				<details>
+					<summary>{{t $.locale "page.muted_post"}}</summary>
+					<p>
+						{{$post.Body}}
+					</p>
+				</details>{{else}}
 				<p>
 					{{$post.Body}}
-				</p>
+				</p>{{end}}
 			</div>{{end}}
 		</div>
-	</div>
+	</div>{{if or .canModerate .moderatedPosts}}
+	<script src="/static/js/moderation.js"></script>{{end}}
 `
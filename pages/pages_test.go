@@ -0,0 +1,57 @@
+package pages
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/comforme/comforme/common"
+	"github.com/comforme/comforme/database"
+	"github.com/comforme/comforme/databaseActions"
+	"github.com/comforme/comforme/dbtest"
+)
+
+// The number after a post's author is how many communities the reader shares
+// with them, so it must not count the author's private memberships.
+func TestPageHidesPrivateMemberships(t *testing.T) {
+	conn, dsn := dbtest.New(t)
+	testDB, err := database.NewDB(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	databaseActions.UseDB(testDB)
+
+	author := dbtest.User(t, conn, "author")
+	reader := dbtest.User(t, conn, "reader")
+	shared := dbtest.Community(t, conn, "Gardeners")
+	secret := dbtest.Community(t, conn, "Secret Circle")
+	dbtest.Join(t, conn, author, shared, "public")
+	dbtest.Join(t, conn, reader, shared, "public")
+	dbtest.Join(t, conn, author, secret, "private")
+	dbtest.Join(t, conn, reader, secret, "public")
+
+	category := dbtest.Category(t, conn, "Food")
+	page := dbtest.Page(t, conn, author, category, "Bakery")
+	dbtest.Post(t, conn, author, page, "Fresh bread every morning.")
+
+	req := httptest.NewRequest("GET", "/page/food/bakery", nil)
+	res := httptest.NewRecorder()
+	ps := httprouter.Params{{Key: "category", Value: "food"}, {Key: "slug", Value: "bakery"}}
+	PageHandler(res, req, ps, common.UserInfo{UserID: reader, Username: "reader", Role: common.RoleUser})
+
+	body := res.Body.String()
+	if !strings.Contains(body, "Fresh bread every morning.") {
+		t.Fatalf("post is missing from %q", body)
+	}
+	if !strings.Contains(body, `<a href="/user/author">author</a> (1)`) {
+		t.Error("post does not show the one public community in common")
+	}
+	if strings.Contains(body, "(2)") {
+		t.Error("post counts the author's private membership")
+	}
+	if strings.Contains(body, "Secret Circle") {
+		t.Error("page names the author's private community")
+	}
+}
@@ -0,0 +1,134 @@
+package profiles
+
+import (
+	"html/template"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/comforme/comforme/common"
+	"github.com/comforme/comforme/databaseActions"
+	"github.com/comforme/comforme/i18n"
+	"github.com/comforme/comforme/templates"
+)
+
+var profileTemplate *template.Template
+
+func init() {
+	profileTemplate = template.Must(template.New("siteLayout").Funcs(i18n.Funcs).Parse(templates.SiteLayout))
+	template.Must(profileTemplate.New("nav").Parse(templates.NavBar))
+	template.Must(profileTemplate.New("content").Parse(profileTemplateText))
+}
+
+func ProfileHandler(res http.ResponseWriter, req *http.Request, ps httprouter.Params, userInfo common.UserInfo) {
+	data := map[string]interface{}{}
+	data["siteName"] = common.SiteName
+	data["locale"] = userInfo.Locale
+	data["formAction"] = req.URL.Path
+
+	profile, err := databaseActions.GetProfile(userInfo.UserID, ps.ByName("username"))
+	if err != nil {
+		http.NotFound(res, req)
+		log.Printf("Error looking up profile (%s): %s\n", req.URL.Path, err.Error())
+		return
+	}
+	data["profile"] = profile
+	data["pageTitle"] = profile.Username
+	data["isSelf"] = profile.Id == userInfo.UserID
+
+	if req.Method == "POST" {
+		reason := req.PostFormValue("reason")
+		err = databaseActions.ReportUser(userInfo, profile.Id, reason)
+		if err != nil {
+			data["errorMsg"] = i18n.ErrorMessage(userInfo.Locale, err)
+			data["reason"] = reason
+		} else {
+			data["successMsg"] = i18n.Translate(userInfo.Locale, "profile.report_thanks")
+		}
+	}
+
+	// Someone the viewer has blocked only shows up as a name
+	blocked, err := databaseActions.HasBlocked(userInfo.UserID, profile.Id)
+	if err != nil {
+		data["errorMsg"] = i18n.ErrorMessage(userInfo.Locale, err)
+	}
+	data["blocked"] = blocked
+	if blocked {
+		common.ExecTemplate(profileTemplate, res, data)
+		return
+	}
+
+	data["communities"], err = databaseActions.GetVisibleCommunities(userInfo.UserID, profile.Id)
+	if err != nil {
+		data["errorMsg"] = i18n.ErrorMessage(userInfo.Locale, err)
+	}
+
+	page, err := strconv.Atoi(req.URL.Query().Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	contributions, more, err := databaseActions.GetUserContributions(profile.Id, page)
+	if err != nil {
+		data["errorMsg"] = i18n.ErrorMessage(userInfo.Locale, err)
+	}
+	data["contributions"] = contributions
+	if page > 1 {
+		data["newerPage"] = page - 1
+	}
+	if more {
+		data["olderPage"] = page + 1
+	}
+
+	common.ExecTemplate(profileTemplate, res, data)
+}
+
+const profileTemplateText = `
+	<div class="content">
+		<div class="row">
+			<div class="columns">
+				<h1><i class="fi-torso"></i> {{.profile.Username}}</h1>{{if .successMsg}}
+				<div class="alert-box success">{{.successMsg}}</div>{{end}}{{if .errorMsg}}
+				<div class="alert-box alert">{{.errorMsg}}</div>{{end}}{{if .blocked}}
+				<p>{{t $.locale "profile.blocked"}} <a href="/settings">{{t $.locale "profile.manage_blocks"}}</a>.</p>{{else}}{{if .profile.Bio}}
+				<p>{{.profile.Bio}}</p>{{end}}{{if .communities}}
+				<h6>{{t $.locale "profile.communities"}}{{range .communities}} <a href="/community/{{.Id}}" class="label secondary">{{.Name}}</a>{{end}}</h6>{{end}}{{end}}
+			</div>
+		</div>{{if not .blocked}}
+		<div class="row">
+			<div class="columns">
+				<h2>{{t $.locale "profile.contributions"}}</h2>
+			</div>{{range .contributions}}
+			<div class="columns">
+				<p>
+					{{if .IsPage}}{{t $.locale "profile.added"}}{{else}}{{t $.locale "profile.posted_on"}}{{end}}
+					<a href="/page/{{.CategorySlug}}/{{.PageSlug}}">{{.PageTitle}}</a>
+					<small>{{.Date.Format "2006-01-02 15:04"}}</small>
+				</p>
+				<p>{{.Body}}</p>
+			</div>{{else}}
+			<div class="columns">
+				<p>{{t $.locale "profile.no_contributions"}}</p>
+			</div>{{end}}
+			<div class="columns">{{if .newerPage}}
+				<a href="?page={{.newerPage}}" class="button tiny secondary">{{t $.locale "profile.newer"}}</a>{{end}}{{if .olderPage}}
+				<a href="?page={{.olderPage}}" class="button tiny secondary">{{t $.locale "profile.older"}}</a>{{end}}
+			</div>
+		</div>{{end}}{{if not .isSelf}}
+		<div class="row">
+			<div class="columns">
+				<form method="post" action="{{.formAction}}">
+					<fieldset>
+						<legend>{{t $.locale "profile.report" .profile.Username}}</legend>
+						<label>
+							{{t $.locale "profile.report_reason"}}
+							<textarea name="reason">{{if .reason}}{{.reason}}{{end}}</textarea>
+						</label>
+						<button type="submit" class="button small alert">{{t $.locale "profile.report_submit"}}</button>
+					</fieldset>
+				</form>
+			</div>
+		</div>{{end}}
+	</div>
+`
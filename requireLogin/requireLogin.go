@@ -1,29 +1,100 @@
 package requireLogin
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
 
 	"github.com/julienschmidt/httprouter"
 
 	"github.com/comforme/comforme/ajax"
 	"github.com/comforme/comforme/common"
 	"github.com/comforme/comforme/databaseActions"
+	"github.com/comforme/comforme/i18n"
 	"github.com/comforme/comforme/login"
 	"github.com/comforme/comforme/settings"
 )
 
+// Looks up the user from an "Authorization: Bearer" header and checks that
+// the token's scopes allow the request. ok is false if there is no such
+// header, in which case the session cookie should be checked instead.
+func BearerLogin(req *http.Request) (userInfo common.UserInfo, ok bool, err error) {
+	if !hasBearerToken(req) {
+		return userInfo, false, nil
+	}
+
+	header := req.Header.Get("Authorization")
+	userInfo, err = databaseActions.GetTokenUserInfo(strings.TrimSpace(header[len("Bearer "):]))
+	if err != nil {
+		log.Println("Error checking API token:", err)
+		return userInfo, true, err
+	}
+
+	if !userInfo.HasScope(common.ScopeForMethod(req.Method)) {
+		return userInfo, true, common.InsufficientScope
+	}
+
+	userInfo.RequestInfo = common.GetRequestInfo(req)
+	userInfo.Locale = i18n.Negotiate(req.Header.Get("Accept-Language"), userInfo.Locale)
+	return userInfo, true, nil
+}
+
+// Other kinds of Authorization header, such as the Basic credentials browsers
+// send to a site behind an HTTP authentication proxy, are not ours to check.
+func hasBearerToken(req *http.Request) bool {
+	return strings.HasPrefix(req.Header.Get("Authorization"), "Bearer ")
+}
+
+// The status and RFC 6750 error code for a failed bearer token login.
+func BearerErrorStatus(err error) (status int, code string) {
+	switch err {
+	case common.InsufficientScope, common.SessionRequired:
+		return http.StatusForbidden, "insufficient_scope"
+	case common.DatabaseError:
+		return http.StatusInternalServerError, ""
+	}
+	return http.StatusUnauthorized, "invalid_token"
+}
+
+func bearerError(res http.ResponseWriter, err error, asJSON bool) {
+	status, code := BearerErrorStatus(err)
+	if code != "" {
+		res.Header().Set("WWW-Authenticate", fmt.Sprintf("Bearer error=%q", code))
+	}
+
+	if !asJSON {
+		http.Error(res, err.Error(), status)
+		return
+	}
+
+	encoded, _ := json.Marshal(ajax.AjaxError{Message: err.Error()})
+	res.Header().Set("Content-Type", "application/json; charset=utf-8")
+	res.WriteHeader(status)
+	fmt.Fprintln(res, string(encoded))
+}
+
+// Only accepts a session cookie. API tokens are only good for the JSON API
+// under /api/v1, so pages refuse them rather than let a token reach actions
+// meant for someone at a browser.
 func RequireLogin(handler func(http.ResponseWriter, *http.Request, httprouter.Params, common.UserInfo)) func(http.ResponseWriter, *http.Request, httprouter.Params) {
 	return func(res http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		res.Header().Set("cache-control", "private, max-age=0, no-cache")
 
+		if hasBearerToken(req) {
+			bearerError(res, common.SessionRequired, false)
+			return
+		}
+
 		cookie, err := req.Cookie("sessionid")
 		if err == nil {
 			sessionid := cookie.Value
 			userInfo, err := databaseActions.GetUserInfo(sessionid)
 			if err == nil {
 				log.Printf("User with email %s logged in.", userInfo.Email)
+				userInfo.RequestInfo = common.GetRequestInfo(req)
+				userInfo.Locale = i18n.Negotiate(req.Header.Get("Accept-Language"), userInfo.Locale)
 				isRequired, err := databaseActions.PasswordChangeRequired(sessionid)
 				if err == nil {
 					if isRequired {
@@ -50,16 +121,24 @@ func RequireLogin(handler func(http.ResponseWriter, *http.Request, httprouter.Pa
 	}
 }
 
+// Like RequireLogin, but answers with JSON errors.
 func AjaxRequireLogin(handler func(http.ResponseWriter, *http.Request, httprouter.Params, common.UserInfo)) func(http.ResponseWriter, *http.Request, httprouter.Params) {
 	return func(res http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		res.Header().Set("cache-control", "private, max-age=0, no-cache")
 
+		if hasBearerToken(req) {
+			bearerError(res, common.SessionRequired, true)
+			return
+		}
+
 		cookie, err := req.Cookie("sessionid")
 		if err == nil {
 			sessionid := cookie.Value
 			userInfo, err := databaseActions.GetUserInfo(sessionid)
 			if err == nil {
 				log.Printf("User with email %s logged in.", userInfo.Email)
+				userInfo.RequestInfo = common.GetRequestInfo(req)
+				userInfo.Locale = i18n.Negotiate(req.Header.Get("Accept-Language"), userInfo.Locale)
 				handler(res, req, ps, userInfo)
 				return
 			}
@@ -78,3 +157,27 @@ func AjaxRequireLogin(handler func(http.ResponseWriter, *http.Request, httproute
 		fmt.Fprintln(res, ajax.JSONLoginError)
 	}
 }
+
+func RequirePermission(permission common.Permission, handler func(http.ResponseWriter, *http.Request, httprouter.Params, common.UserInfo)) func(http.ResponseWriter, *http.Request, httprouter.Params) {
+	return RequireLogin(func(res http.ResponseWriter, req *http.Request, ps httprouter.Params, userInfo common.UserInfo) {
+		if !userInfo.Can(permission) {
+			log.Printf("User with email %s (%s) denied access to %s.", userInfo.Email, userInfo.Role, req.URL.Path)
+			http.Error(res, common.PermissionDenied.Error(), http.StatusForbidden)
+			return
+		}
+		handler(res, req, ps, userInfo)
+	})
+}
+
+func AjaxRequirePermission(permission common.Permission, handler func(http.ResponseWriter, *http.Request, httprouter.Params, common.UserInfo)) func(http.ResponseWriter, *http.Request, httprouter.Params) {
+	return AjaxRequireLogin(func(res http.ResponseWriter, req *http.Request, ps httprouter.Params, userInfo common.UserInfo) {
+		if !userInfo.Can(permission) {
+			log.Printf("User with email %s (%s) denied access to %s.", userInfo.Email, userInfo.Role, req.URL.Path)
+			res.Header().Set("Content-Type", "application/json; charset=utf-8")
+			res.WriteHeader(http.StatusForbidden)
+			fmt.Fprintln(res, ajax.JSONPermissionError)
+			return
+		}
+		handler(res, req, ps, userInfo)
+	})
+}
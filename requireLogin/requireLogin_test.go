@@ -0,0 +1,76 @@
+package requireLogin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/comforme/comforme/ajax"
+	"github.com/comforme/comforme/common"
+)
+
+// API tokens must not reach anything outside the API, whether or not they
+// are valid, so these never get as far as looking the token up.
+func TestPagesRefuseTokens(t *testing.T) {
+	handler := func(res http.ResponseWriter, req *http.Request, ps httprouter.Params, userInfo common.UserInfo) {
+		t.Errorf("%s reached the handler", req.URL.Path)
+	}
+
+	tests := []struct {
+		name   string
+		handle httprouter.Handle
+		asJSON bool
+	}{
+		{"RequireLogin", RequireLogin(handler), false},
+		{"RequirePermission", RequirePermission(common.PermissionModerate, handler), false},
+		{"AjaxRequireLogin", AjaxRequireLogin(handler), true},
+		{"AjaxRequirePermission", AjaxRequirePermission(common.PermissionModerate, handler), true},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest("POST", "/ajax/removePost", nil)
+		req.Header.Set("Authorization", "Bearer token")
+		res := httptest.NewRecorder()
+		test.handle(res, req, nil)
+
+		if res.Code != http.StatusForbidden {
+			t.Errorf("%s: got status %d, want %d", test.name, res.Code, http.StatusForbidden)
+		}
+		if !test.asJSON {
+			continue
+		}
+		var body ajax.AjaxError
+		if err := json.Unmarshal(res.Body.Bytes(), &body); err != nil {
+			t.Errorf("%s: %s in %q", test.name, err, res.Body.String())
+		} else if body.Message != common.SessionRequired.Error() {
+			t.Errorf("%s: got error %q", test.name, body.Message)
+		}
+	}
+}
+
+// Browsers send Basic credentials with every request to a site behind an
+// HTTP authentication proxy, which must not be taken for a token.
+func TestPagesIgnoreBasicAuth(t *testing.T) {
+	handler := func(res http.ResponseWriter, req *http.Request, ps httprouter.Params, userInfo common.UserInfo) {
+		t.Errorf("%s reached the handler without logging in", req.URL.Path)
+	}
+
+	req := httptest.NewRequest("GET", "/settings", nil)
+	req.SetBasicAuth("staging", "password")
+	res := httptest.NewRecorder()
+	RequireLogin(handler)(res, req, nil)
+	if res.Code != http.StatusOK || !strings.Contains(res.Body.String(), "sign-up-and-log-in") {
+		t.Errorf("RequireLogin: got status %d, want the login page", res.Code)
+	}
+
+	req = httptest.NewRequest("POST", "/ajax/removePost", nil)
+	req.SetBasicAuth("staging", "password")
+	res = httptest.NewRecorder()
+	AjaxRequireLogin(handler)(res, req, nil)
+	if strings.TrimSpace(res.Body.String()) != ajax.JSONLoginError {
+		t.Errorf("AjaxRequireLogin: got %d %q, want the login error", res.Code, res.Body.String())
+	}
+}
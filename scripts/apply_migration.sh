@@ -0,0 +1,5 @@
+#!/bin/bash
+# Applies a single migration to the Heroku database.
+# Usage: ./apply_migration.sh migrations/001_roles.sql
+
+cat "$1" | heroku pg:psql --app comforme
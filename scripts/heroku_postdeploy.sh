@@ -1,3 +1,6 @@
 #!/bin/bash
 # Postdeploy script for automated Heroku deployment.
 cat schema.sql | psql $DATABASE_URL
+for migration in migrations/*.sql; do
+	cat $migration | psql $DATABASE_URL
+done
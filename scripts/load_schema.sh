@@ -6,3 +6,6 @@ DATABASE=$(heroku pg:info --app comforme | grep -oE 'HEROKU_POSTGRESQL_[A-Z]+_UR
 heroku pg:promote $DATABASE --app comforme
 
 cat schema.sql | heroku pg:psql --app comforme
+for migration in migrations/*.sql; do
+	cat $migration | heroku pg:psql --app comforme
+done
@@ -10,13 +10,14 @@ import (
 
 	"github.com/comforme/comforme/common"
 	"github.com/comforme/comforme/databaseActions"
+	"github.com/comforme/comforme/i18n"
 	"github.com/comforme/comforme/templates"
 )
 
 var searchTemplate *template.Template
 
 func init() {
-	searchTemplate = template.Must(template.New("siteLayout").Parse(templates.SiteLayout))
+	searchTemplate = template.Must(template.New("siteLayout").Funcs(i18n.Funcs).Parse(templates.SiteLayout))
 	template.Must(searchTemplate.New("nav").Parse(templates.NavBar))
 	template.Must(searchTemplate.New("searchBar").Parse(templates.SearchBar))
 	template.Must(searchTemplate.New("content").Parse(searchTemplateText))
@@ -26,6 +27,7 @@ func init() {
 func SearchHandler(res http.ResponseWriter, req *http.Request, ps httprouter.Params, userInfo common.UserInfo) {
   data := map[string]interface{}{}
 	data["siteName"] = common.SiteName
+	data["locale"] = userInfo.Locale
 	if common.CheckParam(req.URL.Query(), "q") {
 		query := req.URL.Query()["q"][0]
 		log.Println("Performing search for:", query)
@@ -34,7 +36,7 @@ func SearchHandler(res http.ResponseWriter, req *http.Request, ps httprouter.Par
 		data["appId"] = os.Getenv("ALGOLIASEARCH_APPLICATION_ID")
 		data["publicSearchKey"] = os.Getenv("ALGOLIASEARCH_API_KEY_SEARCH")
 		var err error
-		data["results"], err = databaseActions.SearchPages(userInfo.SessionID, query)
+		data["results"], err = databaseActions.SearchPages(userInfo.UserID, query)
 		if err != nil {
 			log.Println("Failed to retrieve search results for "+
 				query, err)
@@ -42,7 +44,7 @@ func SearchHandler(res http.ResponseWriter, req *http.Request, ps httprouter.Par
 			log.Printf("Search results for %s:\n%+v\n", query, data["results"])
 		}
 	} else {
-		data["pageTitle"] = "Search"
+		data["pageTitle"] = i18n.Translate(userInfo.Locale, "search.title")
 	}
 
 	common.ExecTemplate(searchTemplate, res, data)
@@ -53,11 +55,11 @@ const searchTemplateText = `
 	<div class="content">
 		<div class="row">
 			<div class="columns">
-				<h1>Search</h1>
+				<h1>{{t $.locale "search.title"}}</h1>
 				{{template "searchBar" .}}
 				<div class="alert-box secondary">{{if .results}}
-					Results for <span style="color:red">{{.query}}</span>{{else}}
-					<span style="color:red">No matches found for "{{.query}}"</span> Would you like to <a href="/newPage">add a new resource</a>?{{end}}
+					{{t $.locale "search.results_for"}} <span style="color:red">{{.query}}</span>{{else}}
+					<span style="color:red">{{t $.locale "search.no_matches" .query}}</span> {{t $.locale "search.add_resource_prompt"}} <a href="/newPage">{{t $.locale "search.add_resource"}}</a>?{{end}}
 				</div>
 			</div>
 		</div>
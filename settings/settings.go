@@ -4,18 +4,21 @@ import (
 	"html/template"
 	"log"
 	"net/http"
+	"strconv"
 
 	"github.com/julienschmidt/httprouter"
 
 	"github.com/comforme/comforme/common"
 	"github.com/comforme/comforme/databaseActions"
+	"github.com/comforme/comforme/i18n"
+	"github.com/comforme/comforme/oidc"
 	"github.com/comforme/comforme/templates"
 )
 
 var settingsTemplate *template.Template
 
 func init() {
-	settingsTemplate = template.Must(template.New("siteLayout").Parse(templates.SiteLayout))
+	settingsTemplate = template.Must(template.New("siteLayout").Funcs(i18n.Funcs).Parse(templates.SiteLayout))
 	template.Must(settingsTemplate.New("nav").Parse(templates.NavBar))
 	template.Must(settingsTemplate.New("searchBar").Parse(templates.SearchBar))
 	template.Must(settingsTemplate.New("communitySearch").Parse(templates.CommunitySearch))
@@ -27,11 +30,14 @@ func init() {
 func SettingsHandler(res http.ResponseWriter, req *http.Request, ps httprouter.Params, userInfo common.UserInfo) {
 	data := map[string]interface{}{}
 	data["siteName"] = common.SiteName
+	data["locale"] = userInfo.Locale
 
 	data["formAction"] = req.URL.Path
-	data["pageTitle"] = "Settings"
+	data["pageTitle"] = i18n.Translate(userInfo.Locale, "settings.title")
 	data["email"] = userInfo.Email
 	data["username"] = userInfo.Username
+	data["isAdmin"] = userInfo.Can(common.PermissionViewStatistics)
+	data["isModerator"] = userInfo.Can(common.PermissionModerate)
 
 	var err error
 	data["communitiesCols"], err = databaseActions.GetCommunityColumns(userInfo.UserID)
@@ -56,36 +62,246 @@ func SettingsHandler(res http.ResponseWriter, req *http.Request, ps httprouter.P
 			newPassword := req.PostFormValue("newPassword")
 			newPasswordAgain := req.PostFormValue("newPasswordAgain")
 			if len(oldPassword) == 0 || len(newPassword) == 0 {
-				data["errorMsg"] = "Both old and new password required to change password"
+				data["errorMsg"] = i18n.Translate(userInfo.Locale, "settings.password_required")
 			} else if newPassword == newPasswordAgain {
-				err := databaseActions.ChangePassword(userInfo.Email, oldPassword, newPassword)
+				err := databaseActions.ChangePassword(userInfo, oldPassword, newPassword)
 				if err == nil {
-					data["successMsg"] = "Password changed."
+					data["successMsg"] = i18n.Translate(userInfo.Locale, "settings.password_changed")
 					if req.URL.Path != "/settings" {
 						http.Redirect(res, req, req.URL.Path, http.StatusFound)
 						return
 					}
 				} else {
-					data["errorMsg"] = err.Error()
+					data["errorMsg"] = i18n.ErrorMessage(userInfo.Locale, err)
 				}
 			} else {
-				data["errorMsg"] = "Passwords do not match."
+				data["errorMsg"] = i18n.Translate(userInfo.Locale, "settings.passwords_differ")
+			}
+		} else if req.PostFormValue("bio-update") == "true" {
+			bio := req.PostFormValue("bio")
+
+			err := databaseActions.SetBio(userInfo, bio)
+			if err != nil {
+				data["bio"] = bio
+				data["errorMsg"] = i18n.ErrorMessage(userInfo.Locale, err)
+			} else {
+				data["successMsg"] = i18n.Translate(userInfo.Locale, "settings.profile_updated")
+			}
+		} else if req.PostFormValue("digest-update") == "true" {
+			err := databaseActions.SetDigestFrequency(userInfo.UserID, req.PostFormValue("digestFrequency"))
+			if err != nil {
+				data["errorMsg"] = i18n.ErrorMessage(userInfo.Locale, err)
+			} else {
+				data["successMsg"] = i18n.Translate(userInfo.Locale, "settings.digest_updated")
+			}
+		} else if req.PostFormValue("locale-update") == "true" {
+			locale := req.PostFormValue("locale")
+
+			err := databaseActions.SetLocale(userInfo.UserID, locale)
+			if err != nil {
+				data["errorMsg"] = i18n.ErrorMessage(userInfo.Locale, err)
+			} else {
+				// Show the rest of the page in the new language straight away
+				userInfo.Locale = i18n.Negotiate(req.Header.Get("Accept-Language"), locale)
+				data["locale"] = userInfo.Locale
+				data["pageTitle"] = i18n.Translate(userInfo.Locale, "settings.title")
+				data["successMsg"] = i18n.Translate(userInfo.Locale, "settings.language_updated")
+			}
+		} else if req.PostFormValue("pseudonym-add") == "true" {
+			pseudonym := req.PostFormValue("pseudonym")
+
+			err := databaseActions.NewPseudonym(userInfo.UserID, pseudonym)
+			if err != nil {
+				data["pseudonym"] = pseudonym
+				data["errorMsg"] = i18n.ErrorMessage(userInfo.Locale, err)
+			} else {
+				data["successMsg"] = i18n.Translate(userInfo.Locale, "settings.pseudonym_added")
+			}
+		} else if req.PostFormValue("block-add") == "true" {
+			blockUsername := req.PostFormValue("blockUsername")
+			kind := req.PostFormValue("blockKind")
+
+			err := databaseActions.BlockUser(userInfo, blockUsername, kind)
+			if err != nil {
+				data["blockUsername"] = blockUsername
+				data["errorMsg"] = i18n.ErrorMessage(userInfo.Locale, err)
+			} else if kind == common.BlockKindMute {
+				data["successMsg"] = i18n.Translate(userInfo.Locale, "settings.user_muted")
+			} else {
+				data["successMsg"] = i18n.Translate(userInfo.Locale, "settings.user_blocked")
+			}
+		} else if unblock := req.PostFormValue("block-remove"); unblock != "" {
+			blockedID, err := strconv.Atoi(unblock)
+			if err == nil {
+				err = databaseActions.UnblockUser(userInfo, blockedID)
+			}
+			if err != nil {
+				data["errorMsg"] = common.UserNotFound.In(userInfo.Locale)
+			} else {
+				data["successMsg"] = i18n.Translate(userInfo.Locale, "settings.user_unblocked")
+			}
+		} else if req.PostFormValue("token-add") == "true" {
+			tokenName := req.PostFormValue("tokenName")
+
+			token, err := databaseActions.CreateAPIToken(userInfo, tokenName, req.PostForm["tokenScope"])
+			if err != nil {
+				data["tokenName"] = tokenName
+				data["errorMsg"] = i18n.ErrorMessage(userInfo.Locale, err)
+			} else {
+				data["successMsg"] = i18n.Translate(userInfo.Locale, "settings.token_created")
+				data["newToken"] = token
+			}
+		} else if revoke := req.PostFormValue("token-revoke"); revoke != "" {
+			tokenID, err := strconv.Atoi(revoke)
+			if err == nil {
+				err = databaseActions.RevokeAPIToken(userInfo, tokenID)
+			}
+			if err != nil {
+				data["errorMsg"] = common.TokenNotFound.In(userInfo.Locale)
+			} else {
+				data["successMsg"] = i18n.Translate(userInfo.Locale, "settings.token_revoked")
+			}
+		} else if remove := req.PostFormValue("passkey-remove"); remove != "" {
+			passkeyID, err := strconv.Atoi(remove)
+			if err == nil {
+				err = databaseActions.RemovePasskey(userInfo, passkeyID)
+			}
+			if err != nil {
+				data["errorMsg"] = common.PasskeyNotFound.In(userInfo.Locale)
+			} else {
+				data["successMsg"] = i18n.Translate(userInfo.Locale, "settings.passkey_removed")
+			}
+		} else if rename := req.PostFormValue("passkey-rename"); rename != "" {
+			passkeyID, err := strconv.Atoi(rename)
+			if err != nil {
+				err = common.PasskeyNotFound
+			} else {
+				err = databaseActions.RenamePasskey(userInfo, passkeyID, req.PostFormValue("passkeyName"))
+			}
+			if err != nil {
+				data["errorMsg"] = i18n.ErrorMessage(userInfo.Locale, err)
+			} else {
+				data["successMsg"] = i18n.Translate(userInfo.Locale, "settings.passkey_renamed")
+			}
+		} else if unlink := req.PostFormValue("identity-remove"); unlink != "" {
+			identityID, err := strconv.Atoi(unlink)
+			if err == nil {
+				err = databaseActions.UnlinkIdentity(userInfo, identityID)
+			}
+			if err != nil {
+				data["errorMsg"] = common.IdentityNotFound.In(userInfo.Locale)
+			} else {
+				data["successMsg"] = i18n.Translate(userInfo.Locale, "settings.identity_removed")
+			}
+		} else if req.PostFormValue("export-request") == "true" {
+			err := databaseActions.RequestDataExport(userInfo, common.GetBaseURL(req))
+			if err != nil {
+				data["errorMsg"] = i18n.ErrorMessage(userInfo.Locale, err)
+			} else {
+				data["successMsg"] = i18n.Translate(userInfo.Locale, "settings.export_requested")
+			}
+		} else if req.PostFormValue("account-delete") == "true" {
+			scheduled, err := databaseActions.RequestAccountDeletion(userInfo, req.PostFormValue("deletePassword"), common.GetBaseURL(req))
+			if err != nil {
+				data["errorMsg"] = i18n.ErrorMessage(userInfo.Locale, err)
+			} else {
+				data["successMsg"] = i18n.Translate(userInfo.Locale, "settings.deletion_scheduled", scheduled.Format("2006-01-02"))
+			}
+		} else if req.PostFormValue("account-delete-cancel") == "true" {
+			err := databaseActions.CancelAccountDeletion(userInfo)
+			if err != nil {
+				data["errorMsg"] = i18n.ErrorMessage(userInfo.Locale, err)
+			} else {
+				data["successMsg"] = i18n.Translate(userInfo.Locale, "settings.deletion_cancelled")
 			}
 		} else if req.PostFormValue("username-update") == "true" {
 			usernameChangePassword := req.PostFormValue("usernameChangePassword")
 			newUsername := req.PostFormValue("newUsername")
 
-			err := databaseActions.ChangeUsername(userInfo.Email, newUsername, usernameChangePassword)
+			err := databaseActions.ChangeUsername(userInfo, newUsername, usernameChangePassword)
 			if err != nil {
 				data["newUsername"] = newUsername
-				data["errorMsg"] = err.Error()
+				data["errorMsg"] = i18n.ErrorMessage(userInfo.Locale, err)
 			} else {
-				data["successMsg"] = "Username changed."
+				data["successMsg"] = i18n.Translate(userInfo.Locale, "settings.username_changed")
 				data["username"] = newUsername
 			}
 		}
 	}
 
+	if data["bio"] == nil {
+		profile, err := databaseActions.GetProfile(userInfo.UserID, data["username"].(string))
+		if err != nil {
+			log.Println("Error looking up profile:", err)
+		}
+		data["bio"] = profile.Bio
+	}
+
+	data["digestFrequency"], err = databaseActions.GetDigestFrequency(userInfo.UserID)
+	if err != nil {
+		log.Println("Error looking up digest frequency:", err)
+	}
+
+	data["preferredLocale"], err = databaseActions.GetLocale(userInfo.UserID)
+	if err != nil {
+		log.Println("Error looking up locale:", err)
+	}
+	data["locales"] = i18n.Locales
+
+	data["pseudonyms"], err = databaseActions.GetPseudonyms(userInfo.UserID)
+	if err != nil {
+		log.Println("Error listing pseudonyms:", err)
+	}
+
+	data["blockedUsers"], err = databaseActions.GetUserBlocks(userInfo.UserID)
+	if err != nil {
+		log.Println("Error listing blocked users:", err)
+	}
+
+	data["passkeys"], err = databaseActions.GetPasskeys(userInfo.UserID)
+	if err != nil {
+		log.Println("Error listing passkeys:", err)
+	}
+
+	data["identities"], err = databaseActions.GetIdentities(userInfo.UserID)
+	if err != nil {
+		log.Println("Error listing linked accounts:", err)
+	}
+	providerLabels := map[string]string{}
+	for _, provider := range oidc.Providers() {
+		providerLabels[provider.Name] = provider.Label
+	}
+	data["providerLabels"] = providerLabels
+	data["oidcProviders"] = oidc.Providers()
+
+	data["apiTokens"], err = databaseActions.GetAPITokens(userInfo.UserID)
+	if err != nil {
+		log.Println("Error listing API tokens:", err)
+	}
+
+	exports, err := databaseActions.GetDataExports(userInfo.UserID)
+	if err != nil {
+		log.Println("Error listing data exports:", err)
+	}
+	exportLinks := map[int]string{}
+	for _, export := range exports {
+		if export.Status == common.ExportReady {
+			exportLinks[export.Id] = databaseActions.ExportLink(export)
+		}
+	}
+	data["exports"] = exports
+	data["exportLinks"] = exportLinks
+
+	data["deletionScheduled"], err = databaseActions.GetDeletionScheduled(userInfo.UserID)
+	if err != nil {
+		log.Println("Error looking up account deletion:", err)
+	}
+
+	data["securityEvents"], err = databaseActions.GetSecurityEvents(userInfo.UserID)
+	if err != nil {
+		log.Println("Error listing security events:", err)
+	}
+
 	if data["errorMsg"] == nil {
 		cookie, err := req.Cookie("sessionid")
 		if err != nil {
@@ -100,7 +316,7 @@ func SettingsHandler(res http.ResponseWriter, req *http.Request, ps httprouter.P
 		}
 
 		if isRequired {
-			data["errorMsg"] = "Password change required."
+			data["errorMsg"] = i18n.Translate(userInfo.Locale, "settings.password_change_required")
 		}
 	}
 
@@ -112,76 +328,333 @@ const settingsTemplateText = `
 	<div class="content">
 		<div class="row">
 			<div class="columns communities-settings">
-				<h1><i class="fi-widget"></i> Settings</h1>{{if .successMsg}}
+				<h1><i class="fi-widget"></i> {{t $.locale "settings.title"}}</h1>{{if .successMsg}}
 				<div class="alert-box success">{{.successMsg}}</div>{{end}}{{if .errorMsg}}
 				<div class="alert-box alert">{{.errorMsg}}</div>{{end}}
 				<section>
-					<h2>User Information</h2>
+					<h2>{{t $.locale "settings.user_information"}}</h2>
 					<div class="row">
 						<div class="large-4 columns left">
-							<h5>Email:</h5> {{.email}}
+							<h5>{{t $.locale "settings.email"}}</h5> {{.email}}
 						</div>
 						<div class="large-4 columns left">
-							<h5>Username:</h5> {{.username}}
+							<h5>{{t $.locale "settings.username"}}</h5> <a href="/user/{{.username}}">{{.username}}</a>
 						</div>
 					</div>
+					<form action="{{.formAction}}" method="post">
+						<label>
+							{{t $.locale "settings.bio"}}
+							<textarea name="bio">{{.bio}}</textarea>
+						</label>
+						<button type="submit" name="bio-update" value="true">{{t $.locale "settings.update_profile"}}</button>
+					</form>
 				</section>
 				<section>
-					<h2>Password Change</h2>
+					<h2>{{t $.locale "settings.password_change"}}</h2>
 					<form action="{{.formAction}}" method="post">
 						<div class="row">
 							<div class="large-4 columns left">
 								<label>
-									Old password
+									{{t $.locale "settings.old_password"}}
 									<input type="password" name="oldPassword">
 								</label>
 							</div>
 							<div class="large-4 columns left">
 								<label>
-									New password
+									{{t $.locale "settings.new_password"}}
 									<input type="password" name="newPassword">
 								</label>
 							</div>
 							<div class="large-4 columns left">
 								<label>
-									New password (again)
+									{{t $.locale "settings.new_password_again"}}
 									<input type="password" name="newPasswordAgain">
 								</label>
 							</div>
 						</div>
-						<button type="submit" name="password-update" value="true">Update Password</button>
+						<button type="submit" name="password-update" value="true">{{t $.locale "settings.update_password"}}</button>
 					</form>
 				</section>
 				<section>
 {{ template "communities" .}}
 				</section>
 				<section>
-					<h2>Sessions</h2>
-					<h6>You currently have <span id="numOpenSessions">{{.openSessions}}</span> sessions open in addition to this one.</h6>
-					<button onclick="logoutOtherSessions(this)" name="logout-sessions">Logout Other Sessions</button>
+					<h2>{{t $.locale "settings.sessions"}}</h2>
+					<h6 id="openSessions" data-none="{{plural $.locale "settings.open_sessions" 0}}">{{plural $.locale "settings.open_sessions" .openSessions}}</h6>
+					<button onclick="logoutOtherSessions(this)" name="logout-sessions">{{t $.locale "settings.logout_sessions"}}</button>
 				</section>
 				<section>
-					<h2>Username Change</h2>
+					<h2>{{t $.locale "settings.username_change"}}</h2>
 					<form action="{{.formAction}}" method="post">
 						<div class="row">
 							<div class="large-4 columns left">
 								<label>
-									Password
+									{{t $.locale "settings.password"}}
 									<input type="password" name="usernameChangePassword">
 								</label>
 							</div>
 							<div class="large-4 columns left">
 								<label>
-									New username
+									{{t $.locale "settings.new_username"}}
 									<input type="text" name="newUsername"{{if .newUsername}} value="{{.newUsername}}"{{end}}>
 								</label>
 							</div>
 						</div>
-						<button type="submit" name="username-update" value="true">Update Username</button>
+						<button type="submit" name="username-update" value="true">{{t $.locale "settings.update_username"}}</button>
+					</form>
+				</section>
+				<section>
+					<h2>{{t $.locale "settings.digest"}}</h2>
+					<h6>{{t $.locale "settings.digest_help"}}</h6>
+					<form action="{{.formAction}}" method="post">
+						<div class="row">
+							<div class="large-4 columns left">
+								<select name="digestFrequency">
+									<option value="daily"{{if eq .digestFrequency "daily"}} selected{{end}}>{{t $.locale "settings.digest_daily"}}</option>
+									<option value="weekly"{{if eq .digestFrequency "weekly"}} selected{{end}}>{{t $.locale "settings.digest_weekly"}}</option>
+									<option value="never"{{if eq .digestFrequency "never"}} selected{{end}}>{{t $.locale "settings.digest_never"}}</option>
+								</select>
+							</div>
+						</div>
+						<button type="submit" name="digest-update" value="true">{{t $.locale "settings.update_digest"}}</button>
+					</form>
+				</section>
+				<section>
+					<h2>{{t $.locale "settings.language"}}</h2>
+					<form action="{{.formAction}}" method="post">
+						<div class="row">
+							<div class="large-4 columns left">
+								<select name="locale">
+									<option value="">{{t $.locale "settings.language_automatic"}}</option>{{range .locales}}
+									<option value="{{.Code}}"{{if eq .Code $.preferredLocale}} selected{{end}}>{{.Name}}</option>{{end}}
+								</select>
+							</div>
+						</div>
+						<button type="submit" name="locale-update" value="true">{{t $.locale "settings.update_language"}}</button>
+					</form>
+				</section>
+				<section>
+					<h2>{{t $.locale "settings.pseudonyms"}}</h2>
+					<h6>{{t $.locale "settings.pseudonyms_help"}}</h6>
+					<ul>{{range .pseudonyms}}
+						<li>{{.Name}}</li>{{else}}
+						<li>{{t $.locale "settings.no_pseudonyms"}}</li>{{end}}
+					</ul>
+					<form action="{{.formAction}}" method="post">
+						<div class="row">
+							<div class="large-4 columns left">
+								<label>
+									{{t $.locale "settings.new_pseudonym"}}
+									<input type="text" name="pseudonym"{{if .pseudonym}} value="{{.pseudonym}}"{{end}}>
+								</label>
+							</div>
+						</div>
+						<button type="submit" name="pseudonym-add" value="true">{{t $.locale "settings.add_pseudonym"}}</button>
+					</form>
+				</section>
+				<section>
+					<h2>{{t $.locale "settings.blocks"}}</h2>
+					<h6>{{t $.locale "settings.blocks_help"}}</h6>
+					<form action="{{.formAction}}" method="post">
+						<table>
+							<thead>
+								<tr><th>{{t $.locale "settings.username_column"}}</th><th>{{t $.locale "settings.block_type"}}</th><th>{{t $.locale "settings.since"}}</th><th></th></tr>
+							</thead>
+							<tbody>{{range .blockedUsers}}
+								<tr>
+									<td>{{.Username}}</td>
+									<td>{{if eq .Kind "mute"}}{{t $.locale "settings.muted"}}{{else}}{{t $.locale "settings.blocked"}}{{end}}</td>
+									<td>{{.DateCreated.Format "2006-01-02"}}</td>
+									<td><button class="tiny secondary" type="submit" name="block-remove" value="{{.Id}}">{{if eq .Kind "mute"}}{{t $.locale "settings.unmute"}}{{else}}{{t $.locale "settings.unblock"}}{{end}}</button></td>
+								</tr>{{else}}
+								<tr><td colspan="4">{{t $.locale "settings.no_blocks"}}</td></tr>{{end}}
+							</tbody>
+						</table>
+					</form>
+					<form action="{{.formAction}}" method="post">
+						<div class="row">
+							<div class="large-4 columns left">
+								<label>
+									{{t $.locale "settings.username_column"}}
+									<input type="text" name="blockUsername"{{if .blockUsername}} value="{{.blockUsername}}"{{end}}>
+								</label>
+							</div>
+							<div class="large-4 columns left">
+								<label>
+									{{t $.locale "settings.block_type"}}
+									<select name="blockKind">
+										<option value="block">{{t $.locale "settings.block"}}</option>
+										<option value="mute">{{t $.locale "settings.mute"}}</option>
+									</select>
+								</label>
+							</div>
+						</div>
+						<button type="submit" name="block-add" value="true">{{t $.locale "settings.add_block"}}</button>
 					</form>
 				</section>
+				<section>
+					<h2>{{t $.locale "settings.passkeys"}}</h2>
+					<h6>{{t $.locale "settings.passkeys_help"}}</h6>
+					<table>
+						<thead>
+							<tr><th>{{t $.locale "settings.name"}}</th><th>{{t $.locale "settings.added"}}</th><th>{{t $.locale "settings.last_used"}}</th><th></th></tr>
+						</thead>
+						<tbody>{{range .passkeys}}
+							<tr>
+								<td>
+									<form action="{{$.formAction}}" method="post" class="inline-form">
+										<input type="text" name="passkeyName" value="{{.Name}}">
+										<button class="tiny secondary" type="submit" name="passkey-rename" value="{{.Id}}">{{t $.locale "settings.rename"}}</button>
+									</form>
+								</td>
+								<td>{{.DateCreated.Format "2006-01-02"}}</td>
+								<td>{{if .LastUsed}}{{.LastUsed.Format "2006-01-02"}}{{else}}{{t $.locale "settings.never_used"}}{{end}}</td>
+								<td>
+									<form action="{{$.formAction}}" method="post">
+										<button class="tiny secondary" type="submit" name="passkey-remove" value="{{.Id}}">{{t $.locale "settings.remove"}}</button>
+									</form>
+								</td>
+							</tr>{{else}}
+							<tr><td colspan="4">{{t $.locale "settings.no_passkeys"}}</td></tr>{{end}}
+						</tbody>
+					</table>
+					<div class="alert-box alert" id="passkey-error" data-failed="{{t $.locale "passkey.failed"}}" data-unsupported="{{t $.locale "passkey.unsupported"}}" data-not-added="{{t $.locale "passkey.not_added"}}" data-cancelled="{{t $.locale "passkey.cancelled"}}" style="display: none;"></div>
+					<div class="row">
+						<div class="large-4 columns left">
+							<label>
+								{{t $.locale "settings.passkey_name"}}
+								<input type="text" id="passkey-name" placeholder="{{t $.locale "settings.passkey_name_example"}}">
+							</label>
+						</div>
+					</div>
+					<button type="button" onclick="addPasskey()">{{t $.locale "settings.add_passkey"}}</button>
+				</section>
+				{{if or .oidcProviders .identities}}<section>
+					<h2>{{t $.locale "settings.identities"}}</h2>
+					<h6>{{t $.locale "settings.identities_help"}}</h6>
+					<form action="{{.formAction}}" method="post">
+						<table>
+							<thead>
+								<tr><th>{{t $.locale "settings.provider"}}</th><th>{{t $.locale "settings.email_column"}}</th><th>{{t $.locale "settings.linked"}}</th><th>{{t $.locale "settings.last_used"}}</th><th></th></tr>
+							</thead>
+							<tbody>{{range .identities}}
+								<tr>
+									<td>{{or (index $.providerLabels .Provider) .Provider}}</td>
+									<td>{{.Email}}</td>
+									<td>{{.DateCreated.Format "2006-01-02"}}</td>
+									<td>{{if .LastUsed}}{{.LastUsed.Format "2006-01-02"}}{{else}}{{t $.locale "settings.never_used"}}{{end}}</td>
+									<td><button class="tiny secondary" type="submit" name="identity-remove" value="{{.Id}}">{{t $.locale "settings.unlink"}}</button></td>
+								</tr>{{else}}
+								<tr><td colspan="5">{{t $.locale "settings.no_identities"}}</td></tr>{{end}}
+							</tbody>
+						</table>
+					</form>{{range .oidcProviders}}
+					<form action="/settings/identities/{{.Name}}" method="post" class="inline-form">
+						<button type="submit" class="small">{{t $.locale "settings.link_provider" .Label}}</button>
+					</form>{{end}}
+				</section>{{end}}
+				<section>
+					<h2>{{t $.locale "settings.tokens"}}</h2>
+					<h6>{{t $.locale "settings.tokens_help"}} <a href="/api/v1/openapi.json">{{t $.locale "settings.api_reference"}}</a></h6>{{if .newToken}}
+					<div class="panel"><code>{{.newToken}}</code></div>{{end}}
+					<form action="{{.formAction}}" method="post">
+						<table>
+							<thead>
+								<tr><th>{{t $.locale "settings.name"}}</th><th>{{t $.locale "settings.access"}}</th><th>{{t $.locale "settings.created"}}</th><th>{{t $.locale "settings.last_used"}}</th><th></th></tr>
+							</thead>
+							<tbody>{{range .apiTokens}}
+								<tr>
+									<td>{{if .ClientName}}{{.ClientName}} <small>({{t $.locale "settings.application"}})</small>{{else}}{{.Name}}{{end}}</td>
+									<td>{{range $i, $scope := .Scopes}}{{if $i}}, {{end}}{{$scope}}{{end}}</td>
+									<td>{{.DateCreated.Format "2006-01-02"}}</td>
+									<td>{{if .LastUsed}}{{.LastUsed.Format "2006-01-02"}}{{else}}{{t $.locale "settings.never_used"}}{{end}}</td>
+									<td><button class="tiny secondary" type="submit" name="token-revoke" value="{{.Id}}">{{t $.locale "settings.revoke"}}</button></td>
+								</tr>{{else}}
+								<tr><td colspan="5">{{t $.locale "settings.no_tokens"}}</td></tr>{{end}}
+							</tbody>
+						</table>
+					</form>
+					<form action="{{.formAction}}" method="post">
+						<div class="row">
+							<div class="large-4 columns left">
+								<label>
+									{{t $.locale "settings.token_name"}}
+									<input type="text" name="tokenName"{{if .tokenName}} value="{{.tokenName}}"{{end}}>
+								</label>
+							</div>
+							<div class="large-4 columns left">
+								<label>{{t $.locale "settings.access"}}</label>
+								<label><input type="checkbox" name="tokenScope" value="read" checked> {{t $.locale "settings.scope_read"}}</label>
+								<label><input type="checkbox" name="tokenScope" value="write"> {{t $.locale "settings.scope_write"}}</label>
+							</div>
+						</div>
+						<button type="submit" name="token-add" value="true">{{t $.locale "settings.create_token"}}</button>
+					</form>
+				</section>
+				<section>
+					<h2>{{t $.locale "settings.export"}}</h2>
+					<h6>{{t $.locale "settings.export_help"}}</h6>{{if .exports}}
+					<table>
+						<thead>
+							<tr><th>{{t $.locale "settings.requested"}}</th><th>{{t $.locale "settings.status"}}</th><th>{{t $.locale "settings.size"}}</th><th></th></tr>
+						</thead>
+						<tbody>{{range .exports}}
+							<tr>
+								<td>{{.DateRequested.Format "2006-01-02 15:04"}}</td>
+								<td>{{t $.locale (printf "export.%s" .Status)}}</td>
+								<td>{{if .Size}}{{plural $.locale "settings.bytes" .Size}}{{end}}</td>
+								<td>{{with index $.exportLinks .Id}}<a href="{{.}}">{{t $.locale "settings.download"}}</a>{{end}}</td>
+							</tr>{{end}}
+						</tbody>
+					</table>{{end}}
+					<form action="{{.formAction}}" method="post">
+						<button type="submit" name="export-request" value="true">{{t $.locale "settings.request_export"}}</button>
+					</form>
+				</section>
+				<section>
+					<h2>{{t $.locale "settings.security"}}</h2>
+					<table>
+						<thead>
+							<tr><th>{{t $.locale "settings.date"}}</th><th>{{t $.locale "settings.event"}}</th><th>{{t $.locale "settings.ip_address"}}</th><th>{{t $.locale "settings.browser"}}</th></tr>
+						</thead>
+						<tbody>{{range .securityEvents}}
+							<tr>
+								<td>{{.Date.Format "2006-01-02 15:04"}}</td>
+								<td>{{t $.locale (printf "audit.%s" .Action)}}</td>
+								<td>{{.IpAddress}}</td>
+								<td>{{.UserAgent}}</td>
+							</tr>{{else}}
+							<tr><td colspan="4">{{t $.locale "settings.no_security_events"}}</td></tr>{{end}}
+						</tbody>
+					</table>
+				</section>
+				<section>
+					<h2>{{t $.locale "settings.delete_account"}}</h2>{{if .deletionScheduled}}
+					<h6>{{t $.locale "settings.deletion_pending" (.deletionScheduled.Format "2006-01-02")}}</h6>
+					<form action="{{.formAction}}" method="post">
+						<button type="submit" name="account-delete-cancel" value="true">{{t $.locale "settings.cancel_deletion"}}</button>
+					</form>{{else}}
+					<h6>{{t $.locale "settings.delete_help"}}</h6>
+					<form action="{{.formAction}}" method="post">
+						<div class="row">
+							<div class="large-4 columns left">
+								<label>
+									{{t $.locale "settings.password"}}
+									<input type="password" name="deletePassword">
+								</label>
+							</div>
+						</div>
+						<button type="submit" class="alert" name="account-delete" value="true">{{t $.locale "settings.delete_my_account"}}</button>
+					</form>{{end}}
+				</section>{{if or .isAdmin .isModerator}}
+				<section>
+					<h2>{{t $.locale "settings.administration"}}</h2>{{if .isAdmin}}
+					<a href="/admin" class="button">{{t $.locale "settings.admin_dashboard"}}</a>{{end}}{{if .isModerator}}
+					<a href="/admin/reports" class="button">{{t $.locale "settings.reports"}}</a>{{end}}
+				</section>{{end}}
 			</div>
 		</div>
 	</div>
 	<script src="/static/js/settings.js"></script>
+	<script src="/static/js/passkeys.js"></script>
 `
@@ -0,0 +1,58 @@
+/* ---------- Home Feed ---------- */
+var loadingFeed = false;
+
+function feedItem(feed, item) {
+	var author;
+	if(item.author == "") {
+		author = $("<strong>").text(feed.data("anonymous"));
+	} else if(item.profileUrl) {
+		author = $("<strong>").append($("<a>", { "href": item.profileUrl }).text(item.author));
+	} else {
+		author = $("<strong>").text(item.author);
+	}
+
+	var heading = $("<p>").append(
+		author,
+		" " + (item.isPage ? feed.data("added") : feed.data("posted-on")) + " ",
+		$("<a>", { "href": item.pageUrl }).text(item.pageTitle),
+		" ",
+		$("<small>").text(item.date)
+	);
+	return $("<div>", { "class": "columns feed-item" }).append(heading, $("<p>").text(item.body));
+}
+
+function loadMoreFeed() {
+	var feed = $("#feed");
+	if(loadingFeed || feed.data("more") != true) {
+		return;
+	}
+
+	loadingFeed = true;
+	$.post(
+		"/ajax/feed",
+		{ "asOf": feed.data("as-of"), "offset": feed.data("offset") }
+	).done(
+		function(data) {
+			console.log(data);
+			if(typeof data.items == "undefined") {
+				return;
+			}
+			$.each(data.items, function(i, item) {
+				feed.append(feedItem(feed, item));
+			});
+			feed.data("offset", data.nextOffset);
+			feed.data("more", data.more);
+		}
+	).always(
+		function() {
+			loadingFeed = false;
+		}
+	);
+}
+
+// Load the next page when the user scrolls near the bottom
+$(window).scroll(function() {
+	if($(window).scrollTop() + $(window).height() > $(document).height() - 400) {
+		loadMoreFeed();
+	}
+});
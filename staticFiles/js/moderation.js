@@ -0,0 +1,18 @@
+/* ---------- Moderation ---------- */
+function removePost(postId, link) {
+	if(!confirm($(link).data("confirm"))) {
+		return;
+	}
+
+	$.post(
+		"/ajax/removePost",
+		{ "postid": postId }
+	).done(
+		function(data) {
+			console.log(data);
+			if(typeof data.error == "undefined") {
+				$("#post-" + postId).remove();
+			}
+		}
+	);
+}
@@ -0,0 +1,73 @@
+/* ---------- Notifications ---------- */
+function showUnreadNotifications(unread) {
+	var badge = $("#unread-notifications");
+	if(unread > 0) {
+		badge.text(unread).show();
+	} else {
+		badge.hide();
+	}
+}
+
+function loadUnreadNotifications() {
+	$.post("/ajax/unreadNotifications").done(
+		function(data) {
+			if(typeof data.number != "undefined") {
+				showUnreadNotifications(data.number);
+			}
+		}
+	);
+}
+
+function markNotificationRead(notificationId) {
+	$.post(
+		"/ajax/markNotificationRead",
+		{ "notificationid": notificationId }
+	).done(
+		function(data) {
+			console.log(data);
+			$("#notification-" + notificationId).removeClass("unread").find(".mark-read").remove();
+			loadUnreadNotifications();
+		}
+	);
+}
+
+// Marks the notification read before following its link
+function openNotification(notificationId, href) {
+	$.post(
+		"/ajax/markNotificationRead",
+		{ "notificationid": notificationId }
+	).always(
+		function() {
+			window.location = href;
+		}
+	);
+	return false;
+}
+
+function markAllNotificationsRead() {
+	$.post("/ajax/markAllNotificationsRead").done(
+		function(data) {
+			console.log(data);
+			$(".notification").removeClass("unread");
+			$(".mark-read").remove();
+			showUnreadNotifications(0);
+		}
+	);
+}
+
+function setPageSubscription(pageId, subscribed) {
+	$.post(
+		"/ajax/" + (subscribed ? "followPage" : "unfollowPage"),
+		{ "pageid": pageId }
+	).done(
+		function(data) {
+			console.log(data);
+			if(typeof data.error == "undefined") {
+				$("#follow-page").toggle(!subscribed);
+				$("#unfollow-page").toggle(subscribed);
+			}
+		}
+	);
+}
+
+$(document).ready(loadUnreadNotifications);
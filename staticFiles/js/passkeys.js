@@ -0,0 +1,119 @@
+/* ---------- Passkeys ---------- */
+function base64urlToBuffer(value) {
+	var base64 = value.replace(/-/g, "+").replace(/_/g, "/");
+	while(base64.length % 4) {
+		base64 += "=";
+	}
+	var binary = atob(base64);
+	var bytes = new Uint8Array(binary.length);
+	for(var i = 0; i < binary.length; i++) {
+		bytes[i] = binary.charCodeAt(i);
+	}
+	return bytes.buffer;
+}
+
+function bufferToBase64url(buffer) {
+	var bytes = new Uint8Array(buffer);
+	var binary = "";
+	for(var i = 0; i < bytes.length; i++) {
+		binary += String.fromCharCode(bytes[i]);
+	}
+	return btoa(binary).replace(/\+/g, "-").replace(/\//g, "_").replace(/=+$/, "");
+}
+
+function postJSON(url, body) {
+	return $.ajax({
+		url: url,
+		type: "POST",
+		contentType: "application/json",
+		data: JSON.stringify(body || {}),
+		dataType: "json"
+	});
+}
+
+function passkeyError(message) {
+	$("#passkey-error").text(message).show();
+}
+
+function passkeyFailed(xhr) {
+	if(xhr && xhr.responseJSON && xhr.responseJSON.error) {
+		passkeyError(xhr.responseJSON.error);
+	} else {
+		passkeyError($("#passkey-error").data("failed"));
+	}
+}
+
+function passkeysSupported() {
+	if(!window.PublicKeyCredential) {
+		passkeyError($("#passkey-error").data("unsupported"));
+		return false;
+	}
+	return true;
+}
+
+function addPasskey() {
+	if(!passkeysSupported()) {
+		return;
+	}
+	var name = $("#passkey-name").val();
+
+	postJSON("/webauthn/register/options").done(
+		function(options) {
+			options.challenge = base64urlToBuffer(options.challenge);
+			options.user.id = base64urlToBuffer(options.user.id);
+			$.each(options.excludeCredentials, function(i, credential) {
+				credential.id = base64urlToBuffer(credential.id);
+			});
+
+			navigator.credentials.create({ publicKey: options }).then(
+				function(credential) {
+					postJSON("/webauthn/register", {
+						name: name,
+						clientDataJSON: bufferToBase64url(credential.response.clientDataJSON),
+						attestationObject: bufferToBase64url(credential.response.attestationObject)
+					}).done(
+						function() {
+							window.location.reload();
+						}
+					).fail(passkeyFailed);
+				},
+				function(err) {
+					console.log(err);
+					passkeyError($("#passkey-error").data("not-added"));
+				}
+			);
+		}
+	).fail(passkeyFailed);
+}
+
+function logInWithPasskey() {
+	if(!passkeysSupported()) {
+		return;
+	}
+
+	postJSON("/webauthn/login/options").done(
+		function(options) {
+			options.challenge = base64urlToBuffer(options.challenge);
+
+			navigator.credentials.get({ publicKey: options }).then(
+				function(credential) {
+					postJSON("/webauthn/login", {
+						id: bufferToBase64url(credential.rawId),
+						clientDataJSON: bufferToBase64url(credential.response.clientDataJSON),
+						authenticatorData: bufferToBase64url(credential.response.authenticatorData),
+						signature: bufferToBase64url(credential.response.signature),
+						userHandle: credential.response.userHandle ? bufferToBase64url(credential.response.userHandle) : ""
+					}).done(
+						function() {
+							window.location.reload();
+						}
+					).fail(passkeyFailed);
+				},
+				function(err) {
+					console.log(err);
+					passkeyError($("#passkey-error").data("cancelled"));
+				}
+			);
+		}
+	).fail(passkeyFailed);
+}
@@ -1,7 +1,9 @@
 /* ---------- Communities Settings ---------- */
 function registerCommunityCheckboxes() {
-	$(".communityCheckbox").on(
+	// Delegated so that checkboxes added by search results work too
+	$(document).on(
 		"click",
+		".communityCheckbox",
 		function() {
 			console.log( "Sending AJAX Request: Name: " + this.name + ", Value: " + this.value + ", Checked: " + this.checked );
 			if(this.checked) {
@@ -9,7 +11,18 @@ function registerCommunityCheckboxes() {
 			} else {
 				action = "removeCommunity";
 			}
-			
+
+			// Keep every checkbox for this community in sync
+			$(".communityCheckbox[name='" + this.name + "']").prop("checked", this.checked);
+
+			// New memberships start out public
+			var visibility = $(".membershipVisibility[data-community='" + this.name + "']");
+			if(this.checked) {
+				visibility.val("public").show();
+			} else {
+				visibility.hide();
+			}
+
 			$.post(
 				"/ajax/" + action,
 				{ "communityid": this.name }
@@ -23,15 +36,101 @@ function registerCommunityCheckboxes() {
 	);
 }
 
+function registerMembershipVisibility() {
+	$(document).on(
+		"change",
+		".membershipVisibility",
+		function() {
+			var community = $(this).data("community");
+			var visibility = $(this).val();
+			$(".membershipVisibility[data-community='" + community + "']").val(visibility);
+
+			$.post(
+				"/ajax/setMembershipVisibility",
+				{ "communityid": community, "visibility": visibility }
+			).done(
+				function(data) {
+					console.log(data);
+				}
+			);
+		}
+	);
+}
+
+function communityCheckbox(community) {
+	var label = $("<label>");
+	var checkbox = $("<input>", {
+		"class": "communityCheckbox",
+		"type": "checkbox",
+		"name": community.id,
+		"value": community.name
+	}).prop("checked", community.isMember);
+	var link = $("<a>", { "href": "/community/" + community.id }).text(community.name);
+	label.append(checkbox, " ", link);
+	if(community.parentName) {
+		label.append($("<small>").text(" " + $("#communities-search-results").data("in") + " " + community.parentName));
+	}
+	return $("<div>").append(label);
+}
+
+function searchCommunities() {
+	var query = $("#communities-search-textbox").val();
+	$.post(
+		"/ajax/searchCommunities",
+		{ "query": query }
+	).done(
+		function(data) {
+			console.log(data);
+			var results = $("#communities-search-results").empty();
+			if(typeof data.communities == "undefined") {
+				return;
+			}
+			if(data.communities.length == 0) {
+				results.append(
+					$("<p>").text(results.data("none") + " ").append(
+						$("<a>", { "href": "/communities/new" }).text(results.data("propose"))
+					)
+				);
+			}
+			$.each(data.communities, function(i, community) {
+				results.append(communityCheckbox(community));
+			});
+		}
+	);
+	return false;
+}
+
+function loadCommunitySuggestions() {
+	if($("#community-suggestions").length == 0) {
+		return;
+	}
+
+	$.post("/ajax/suggestCommunities").done(
+		function(data) {
+			console.log(data);
+			if(typeof data.communities == "undefined" || data.communities.length == 0) {
+				return;
+			}
+			var suggestions = $("#community-suggestions").empty();
+			$.each(data.communities, function(i, community) {
+				suggestions.append(communityCheckbox(community));
+			});
+			$("#community-suggestions-section").show();
+		}
+	);
+}
+
 function logoutOtherSessions(clickedButton) {
 	$.post("/ajax/logoutOtherSessions").done(
 		function(data) {
 			console.log(data);
 			if(typeof data.number != "undefined") {
-				$("#numOpenSessions").html("0");
+				$("#openSessions").text($("#openSessions").data("none"));
 			}
 		}
 	)
 }
 
-$(document).ready(registerCommunityCheckboxes);
\ No newline at end of file
+$(document).ready(registerCommunityCheckboxes);
+$(document).ready(registerMembershipVisibility);
+$(document).ready(loadCommunitySuggestions);
@@ -0,0 +1,16 @@
+package templates
+
+const AdminNav = `
+				<dl class="sub-nav">
+					<dt>{{t $.locale "admin.nav"}}</dt>
+					<dd><a href="/admin">{{t $.locale "admin.nav.statistics"}}</a></dd>
+					<dd><a href="/admin/users">{{t $.locale "admin.nav.users"}}</a></dd>
+					<dd><a href="/admin/categories">{{t $.locale "admin.nav.categories"}}</a></dd>
+					<dd><a href="/admin/import">{{t $.locale "admin.nav.import"}}</a></dd>
+					<dd><a href="/admin/communities">{{t $.locale "admin.nav.communities"}}</a></dd>
+					<dd><a href="/admin/reports">{{t $.locale "admin.nav.reports"}}</a></dd>
+					<dd><a href="/admin/federation">{{t $.locale "admin.nav.federation"}}</a></dd>
+					<dd><a href="/admin/audit">{{t $.locale "admin.nav.audit"}}</a></dd>
+					<dd><a href="/admin/webhooks">{{t $.locale "admin.nav.webhooks"}}</a></dd>
+				</dl>
+`
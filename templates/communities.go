@@ -1,19 +1,33 @@
 package templates
 
 const Communities = `
-					<h2>Your Communities</h2>
-					<h6>Check all that apply.</h6>
+					<h2>{{t $.locale "communities.yours"}}</h2>
+					<h6>{{t $.locale "communities.check_all"}} <a href="/communities/new">{{t $.locale "communities.propose"}}</a>.</h6>
 					<noscript>
-						<small class="error">This site requires JavaScript to function!</small>
+						<small class="error">{{t $.locale "common.requires_javascript"}}</small>
 					</noscript>
+{{template "communitySearch" .}}
 					<div class="row">{{range $col_number, $communitiesCol := $.communitiesCols}}
 						<div class="large-3 medium-6 small-12 columns left">{{range $line_number, $community := $communitiesCol}}
-							<div>
-								<label>
-									<input class="communityCheckbox" type="checkbox" name="{{$community.Id}}"{{if eq $community.IsMember true}} checked="checked"{{end}} value="{{$community.Name}}">
-									{{$community.Name}}
-								</label>
-							</div>{{end}}
+							{{template "communityNode" withLocale $.locale $community}}{{end}}
 						</div>{{end}}
 					</div>
+{{define "communityNode"}}{{$locale := .locale}}{{with .value}}
+							<div class="community-node">
+								<label>
+									<input class="communityCheckbox" type="checkbox" name="{{.Id}}"{{if eq .IsMember true}} checked="checked"{{end}} value="{{.Name}}">
+									<a href="/community/{{.Id}}">{{.Name}}</a>
+								</label>
+								<select class="membershipVisibility" data-community="{{.Id}}" title="{{t $locale "communities.visibility_title"}}"{{if not .IsMember}} style="display: none"{{end}}>
+									<option value="public"{{if eq .Visibility "public"}} selected{{end}}>{{t $locale "communities.visibility_public"}}</option>
+									<option value="members"{{if eq .Visibility "members"}} selected{{end}}>{{t $locale "communities.visibility_members"}}</option>
+									<option value="private"{{if eq .Visibility "private"}} selected{{end}}>{{t $locale "communities.visibility_private"}}</option>
+								</select>{{if .Children}}
+								<details{{if .ChildIsMember}} open{{end}}>
+									<summary>{{plural $locale "communities.more_specific" (len .Children)}}</summary>
+									<div class="community-children">{{range .Children}}
+										{{template "communityNode" withLocale $locale .}}{{end}}
+									</div>
+								</details>{{end}}
+							</div>{{end}}{{end}}
 `
@@ -1,59 +1,23 @@
 package templates
 
 const CommunitySearch = `
-    <form action="COMMUNITIES-SEARCH-FORM-ACTION-REPLACE-ME" method="post">
-        <div class="row collapse">
-            <div class="small-10 columns">
-                <input type="text" placeholder="Communities Search" name="communities-search" id="communities-search-textbox">
-            </div>
-            <div class="small-2 columns">
-                <button type="submit" class="button postfix">Search</button>
-            </div>
-        </div>
-    </form>
-    <form action="USER-ADD-COMMUNITIES-FORM-ACTION-REPLACE-ME" method="post">
-        <div class="row">
-            <div class="large-6 medium-6 small-12 columns left">
-                <label>
-                    <input type="checkbox" name="add-community-checkbox" value="NAME-OR-ID-OF-COMMUNITY">
-                        NAME OF COMMUNITY
-                </label>
-                <label>
-                    <input type="checkbox" name="add-community-checkbox" value="NAME-OR-ID-OF-COMMUNITY">
-                        NAME OF COMMUNITY
-                </label>
-                <label>
-                    <input type="checkbox" name="add-community-checkbox" value="NAME-OR-ID-OF-COMMUNITY">
-                        NAME OF COMMUNITY
-                </label>
-                <label>
-                    <input type="checkbox" name="add-community-checkbox" value="NAME-OR-ID-OF-COMMUNITY">
-                        NAME OF COMMUNITY
-                </label>
-                <label>
-                    <input type="checkbox" name="add-community-checkbox" value="NAME-OR-ID-OF-COMMUNITY">
-                        NAME OF COMMUNITY
-                </label>
-            </div>
-            <div class="large-6 medium-6 small-12 columns left">
-                <label>
-                    <input type="checkbox" name="add-community-checkbox" value="NAME-OR-ID-OF-COMMUNITY">
-                        NAME OF COMMUNITY
-                </label>
-                <label>
-                    <input type="checkbox" name="add-community-checkbox" value="NAME-OR-ID-OF-COMMUNITY">
-                        NAME OF COMMUNITY
-                </label>
-                <label>
-                    <input type="checkbox" name="add-community-checkbox" value="NAME-OR-ID-OF-COMMUNITY">
-                        NAME OF COMMUNITY
-                </label>
-                <label>
-                    <input type="checkbox" name="add-community-checkbox" value="NAME-OR-ID-OF-COMMUNITY">
-                        NAME OF COMMUNITY
-                </label>
-            </div>
-        </div>
-        <button type="submit">Add</button>
-    </form>
+					<form id="communities-search-form" onsubmit="return searchCommunities()">
+						<div class="row collapse">
+							<div class="small-10 columns">
+								<input type="text" placeholder="{{t $.locale "communities.search_placeholder"}}" name="communities-search" id="communities-search-textbox" autocomplete="off">
+							</div>
+							<div class="small-2 columns">
+								<button type="submit" class="button postfix">{{t $.locale "search.button"}}</button>
+							</div>
+						</div>
+					</form>
+					<div class="row">
+						<div class="columns" id="communities-search-results" data-none="{{t $.locale "communities.none_found"}}" data-propose="{{t $.locale "communities.propose"}}" data-in="{{t $.locale "communities.in_parent"}}"></div>
+					</div>
+					<div class="row" id="community-suggestions-section" style="display: none;">
+						<div class="columns">
+							<h6>{{t $.locale "communities.suggestions"}}</h6>
+							<div id="community-suggestions"></div>
+						</div>
+					</div>
 `
@@ -5,7 +5,7 @@ const NavBar = `
 		<ul class="title-area">
 			<li class="name"></li>
 			<li class="toggle-topbar menu-icon">
-				<a href="#">Menu <span class="icon-menu"></span></a>
+				<a href="#">{{t $.locale "nav.menu"}} <span class="icon-menu"></span></a>
 			</li>
 		</ul>
 		<section class="top-bar-section">
@@ -16,20 +16,24 @@ const NavBar = `
 			</ul>
 			<ul class="right">
 				<li>
-					<a href="/" title="Home"><i class="fi-home"><span class="show-for-small-only"> Home</span></i></a>
+					<a href="/" title="{{t $.locale "nav.home"}}"><i class="fi-home"><span class="show-for-small-only"> {{t $.locale "nav.home"}}</span></i></a>
 				</li>
 				<li>
-					<a href="/newPage" title="Add Resource"><i class="fi-page-add"><span class="show-for-small-only"> Add Resource</span></i></a>
+					<a href="/newPage" title="{{t $.locale "nav.add_resource"}}"><i class="fi-page-add"><span class="show-for-small-only"> {{t $.locale "nav.add_resource"}}</span></i></a>
 				</li>
 				<li>
-					<a href="/settings" title="Settings"><i class="fi-widget"><span class="show-for-small-only"> Settings</span></i></a>
+					<a href="/notifications" title="{{t $.locale "nav.notifications"}}"><i class="fi-mail"><span class="show-for-small-only"> {{t $.locale "nav.notifications"}}</span></i> <span id="unread-notifications" class="round alert label"></span></a>
 				</li>
 				<li>
-					<a href="/logout" title="Log Out"><i class="fi-power"><span class="show-for-small-only"> Logout</span></i></a>
+					<a href="/settings" title="{{t $.locale "nav.settings"}}"><i class="fi-widget"><span class="show-for-small-only"> {{t $.locale "nav.settings"}}</span></i></a>
+				</li>
+				<li>
+					<a href="/logout" title="{{t $.locale "nav.log_out"}}"><i class="fi-power"><span class="show-for-small-only"> {{t $.locale "nav.log_out"}}</span></i></a>
 				</li>
 			</ul>
 		</section>
 	</nav>
+	<script src="/static/js/notifications.js"></script>
 `
 
 const NavlessBar = `
@@ -37,7 +41,7 @@ const NavlessBar = `
 		<ul class="title-area">
 			<li class="name"></li>
 			<li class="toggle-topbar menu-icon">
-				<a href="#">Menu <span class="icon-menu"></span></a>
+				<a href="#">{{t $.locale "nav.menu"}} <span class="icon-menu"></span></a>
 			</li>
 		</ul>
 		<section class="top-bar-section">
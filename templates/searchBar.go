@@ -4,10 +4,10 @@ const SearchBar = `
 	<form method="get" action="/search">
 		<div class="row collapse">
 			<div class="small-10 columns">
-			<input type="text" placeholder="Page Search" name="q" id="page-search-textbox" />
+			<input type="text" placeholder="{{t $.locale "search.placeholder"}}" name="q" id="page-search-textbox" />
 			</div>
 			<div class="small-2 columns">
-				<button type="submit" class="button postfix">Search</button>
+				<button type="submit" class="button postfix">{{t $.locale "search.button"}}</button>
 			</div>
 		</div>
 	</form>
@@ -1,7 +1,7 @@
 package templates
 
 const SiteLayout = `<!DOCTYPE html>
-<html>
+<html lang="{{lang $.locale}}">
 <head>
 	<link href="https://cdnjs.cloudflare.com/ajax/libs/foundation/5.5.0/css/normalize.min.css" rel="stylesheet" type="text/css" />
 	<link href="https://cdnjs.cloudflare.com/ajax/libs/foundation/5.5.0/css/foundation.min.css" rel="stylesheet" type="text/css" />
@@ -4,7 +4,7 @@ const Tour = `
 	<div class="content">
 		<div class="row">
 			<div class="columns communities-settings">
-				<h1><i class="fi-foundation"></i> Tour</h1>
+				<h1><i class="fi-foundation"></i> {{t $.locale "tour.title"}}</h1>
 				{{if .successMsg}}<div class="alert-box success">{{.successMsg}}</div>{{end}}
 				{{if .errorMsg}}<div class="alert-box alert">{{.errorMsg}}</div>{{end}}
 				<section>{{ template "wizardContent" .}}</section>
@@ -9,6 +9,7 @@ import (
 
 	"github.com/comforme/comforme/common"
 	"github.com/comforme/comforme/databaseActions"
+	"github.com/comforme/comforme/i18n"
 	"github.com/comforme/comforme/templates"
 )
 
@@ -16,17 +17,19 @@ var communitiesTemplate *template.Template
 
 func init() {
 	// Community selection page template
-	communitiesTemplate = template.Must(template.New("siteLayout").Parse(templates.SiteLayout))
+	communitiesTemplate = template.Must(template.New("siteLayout").Funcs(i18n.Funcs).Parse(templates.SiteLayout))
 	template.Must(communitiesTemplate.New("nav").Parse(templates.NavBar))
 	template.Must(communitiesTemplate.New("content").Parse(templates.Tour))
 	template.Must(communitiesTemplate.New("wizardContent").Parse(communitiesTemplateText))
 	template.Must(communitiesTemplate.New("communitiesContent").Parse(templates.Communities))
+	template.Must(communitiesTemplate.New("communitySearch").Parse(templates.CommunitySearch))
 }
 
 func TourHandler(res http.ResponseWriter, req *http.Request, ps httprouter.Params, userInfo common.UserInfo) {
 
 	data := map[string]interface{}{}
 	data["siteName"] = common.SiteName
+	data["locale"] = userInfo.Locale
 
 	var err error
 	data["communitiesCols"], err = databaseActions.GetCommunityColumns(userInfo.UserID)
@@ -0,0 +1,123 @@
+package webauthn
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// Just enough of a CBOR (RFC 7049) decoder for attestation objects and COSE
+// keys. Authenticators use definite lengths, so indefinite lengths, tags and
+// floats are not supported.
+
+var errCBOR = errors.New("invalid or unsupported CBOR")
+
+const maxCBORDepth = 16
+
+// Decodes the first CBOR item in data and returns it along with the number of
+// bytes it took. Integers are int64, byte strings []byte, text strings string,
+// arrays []interface{} and maps map[interface{}]interface{}.
+func decodeCBOR(data []byte) (value interface{}, length int, err error) {
+	return decodeCBORItem(data, 0)
+}
+
+func decodeCBORItem(data []byte, depth int) (value interface{}, length int, err error) {
+	if len(data) == 0 || depth > maxCBORDepth {
+		return nil, 0, errCBOR
+	}
+
+	major := data[0] >> 5
+	info := data[0] & 0x1f
+	length = 1
+
+	// The argument following the initial byte
+	var arg uint64
+	switch {
+	case info < 24:
+		arg = uint64(info)
+	case info == 24 && len(data) >= 2:
+		arg = uint64(data[1])
+		length = 2
+	case info == 25 && len(data) >= 3:
+		arg = uint64(binary.BigEndian.Uint16(data[1:]))
+		length = 3
+	case info == 26 && len(data) >= 5:
+		arg = uint64(binary.BigEndian.Uint32(data[1:]))
+		length = 5
+	case info == 27 && len(data) >= 9:
+		arg = binary.BigEndian.Uint64(data[1:])
+		length = 9
+	default:
+		return nil, 0, errCBOR
+	}
+
+	switch major {
+	case 0:
+		if arg > 1<<63-1 {
+			return nil, 0, errCBOR
+		}
+		return int64(arg), length, nil
+	case 1:
+		if arg > 1<<63-1 {
+			return nil, 0, errCBOR
+		}
+		return -1 - int64(arg), length, nil
+	case 2, 3:
+		if arg > uint64(len(data)-length) {
+			return nil, 0, errCBOR
+		}
+		end := length + int(arg)
+		if major == 2 {
+			return append([]byte{}, data[length:end]...), end, nil
+		}
+		return string(data[length:end]), end, nil
+	case 4:
+		if arg > uint64(len(data)) {
+			return nil, 0, errCBOR
+		}
+		items := make([]interface{}, 0, int(arg))
+		for i := uint64(0); i < arg; i++ {
+			item, n, err := decodeCBORItem(data[length:], depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			items = append(items, item)
+			length += n
+		}
+		return items, length, nil
+	case 5:
+		if arg > uint64(len(data)) {
+			return nil, 0, errCBOR
+		}
+		items := map[interface{}]interface{}{}
+		for i := uint64(0); i < arg; i++ {
+			key, n, err := decodeCBORItem(data[length:], depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			length += n
+			switch key.(type) {
+			case int64, string:
+			default:
+				return nil, 0, errCBOR
+			}
+
+			item, n, err := decodeCBORItem(data[length:], depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			length += n
+			items[key] = item
+		}
+		return items, length, nil
+	case 7:
+		switch info {
+		case 20:
+			return false, length, nil
+		case 21:
+			return true, length, nil
+		case 22:
+			return nil, length, nil
+		}
+	}
+	return nil, 0, errCBOR
+}
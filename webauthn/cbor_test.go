@@ -0,0 +1,97 @@
+package webauthn
+
+import (
+	"bytes"
+	"encoding/hex"
+	"reflect"
+	"testing"
+)
+
+func TestDecodeCBOR(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  string // Hex
+		value interface{}
+	}{
+		{"small integer", "17", int64(23)},
+		{"one byte integer", "1818", int64(24)},
+		{"two byte integer", "190100", int64(256)},
+		{"eight byte integer", "1b7fffffffffffffff", int64(1<<63 - 1)},
+		{"negative integer", "26", int64(-7)},
+		{"two byte negative integer", "390100", int64(-257)},
+		{"byte string", "43010203", []byte{1, 2, 3}},
+		{"text string", "646e6f6e65", "none"},
+		{"array", "820102", []interface{}{int64(1), int64(2)}},
+		{"map", "a201020363616c67", map[interface{}]interface{}{int64(1): int64(2), int64(3): "alg"}},
+		{"false", "f4", false},
+		{"true", "f5", true},
+		{"null", "f6", nil},
+	}
+
+	for _, test := range tests {
+		data, _ := hex.DecodeString(test.data)
+		// Anything after the first item is left alone
+		value, length, err := decodeCBOR(append(data, 0xff))
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+		if length != len(data) {
+			t.Errorf("%s: got length %d, want %d", test.name, length, len(data))
+		}
+		if !reflect.DeepEqual(value, test.value) {
+			t.Errorf("%s: got %#v, want %#v", test.name, value, test.value)
+		}
+	}
+}
+
+func TestDecodeCBORRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"truncated argument", []byte{0x19, 0x01}},
+		{"truncated byte string", []byte{0x43, 0x01, 0x02}},
+		{"truncated text string", []byte{0x64, 'n', 'o'}},
+		{"truncated array", []byte{0x82, 0x01}},
+		{"truncated map", []byte{0xa1, 0x01}},
+		{"oversized byte string", []byte{0x5b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+		{"oversized text string", []byte{0x7a, 0xff, 0xff, 0xff, 0xff, 0x61}},
+		{"oversized array", []byte{0x9b, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x01}},
+		{"oversized map", []byte{0xbb, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x01, 0x01}},
+		{"integer out of range", []byte{0x1b, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}},
+		{"negative integer out of range", []byte{0x3b, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}},
+		{"indefinite length", []byte{0x5f, 0x41, 0x01, 0xff}},
+		{"tag", []byte{0xc0, 0x01}},
+		{"float", []byte{0xf9, 0x3c, 0x00}},
+		{"byte string map key", []byte{0xa1, 0x41, 0x01, 0x01}},
+		{"nested too deep", append(bytes.Repeat([]byte{0x81}, maxCBORDepth+1), 0x01)},
+	}
+
+	for _, test := range tests {
+		if value, _, err := decodeCBOR(test.data); err == nil {
+			t.Errorf("%s: decoded %#v", test.name, value)
+		}
+	}
+
+	if _, _, err := decodeCBOR(append(bytes.Repeat([]byte{0x81}, maxCBORDepth), 0x01)); err != nil {
+		t.Errorf("nesting up to the limit: %s", err)
+	}
+}
+
+// No prefix of a real attestation object is a complete one.
+func TestDecodeCBORTruncated(t *testing.T) {
+	for _, name := range vectorNames {
+		vector := loadVector(t, name)
+		attestationObject := decodeField(t, vector.Registration.AttestationObject)
+		for i := 0; i < len(attestationObject); i++ {
+			if _, _, err := decodeCBOR(attestationObject[:i]); err == nil {
+				t.Errorf("%s: decoded the first %d of %d bytes", name, i, len(attestationObject))
+			}
+			if _, err := parseAttestationObject(attestationObject[:i]); err == nil {
+				t.Errorf("%s: parsed the first %d of %d bytes as an attestation object", name, i, len(attestationObject))
+			}
+		}
+	}
+}
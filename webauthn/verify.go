@@ -0,0 +1,220 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+)
+
+// COSE (RFC 8152) key parameters and algorithms
+const (
+	coseKty = 1
+	coseAlg = 3
+
+	coseKtyEC2 = 2
+	coseKtyRSA = 3
+
+	coseAlgES256 = -7
+	coseAlgRS256 = -257
+
+	coseEC2Crv   = -1
+	coseEC2X     = -2
+	coseEC2Y     = -3
+	coseCrvP256  = 1
+	coseRSAN     = -1
+	coseRSAE     = -2
+	minRSABits   = 2048
+	rpIDHashSize = 32
+)
+
+// Authenticator data flags
+const (
+	flagUserPresent  = 0x01
+	flagUserVerified = 0x04
+	flagAttestedData = 0x40
+)
+
+var errInvalid = errors.New("invalid authenticator response")
+
+type clientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Origin    string `json:"origin"`
+}
+
+// Checks the client data the browser signed over: that it is for the right
+// ceremony and origin, and returns the challenge it answers.
+func checkClientData(clientDataJSON []byte, ceremony, origin string) (challenge string, err error) {
+	var data clientData
+	err = json.Unmarshal(clientDataJSON, &data)
+	if err != nil || data.Type != ceremony || data.Origin != origin || data.Challenge == "" {
+		return "", errInvalid
+	}
+	return data.Challenge, nil
+}
+
+type authenticatorData struct {
+	rpIDHash     []byte
+	flags        byte
+	signCount    uint32
+	credentialID []byte
+	publicKey    []byte // COSE_Key, only present when registering
+}
+
+func parseAuthenticatorData(data []byte) (authData authenticatorData, err error) {
+	if len(data) < rpIDHashSize+5 {
+		return authData, errInvalid
+	}
+	authData.rpIDHash = data[:rpIDHashSize]
+	authData.flags = data[rpIDHashSize]
+	authData.signCount = binary.BigEndian.Uint32(data[rpIDHashSize+1:])
+
+	if authData.flags&flagAttestedData == 0 {
+		return authData, nil
+	}
+
+	// AAGUID, then the credential ID's length and the credential ID
+	rest := data[rpIDHashSize+5:]
+	if len(rest) < 18 {
+		return authData, errInvalid
+	}
+	idLength := int(binary.BigEndian.Uint16(rest[16:]))
+	rest = rest[18:]
+	if len(rest) < idLength {
+		return authData, errInvalid
+	}
+	authData.credentialID = rest[:idLength]
+
+	_, keyLength, err := decodeCBOR(rest[idLength:])
+	if err != nil {
+		return authData, errInvalid
+	}
+	authData.publicKey = rest[idLength : idLength+keyLength]
+	return authData, nil
+}
+
+// Checks the parts of the authenticator data common to registering and
+// logging in. Passkeys replace passwords, so the authenticator must have
+// verified the user, not just seen that someone is there.
+func (authData authenticatorData) check(rpID string) error {
+	hash := sha256.Sum256([]byte(rpID))
+	if subtle.ConstantTimeCompare(authData.rpIDHash, hash[:]) != 1 {
+		return errInvalid
+	}
+	if authData.flags&flagUserPresent == 0 || authData.flags&flagUserVerified == 0 {
+		return errors.New("the authenticator did not verify the user")
+	}
+	return nil
+}
+
+func coseInt(key map[interface{}]interface{}, label int64) (int64, bool) {
+	value, ok := key[label].(int64)
+	return value, ok
+}
+
+func coseBytes(key map[interface{}]interface{}, label int64) []byte {
+	value, _ := key[label].([]byte)
+	return value
+}
+
+// Parses a COSE_Key into a public key. Only ES256 and RS256 are supported,
+// which between them cover every current authenticator.
+func parsePublicKey(coseKey []byte) (crypto.PublicKey, int64, error) {
+	decoded, _, err := decodeCBOR(coseKey)
+	if err != nil {
+		return nil, 0, err
+	}
+	key, ok := decoded.(map[interface{}]interface{})
+	if !ok {
+		return nil, 0, errInvalid
+	}
+
+	kty, _ := coseInt(key, coseKty)
+	alg, _ := coseInt(key, coseAlg)
+	switch {
+	case kty == coseKtyEC2 && alg == coseAlgES256:
+		crv, _ := coseInt(key, coseEC2Crv)
+		x, y := coseBytes(key, coseEC2X), coseBytes(key, coseEC2Y)
+		if crv != coseCrvP256 || len(x) != 32 || len(y) != 32 {
+			return nil, 0, errInvalid
+		}
+		publicKey := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !publicKey.Curve.IsOnCurve(publicKey.X, publicKey.Y) {
+			return nil, 0, errInvalid
+		}
+		return publicKey, alg, nil
+	case kty == coseKtyRSA && alg == coseAlgRS256:
+		n, e := coseBytes(key, coseRSAN), new(big.Int).SetBytes(coseBytes(key, coseRSAE))
+		if len(n)*8 < minRSABits || !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, 0, errInvalid
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(e.Int64())}, alg, nil
+	}
+	return nil, 0, errors.New("unsupported passkey algorithm")
+}
+
+// Checks an assertion signature, which covers the authenticator data followed
+// by the hash of the client data.
+func verifySignature(coseKey, authData, clientDataJSON, signature []byte) error {
+	publicKey, alg, err := parsePublicKey(coseKey)
+	if err != nil {
+		return err
+	}
+
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	hash := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+
+	switch alg {
+	case coseAlgES256:
+		if !ecdsa.VerifyASN1(publicKey.(*ecdsa.PublicKey), hash[:], signature) {
+			return errInvalid
+		}
+		return nil
+	case coseAlgRS256:
+		return rsa.VerifyPKCS1v15(publicKey.(*rsa.PublicKey), crypto.SHA256, hash[:], signature)
+	}
+	return errInvalid
+}
+
+// Parses an attestation object from registering a passkey. The attestation
+// statement is not checked, since passkeys from any authenticator are
+// accepted, so this is equivalent to "none" attestation.
+func parseAttestationObject(attestationObject []byte) (authData authenticatorData, err error) {
+	decoded, _, err := decodeCBOR(attestationObject)
+	if err != nil {
+		return authData, errInvalid
+	}
+	object, ok := decoded.(map[interface{}]interface{})
+	if !ok {
+		return authData, errInvalid
+	}
+	rawAuthData, ok := object["authData"].([]byte)
+	if !ok {
+		return authData, errInvalid
+	}
+
+	authData, err = parseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return authData, err
+	}
+	if authData.credentialID == nil || authData.publicKey == nil {
+		return authData, errInvalid
+	}
+	if _, _, err = parsePublicKey(authData.publicKey); err != nil {
+		return authData, err
+	}
+	return authData, nil
+}
+
+func decodeBase64URL(value string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+}
@@ -0,0 +1,239 @@
+package webauthn
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+// A registration and a login recorded from a software authenticator on
+// http://localhost:5000, as the browser posts them. The ES256 passkey does
+// not keep a signature counter, and the RS256 one does.
+type vector struct {
+	RPID         string       `json:"rpId"`
+	Origin       string       `json:"origin"`
+	Registration registration `json:"registration"`
+	Assertion    assertion    `json:"assertion"`
+}
+
+var vectorNames = []string{"es256", "rs256"}
+
+func loadVector(t *testing.T, name string) vector {
+	data, err := ioutil.ReadFile(filepath.Join("testdata", name+".json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v vector
+	if err := json.Unmarshal(data, &v); err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func decodeField(t *testing.T, field string) []byte {
+	decoded, err := decodeBase64URL(field)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return decoded
+}
+
+func TestRegistration(t *testing.T) {
+	algs := map[string]int64{"es256": coseAlgES256, "rs256": coseAlgRS256}
+	counts := map[string]uint32{"es256": 0, "rs256": 1}
+
+	for _, name := range vectorNames {
+		v := loadVector(t, name)
+		if _, err := checkClientData(decodeField(t, v.Registration.ClientDataJSON), "webauthn.create", v.Origin); err != nil {
+			t.Errorf("%s: client data: %s", name, err)
+		}
+
+		authData, err := parseAttestationObject(decodeField(t, v.Registration.AttestationObject))
+		if err != nil {
+			t.Errorf("%s: %s", name, err)
+			continue
+		}
+		if err := authData.check(v.RPID); err != nil {
+			t.Errorf("%s: %s", name, err)
+		}
+		if string(authData.credentialID) != string(decodeField(t, v.Assertion.Id)) {
+			t.Errorf("%s: got credential ID %x", name, authData.credentialID)
+		}
+		if authData.signCount != counts[name] {
+			t.Errorf("%s: got sign count %d, want %d", name, authData.signCount, counts[name])
+		}
+		if _, alg, err := parsePublicKey(authData.publicKey); err != nil || alg != algs[name] {
+			t.Errorf("%s: got algorithm %d (%v), want %d", name, alg, err, algs[name])
+		}
+	}
+}
+
+func TestLogin(t *testing.T) {
+	counts := map[string]uint32{"es256": 0, "rs256": 2}
+
+	for _, name := range vectorNames {
+		v := loadVector(t, name)
+		registered, err := parseAttestationObject(decodeField(t, v.Registration.AttestationObject))
+		if err != nil {
+			t.Fatal(err)
+		}
+		clientDataJSON := decodeField(t, v.Assertion.ClientDataJSON)
+		rawAuthData := decodeField(t, v.Assertion.AuthenticatorData)
+
+		if _, err := checkClientData(clientDataJSON, "webauthn.get", v.Origin); err != nil {
+			t.Errorf("%s: client data: %s", name, err)
+		}
+		authData, err := parseAuthenticatorData(rawAuthData)
+		if err == nil {
+			err = authData.check(v.RPID)
+		}
+		if err != nil {
+			t.Errorf("%s: %s", name, err)
+		}
+		if authData.signCount != counts[name] {
+			t.Errorf("%s: got sign count %d, want %d", name, authData.signCount, counts[name])
+		}
+		if err := verifySignature(registered.publicKey, rawAuthData, clientDataJSON, decodeField(t, v.Assertion.Signature)); err != nil {
+			t.Errorf("%s: signature: %s", name, err)
+		}
+	}
+}
+
+func TestClientDataRejected(t *testing.T) {
+	tests := []struct {
+		name     string
+		ceremony string
+		origin   string
+		login    bool // Check the client data from logging in
+	}{
+		{"registration as a login", "webauthn.get", "", false},
+		{"login as a registration", "webauthn.create", "", true},
+		{"registration from another origin", "webauthn.create", "http://evil.example", false},
+		{"login from another origin", "webauthn.get", "http://evil.example", true},
+		{"login from another port", "webauthn.get", "http://localhost:5001", true},
+		{"login over https", "webauthn.get", "https://localhost:5000", true},
+	}
+
+	for _, name := range vectorNames {
+		v := loadVector(t, name)
+		for _, test := range tests {
+			clientDataJSON := decodeField(t, v.Registration.ClientDataJSON)
+			if test.login {
+				clientDataJSON = decodeField(t, v.Assertion.ClientDataJSON)
+			}
+			origin := test.origin
+			if origin == "" {
+				origin = v.Origin
+			}
+			if _, err := checkClientData(clientDataJSON, test.ceremony, origin); err == nil {
+				t.Errorf("%s: %s was accepted", name, test.name)
+			}
+		}
+	}
+
+	for _, clientDataJSON := range []string{``, `null`, `{"type":"webauthn.get","origin":"http://localhost:5000"}`} {
+		if _, err := checkClientData([]byte(clientDataJSON), "webauthn.get", "http://localhost:5000"); err == nil {
+			t.Errorf("client data %q was accepted", clientDataJSON)
+		}
+	}
+}
+
+// Changes to the authenticator data the login was signed over. Each should
+// be refused by the checks on the authenticator data, the signature or both.
+func TestAuthenticatorDataRejected(t *testing.T) {
+	tests := []struct {
+		name          string
+		rpID          string
+		change        func(authData []byte)
+		failsCheck    bool
+		failsVerifier bool
+	}{
+		{"another relying party", "evil.example", func(authData []byte) {}, true, false},
+		{"wrong rpIdHash", "", func(authData []byte) { authData[0] ^= 1 }, true, true},
+		{"missing UV flag", "", func(authData []byte) { authData[rpIDHashSize] &^= flagUserVerified }, true, true},
+		{"missing UP flag", "", func(authData []byte) { authData[rpIDHashSize] &^= flagUserPresent }, true, true},
+		{"changed sign count", "", func(authData []byte) { authData[rpIDHashSize+4]++ }, false, true},
+	}
+
+	for _, name := range vectorNames {
+		v := loadVector(t, name)
+		registered, err := parseAttestationObject(decodeField(t, v.Registration.AttestationObject))
+		if err != nil {
+			t.Fatal(err)
+		}
+		clientDataJSON := decodeField(t, v.Assertion.ClientDataJSON)
+		signature := decodeField(t, v.Assertion.Signature)
+
+		for _, test := range tests {
+			rawAuthData := decodeField(t, v.Assertion.AuthenticatorData)
+			test.change(rawAuthData)
+			rpID := test.rpID
+			if rpID == "" {
+				rpID = v.RPID
+			}
+
+			authData, err := parseAuthenticatorData(rawAuthData)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := authData.check(rpID); (err != nil) != test.failsCheck {
+				t.Errorf("%s: %s: got check error %v", name, test.name, err)
+			}
+			if err := verifySignature(registered.publicKey, rawAuthData, clientDataJSON, signature); (err != nil) != test.failsVerifier {
+				t.Errorf("%s: %s: got signature error %v", name, test.name, err)
+			}
+		}
+
+		// Registering is checked the same way
+		registered.flags &^= flagUserVerified
+		if err := registered.check(v.RPID); err == nil {
+			t.Errorf("%s: registration without the UV flag was accepted", name)
+		}
+	}
+}
+
+func TestSignatureRejected(t *testing.T) {
+	es256, rs256 := loadVector(t, "es256"), loadVector(t, "rs256")
+	esKey, err := parseAttestationObject(decodeField(t, es256.Registration.AttestationObject))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsKey, err := parseAttestationObject(decodeField(t, rs256.Registration.AttestationObject))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tamperedSignature := decodeField(t, es256.Assertion.Signature)
+	tamperedSignature[len(tamperedSignature)-1] ^= 1
+
+	tests := []struct {
+		name           string
+		publicKey      []byte
+		v              vector
+		clientDataJSON []byte
+		signature      []byte
+	}{
+		{"ES256 signature with the RS256 key", rsKey.publicKey, es256, nil, nil},
+		{"RS256 signature with the ES256 key", esKey.publicKey, rs256, nil, nil},
+		{"tampered signature", esKey.publicKey, es256, nil, tamperedSignature},
+		{"empty signature", esKey.publicKey, es256, nil, []byte{}},
+		{"other client data", esKey.publicKey, es256, decodeField(t, es256.Registration.ClientDataJSON), nil},
+		{"unsupported key", []byte{0xa2, 0x01, 0x01, 0x03, 0x27}, es256, nil, nil}, // OKP, EdDSA
+		{"not a key", []byte{0x01}, es256, nil, nil},
+	}
+
+	for _, test := range tests {
+		clientDataJSON := test.clientDataJSON
+		if clientDataJSON == nil {
+			clientDataJSON = decodeField(t, test.v.Assertion.ClientDataJSON)
+		}
+		signature := test.signature
+		if signature == nil {
+			signature = decodeField(t, test.v.Assertion.Signature)
+		}
+		if err := verifySignature(test.publicKey, decodeField(t, test.v.Assertion.AuthenticatorData), clientDataJSON, signature); err == nil {
+			t.Errorf("%s was accepted", test.name)
+		}
+	}
+}
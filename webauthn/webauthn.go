@@ -0,0 +1,298 @@
+// Package webauthn lets users register passkeys and log in with them instead
+// of a password, using the Web Authentication API.
+package webauthn
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/comforme/comforme/ajax"
+	"github.com/comforme/comforme/common"
+	"github.com/comforme/comforme/databaseActions"
+	"github.com/comforme/comforme/i18n"
+)
+
+// How long the browser waits for the user, in milliseconds.
+const timeout = 5 * 60 * 1000
+
+type credentialDescriptor struct {
+	Type string `json:"type"`
+	Id   string `json:"id"`
+}
+
+type credentialParameter struct {
+	Type string `json:"type"`
+	Alg  int64  `json:"alg"`
+}
+
+type relyingParty struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type user struct {
+	Id          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+type authenticatorSelection struct {
+	ResidentKey        string `json:"residentKey"`
+	RequireResidentKey bool   `json:"requireResidentKey"`
+	UserVerification   string `json:"userVerification"`
+}
+
+// PublicKeyCredentialCreationOptions, with binary fields base64url encoded.
+type creationOptions struct {
+	Challenge              string                 `json:"challenge"`
+	RP                     relyingParty           `json:"rp"`
+	User                   user                   `json:"user"`
+	PubKeyCredParams       []credentialParameter  `json:"pubKeyCredParams"`
+	ExcludeCredentials     []credentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection authenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                 `json:"attestation"`
+	Timeout                int                    `json:"timeout"`
+}
+
+// PublicKeyCredentialRequestOptions. No credentials are listed, so the user
+// picks one of the passkeys stored on their authenticator.
+type requestOptions struct {
+	Challenge        string `json:"challenge"`
+	RPID             string `json:"rpId"`
+	UserVerification string `json:"userVerification"`
+	Timeout          int    `json:"timeout"`
+}
+
+type registration struct {
+	Name              string `json:"name"`
+	ClientDataJSON    string `json:"clientDataJSON"`
+	AttestationObject string `json:"attestationObject"`
+}
+
+type assertion struct {
+	Id                string `json:"id"`
+	ClientDataJSON    string `json:"clientDataJSON"`
+	AuthenticatorData string `json:"authenticatorData"`
+	Signature         string `json:"signature"`
+	UserHandle        string `json:"userHandle"`
+}
+
+type result struct {
+	Ok bool `json:"ok"`
+}
+
+func writeJSON(res http.ResponseWriter, status int, value interface{}) {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		log.Println("Error marshaling passkey response:", err)
+		status = http.StatusInternalServerError
+		encoded = []byte(ajax.JSONError)
+	}
+
+	res.Header().Set("Content-Type", "application/json; charset=utf-8")
+	res.WriteHeader(status)
+	fmt.Fprintln(res, string(encoded))
+}
+
+func writeError(res http.ResponseWriter, locale string, err error) {
+	status := http.StatusBadRequest
+	if err == common.DatabaseError {
+		status = http.StatusInternalServerError
+	}
+	writeJSON(res, status, ajax.AjaxError{Message: i18n.ErrorMessage(locale, err)})
+}
+
+// Only JSON is accepted, which also stops other sites from posting forms.
+func decodeBody(req *http.Request, value interface{}) bool {
+	mediaType, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil || mediaType != "application/json" {
+		return false
+	}
+	return json.NewDecoder(req.Body).Decode(value) == nil
+}
+
+// The relying party ID is the site's host name, without a port.
+func rpID(req *http.Request) string {
+	host, _, err := net.SplitHostPort(req.Host)
+	if err != nil {
+		return req.Host
+	}
+	return host
+}
+
+// Passkeys are tied to the user's ID rather than their email or username,
+// which can change.
+func userHandle(userID int) string {
+	return strconv.Itoa(userID)
+}
+
+func RegisterOptionsHandler(res http.ResponseWriter, req *http.Request, ps httprouter.Params, userInfo common.UserInfo) {
+	challenge, err := databaseActions.NewPasskeyChallenge(userInfo.UserID)
+	if err != nil {
+		writeError(res, userInfo.Locale, err)
+		return
+	}
+
+	credentialIDs, err := databaseActions.GetPasskeyCredentialIDs(userInfo.UserID)
+	if err != nil {
+		writeError(res, userInfo.Locale, err)
+		return
+	}
+	exclude := []credentialDescriptor{}
+	for _, credentialID := range credentialIDs {
+		exclude = append(exclude, credentialDescriptor{"public-key", base64.RawURLEncoding.EncodeToString(credentialID)})
+	}
+
+	writeJSON(res, http.StatusOK, creationOptions{
+		Challenge: challenge,
+		RP:        relyingParty{rpID(req), common.SiteName},
+		User: user{
+			Id:          base64.RawURLEncoding.EncodeToString([]byte(userHandle(userInfo.UserID))),
+			Name:        userInfo.Email,
+			DisplayName: userInfo.Username,
+		},
+		PubKeyCredParams: []credentialParameter{
+			{"public-key", coseAlgES256},
+			{"public-key", coseAlgRS256},
+		},
+		ExcludeCredentials:     exclude,
+		AuthenticatorSelection: authenticatorSelection{"required", true, "required"},
+		Attestation:            "none",
+		Timeout:                timeout,
+	})
+}
+
+func RegisterHandler(res http.ResponseWriter, req *http.Request, ps httprouter.Params, userInfo common.UserInfo) {
+	var body registration
+	if !decodeBody(req, &body) {
+		writeError(res, userInfo.Locale, common.PasskeyVerifyFailed)
+		return
+	}
+
+	clientDataJSON, err := decodeBase64URL(body.ClientDataJSON)
+	if err != nil {
+		writeError(res, userInfo.Locale, common.PasskeyVerifyFailed)
+		return
+	}
+	attestationObject, err := decodeBase64URL(body.AttestationObject)
+	if err != nil {
+		writeError(res, userInfo.Locale, common.PasskeyVerifyFailed)
+		return
+	}
+
+	challenge, err := checkClientData(clientDataJSON, "webauthn.create", common.GetBaseURL(req))
+	if err != nil {
+		writeError(res, userInfo.Locale, common.PasskeyVerifyFailed)
+		return
+	}
+
+	authData, err := parseAttestationObject(attestationObject)
+	if err == nil {
+		err = authData.check(rpID(req))
+	}
+	if err != nil {
+		log.Printf("Error verifying passkey registration for user (%d): %s\n", userInfo.UserID, err.Error())
+		writeError(res, userInfo.Locale, common.PasskeyVerifyFailed)
+		return
+	}
+
+	err = databaseActions.AddPasskey(userInfo, challenge, body.Name, authData.credentialID, authData.publicKey, authData.signCount)
+	if err != nil {
+		writeError(res, userInfo.Locale, err)
+		return
+	}
+
+	writeJSON(res, http.StatusOK, result{true})
+}
+
+func LoginOptionsHandler(res http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	res.Header().Set("cache-control", "private, max-age=0, no-cache")
+	locale := i18n.RequestLocale(req)
+
+	challenge, err := databaseActions.NewPasskeyChallenge(0)
+	if err != nil {
+		writeError(res, locale, err)
+		return
+	}
+
+	writeJSON(res, http.StatusOK, requestOptions{
+		Challenge:        challenge,
+		RPID:             rpID(req),
+		UserVerification: "required",
+		Timeout:          timeout,
+	})
+}
+
+// Checks a passkey assertion and logs the user in by setting the session
+// cookie.
+func LoginHandler(res http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	res.Header().Set("cache-control", "private, max-age=0, no-cache")
+	locale := i18n.RequestLocale(req)
+
+	var body assertion
+	if !decodeBody(req, &body) {
+		writeError(res, locale, common.PasskeyVerifyFailed)
+		return
+	}
+
+	fields := [][]byte{}
+	for _, field := range []string{body.Id, body.ClientDataJSON, body.AuthenticatorData, body.Signature, body.UserHandle} {
+		decoded, err := decodeBase64URL(field)
+		if err != nil {
+			writeError(res, locale, common.PasskeyVerifyFailed)
+			return
+		}
+		fields = append(fields, decoded)
+	}
+	credentialID, clientDataJSON, rawAuthData, signature, handle := fields[0], fields[1], fields[2], fields[3], fields[4]
+
+	challenge, err := checkClientData(clientDataJSON, "webauthn.get", common.GetBaseURL(req))
+	if err != nil {
+		writeError(res, locale, common.PasskeyVerifyFailed)
+		return
+	}
+
+	authData, err := parseAuthenticatorData(rawAuthData)
+	if err == nil {
+		err = authData.check(rpID(req))
+	}
+	if err != nil {
+		log.Println("Error verifying passkey login:", err)
+		writeError(res, locale, common.PasskeyVerifyFailed)
+		return
+	}
+
+	credential, err := databaseActions.GetPasskeyCredential(credentialID)
+	if err != nil {
+		writeError(res, locale, err)
+		return
+	}
+	if len(handle) > 0 && string(handle) != userHandle(credential.UserID) {
+		writeError(res, locale, common.PasskeyVerifyFailed)
+		return
+	}
+
+	err = verifySignature(credential.PublicKey, rawAuthData, clientDataJSON, signature)
+	if err != nil {
+		log.Printf("Invalid signature from passkey (%d): %s\n", credential.Id, err.Error())
+		writeError(res, locale, common.PasskeyVerifyFailed)
+		return
+	}
+
+	sessionid, err := databaseActions.PasskeyLogin(challenge, credential, authData.signCount, common.GetRequestInfo(req))
+	if err != nil {
+		writeError(res, locale, err)
+		return
+	}
+
+	common.SetSessionCookie(res, sessionid)
+	writeJSON(res, http.StatusOK, result{true})
+}
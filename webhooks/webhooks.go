@@ -0,0 +1,113 @@
+package webhooks
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/comforme/comforme/common"
+	"github.com/comforme/comforme/databaseActions"
+)
+
+const (
+	pollInterval  = 30 * time.Second
+	batchSize     = 20
+	sendTimeout   = 10 * time.Second
+	maxErrorBytes = 512
+)
+
+var client = &http.Client{
+	Timeout: sendTimeout,
+	// A redirect could send the payload somewhere the admin did not choose
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// Sends queued webhook deliveries until the process exits. Deliveries are
+// picked up when they are queued and otherwise every pollInterval, which is
+// also how retries become due.
+func Run() {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		sendDue()
+
+		select {
+		case <-ticker.C:
+		case <-databaseActions.WebhooksQueued:
+		}
+	}
+}
+
+func sendDue() {
+	for {
+		deliveries, err := databaseActions.ClaimDueDeliveries(batchSize)
+		if err != nil {
+			log.Printf("Error claiming webhook deliveries: %s\n", err.Error())
+			return
+		}
+
+		for _, delivery := range deliveries {
+			status, err := send(delivery)
+			if err != nil {
+				log.Printf("Webhook delivery (%d) to %s failed: %s\n", delivery.Id, delivery.URL, err.Error())
+			}
+			if err := databaseActions.RecordDeliveryAttempt(delivery, status, err); err != nil {
+				log.Printf("Error recording webhook delivery (%d): %s\n", delivery.Id, err.Error())
+			}
+		}
+
+		if len(deliveries) < batchSize {
+			return
+		}
+	}
+}
+
+// Signature returns the value of the X-Webhook-Signature header: an
+// HMAC-SHA256 of the timestamp and body, keyed with the webhook's secret.
+func Signature(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	io.WriteString(mac, timestamp+".")
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Posts a delivery, returning the response status if there was a response.
+// Anything but a 2xx response is an error.
+func send(delivery common.PendingDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequest("POST", delivery.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "ComForMe-Webhooks")
+	req.Header.Set("X-Webhook-Event", delivery.Event)
+	req.Header.Set("X-Webhook-Delivery", strconv.Itoa(delivery.Id))
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", Signature(delivery.Secret, timestamp, body))
+
+	res, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		excerpt, _ := ioutil.ReadAll(io.LimitReader(res.Body, maxErrorBytes))
+		return res.StatusCode, fmt.Errorf("%s: %s", res.Status, bytes.TrimSpace(excerpt))
+	}
+	return res.StatusCode, nil
+}
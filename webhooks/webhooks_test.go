@@ -0,0 +1,78 @@
+package webhooks
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/comforme/comforme/common"
+)
+
+func TestSignature(t *testing.T) {
+	// Computed independently, as a receiver would
+	want := "sha256=fa2a544f17d386ab8e68455c9afce56bd5f3638d8f7a851f62e61e9af857654b"
+	body := []byte(`{"event":"page.created"}`)
+	if got := Signature("whsec_test", "1700000000", body); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+
+	// The timestamp is signed, so a delivery cannot be replayed as a newer one
+	if Signature("whsec_test", "1700000001", body) == want {
+		t.Error("signature does not cover the timestamp")
+	}
+	if Signature("another secret", "1700000000", body) == want {
+		t.Error("signature does not depend on the secret")
+	}
+}
+
+func TestSend(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		response string
+		err      string
+	}{
+		{"accepted", http.StatusNoContent, "", ""},
+		{"refused", http.StatusInternalServerError, " try again later\n", "500 Internal Server Error: try again later"},
+		{"redirected", http.StatusFound, "", "302 Found"},
+	}
+
+	for _, test := range tests {
+		delivery := common.PendingDelivery{Secret: "whsec_test"}
+		delivery.Id = 7
+		delivery.Event = "page.created"
+		delivery.Payload = `{"event":"page.created"}`
+
+		server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			body, _ := ioutil.ReadAll(req.Body)
+			if string(body) != delivery.Payload {
+				t.Errorf("%s: got body %q", test.name, body)
+			}
+			if req.Header.Get("X-Webhook-Event") != "page.created" || req.Header.Get("X-Webhook-Delivery") != "7" {
+				t.Errorf("%s: got headers %v", test.name, req.Header)
+			}
+			want := Signature(delivery.Secret, req.Header.Get("X-Webhook-Timestamp"), body)
+			if req.Header.Get("X-Webhook-Signature") != want {
+				t.Errorf("%s: got signature %q, want %q", test.name, req.Header.Get("X-Webhook-Signature"), want)
+			}
+
+			if test.status == http.StatusFound {
+				res.Header().Set("Location", "/elsewhere")
+			}
+			res.WriteHeader(test.status)
+			res.Write([]byte(test.response))
+		}))
+		delivery.URL = server.URL
+
+		status, err := send(delivery)
+		server.Close()
+		if status != test.status {
+			t.Errorf("%s: got status %d", test.name, status)
+		}
+		if test.err == "" && err != nil || test.err != "" && (err == nil || !strings.HasPrefix(err.Error(), test.err)) {
+			t.Errorf("%s: got error %v, want %q", test.name, err, test.err)
+		}
+	}
+}